@@ -0,0 +1,95 @@
+// Command fileforge runs FileForge tools without the desktop UI, for scripting
+// in CI or over SSH. It uses the same registry, orchestrator and tools as the
+// Wails application.
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	// Import for auto-registration
+	_ "fileforge-desktop/internal/doc"
+	_ "fileforge-desktop/internal/image"
+	_ "fileforge-desktop/internal/pdf"
+	_ "fileforge-desktop/internal/video"
+)
+
+// Exit codes. Job statuses map onto the first five; the rest report problems
+// that prevented a job from running at all.
+const (
+	exitSuccess        = 0
+	exitFailed         = 1
+	exitPartialSuccess = 2
+	exitCancelled      = 3
+	exitTimedOut       = 4
+	exitInvalid        = 5
+	exitUsage          = 64
+)
+
+const usage = `usage: fileforge <command> [arguments]
+
+commands:
+  tools list [--json]                 list registered tools and their state
+  run <toolId> [flags]                run a job and print its JobResultV1 as JSON
+  validate <toolId> [flags]           validate a job request without running it
+  plan <toolId> [flags]               print the outputs a job would write, without writing them
+
+run, validate and plan flags:
+  --mode single|batch                 defaults to single for one input file, batch otherwise
+  --input PATH                        input file, directory or glob, repeatable
+  --recursive                         include files in sub-directories of input directories
+  --include GLOB                      only use expanded files matching GLOB, repeatable
+  --exclude GLOB                      skip expanded files matching GLOB, repeatable
+  --flatten                           write all outputs directly into --output-dir
+  --output-dir DIR                    output directory
+  --name TEMPLATE                     output name, e.g. {name}_{width}x{height}.{ext}
+  --conflict POLICY                   existing outputs: error, overwrite, rename (default) or skip
+  --opt KEY=VALUE                     tool option, repeatable; JSON values are decoded
+                                      unless the tool declares KEY as a string
+  --workers N                         batch worker count
+  --priority low|normal|high          scheduling priority
+  --timeout DURATION                  job timeout, e.g. 10m
+  --item-timeout DURATION             per-input timeout
+  --no-reuse                          run even if an identical job succeeded before
+  --quiet                             do not report progress on stderr (run only)
+
+exit codes:
+  0 success, 1 failed, 2 partial_success, 3 cancelled, 4 timed_out,
+  5 invalid request, 64 usage error
+`
+
+func main() {
+	os.Exit(runCLI(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+func runCLI(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprint(stderr, usage)
+		return exitUsage
+	}
+
+	switch args[0] {
+	case "tools":
+		return toolsCommand(args[1:], stdout, stderr)
+	case "run":
+		return runCommand(args[1:], stdout, stderr)
+	case "validate":
+		return validateCommand(args[1:], stdout, stderr)
+	case "plan":
+		return planCommand(args[1:], stdout, stderr)
+	case "help", "-h", "--help":
+		fmt.Fprint(stdout, usage)
+		return exitSuccess
+	default:
+		fmt.Fprintf(stderr, "fileforge: unknown command %q\n\n%s", args[0], usage)
+		return exitUsage
+	}
+}
+
+func writeJSON(w io.Writer, value any) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(value)
+}
@@ -0,0 +1,338 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"fileforge-desktop/internal/jobs"
+	"fileforge-desktop/internal/models"
+	"fileforge-desktop/internal/registry"
+	"fileforge-desktop/internal/tools"
+	"fileforge-desktop/internal/utils/procwatch"
+)
+
+type stringList []string
+
+func (l *stringList) String() string { return strings.Join(*l, ",") }
+
+func (l *stringList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+// optionFlags collects --opt key=value pairs as strings until decode sees the
+// tool's option schema.
+type optionFlags map[string]any
+
+func (o optionFlags) String() string { return "" }
+
+func (o optionFlags) Set(value string) error {
+	key, raw, ok := strings.Cut(value, "=")
+	key = strings.TrimSpace(key)
+	if !ok || key == "" {
+		return fmt.Errorf("expected key=value, got %q", value)
+	}
+
+	o[key] = raw
+	return nil
+}
+
+// decode passes values that parse as JSON, such as numbers, booleans or
+// arrays, to the tool decoded. Options the schema declares as strings keep
+// their text, so --opt suffix=2024 stays "2024"; anything else that is not
+// JSON stays a string too.
+func (o optionFlags) decode(schema []models.OptionSchemaV1) {
+	stringKeys := make(map[string]bool, len(schema))
+	for _, option := range schema {
+		stringKeys[option.Key] = option.Type == models.OptionTypeString
+	}
+
+	for key, value := range o {
+		raw, ok := value.(string)
+		if !ok || stringKeys[key] {
+			continue
+		}
+		var decoded any
+		if err := json.Unmarshal([]byte(raw), &decoded); err == nil {
+			o[key] = decoded
+		}
+	}
+}
+
+type jobFlags struct {
+	req   models.JobRequestV1
+	quiet bool
+}
+
+// parseJobFlags reads "<toolId> [flags] [inputs...]" into a job request.
+// Positional arguments after the flags are treated as additional inputs.
+func parseJobFlags(name string, args []string, stderr io.Writer) (jobFlags, bool) {
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		fmt.Fprintf(stderr, "fileforge: %s requires a tool ID\n\n%s", name, usage)
+		return jobFlags{}, false
+	}
+
+	var (
+		inputs  stringList
+		options = optionFlags{}
+		expand  models.InputExpansionV1
+		parsed  = jobFlags{req: models.JobRequestV1{ToolID: args[0]}}
+	)
+
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.StringVar(&parsed.req.Mode, "mode", "", "single or batch")
+	flags.Var(&inputs, "input", "input file, directory or glob, repeatable")
+	flags.BoolVar(&expand.Recursive, "recursive", false, "include files in sub-directories of input directories")
+	flags.Var((*stringList)(&expand.Include), "include", "only use expanded files matching this glob, repeatable")
+	flags.Var((*stringList)(&expand.Exclude), "exclude", "skip expanded files matching this glob, repeatable")
+	flags.BoolVar(&expand.Flatten, "flatten", false, "write all outputs directly into the output directory")
+	flags.StringVar(&parsed.req.OutputDir, "output-dir", "", "output directory")
+	flags.Var(options, "opt", "tool option as key=value, repeatable")
+	nameTemplate := flags.String("name", "", "output name template such as {name}_{index}.{ext}")
+	flags.StringVar(&parsed.req.ConflictPolicy, "conflict", "", "existing outputs: error, overwrite, rename or skip")
+	flags.IntVar(&parsed.req.Workers, "workers", 0, "batch worker count")
+	flags.StringVar(&parsed.req.Priority, "priority", "", "low, normal or high")
+	timeout := flags.Duration("timeout", 0, "job timeout")
+	itemTimeout := flags.Duration("item-timeout", 0, "per-input timeout")
+	flags.BoolVar(&parsed.req.DisableReuse, "no-reuse", false, "always run the job")
+	flags.BoolVar(&parsed.quiet, "quiet", false, "do not report progress on stderr")
+	if err := flags.Parse(args[1:]); err != nil {
+		return jobFlags{}, false
+	}
+
+	// An unknown tool is reported by validation; its options are decoded as JSON.
+	var schema []models.OptionSchemaV1
+	if tool, err := registry.GetGlobalRegistry().GetToolV2(parsed.req.ToolID); err == nil {
+		schema = tool.Manifest().Options
+	}
+	options.decode(schema)
+
+	parsed.req.InputPaths = append(inputs, flags.Args()...)
+	if expand.Recursive || expand.Flatten || len(expand.Include) > 0 || len(expand.Exclude) > 0 {
+		parsed.req.Expand = &expand
+	}
+	// Some tools read the output directory from their options, as pipeline
+	// steps do, so --output-dir fills it in unless --opt set it explicitly.
+	if _, ok := options["outputDir"]; !ok && parsed.req.OutputDir != "" {
+		options["outputDir"] = parsed.req.OutputDir
+	}
+	if _, ok := options[tools.OutputNameTemplateOption]; !ok && *nameTemplate != "" {
+		options[tools.OutputNameTemplateOption] = *nameTemplate
+	}
+	parsed.req.Options = options
+	parsed.req.TimeoutMillis = timeout.Milliseconds()
+	parsed.req.ItemTimeoutMillis = itemTimeout.Milliseconds()
+	if parsed.req.Mode == "" {
+		parsed.req.Mode = "batch"
+		if len(parsed.req.InputPaths) == 1 && !expandsToMany(parsed.req.InputPaths[0]) {
+			parsed.req.Mode = "single"
+		}
+	}
+
+	return parsed, true
+}
+
+// expandsToMany reports whether an input is a directory or glob pattern that
+// the orchestrator expands into several files. Like the orchestrator, it only
+// treats an input as a pattern when nothing exists at that path.
+func expandsToMany(input string) bool {
+	info, err := os.Stat(input)
+	if err == nil {
+		return info.IsDir()
+	}
+	return strings.ContainsAny(input, "*?[")
+}
+
+func validateCommand(args []string, stdout, stderr io.Writer) int {
+	parsed, ok := parseJobFlags("validate", args, stderr)
+	if !ok {
+		return exitUsage
+	}
+
+	orchestrator := jobs.NewOrchestrator(registry.GetGlobalRegistry(), 1)
+	res := orchestrator.Validate(context.Background(), parsed.req)
+	if err := writeJSON(stdout, res); err != nil {
+		fmt.Fprintf(stderr, "fileforge: %v\n", err)
+		return exitFailed
+	}
+	if !res.Valid {
+		return exitInvalid
+	}
+	return exitSuccess
+}
+
+func planCommand(args []string, stdout, stderr io.Writer) int {
+	parsed, ok := parseJobFlags("plan", args, stderr)
+	if !ok {
+		return exitUsage
+	}
+
+	orchestrator := jobs.NewOrchestrator(registry.GetGlobalRegistry(), 1)
+	res := orchestrator.Plan(context.Background(), parsed.req)
+	if err := writeJSON(stdout, res); err != nil {
+		fmt.Fprintf(stderr, "fileforge: %v\n", err)
+		return exitFailed
+	}
+	if !res.Success {
+		return exitInvalid
+	}
+	return exitSuccess
+}
+
+func runCommand(args []string, stdout, stderr io.Writer) int {
+	parsed, ok := parseJobFlags("run", args, stderr)
+	if !ok {
+		return exitUsage
+	}
+
+	orchestrator := jobs.NewOrchestrator(registry.GetGlobalRegistry(), 1)
+
+	// The emitter is called in sequence order, so it only has to note that
+	// something finished; the loop below reads the result from the orchestrator.
+	completed := make(chan struct{}, 1)
+	reporter := &progressReporter{w: stderr, total: len(parsed.req.InputPaths)}
+	orchestrator.SetEventEmitter(func(evt models.JobEventV1) {
+		if !parsed.quiet {
+			reporter.report(evt)
+		}
+		if evt.Type == models.JobEventCompleted {
+			select {
+			case completed <- struct{}{}:
+			default:
+			}
+		}
+	})
+
+	res, err := orchestrator.Submit(context.Background(), parsed.req)
+	if err != nil {
+		fmt.Fprintf(stderr, "fileforge: %v\n", err)
+		return exitInvalid
+	}
+	if !res.Success || res.JobID == "" {
+		if writeErr := writeJSON(stdout, res); writeErr != nil {
+			fmt.Fprintf(stderr, "fileforge: %v\n", writeErr)
+		}
+		return exitInvalid
+	}
+
+	interrupts := make(chan os.Signal, 1)
+	signal.Notify(interrupts, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(interrupts)
+
+	result := waitForJob(orchestrator, res.JobID, completed, interrupts, stderr)
+	procwatch.Default().KillAll()
+
+	if err := writeJSON(stdout, result); err != nil {
+		fmt.Fprintf(stderr, "fileforge: %v\n", err)
+		return exitFailed
+	}
+	return exitCode(result.Status)
+}
+
+// waitForJob blocks until the job reaches a final state. The first interrupt
+// cancels the job and keeps waiting for its cancelled result; a second one
+// gives up on it.
+func waitForJob(orchestrator *jobs.Orchestrator, jobID string, completed <-chan struct{}, interrupts <-chan os.Signal, stderr io.Writer) models.JobResultV1 {
+	ticker := time.NewTicker(500 * time.Millisecond)
+	defer ticker.Stop()
+
+	cancelling := false
+	for {
+		result, _ := orchestrator.GetJob(jobID)
+		if isFinalStatus(result.Status) {
+			return result
+		}
+
+		select {
+		case <-completed:
+		case <-ticker.C:
+		case <-interrupts:
+			if cancelling {
+				result.Status = jobs.StatusCancelled
+				result.Message = "job abandoned"
+				return result
+			}
+			cancelling = true
+			fmt.Fprintln(stderr, "fileforge: cancelling job, interrupt again to exit immediately")
+			if err := orchestrator.Cancel(jobID); err != nil {
+				fmt.Fprintf(stderr, "fileforge: cancel failed: %v\n", err)
+			}
+		}
+	}
+}
+
+func isFinalStatus(status string) bool {
+	switch status {
+	case jobs.StatusSuccess, jobs.StatusPartialSuccess, jobs.StatusFailed, jobs.StatusCancelled, jobs.StatusTimedOut, jobs.StatusInterrupted:
+		return true
+	}
+	return false
+}
+
+func exitCode(status string) int {
+	switch status {
+	case jobs.StatusSuccess:
+		return exitSuccess
+	case jobs.StatusPartialSuccess:
+		return exitPartialSuccess
+	case jobs.StatusCancelled, jobs.StatusInterrupted:
+		return exitCancelled
+	case jobs.StatusTimedOut:
+		return exitTimedOut
+	default:
+		return exitFailed
+	}
+}
+
+// progressReporter writes one stderr line per lifecycle event. Events arrive in
+// sequence order, so counting completed items needs no locking.
+type progressReporter struct {
+	w     io.Writer
+	total int
+	done  int
+}
+
+func (r *progressReporter) report(evt models.JobEventV1) {
+	switch evt.Type {
+	case models.JobEventStarted:
+		fmt.Fprintf(r.w, "fileforge: %s started job %s\n", evt.ToolID, evt.JobID)
+	case models.JobEventItemCompleted:
+		if evt.Item == nil {
+			return
+		}
+		r.done++
+		status := evt.Item.Status
+		if status == "" {
+			status = models.JobItemStatusSuccess
+			if !evt.Item.Success {
+				status = models.JobItemStatusFailed
+			}
+		}
+		line := fmt.Sprintf("fileforge: [%d/%d] %s %s", r.done, max(r.total, r.done), status, evt.Item.InputPath)
+		if evt.Item.OutputPath != "" {
+			line += " -> " + evt.Item.OutputPath
+		}
+		if evt.Item.Error != nil {
+			line += ": " + evt.Item.Error.Message
+		}
+		fmt.Fprintln(r.w, line)
+	case models.JobEventRetrying:
+		if evt.Retry == nil {
+			return
+		}
+		// Retried items report again, so they are no longer counted as done.
+		r.done = max(r.done-len(evt.Retry.InputPaths), 0)
+		fmt.Fprintf(r.w, "fileforge: retrying %d input(s), attempt %d of %d in %dms\n", len(evt.Retry.InputPaths), evt.Retry.Attempt, evt.Retry.MaxAttempts, evt.Retry.DelayMillis)
+	case models.JobEventCompleted:
+		fmt.Fprintf(r.w, "fileforge: job %s finished with status %s\n", evt.JobID, evt.Status)
+	}
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"fileforge-desktop/internal/models"
+	"fileforge-desktop/internal/registry"
+)
+
+func toolsCommand(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 || args[0] != "list" {
+		fmt.Fprintf(stderr, "fileforge: expected 'tools list'\n\n%s", usage)
+		return exitUsage
+	}
+
+	flags := flag.NewFlagSet("tools list", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	asJSON := flags.Bool("json", false, "print the catalog as JSON")
+	if err := flags.Parse(args[1:]); err != nil {
+		return exitUsage
+	}
+
+	catalog := registry.GetGlobalRegistry().ListToolsV2(context.Background())
+	if *asJSON {
+		if err := writeJSON(stdout, models.ListToolsResponseV1{Success: true, Message: "tools listed successfully", Tools: catalog}); err != nil {
+			fmt.Fprintf(stderr, "fileforge: %v\n", err)
+			return exitFailed
+		}
+		return exitSuccess
+	}
+
+	table := tabwriter.NewWriter(stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, "TOOL\tVERSION\tMODES\tSTATE\tINPUTS")
+	for _, entry := range catalog {
+		state := entry.State.Status
+		if entry.State.Reason != "" {
+			state += " (" + entry.State.Reason + ")"
+		}
+		fmt.Fprintf(table, "%s\t%s\t%s\t%s\t%s\n", entry.Manifest.ToolID, entry.Manifest.Version, toolModes(entry.Manifest), state, strings.Join(entry.Manifest.InputExtensions, ","))
+	}
+	if err := table.Flush(); err != nil {
+		fmt.Fprintf(stderr, "fileforge: %v\n", err)
+		return exitFailed
+	}
+	return exitSuccess
+}
+
+func toolModes(manifest models.ToolManifestV1) string {
+	modes := make([]string, 0, 2)
+	if manifest.SupportsSingle {
+		modes = append(modes, "single")
+	}
+	if manifest.SupportsBatch {
+		modes = append(modes, "batch")
+	}
+	return strings.Join(modes, ",")
+}
@@ -19,7 +19,26 @@ export type CanonicalErrorCodeV1 =
   | 'EXEC_IO_TRANSIENT'
   | 'EXEC_TIMEOUT_TRANSIENT'
   | 'UNSUPPORTED_FORMAT'
-  | 'CANCELLED_BY_USER';
+  | 'CANCELLED_BY_USER'
+  | 'DEPENDENCY_FAILED';
+
+export type OptionTypeV1 = 'string' | 'integer' | 'number' | 'boolean' | 'object' | 'array';
+
+export interface OptionSchemaV1 {
+  key: string;
+  type: OptionTypeV1;
+  label?: string;
+  description?: string;
+  required?: boolean;
+  default?: unknown;
+  enum?: string[];
+  min?: number;
+  max?: number;
+  format?: 'path' | 'dir' | 'color' | 'time' | string;
+  modes?: JobModeV1[];
+  fields?: OptionSchemaV1[];
+  items?: OptionSchemaV1;
+}
 
 export interface ToolManifestV1 {
   toolId: string;
@@ -34,6 +53,8 @@ export interface ToolManifestV1 {
   outputExtensions: string[];
   runtimeDependencies: string[];
   tags: string[];
+  itemTimeoutMillis?: number;
+  options?: OptionSchemaV1[];
 }
 
 export interface ToolRuntimeStateV1 {
@@ -60,8 +60,14 @@ func (a *App) Shutdown(ctx context.Context) error {
 			}
 		}
 	}
-	// shutdown tooling service if it exposes a shutdown (none currently)
-	// TODO: if toolingService implements Shutdown(ctx) call it here
+	// shutdown tooling service
+	if a.toolingService != nil {
+		if err := a.toolingService.Shutdown(ctx); err != nil {
+			if firstErr == nil {
+				firstErr = fmt.Errorf("app: %w", err)
+			}
+		}
+	}
 	return firstErr
 }
 
@@ -73,18 +79,102 @@ func (a *App) ValidateJobV1(req models.JobRequestV1) models.ValidateJobResponseV
 	return a.toolingService.ValidateJobV1(req)
 }
 
+func (a *App) PlanJobV1(req models.JobRequestV1) models.PlanJobResponseV1 {
+	return a.toolingService.PlanJobV1(req)
+}
+
 func (a *App) RunJobV1(req models.JobRequestV1) models.RunJobResponseV1 {
 	return a.toolingService.RunJobV1(req)
 }
 
+func (a *App) RunPipelineV1(req models.PipelineRequestV1) models.RunJobResponseV1 {
+	return a.toolingService.RunPipelineV1(req)
+}
+
+func (a *App) ResumeJobV1(jobID string) models.RunJobResponseV1 {
+	return a.toolingService.ResumeJobV1(jobID)
+}
+
 func (a *App) CancelJobV1(jobID string) models.CancelJobResponseV1 {
 	return a.toolingService.CancelJobV1(jobID)
 }
 
+func (a *App) CancelJobItemV1(jobID, inputPath string) models.CancelJobItemResponseV1 {
+	return a.toolingService.CancelJobItemV1(jobID, inputPath)
+}
+
+func (a *App) ReorderQueuedJobV1(jobID string, position int) models.QueueJobResponseV1 {
+	return a.toolingService.ReorderQueuedJobV1(jobID, position)
+}
+
+func (a *App) PauseQueuedJobV1(jobID string) models.QueueJobResponseV1 {
+	return a.toolingService.PauseQueuedJobV1(jobID)
+}
+
+func (a *App) ResumeQueuedJobV1(jobID string) models.QueueJobResponseV1 {
+	return a.toolingService.ResumeQueuedJobV1(jobID)
+}
+
 func (a *App) GetJobStatusV1(jobID string) models.JobStatusResponseV1 {
 	return a.toolingService.GetJobStatusV1(jobID)
 }
 
+func (a *App) GetJobEventsV1(query models.JobEventsQueryV1) models.JobEventsResponseV1 {
+	return a.toolingService.GetJobEventsV1(query)
+}
+
+func (a *App) ListJobsV1(query models.JobHistoryQueryV1) models.ListJobsResponseV1 {
+	return a.toolingService.ListJobsV1(query)
+}
+
+func (a *App) GetJobHistoryV1(jobID string) models.JobHistoryResponseV1 {
+	return a.toolingService.GetJobHistoryV1(jobID)
+}
+
+func (a *App) StartAPIServerV1(cfg models.APIServerConfigV1) models.APIServerStatusResponseV1 {
+	return a.toolingService.StartAPIServerV1(cfg)
+}
+
+func (a *App) StopAPIServerV1() models.APIServerStatusResponseV1 {
+	return a.toolingService.StopAPIServerV1()
+}
+
+func (a *App) GetAPIServerStatusV1() models.APIServerStatusResponseV1 {
+	return a.toolingService.GetAPIServerStatusV1()
+}
+
+func (a *App) ListWatchFoldersV1() models.ListWatchFoldersResponseV1 {
+	return a.toolingService.ListWatchFoldersV1()
+}
+
+func (a *App) SaveWatchFolderV1(folder models.WatchFolderV1) models.WatchFolderResponseV1 {
+	return a.toolingService.SaveWatchFolderV1(folder)
+}
+
+func (a *App) RemoveWatchFolderV1(id string) models.WatchFolderResponseV1 {
+	return a.toolingService.RemoveWatchFolderV1(id)
+}
+
+func (a *App) ListPresetsV1(toolID string) models.ListPresetsResponseV1 {
+	return a.toolingService.ListPresetsV1(toolID)
+}
+
+func (a *App) SavePresetV1(preset models.PresetV1, sample models.JobRequestV1) models.PresetResponseV1 {
+	return a.toolingService.SavePresetV1(preset, sample)
+}
+
+func (a *App) DeletePresetV1(toolID, name string) models.PresetResponseV1 {
+	return a.toolingService.DeletePresetV1(toolID, name)
+}
+
+func (a *App) ExportPresetsV1(path, toolID string) models.PresetTransferResponseV1 {
+	return a.toolingService.ExportPresetsV1(path, toolID)
+}
+
+func (a *App) ImportPresetsV1(path string, overwrite bool) models.PresetTransferResponseV1 {
+	return a.toolingService.ImportPresetsV1(path, overwrite)
+}
+
 // OpenFileDialog opens a native file dialog and returns the selected file path
 func (a *App) OpenFileDialog() (string, error) {
 	app := application.Get()
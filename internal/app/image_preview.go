@@ -47,6 +47,19 @@ func (a *App) GetImageAnnotatePreviewV1(req models.ImageAnnotatePreviewRequestV1
 	return tool.GetImageAnnotatePreviewV1(req)
 }
 
+func (a *App) GetImageMetadataV1(inputPath string) models.ImageMetadataResponseV1 {
+	tool, ok := a.imageMetadataTool()
+	if !ok {
+		return models.ImageMetadataResponseV1{
+			Success: false,
+			Message: "Image metadata tool is unavailable.",
+			Error:   models.NewCanonicalJobError("TOOL_NOT_FOUND", "tool.image.metadata is not registered", nil),
+		}
+	}
+
+	return tool.ReadImageMetadata(strings.TrimSpace(inputPath))
+}
+
 func (a *App) imageCropTool() (*image.CropTool, bool) {
 	reg := registry.GetGlobalRegistry()
 	rawTool, err := reg.GetToolV2(image.ToolIDImageCropV1)
@@ -76,3 +89,18 @@ func (a *App) imageAnnotateTool() (*image.AnnotateTool, bool) {
 
 	return annotateTool, true
 }
+
+func (a *App) imageMetadataTool() (*image.MetadataTool, bool) {
+	reg := registry.GetGlobalRegistry()
+	rawTool, err := reg.GetToolV2(image.ToolIDImageMetadataV1)
+	if err != nil {
+		return nil, false
+	}
+
+	metadataTool, ok := rawTool.(*image.MetadataTool)
+	if !ok {
+		return nil, false
+	}
+
+	return metadataTool, true
+}
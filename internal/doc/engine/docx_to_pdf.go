@@ -11,6 +11,8 @@ import (
 	"os/exec"
 	"path/filepath"
 	"strings"
+
+	"fileforge-desktop/internal/utils/procwatch"
 )
 
 const (
@@ -105,12 +107,12 @@ func (p *HybridRuntimeProbe) Check(_ context.Context) error {
 type ExecCommandRunner struct{}
 
 func (r *ExecCommandRunner) Run(ctx context.Context, name string, args ...string) (CommandResult, error) {
-	cmd := exec.CommandContext(ctx, name, args...)
+	cmd := procwatch.Command(ctx, name, args...)
 	var stdout bytes.Buffer
 	var stderr bytes.Buffer
 	cmd.Stdout = &stdout
 	cmd.Stderr = &stderr
-	err := cmd.Run()
+	err := procwatch.Run(cmd)
 	return CommandResult{Stdout: stdout.String(), Stderr: stderr.String()}, err
 }
 
@@ -8,9 +8,11 @@ import (
 	"path/filepath"
 	"runtime"
 	"strings"
+	"time"
 
 	"fileforge-desktop/internal/doc/engine"
 	"fileforge-desktop/internal/models"
+	"fileforge-desktop/internal/tools"
 )
 
 const ToolIDDocDOCXToPDFV1 = "tool.doc.docx_to_pdf"
@@ -38,18 +40,31 @@ func (t *DOCXToPDFTool) Capability() string {
 
 func (t *DOCXToPDFTool) Manifest() models.ToolManifestV1 {
 	return models.ToolManifestV1{
-		ToolID:           t.ID(),
-		Name:             "DOCX to PDF",
-		Description:      "Convert DOCX to PDF with standard fidelity (LibreOffice + Pandoc fallback)",
-		Domain:           "doc",
-		Capability:       t.Capability(),
-		Version:          "v1",
-		SupportsSingle:   true,
-		SupportsBatch:    true,
-		InputExtensions:  []string{"docx"},
-		OutputExtensions: []string{"pdf"},
-		RuntimeDeps:      []string{"libreoffice", "pandoc"},
-		Tags:             []string{"doc", "docx", "pdf", "standard-fidelity", "fallback"},
+		ToolID:            t.ID(),
+		Name:              "DOCX to PDF",
+		Description:       "Convert DOCX to PDF with standard fidelity (LibreOffice + Pandoc fallback)",
+		Domain:            "doc",
+		Capability:        t.Capability(),
+		Version:           "v1",
+		SupportsSingle:    true,
+		SupportsBatch:     true,
+		InputExtensions:   []string{"docx"},
+		OutputExtensions:  []string{"pdf"},
+		RuntimeDeps:       []string{"libreoffice", "pandoc"},
+		Tags:              []string{"doc", "docx", "pdf", "standard-fidelity", "fallback"},
+		ItemTimeoutMillis: (10 * time.Minute).Milliseconds(),
+		Options: []models.OptionSchemaV1{
+			{Key: "outputPath", Type: models.OptionTypeString, Label: "Output file", Description: "Must end in .pdf", Format: "path", Modes: []string{"single"}},
+			{Key: "outputDir", Type: models.OptionTypeString, Label: "Output folder", Description: "Used when the job has no output folder", Format: "dir", Modes: []string{"single"}},
+			tools.OutputNameTemplateSchema("{name}_docx2pdf.{ext}"),
+		},
+	}
+}
+
+func (t *DOCXToPDFTool) RetryPolicy() models.RetryPolicyV1 {
+	return models.RetryPolicyV1{
+		MaxAttempts:    3,
+		RetryableCodes: []string{"DOC_DOCX_TO_PDF_EXECUTION_FAILED", "DOC_DOCX_TO_PDF_PRIMARY_EXECUTION_FAILED", "DOC_DOCX_TO_PDF_FALLBACK_EXECUTION_FAILED"},
 	}
 }
 
@@ -91,7 +106,7 @@ func (t *DOCXToPDFTool) Validate(ctx context.Context, req models.JobRequestV1) *
 	}
 
 	if parsed.mode == "single" {
-		_, outErr := resolveSingleDOCXOutput(parsed.inputPaths[0], parsed.outputPath, parsed.outputDir)
+		_, outErr := resolveSingleDOCXOutput(parsed.inputPaths[0], parsed)
 		if outErr != nil {
 			return outErr
 		}
@@ -109,6 +124,61 @@ func (t *DOCXToPDFTool) Validate(ctx context.Context, req models.JobRequestV1) *
 	return nil
 }
 
+func (t *DOCXToPDFTool) Plan(ctx context.Context, req models.JobRequestV1) (models.JobPlanV1, *models.JobErrorV1) {
+	probe := t.probe
+	if probe == nil {
+		probe = engine.NewHybridRuntimeProbe()
+	}
+	if err := probe.Check(ctx); err != nil {
+		return models.JobPlanV1{}, mapDOCXEngineError(err)
+	}
+
+	parsed, parseErr := parseDOCXRequest(req)
+	if parseErr != nil {
+		return models.JobPlanV1{}, parseErr
+	}
+
+	if parsed.mode == "single" {
+		inputPath := parsed.inputPaths[0]
+		if inputErr := validateDOCXInputPath(inputPath); inputErr != nil {
+			return models.JobPlanV1{}, inputErr
+		}
+		target, outErr := resolveSingleDOCXOutput(inputPath, parsed)
+		if outErr != nil {
+			return models.JobPlanV1{}, outErr
+		}
+		item := tools.PlanItem(inputPath, []tools.OutputTarget{target}, 0)
+		return tools.NewJobPlan(ToolIDDocDOCXToPDFV1, parsed.mode, []models.PlanItemV1{item}), nil
+	}
+
+	if strings.TrimSpace(parsed.outputDir) == "" {
+		return models.JobPlanV1{}, models.NewCanonicalJobError("DOC_DOCX_TO_PDF_OUTPUT_DIR_REQUIRED", "outputDir is required in batch mode", nil)
+	}
+	if outDirErr := validateDOCXOutputDir(parsed.outputDir); outDirErr != nil {
+		return models.JobPlanV1{}, outDirErr
+	}
+
+	usedOutputs := make(map[string]struct{}, len(parsed.inputPaths))
+	items := make([]models.PlanItemV1, 0, len(parsed.inputPaths))
+	for index, inputPath := range parsed.inputPaths {
+		if inputErr := validateDOCXInputPath(inputPath); inputErr != nil {
+			items = append(items, tools.FailedPlanItem(inputPath, inputErr))
+			continue
+		}
+		if protectedErr := engine.DetectProtectedDOCX(inputPath); protectedErr != nil {
+			items = append(items, tools.FailedPlanItem(inputPath, mapDOCXEngineError(protectedErr)))
+			continue
+		}
+		target, conflictErr := tools.ResolveOutputTarget(parsed.conflicts, docxOutputPath(parsed.outputDir, parsed.subdirs[inputPath], parsed.nameTmpl, inputPath, index+1), usedOutputs)
+		if conflictErr != nil {
+			items = append(items, tools.FailedPlanItem(inputPath, conflictErr))
+			continue
+		}
+		items = append(items, tools.PlanItem(inputPath, []tools.OutputTarget{target}, 0))
+	}
+	return tools.NewJobPlan(ToolIDDocDOCXToPDFV1, parsed.mode, items), nil
+}
+
 func (t *DOCXToPDFTool) ExecuteSingle(ctx context.Context, req models.JobRequestV1) (models.JobResultItemV1, *models.JobErrorV1) {
 	parsed, parseErr := parseDOCXRequest(req)
 	if parseErr != nil {
@@ -125,12 +195,16 @@ func (t *DOCXToPDFTool) ExecuteSingle(ctx context.Context, req models.JobRequest
 		return models.JobResultItemV1{InputPath: inputPath, Success: false, Message: jobErr.Message, Error: jobErr}, jobErr
 	}
 
-	outputPath, outErr := resolveSingleDOCXOutput(inputPath, parsed.outputPath, parsed.outputDir)
+	target, outErr := resolveSingleDOCXOutput(inputPath, parsed)
 	if outErr != nil {
 		return models.JobResultItemV1{InputPath: inputPath, Success: false, Message: outErr.Message, Error: outErr}, outErr
 	}
+	outputPath := target.Path
+	if target.Skip {
+		return models.SkippedItemV1(inputPath, outputPath), nil
+	}
 
-	result, err := engine.ConvertDOCX(ctx, t.probe, t.runner, engine.ConvertDOCXRequest{InputPath: inputPath, OutputPath: outputPath})
+	result, err := t.convert(ctx, inputPath, target)
 	if err != nil {
 		jobErr := mapDOCXEngineError(err)
 		return models.JobResultItemV1{InputPath: inputPath, OutputPath: outputPath, Success: false, Message: jobErr.Message, Error: jobErr}, jobErr
@@ -177,12 +251,18 @@ func (t *DOCXToPDFTool) ExecuteBatch(ctx context.Context, req models.JobRequestV
 		default:
 		}
 
+		if tools.ItemCancelled(ctx, inputPath) {
+			items = append(items, models.CancelledItemV1(inputPath, ""))
+			emitDOCXBatchProgress(onProgress, index+1, len(parsed.inputPaths), models.LastResultItemV1(items))
+			continue
+		}
+
 		if inputErr := validateDOCXInputPath(inputPath); inputErr != nil {
 			if firstErr == nil {
 				firstErr = inputErr
 			}
 			items = append(items, models.JobResultItemV1{InputPath: inputPath, Success: false, Message: inputErr.Message, Error: inputErr})
-			emitDOCXBatchProgress(onProgress, index+1, len(parsed.inputPaths))
+			emitDOCXBatchProgress(onProgress, index+1, len(parsed.inputPaths), models.LastResultItemV1(items))
 			continue
 		}
 
@@ -192,13 +272,32 @@ func (t *DOCXToPDFTool) ExecuteBatch(ctx context.Context, req models.JobRequestV
 				firstErr = jobErr
 			}
 			items = append(items, models.JobResultItemV1{InputPath: inputPath, Success: false, Message: jobErr.Message, Error: jobErr})
-			emitDOCXBatchProgress(onProgress, index+1, len(parsed.inputPaths))
+			emitDOCXBatchProgress(onProgress, index+1, len(parsed.inputPaths), models.LastResultItemV1(items))
+			continue
+		}
+
+		target, conflictErr := tools.ResolveOutputTarget(parsed.conflicts, docxOutputPath(parsed.outputDir, parsed.subdirs[inputPath], parsed.nameTmpl, inputPath, index+1), usedOutputs)
+		if conflictErr != nil {
+			if firstErr == nil {
+				firstErr = conflictErr
+			}
+			items = append(items, models.JobResultItemV1{InputPath: inputPath, Success: false, Message: conflictErr.Message, Error: conflictErr})
+			emitDOCXBatchProgress(onProgress, index+1, len(parsed.inputPaths), models.LastResultItemV1(items))
+			continue
+		}
+		outputPath := target.Path
+		if target.Skip {
+			items = append(items, models.SkippedItemV1(inputPath, outputPath))
+			emitDOCXBatchProgress(onProgress, index+1, len(parsed.inputPaths), models.LastResultItemV1(items))
 			continue
 		}
 
-		outputPath := nextAvailableDOCXOutput(parsed.outputDir, inputPath, usedOutputs)
-		result, execErr := engine.ConvertDOCX(ctx, t.probe, t.runner, engine.ConvertDOCXRequest{InputPath: inputPath, OutputPath: outputPath})
-		if execErr != nil {
+		itemCtx, stopItem := tools.ItemContext(ctx, inputPath)
+		result, execErr := t.convert(itemCtx, inputPath, target)
+		stopItem()
+		if execErr != nil && ctx.Err() == nil && tools.ItemCancelled(ctx, inputPath) {
+			items = append(items, models.CancelledItemV1(inputPath, outputPath))
+		} else if execErr != nil {
 			jobErr := mapDOCXEngineError(execErr)
 			if firstErr == nil {
 				firstErr = jobErr
@@ -212,7 +311,7 @@ func (t *DOCXToPDFTool) ExecuteBatch(ctx context.Context, req models.JobRequestV
 			items = append(items, models.JobResultItemV1{InputPath: inputPath, OutputPath: outputPath, Outputs: []string{outputPath}, OutputCount: 1, Success: true, Message: message})
 		}
 
-		emitDOCXBatchProgress(onProgress, index+1, len(parsed.inputPaths))
+		emitDOCXBatchProgress(onProgress, index+1, len(parsed.inputPaths), models.LastResultItemV1(items))
 	}
 
 	return items, firstErr
@@ -223,6 +322,9 @@ type docxRequest struct {
 	inputPaths []string
 	outputDir  string
 	outputPath string
+	nameTmpl   string
+	conflicts  string
+	subdirs    map[string]string // output sub-directory of each input, from input expansion
 }
 
 func parseDOCXRequest(req models.JobRequestV1) (docxRequest, *models.JobErrorV1) {
@@ -252,6 +354,13 @@ func parseDOCXRequest(req models.JobRequestV1) (docxRequest, *models.JobErrorV1)
 		inputPaths: inputs,
 		outputDir:  strings.TrimSpace(req.OutputDir),
 		outputPath: strings.TrimSpace(optionString(req.Options, "outputPath")),
+		nameTmpl:   tools.OutputNameTemplate(req.Options, "{name}_docx2pdf.{ext}"),
+		conflicts:  tools.ConflictPolicy(req),
+		subdirs:    req.InputSubdirs,
+	}
+
+	if tmplErr := tools.ValidateOutputNameTemplate(req.Options); tmplErr != nil {
+		return docxRequest{}, tmplErr
 	}
 
 	if mode == "single" && parsed.outputDir == "" {
@@ -295,89 +404,52 @@ func validateDOCXOutputDir(dir string) *models.JobErrorV1 {
 	return nil
 }
 
-func resolveSingleDOCXOutput(inputPath, rawOutputPath, rawOutputDir string) (string, *models.JobErrorV1) {
-	outputPath := strings.TrimSpace(rawOutputPath)
+func resolveSingleDOCXOutput(inputPath string, parsed docxRequest) (tools.OutputTarget, *models.JobErrorV1) {
+	outputPath := strings.TrimSpace(parsed.outputPath)
 	if outputPath != "" {
 		if strings.ToLower(filepath.Ext(outputPath)) != ".pdf" {
-			return "", models.NewCanonicalJobError("DOC_DOCX_TO_PDF_OUTPUT_INVALID", "outputPath must use .pdf extension", nil)
+			return tools.OutputTarget{}, models.NewCanonicalJobError("DOC_DOCX_TO_PDF_OUTPUT_INVALID", "outputPath must use .pdf extension", nil)
 		}
 		if outDirErr := validateDOCXOutputDir(filepath.Dir(outputPath)); outDirErr != nil {
-			return "", outDirErr
-		}
-
-		if sameDocFile(inputPath, outputPath) {
-			return "", models.NewCanonicalJobError("DOC_DOCX_TO_PDF_OUTPUT_COLLIDES_INPUT", "output path cannot match input path", nil)
+			return tools.OutputTarget{}, outDirErr
 		}
-
-		if isDocOutputAvailable(outputPath, nil) {
-			return outputPath, nil
+	} else {
+		outputDir := strings.TrimSpace(parsed.outputDir)
+		if outputDir == "" {
+			outputDir = filepath.Dir(inputPath)
 		}
 
-		base := strings.TrimSuffix(outputPath, filepath.Ext(outputPath))
-		for i := 2; ; i++ {
-			candidate := fmt.Sprintf("%s-%d.pdf", base, i)
-			if isDocOutputAvailable(candidate, nil) {
-				return candidate, nil
-			}
+		if outDirErr := validateDOCXOutputDir(outputDir); outDirErr != nil {
+			return tools.OutputTarget{}, outDirErr
 		}
+		outputPath = docxOutputPath(outputDir, "", parsed.nameTmpl, inputPath, 1)
 	}
 
-	outputDir := strings.TrimSpace(rawOutputDir)
-	if outputDir == "" {
-		outputDir = filepath.Dir(inputPath)
-	}
-
-	if outDirErr := validateDOCXOutputDir(outputDir); outDirErr != nil {
-		return "", outDirErr
-	}
-
-	candidate := nextAvailableDOCXOutput(outputDir, inputPath, nil)
-	if sameDocFile(inputPath, candidate) {
-		return "", models.NewCanonicalJobError("DOC_DOCX_TO_PDF_OUTPUT_COLLIDES_INPUT", "output path cannot match input path", nil)
-	}
-
-	return candidate, nil
-}
-
-func nextAvailableDOCXOutput(outputDir, inputPath string, used map[string]struct{}) string {
-	base := strings.TrimSuffix(filepath.Base(inputPath), filepath.Ext(inputPath))
-	if strings.TrimSpace(base) == "" {
-		base = "document"
-	}
-
-	prefix := filepath.Join(outputDir, fmt.Sprintf("%s_docx2pdf", base))
-	first := prefix + ".pdf"
-	if isDocOutputAvailable(first, used) {
-		markDocOutputUsed(first, used)
-		return first
+	if sameDocFile(inputPath, outputPath) {
+		return tools.OutputTarget{}, models.NewCanonicalJobError("DOC_DOCX_TO_PDF_OUTPUT_COLLIDES_INPUT", "output path cannot match input path", nil)
 	}
 
-	for i := 2; ; i++ {
-		candidate := fmt.Sprintf("%s-%d.pdf", prefix, i)
-		if isDocOutputAvailable(candidate, used) {
-			markDocOutputUsed(candidate, used)
-			return candidate
-		}
-	}
+	return tools.ResolveOutputTarget(parsed.conflicts, outputPath, nil)
 }
 
-func isDocOutputAvailable(outputPath string, used map[string]struct{}) bool {
-	cleaned := strings.ToLower(filepath.Clean(outputPath))
-	if used != nil {
-		if _, exists := used[cleaned]; exists {
-			return false
-		}
-	}
-
-	_, err := os.Stat(outputPath)
-	return err != nil
+func docxOutputPath(outputDir, subdir, template, inputPath string, index int) string {
+	return tools.RenderOutputPath(outputDir, template, tools.OutputNameVars{
+		InputPath: inputPath,
+		Ext:       "pdf",
+		Tool:      ToolIDDocDOCXToPDFV1,
+		Index:     index,
+		Subdir:    subdir,
+	})
 }
 
-func markDocOutputUsed(outputPath string, used map[string]struct{}) {
-	if used == nil {
-		return
-	}
-	used[strings.ToLower(filepath.Clean(outputPath))] = struct{}{}
+func (t *DOCXToPDFTool) convert(ctx context.Context, inputPath string, target tools.OutputTarget) (engine.ConvertDOCXResult, error) {
+	var result engine.ConvertDOCXResult
+	err := tools.WriteOutput(ctx, target, func(writePath string) error {
+		var convertErr error
+		result, convertErr = engine.ConvertDOCX(ctx, t.probe, t.runner, engine.ConvertDOCXRequest{InputPath: inputPath, OutputPath: writePath})
+		return convertErr
+	})
+	return result, err
 }
 
 func sameDocFile(inputPath, outputPath string) bool {
@@ -394,11 +466,11 @@ func sameDocFile(inputPath, outputPath string) bool {
 	return left == right
 }
 
-func emitDOCXBatchProgress(onProgress func(models.JobProgressV1), current, total int) {
+func emitDOCXBatchProgress(onProgress func(models.JobProgressV1), current, total int, item *models.JobResultItemV1) {
 	if onProgress == nil {
 		return
 	}
-	onProgress(models.JobProgressV1{Current: current, Total: total, Stage: models.JobStatusRunning, Message: fmt.Sprintf("processed %d/%d", current, total)})
+	onProgress(models.JobProgressV1{Current: current, Total: total, Stage: models.JobStatusRunning, Message: fmt.Sprintf("processed %d/%d", current, total), Item: item})
 }
 
 func mapDOCXEngineError(err error) *models.JobErrorV1 {
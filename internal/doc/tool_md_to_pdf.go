@@ -12,6 +12,7 @@ import (
 
 	"fileforge-desktop/internal/doc/engine"
 	"fileforge-desktop/internal/models"
+	"fileforge-desktop/internal/tools"
 )
 
 const ToolIDDocMDToPDFV1 = "tool.doc.md_to_pdf"
@@ -53,6 +54,36 @@ func (t *MDToPDFTool) Manifest() models.ToolManifestV1 {
 		OutputExtensions: []string{"pdf"},
 		RuntimeDeps:      []string{},
 		Tags:             []string{"doc", "markdown", "pdf", "header", "footer"},
+		Options: []models.OptionSchemaV1{
+			headerFooterSchema("header", "Header"),
+			headerFooterSchema("footer", "Footer"),
+			{Key: "outputPath", Type: models.OptionTypeString, Label: "Output file", Description: "Defaults to the input name with a .pdf extension", Format: "path"},
+			tools.OutputNameTemplateSchema("{name}_md2pdf.{ext}"),
+		},
+	}
+}
+
+func headerFooterSchema(key, label string) models.OptionSchemaV1 {
+	return models.OptionSchemaV1{
+		Key:   key,
+		Type:  models.OptionTypeObject,
+		Label: label,
+		Fields: []models.OptionSchemaV1{
+			{Key: "enabled", Type: models.OptionTypeBoolean, Label: "Enabled", Required: true},
+			{Key: "text", Type: models.OptionTypeString, Label: "Text"},
+			{Key: "align", Type: models.OptionTypeString, Label: "Alignment", Default: "left", Enum: []string{"left", "center", "right"}},
+			{Key: "font", Type: models.OptionTypeString, Label: "Font", Default: "helvetica", Enum: []string{"helvetica", "times", "courier"}},
+			{Key: "marginTop", Type: models.OptionTypeNumber, Label: "Top margin", Required: true, Min: models.OptionBound(0)},
+			{Key: "marginBottom", Type: models.OptionTypeNumber, Label: "Bottom margin", Required: true, Min: models.OptionBound(0)},
+			{Key: "color", Type: models.OptionTypeString, Label: "Color", Description: "#RRGGBB", Default: "#000000", Format: "color"},
+		},
+	}
+}
+
+func (t *MDToPDFTool) RetryPolicy() models.RetryPolicyV1 {
+	return models.RetryPolicyV1{
+		MaxAttempts:    3,
+		RetryableCodes: []string{"DOC_MD_TO_PDF_RENDER_FAILED"},
 	}
 }
 
@@ -65,13 +96,29 @@ func (t *MDToPDFTool) Validate(_ context.Context, req models.JobRequestV1) *mode
 	return jobErr
 }
 
+func (t *MDToPDFTool) Plan(_ context.Context, req models.JobRequestV1) (models.JobPlanV1, *models.JobErrorV1) {
+	prepared, jobErr := parseAndPrepare(req)
+	if jobErr != nil {
+		return models.JobPlanV1{}, jobErr
+	}
+	item := tools.PlanItem(prepared.inputPath, []tools.OutputTarget{prepared.target}, 0)
+	return tools.NewJobPlan(ToolIDDocMDToPDFV1, req.Mode, []models.PlanItemV1{item}), nil
+}
+
 func (t *MDToPDFTool) ExecuteSingle(ctx context.Context, req models.JobRequestV1) (models.JobResultItemV1, *models.JobErrorV1) {
 	prepared, jobErr := parseAndPrepare(req)
 	if jobErr != nil {
 		return models.JobResultItemV1{InputPath: firstPath(req.InputPaths), OutputPath: "", Success: false, Message: jobErr.Message, Error: jobErr}, jobErr
 	}
 
-	if err := engine.RenderMarkdownToPDF(ctx, prepared.renderConfig); err != nil {
+	if prepared.target.Skip {
+		return models.SkippedItemV1(prepared.inputPath, prepared.outputPath), nil
+	}
+
+	err := tools.WriteOutput(ctx, prepared.target, func(string) error {
+		return engine.RenderMarkdownToPDF(ctx, prepared.renderConfig)
+	})
+	if err != nil {
 		renderErr := models.NewCanonicalJobError("DOC_MD_TO_PDF_RENDER_FAILED", err.Error(), map[string]any{"inputPath": prepared.inputPath})
 		return models.JobResultItemV1{
 			InputPath:  prepared.inputPath,
@@ -95,6 +142,7 @@ func (t *MDToPDFTool) ExecuteSingle(ctx context.Context, req models.JobRequestV1
 type preparedRequest struct {
 	inputPath    string
 	outputPath   string
+	target       tools.OutputTarget
 	renderConfig engine.RenderConfig
 }
 
@@ -129,7 +177,12 @@ func parseAndPrepare(req models.JobRequestV1) (preparedRequest, *models.JobError
 		return preparedRequest{}, err
 	}
 
-	outputPath, outputErr := resolveOutputPath(inputPath, req.OutputDir, optionString(req.Options, "outputPath"))
+	if tmplErr := tools.ValidateOutputNameTemplate(req.Options); tmplErr != nil {
+		return preparedRequest{}, tmplErr
+	}
+
+	nameTmpl := tools.OutputNameTemplate(req.Options, "{name}_md2pdf.{ext}")
+	outputPath, outputErr := resolveOutputPath(inputPath, req.OutputDir, optionString(req.Options, "outputPath"), nameTmpl)
 	if outputErr != nil {
 		return preparedRequest{}, outputErr
 	}
@@ -138,12 +191,18 @@ func parseAndPrepare(req models.JobRequestV1) (preparedRequest, *models.JobError
 		return preparedRequest{}, models.NewCanonicalJobError("DOC_MD_TO_PDF_OUTPUT_COLLIDES_INPUT", "output path cannot match input path", nil)
 	}
 
+	target, conflictErr := tools.ResolveOutputTarget(tools.ConflictPolicy(req), outputPath, nil)
+	if conflictErr != nil {
+		return preparedRequest{}, conflictErr
+	}
+
 	return preparedRequest{
 		inputPath:  inputPath,
-		outputPath: outputPath,
+		outputPath: target.Path,
+		target:     target,
 		renderConfig: engine.RenderConfig{
 			InputPath:  inputPath,
-			OutputPath: outputPath,
+			OutputPath: target.WritePath,
 			Header:     header,
 			Footer:     footer,
 		},
@@ -276,7 +335,7 @@ func validatePlaceholders(text string) error {
 	return nil
 }
 
-func resolveOutputPath(inputPath, outputDir, rawOutputPath string) (string, *models.JobErrorV1) {
+func resolveOutputPath(inputPath, outputDir, rawOutputPath, nameTmpl string) (string, *models.JobErrorV1) {
 	if strings.TrimSpace(rawOutputPath) != "" {
 		outputPath := strings.TrimSpace(rawOutputPath)
 		if strings.ToLower(filepath.Ext(outputPath)) != ".pdf" {
@@ -285,17 +344,7 @@ func resolveOutputPath(inputPath, outputDir, rawOutputPath string) (string, *mod
 		if dirErr := validateOutputDir(filepath.Dir(outputPath)); dirErr != nil {
 			return "", dirErr
 		}
-		if isOutputAvailable(outputPath) {
-			return outputPath, nil
-		}
-
-		base := strings.TrimSuffix(outputPath, filepath.Ext(outputPath))
-		for i := 2; ; i++ {
-			candidate := fmt.Sprintf("%s-%d.pdf", base, i)
-			if isOutputAvailable(candidate) {
-				return candidate, nil
-			}
-		}
+		return outputPath, nil
 	}
 
 	targetDir := strings.TrimSpace(outputDir)
@@ -307,23 +356,11 @@ func resolveOutputPath(inputPath, outputDir, rawOutputPath string) (string, *mod
 		return "", dirErr
 	}
 
-	base := strings.TrimSuffix(filepath.Base(inputPath), filepath.Ext(inputPath))
-	if strings.TrimSpace(base) == "" {
-		base = "document"
-	}
-
-	prefix := filepath.Join(targetDir, fmt.Sprintf("%s_md2pdf", base))
-	first := prefix + ".pdf"
-	if isOutputAvailable(first) {
-		return first, nil
-	}
-
-	for i := 2; ; i++ {
-		candidate := fmt.Sprintf("%s-%d.pdf", prefix, i)
-		if isOutputAvailable(candidate) {
-			return candidate, nil
-		}
-	}
+	return tools.RenderOutputPath(targetDir, nameTmpl, tools.OutputNameVars{
+		InputPath: inputPath,
+		Ext:       "pdf",
+		Tool:      ToolIDDocMDToPDFV1,
+	}), nil
 }
 
 func validateOutputDir(dir string) *models.JobErrorV1 {
@@ -342,11 +379,6 @@ func validateOutputDir(dir string) *models.JobErrorV1 {
 	return nil
 }
 
-func isOutputAvailable(outputPath string) bool {
-	_, err := os.Stat(outputPath)
-	return err != nil
-}
-
 func optionString(options map[string]any, key string) string {
 	if options == nil {
 		return ""
@@ -3,11 +3,11 @@ package image
 import (
 	"context"
 	"fmt"
-	"os"
-	"path/filepath"
+	"sort"
 	"strings"
 
 	"fileforge-desktop/internal/models"
+	"fileforge-desktop/internal/tools"
 )
 
 const (
@@ -35,6 +35,9 @@ func (t *ImageToolAdapter) Capability() string {
 }
 
 func (t *ImageToolAdapter) Manifest() models.ToolManifestV1 {
+	formats := t.converter.SupportedFormats()
+	sort.Strings(formats)
+
 	return models.ToolManifestV1{
 		ToolID:           t.ID(),
 		Name:             "Image Converter",
@@ -44,15 +47,28 @@ func (t *ImageToolAdapter) Manifest() models.ToolManifestV1 {
 		Version:          "v1",
 		SupportsSingle:   true,
 		SupportsBatch:    true,
-		InputExtensions:  []string{"jpg", "jpeg", "png", "gif", "webp", "bmp", "tiff", "tif"},
-		OutputExtensions: t.converter.SupportedFormats(),
+		InputExtensions:  imageInputExtensions,
+		OutputExtensions: formats,
 		RuntimeDeps:      []string{"libvips"},
 		Tags:             []string{"image", "convert"},
+		Options: []models.OptionSchemaV1{
+			{Key: "format", Type: models.OptionTypeString, Label: "Output format", Default: "webp", Enum: formats},
+			{Key: "quality", Type: models.OptionTypeInteger, Label: "Quality", Description: "Encoder quality, 0 uses the format default; the highest quality tried with targetBytes", Min: models.OptionBound(0), Max: models.OptionBound(100)},
+			{Key: "targetBytes", Type: models.OptionTypeInteger, Label: "Target size (bytes)", Description: "Lower the quality until each output fits in this many bytes", Min: models.OptionBound(1)},
+			{Key: "minQuality", Type: models.OptionTypeInteger, Label: "Minimum quality", Description: "Lowest quality tried with targetBytes", Default: defaultTargetMinQuality, Min: models.OptionBound(1), Max: models.OptionBound(100)},
+			{Key: "allowDownscale", Type: models.OptionTypeBoolean, Label: "Allow downscaling", Description: "Shrink the image when the minimum quality still misses targetBytes", Default: false},
+			{Key: "width", Type: models.OptionTypeInteger, Label: "Width", Description: "Resize to this width in pixels, 0 keeps the original", Min: models.OptionBound(0)},
+			{Key: "height", Type: models.OptionTypeInteger, Label: "Height", Description: "Resize to this height in pixels, 0 keeps the original", Min: models.OptionBound(0)},
+			{Key: "page", Type: models.OptionTypeInteger, Label: "Page", Description: "Page of a multi-page TIFF to convert, 1-based", Default: 1, Min: models.OptionBound(1)},
+			metadataOptionSchema(),
+			{Key: "outputPath", Type: models.OptionTypeString, Label: "Output file", Description: "Defaults to the input name in outputDir", Format: "path", Modes: []string{"single"}},
+			tools.OutputNameTemplateSchema("{name}.{ext}"),
+		},
 	}
 }
 
 func (t *ImageToolAdapter) RuntimeState(_ context.Context) models.ToolRuntimeStateV1 {
-	return models.ToolRuntimeStateV1{Status: "enabled", Healthy: true}
+	return imageRuntimeState()
 }
 
 func (t *ImageToolAdapter) Validate(_ context.Context, req models.JobRequestV1) *models.JobErrorV1 {
@@ -77,17 +93,76 @@ func (t *ImageToolAdapter) Validate(_ context.Context, req models.JobRequestV1)
 		return models.NewCanonicalJobError("IMAGE_BATCH_OUTPUT_DIR_REQUIRED", "outputDir is required in batch mode", nil)
 	}
 
+	if tmplErr := tools.ValidateOutputNameTemplate(req.Options); tmplErr != nil {
+		return tmplErr
+	}
+
+	if _, _, targetErr := parseSizeTarget(req.Options, "IMAGE", false); targetErr != nil {
+		return targetErr
+	}
+
+	if _, pageErr := optionPage(req.Options, "IMAGE"); pageErr != nil {
+		return pageErr
+	}
+
+	metadata, metaErr := optionMetadata(req.Options, "IMAGE")
+	if metaErr != nil {
+		return metaErr
+	}
+	if formatErr := validateMetadataFormat(metadata, format, "IMAGE"); formatErr != nil {
+		return formatErr
+	}
+
 	return nil
 }
 
+// Plan resolves the output path of every input; the converted size is
+// estimated from the input size, capped at targetBytes.
+func (t *ImageToolAdapter) Plan(ctx context.Context, req models.JobRequestV1) (models.JobPlanV1, *models.JobErrorV1) {
+	if jobErr := t.Validate(ctx, req); jobErr != nil {
+		return models.JobPlanV1{}, jobErr
+	}
+
+	format := t.resolveFormat(req.Options)
+	limit, hasTarget, _ := parseSizeTarget(req.Options, "IMAGE", false)
+	policy := tools.ConflictPolicy(req)
+	usedOutputs := make(map[string]struct{}, len(req.InputPaths))
+	items := make([]models.PlanItemV1, 0, len(req.InputPaths))
+	for index, inputPath := range req.InputPaths {
+		outputPath := t.resolveBatchOutputPath(inputPath, req.OutputDir, req.InputSubdirs[inputPath], format, req.Options, index+1)
+		if req.Mode == "single" {
+			outputPath = t.resolveSingleOutputPath(inputPath, req.OutputDir, format, req.Options)
+		}
+		target, conflictErr := tools.ResolveOutputTarget(policy, outputPath, usedOutputs)
+		if conflictErr != nil {
+			items = append(items, tools.FailedPlanItem(inputPath, conflictErr))
+			continue
+		}
+		estimate := tools.FileSize(inputPath)
+		if hasTarget {
+			estimate = min(estimate, limit.bytes)
+		}
+		items = append(items, tools.PlanItem(inputPath, []tools.OutputTarget{target}, estimate))
+	}
+
+	return tools.NewJobPlan(t.ID(), req.Mode, items), nil
+}
+
 func (t *ImageToolAdapter) ExecuteSingle(ctx context.Context, req models.JobRequestV1) (models.JobResultItemV1, *models.JobErrorV1) {
 	inputPath := req.InputPaths[0]
 	format := t.resolveFormat(req.Options)
-	outputPath := t.resolveSingleOutputPath(inputPath, req.OutputDir, format, req.Options)
+	target, conflictErr := tools.ResolveOutputTarget(tools.ConflictPolicy(req), t.resolveSingleOutputPath(inputPath, req.OutputDir, format, req.Options), nil)
+	if conflictErr != nil {
+		return models.JobResultItemV1{InputPath: inputPath, Success: false, Message: conflictErr.Message, Error: conflictErr}, conflictErr
+	}
+	outputPath := target.Path
+	if target.Skip {
+		return models.SkippedItemV1(inputPath, outputPath), nil
+	}
 
-	err := t.converter.ConvertSingle(ctx, inputPath, outputPath, format, req.Options)
+	sized, err := t.convert(ctx, inputPath, target, format, req.Options)
 	if err != nil {
-		jobErr := models.NewCanonicalJobError("IMAGE_SINGLE_EXECUTION", err.Error(), nil)
+		jobErr := imageItemError(err, "IMAGE_SINGLE_EXECUTION", inputPath)
 		return models.JobResultItemV1{
 			InputPath:  inputPath,
 			OutputPath: outputPath,
@@ -97,12 +172,7 @@ func (t *ImageToolAdapter) ExecuteSingle(ctx context.Context, req models.JobRequ
 		}, jobErr
 	}
 
-	return models.JobResultItemV1{
-		InputPath:  inputPath,
-		OutputPath: outputPath,
-		Success:    true,
-		Message:    "conversion successful",
-	}, nil
+	return convertedItem(inputPath, outputPath, sized), nil
 }
 
 func (t *ImageToolAdapter) ExecuteBatch(ctx context.Context, req models.JobRequestV1, onProgress func(models.JobProgressV1)) ([]models.JobResultItemV1, *models.JobErrorV1) {
@@ -110,6 +180,7 @@ func (t *ImageToolAdapter) ExecuteBatch(ctx context.Context, req models.JobReque
 	items := make([]models.JobResultItemV1, 0, len(req.InputPaths))
 	total := len(req.InputPaths)
 	usedOutputs := make(map[string]struct{}, len(req.InputPaths))
+	policy := tools.ConflictPolicy(req)
 
 	for index, inputPath := range req.InputPaths {
 		select {
@@ -118,10 +189,25 @@ func (t *ImageToolAdapter) ExecuteBatch(ctx context.Context, req models.JobReque
 		default:
 		}
 
-		outputPath := t.resolveBatchOutputPath(inputPath, req.OutputDir, format, usedOutputs)
-		err := t.converter.ConvertSingle(ctx, inputPath, outputPath, format, req.Options)
-		if err != nil {
-			itemErr := models.NewCanonicalJobError("IMAGE_BATCH_ITEM", err.Error(), map[string]any{"inputPath": inputPath})
+		target, conflictErr := tools.ResolveOutputTarget(policy, t.resolveBatchOutputPath(inputPath, req.OutputDir, req.InputSubdirs[inputPath], format, req.Options, index+1), usedOutputs)
+		outputPath := target.Path
+		var sized sizedOutput
+		var err error
+		cancelled := tools.ItemCancelled(ctx, inputPath)
+		if !cancelled && conflictErr == nil && !target.Skip {
+			itemCtx, stopItem := tools.ItemContext(ctx, inputPath)
+			sized, err = t.convert(itemCtx, inputPath, target, format, req.Options)
+			stopItem()
+			cancelled = err != nil && ctx.Err() == nil && tools.ItemCancelled(ctx, inputPath)
+		}
+		if cancelled {
+			items = append(items, models.CancelledItemV1(inputPath, outputPath))
+		} else if conflictErr != nil {
+			items = append(items, models.JobResultItemV1{InputPath: inputPath, Success: false, Message: conflictErr.Message, Error: conflictErr})
+		} else if target.Skip {
+			items = append(items, models.SkippedItemV1(inputPath, outputPath))
+		} else if err != nil {
+			itemErr := imageItemError(err, "IMAGE_BATCH_ITEM", inputPath)
 			items = append(items, models.JobResultItemV1{
 				InputPath:  inputPath,
 				OutputPath: outputPath,
@@ -130,12 +216,7 @@ func (t *ImageToolAdapter) ExecuteBatch(ctx context.Context, req models.JobReque
 				Error:      itemErr,
 			})
 		} else {
-			items = append(items, models.JobResultItemV1{
-				InputPath:  inputPath,
-				OutputPath: outputPath,
-				Success:    true,
-				Message:    "conversion successful",
-			})
+			items = append(items, convertedItem(inputPath, outputPath, sized))
 		}
 
 		if onProgress != nil {
@@ -144,13 +225,14 @@ func (t *ImageToolAdapter) ExecuteBatch(ctx context.Context, req models.JobReque
 				Total:   total,
 				Stage:   models.JobStatusRunning,
 				Message: fmt.Sprintf("processed %d/%d", index+1, total),
+				Item:    models.LastResultItemV1(items),
 			})
 		}
 	}
 
 	var firstErr *models.JobErrorV1
 	for _, item := range items {
-		if !item.Success && item.Error != nil {
+		if !item.Success && item.Error != nil && item.Status != models.JobItemStatusCancelled {
 			firstErr = item.Error
 			break
 		}
@@ -169,7 +251,7 @@ func (t *ImageToolAdapter) resolveFormat(options map[string]any) string {
 		return "webp"
 	}
 
-	return strings.ToLower(strings.TrimSpace(format))
+	return canonicalFormat(format)
 }
 
 func (t *ImageToolAdapter) resolveSingleOutputPath(inputPath, outputDir, format string, options map[string]any) string {
@@ -179,36 +261,55 @@ func (t *ImageToolAdapter) resolveSingleOutputPath(inputPath, outputDir, format
 		}
 	}
 
-	if strings.TrimSpace(outputDir) == "" {
-		outputDir = filepath.Dir(inputPath)
-	}
+	return t.resolveBatchOutputPath(inputPath, outputDir, "", format, options, 1)
+}
 
-	name := strings.TrimSuffix(filepath.Base(inputPath), filepath.Ext(inputPath))
-	return filepath.Join(outputDir, fmt.Sprintf("%s.%s", name, format))
+func (t *ImageToolAdapter) resolveBatchOutputPath(inputPath, outputDir, subdir, format string, options map[string]any, index int) string {
+	template := tools.OutputNameTemplate(options, "{name}.{ext}")
+	return imageOutputPath(outputDir, template, tools.OutputNameVars{
+		InputPath: inputPath,
+		Ext:       format,
+		Tool:      ToolIDImageConvertV1,
+		Index:     index,
+		Subdir:    subdir,
+	})
 }
 
-func (t *ImageToolAdapter) resolveBatchOutputPath(inputPath, outputDir, format string, used map[string]struct{}) string {
-	name := strings.TrimSuffix(filepath.Base(inputPath), filepath.Ext(inputPath))
-	if strings.TrimSpace(name) == "" {
-		name = "image"
+// convert writes the converted input to target. With targetBytes set it
+// returns the size, quality and dimensions the search settled on.
+func (t *ImageToolAdapter) convert(ctx context.Context, inputPath string, target tools.OutputTarget, format string, options map[string]any) (sizedOutput, error) {
+	limit, hasTarget, targetErr := parseSizeTarget(options, "IMAGE", false)
+	if targetErr != nil {
+		return sizedOutput{}, fmt.Errorf("%s", targetErr.Message)
+	}
+	if !hasTarget {
+		return sizedOutput{}, tools.WriteOutput(ctx, target, func(writePath string) error {
+			return t.converter.ConvertSingle(ctx, inputPath, writePath, format, options)
+		})
 	}
 
-	candidate := filepath.Join(outputDir, fmt.Sprintf("%s.%s", name, format))
-	if _, exists := used[candidate]; !exists {
-		if _, err := os.Stat(candidate); os.IsNotExist(err) {
-			used[candidate] = struct{}{}
-			return candidate
-		}
+	sized, err := t.converter.ConvertFileToSize(ctx, inputPath, format, options, limit)
+	if err != nil {
+		return sizedOutput{}, err
+	}
+	if writeErr := tools.WriteOutputFile(ctx, target, sized.data, DefaultFilePermissions); writeErr != nil {
+		return sizedOutput{}, fmt.Errorf("error writing output file: %w", writeErr)
 	}
+	return sized, nil
+}
 
-	for suffix := 2; ; suffix++ {
-		next := filepath.Join(outputDir, fmt.Sprintf("%s-%d.%s", name, suffix, format))
-		if _, exists := used[next]; exists {
-			continue
-		}
-		if _, err := os.Stat(next); os.IsNotExist(err) {
-			used[next] = struct{}{}
-			return next
-		}
+func convertedItem(inputPath, outputPath string, sized sizedOutput) models.JobResultItemV1 {
+	item := models.JobResultItemV1{
+		InputPath:  inputPath,
+		OutputPath: outputPath,
+		Success:    true,
+		Message:    "conversion successful",
+	}
+	if sized.data != nil {
+		item.OutputWidth, item.OutputHeight = sized.width, sized.height
+		item.OutputBytes = int64(len(sized.data))
+		item.OutputQuality = sized.quality
+		item.Message = fmt.Sprintf("converted to %d bytes at %dx%d", item.OutputBytes, sized.width, sized.height)
 	}
+	return item
 }
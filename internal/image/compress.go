@@ -0,0 +1,237 @@
+package image
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+
+	"fileforge-desktop/internal/models"
+
+	"github.com/h2non/bimg"
+)
+
+const (
+	defaultTargetMinQuality = 10
+	defaultTargetMaxQuality = 90
+	maxTargetDownscaleSteps = 12
+	minTargetEdge           = 16 // pixels; the long edge is never stepped below this
+)
+
+// qualityFormats are the output formats whose size follows the encoder
+// quality. Other formats only shrink with their dimensions.
+var qualityFormats = map[string]bool{"jpeg": true, "webp": true, "avif": true, "heif": true}
+
+// sizeTarget bounds the search for an encoding of at most bytes.
+type sizeTarget struct {
+	bytes      int64
+	minQuality int
+	maxQuality int
+	downscale  bool // step the dimensions down when minQuality alone is too large
+}
+
+type sizedOutput struct {
+	data    []byte
+	quality int // 0 for formats without a quality setting
+	width   int
+	height  int
+}
+
+// targetUnreachableError describes the smallest encoding the search found.
+type targetUnreachableError struct {
+	target   int64
+	smallest int64
+	quality  int
+	width    int
+	height   int
+}
+
+func (e *targetUnreachableError) Error() string {
+	return fmt.Sprintf("cannot fit output in %d bytes: smallest encoding was %d bytes (quality %d, %dx%d)", e.target, e.smallest, e.quality, e.width, e.height)
+}
+
+// parseSizeTarget reads targetBytes, quality (the highest quality tried),
+// minQuality and allowDownscale. ok is false when targetBytes is not set.
+func parseSizeTarget(options map[string]any, codePrefix string, downscaleDefault bool) (sizeTarget, bool, *models.JobErrorV1) {
+	optionCode := codePrefix + "_OPTION_INVALID"
+	targetBytes, ok, err := optionalInt(options, "targetBytes", optionCode)
+	if err != nil || !ok {
+		return sizeTarget{}, false, err
+	}
+	if targetBytes < 1 {
+		return sizeTarget{}, false, models.NewCanonicalJobError(codePrefix+"_TARGET_BYTES_INVALID", "options.targetBytes must be >= 1", nil)
+	}
+
+	target := sizeTarget{bytes: int64(targetBytes), minQuality: defaultTargetMinQuality, maxQuality: defaultTargetMaxQuality, downscale: downscaleDefault}
+	if quality, set, qErr := optionalInt(options, "quality", optionCode); qErr != nil {
+		return sizeTarget{}, false, qErr
+	} else if set && quality > 0 {
+		target.maxQuality = quality
+	}
+	if quality, set, qErr := optionalInt(options, "minQuality", optionCode); qErr != nil {
+		return sizeTarget{}, false, qErr
+	} else if set {
+		target.minQuality = quality
+	}
+	if target.minQuality < 1 || target.maxQuality > 100 || target.minQuality > target.maxQuality {
+		return sizeTarget{}, false, models.NewCanonicalJobError(codePrefix+"_QUALITY_INVALID", fmt.Sprintf("quality range %d-%d is invalid: need 1 <= minQuality <= quality <= 100", target.minQuality, target.maxQuality), nil)
+	}
+	if downscale, set := options["allowDownscale"].(bool); set {
+		target.downscale = downscale
+	}
+
+	return target, true, nil
+}
+
+// imageItemError maps a conversion failure to a job error. An unreachable
+// size target or a missing page keeps its numbers in the details.
+func imageItemError(err error, detailCode, inputPath string) *models.JobErrorV1 {
+	if pageErr := pageJobError(err, "IMAGE", inputPath); pageErr != nil {
+		return pageErr
+	}
+
+	var unreachable *targetUnreachableError
+	if errors.As(err, &unreachable) {
+		return models.NewCanonicalJobError("IMAGE_TARGET_SIZE_UNREACHABLE", err.Error(), map[string]any{
+			"inputPath":     inputPath,
+			"targetBytes":   unreachable.target,
+			"smallestBytes": unreachable.smallest,
+			"quality":       unreachable.quality,
+			"width":         unreachable.width,
+			"height":        unreachable.height,
+		})
+	}
+	return models.NewCanonicalJobError(detailCode, err.Error(), map[string]any{"inputPath": inputPath})
+}
+
+// encodeToTarget encodes input with base, binary-searching the quality for the
+// best encoding that fits target.bytes. When even minQuality is too large and
+// downscaling is allowed, the dimensions shrink by the square root of the
+// overshoot and the search repeats. Explicit base.Width/Height are the
+// starting size.
+func encodeToTarget(ctx context.Context, input []byte, base bimg.Options, format string, target sizeTarget) (sizedOutput, error) {
+	size, err := bimg.NewImage(input).Size()
+	if err != nil {
+		return sizedOutput{}, fmt.Errorf("read image size: %w", err)
+	}
+
+	width, height := size.Width, size.Height
+	switch {
+	case base.Width > 0 && base.Height > 0:
+		width, height = base.Width, base.Height
+	case base.Width > 0:
+		width, height = base.Width, roundPixels(float64(size.Height)*float64(base.Width)/float64(size.Width))
+	case base.Height > 0:
+		width, height = roundPixels(float64(size.Width)*float64(base.Height)/float64(size.Height)), base.Height
+	}
+	if format == "png" {
+		base.Compression = 9
+	}
+
+	for step := 0; ; step++ {
+		encode := func(quality int) ([]byte, error) {
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				return nil, fmt.Errorf("compression cancelled: %w", ctxErr)
+			}
+			opts := base
+			opts.Quality = quality
+			if step > 0 || base.Width > 0 || base.Height > 0 {
+				opts.Width, opts.Height = width, height
+			}
+			return bimg.NewImage(input).Process(opts)
+		}
+
+		data, quality, fits, searchErr := searchQuality(encode, format, target)
+		if searchErr != nil {
+			return sizedOutput{}, searchErr
+		}
+		if fits {
+			out := sizedOutput{data: data, quality: quality, width: width, height: height}
+			if encoded, sizeErr := bimg.NewImage(data).Size(); sizeErr == nil {
+				out.width, out.height = encoded.Width, encoded.Height
+			}
+			return out, nil
+		}
+
+		if !target.downscale || step == maxTargetDownscaleSteps || max(width, height) <= minTargetEdge {
+			return sizedOutput{}, &targetUnreachableError{target: target.bytes, smallest: int64(len(data)), quality: quality, width: width, height: height}
+		}
+
+		factor := math.Sqrt(float64(target.bytes)/float64(len(data))) * 0.95
+		factor = min(max(factor, 0.5), 0.9)
+		width, height = roundPixels(float64(width)*factor), roundPixels(float64(height)*factor)
+	}
+}
+
+// searchQuality returns the highest-quality encoding that fits; when none
+// does, it returns the minQuality encoding with fits false.
+func searchQuality(encode func(quality int) ([]byte, error), format string, target sizeTarget) ([]byte, int, bool, error) {
+	if !qualityFormats[format] {
+		data, err := encode(0)
+		return data, 0, err == nil && int64(len(data)) <= target.bytes, err
+	}
+
+	data, err := encode(target.maxQuality)
+	if err != nil || int64(len(data)) <= target.bytes {
+		return data, target.maxQuality, err == nil, err
+	}
+	if target.minQuality == target.maxQuality {
+		return data, target.maxQuality, false, nil
+	}
+
+	best, err := encode(target.minQuality)
+	if err != nil || int64(len(best)) > target.bytes {
+		return best, target.minQuality, false, err
+	}
+
+	bestQuality := target.minQuality
+	lo, hi := target.minQuality+1, target.maxQuality-1
+	for lo <= hi {
+		mid := (lo + hi) / 2
+		candidate, encErr := encode(mid)
+		if encErr != nil {
+			return nil, 0, false, encErr
+		}
+		if int64(len(candidate)) <= target.bytes {
+			best, bestQuality = candidate, mid
+			lo = mid + 1
+		} else {
+			hi = mid - 1
+		}
+	}
+	return best, bestQuality, true, nil
+}
+
+// ConvertFileToSize converts inputPath like ConvertSingle, searching quality
+// and, when allowed, dimensions until the output fits target.
+func (c *ImageConverter) ConvertFileToSize(ctx context.Context, inputPath, format string, options map[string]any, target sizeTarget) (sizedOutput, error) {
+	if err := c.validateInputFile(inputPath); err != nil {
+		return sizedOutput{}, fmt.Errorf("input validation failed: %w", err)
+	}
+
+	input, err := readInputPage(inputPath, options)
+	if err != nil {
+		return sizedOutput{}, err
+	}
+
+	opts := map[string]any{"format": format}
+	for k, v := range options {
+		opts[k] = v
+	}
+	base, err := c.processOptions(opts)
+	if err != nil {
+		return sizedOutput{}, err
+	}
+
+	sized, err := encodeToTarget(ctx, input, base, format, target)
+	if err != nil {
+		return sizedOutput{}, err
+	}
+
+	mode, _ := optionMetadata(options, "IMAGE")
+	sized.data, err = finishMetadata(sized.data, mode)
+	if err != nil {
+		return sizedOutput{}, fmt.Errorf("error stripping gps metadata: %w", err)
+	}
+	return sized, nil
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 
 	"github.com/h2non/bimg"
@@ -26,13 +27,25 @@ func NewImageConverter() *ImageConverter {
 			"jpeg": bimg.JPEG,
 			"png":  bimg.PNG,
 			"gif":  bimg.GIF,
+			"tiff": bimg.TIFF,
+			"avif": bimg.AVIF,
+			"heif": bimg.HEIF,
 		},
 	}
 }
 
 func (c *ImageConverter) Convert(input []byte, opts map[string]any) ([]byte, error) {
-	img := bimg.NewImage(input)
+	bimgOptions, err := c.processOptions(opts)
+	if err != nil {
+		return nil, err
+	}
 
+	return bimg.NewImage(input).Process(bimgOptions)
+}
+
+// processOptions maps the format, quality, width, height and metadata
+// options to bimg.
+func (c *ImageConverter) processOptions(opts map[string]any) (bimg.Options, error) {
 	format, ok := opts["format"].(string)
 	if !ok {
 		format = "webp"
@@ -40,7 +53,7 @@ func (c *ImageConverter) Convert(input []byte, opts map[string]any) ([]byte, err
 
 	imageType, exists := c.formats[format]
 	if !exists {
-		return nil, fmt.Errorf("unsupported image format: %s", format)
+		return bimg.Options{}, fmt.Errorf("unsupported image format: %s", format)
 	}
 
 	bimgOptions := bimg.Options{
@@ -65,7 +78,11 @@ func (c *ImageConverter) Convert(input []byte, opts map[string]any) ([]byte, err
 		bimgOptions.Height = height
 	}
 
-	return img.Process(bimgOptions)
+	if mode, _ := optionMetadata(opts, "IMAGE"); mode == metadataStrip {
+		bimgOptions.StripMetadata = true
+	}
+
+	return bimgOptions, nil
 }
 
 func (c *ImageConverter) SupportedFormats() []string {
@@ -94,9 +111,9 @@ func (c *ImageConverter) ConvertSingle(ctx context.Context, inputPath, outputPat
 		return fmt.Errorf("format validation failed: %w", err)
 	}
 
-	input, err := os.ReadFile(inputPath)
+	input, err := readInputPage(inputPath, options)
 	if err != nil {
-		return fmt.Errorf("error reading file: %w", err)
+		return err
 	}
 
 	// Check context before conversion
@@ -115,6 +132,12 @@ func (c *ImageConverter) ConvertSingle(ctx context.Context, inputPath, outputPat
 		return fmt.Errorf("error converting file: %w", err)
 	}
 
+	mode, _ := optionMetadata(options, "IMAGE")
+	output, err = finishMetadata(output, mode)
+	if err != nil {
+		return fmt.Errorf("error stripping gps metadata: %w", err)
+	}
+
 	// Check context before writing
 	select {
 	case <-ctx.Done():
@@ -144,30 +167,29 @@ func (c *ImageConverter) validateInputFile(inputPath string) error {
 	// Remove the dot from extension
 	ext = ext[1:]
 
-	// Define supported input extensions
-	// TODO: Make this configurable or extendable
-	supportedInputExts := map[string]bool{
-		"jpg":  true,
-		"jpeg": true,
-		"png":  true,
-		"gif":  true,
-		"webp": true,
-		"bmp":  true,
-		"tiff": true,
-		"tif":  true,
+	if !slices.Contains(imageInputExtensions, ext) {
+		return fmt.Errorf("unsupported input file extension '%s'. Supported extensions: %v", ext, imageInputExtensions)
 	}
 
-	if !supportedInputExts[ext] {
-		var supported []string
-		for supportedExt := range supportedInputExts {
-			supported = append(supported, supportedExt)
-		}
-		return fmt.Errorf("unsupported input file extension '%s'. Supported extensions: %v", ext, supported)
+	if read, _ := formatSupport(ext); !read {
+		return fmt.Errorf("libvips on this machine cannot read %s files", canonicalFormat(ext))
 	}
 
 	return nil
 }
 
+// readInputPage reads inputPath, keeping only the page selected by
+// options.page when the input is a multi-page TIFF.
+func readInputPage(inputPath string, options map[string]any) ([]byte, error) {
+	input, err := os.ReadFile(inputPath)
+	if err != nil {
+		return nil, fmt.Errorf("error reading file: %w", err)
+	}
+
+	page, _, _ := optionalInt(options, "page", "IMAGE_OPTION_INVALID")
+	return selectImagePage(input, page)
+}
+
 // validateOutputFormat checks if the output format is supported
 func (c *ImageConverter) validateOutputFormat(format string) error {
 	if format == "" {
@@ -180,5 +202,9 @@ func (c *ImageConverter) validateOutputFormat(format string) error {
 		return fmt.Errorf("unsupported output format '%s'. Supported formats: %v", format, c.SupportedFormats())
 	}
 
+	if _, write := formatSupport(format); !write {
+		return fmt.Errorf("libvips on this machine cannot write %s files", format)
+	}
+
 	return nil
 }
@@ -0,0 +1,95 @@
+package image
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"math"
+)
+
+var pngSignature = []byte("\x89PNG\r\n\x1a\n")
+
+// dpiFormats are the output formats setImageDPI can write the density of.
+var dpiFormats = map[string]bool{"jpeg": true, "png": true}
+
+// setImageDPI records dpi as the pixel density of an encoded JPEG (JFIF APP0
+// segment) or PNG (pHYs chunk). The pixel data is left untouched.
+func setImageDPI(data []byte, format string, dpi int) ([]byte, error) {
+	switch format {
+	case "jpeg":
+		return setJPEGDPI(data, dpi)
+	case "png":
+		return setPNGDPI(data, dpi)
+	default:
+		return nil, fmt.Errorf("dpi cannot be set on %s output", format)
+	}
+}
+
+func setJPEGDPI(data []byte, dpi int) ([]byte, error) {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return nil, fmt.Errorf("not a jpeg stream")
+	}
+
+	density := uint16(min(dpi, math.MaxUint16))
+	if len(data) >= 18 && data[2] == 0xFF && data[3] == 0xE0 && bytes.Equal(data[6:11], []byte("JFIF\x00")) {
+		out := bytes.Clone(data)
+		out[13] = 1 // density unit: dots per inch
+		binary.BigEndian.PutUint16(out[14:16], density)
+		binary.BigEndian.PutUint16(out[16:18], density)
+		return out, nil
+	}
+
+	app0 := []byte{0xFF, 0xE0, 0x00, 0x10, 'J', 'F', 'I', 'F', 0x00, 0x01, 0x01, 0x01, 0, 0, 0, 0, 0x00, 0x00}
+	binary.BigEndian.PutUint16(app0[12:14], density)
+	binary.BigEndian.PutUint16(app0[14:16], density)
+
+	out := make([]byte, 0, len(data)+len(app0))
+	out = append(out, data[:2]...)
+	out = append(out, app0...)
+	return append(out, data[2:]...), nil
+}
+
+// setPNGDPI writes a pHYs chunk right after IHDR, dropping any existing one.
+func setPNGDPI(data []byte, dpi int) ([]byte, error) {
+	if len(data) < len(pngSignature) || !bytes.Equal(data[:len(pngSignature)], pngSignature) {
+		return nil, fmt.Errorf("not a png stream")
+	}
+
+	ppm := uint32(math.Round(float64(dpi) / 0.0254))
+	phys := make([]byte, 9)
+	binary.BigEndian.PutUint32(phys[0:4], ppm)
+	binary.BigEndian.PutUint32(phys[4:8], ppm)
+	phys[8] = 1 // unit: metre
+
+	out := make([]byte, 0, len(data)+21)
+	out = append(out, pngSignature...)
+	for offset := len(pngSignature); offset < len(data); {
+		if offset+8 > len(data) {
+			return nil, fmt.Errorf("truncated png chunk")
+		}
+		length := int(binary.BigEndian.Uint32(data[offset : offset+4]))
+		chunkType := string(data[offset+4 : offset+8])
+		end := offset + 12 + length
+		if length < 0 || end > len(data) {
+			return nil, fmt.Errorf("truncated png chunk %s", chunkType)
+		}
+
+		if chunkType != "pHYs" {
+			out = append(out, data[offset:end]...)
+		}
+		if chunkType == "IHDR" {
+			out = appendPNGChunk(out, "pHYs", phys)
+		}
+		offset = end
+	}
+	return out, nil
+}
+
+func appendPNGChunk(out []byte, chunkType string, payload []byte) []byte {
+	out = binary.BigEndian.AppendUint32(out, uint32(len(payload)))
+	start := len(out)
+	out = append(out, chunkType...)
+	out = append(out, payload...)
+	return binary.BigEndian.AppendUint32(out, crc32.ChecksumIEEE(out[start:]))
+}
@@ -0,0 +1,329 @@
+package image
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"strings"
+)
+
+const (
+	exifTagOrientation = 0x0112
+	exifTagExifIFD     = 0x8769
+	exifTagGPSIFD      = 0x8825
+)
+
+// exifTagNames names the tags reported by the metadata tool, per directory.
+var exifTagNames = map[string]map[uint16]string{
+	"ifd0": {
+		0x010E: "ImageDescription", 0x010F: "Make", 0x0110: "Model", 0x0112: "Orientation",
+		0x011A: "XResolution", 0x011B: "YResolution", 0x0128: "ResolutionUnit", 0x0131: "Software",
+		0x0132: "DateTime", 0x013B: "Artist", 0x8298: "Copyright",
+	},
+	"exif": {
+		0x829A: "ExposureTime", 0x829D: "FNumber", 0x8822: "ExposureProgram", 0x8827: "ISOSpeedRatings",
+		0x9003: "DateTimeOriginal", 0x9004: "DateTimeDigitized", 0x9010: "OffsetTime", 0x9011: "OffsetTimeOriginal",
+		0x9201: "ShutterSpeedValue", 0x9202: "ApertureValue", 0x9204: "ExposureBiasValue", 0x9207: "MeteringMode",
+		0x9209: "Flash", 0x920A: "FocalLength", 0xA001: "ColorSpace", 0xA002: "PixelXDimension", 0xA003: "PixelYDimension",
+		0xA402: "ExposureMode", 0xA403: "WhiteBalance", 0xA405: "FocalLengthIn35mmFilm", 0xA420: "ImageUniqueID",
+		0xA430: "CameraOwnerName", 0xA431: "BodySerialNumber", 0xA432: "LensSpecification", 0xA433: "LensMake",
+		0xA434: "LensModel", 0xA435: "LensSerialNumber",
+	},
+	"gps": {
+		0x0001: "GPSLatitudeRef", 0x0002: "GPSLatitude", 0x0003: "GPSLongitudeRef", 0x0004: "GPSLongitude",
+		0x0005: "GPSAltitudeRef", 0x0006: "GPSAltitude", 0x0007: "GPSTimeStamp", 0x0010: "GPSImgDirectionRef",
+		0x0011: "GPSImgDirection", 0x0012: "GPSMapDatum", 0x001D: "GPSDateStamp",
+	},
+}
+
+// exifCameraTags identify the device and its owner; the camera strip group
+// removes them from IFD0 and the Exif directory.
+var exifCameraTags = map[uint16]bool{
+	0x010F: true, 0x0110: true, 0x927C: true, 0xA420: true, 0xA430: true,
+	0xA431: true, 0xA432: true, 0xA433: true, 0xA434: true, 0xA435: true, 0xC62F: true,
+}
+
+var exifTypeSizes = map[uint16]int{1: 1, 2: 1, 3: 2, 4: 4, 5: 8, 6: 1, 7: 1, 8: 2, 9: 4, 10: 8, 11: 4, 12: 8}
+
+// exifBlob is a TIFF-structured EXIF block: the payload of a JPEG APP1
+// segment, a PNG eXIf chunk, a WebP EXIF chunk, or a whole TIFF file. Edits
+// happen in place and never change its length.
+type exifBlob struct {
+	data  []byte
+	order binary.ByteOrder
+}
+
+type exifEntry struct {
+	tag     uint16
+	typ     uint16
+	count   uint32
+	valueAt int // start of the value, inline in the entry when it fits in 4 bytes
+	size    int
+	inline  bool
+}
+
+func parseExifBlob(data []byte) (*exifBlob, error) {
+	if len(data) < 8 {
+		return nil, fmt.Errorf("exif block is too short")
+	}
+	var order binary.ByteOrder
+	switch string(data[:4]) {
+	case "II*\x00":
+		order = binary.LittleEndian
+	case "MM\x00*":
+		order = binary.BigEndian
+	default:
+		return nil, fmt.Errorf("exif block has no tiff header")
+	}
+	return &exifBlob{data: data, order: order}, nil
+}
+
+func (b *exifBlob) ifd0() int {
+	return int(b.order.Uint32(b.data[4:8]))
+}
+
+// entries reads the directory at offset. Entries whose value would fall
+// outside the block are skipped.
+func (b *exifBlob) entries(offset int) ([]exifEntry, error) {
+	if offset < 8 || offset+2 > len(b.data) {
+		return nil, fmt.Errorf("exif directory offset %d is out of range", offset)
+	}
+	count := int(b.order.Uint16(b.data[offset : offset+2]))
+	if offset+2+count*12+4 > len(b.data) {
+		return nil, fmt.Errorf("exif directory at %d is truncated", offset)
+	}
+
+	entries := make([]exifEntry, 0, count)
+	for i := 0; i < count; i++ {
+		at := offset + 2 + i*12
+		entry := exifEntry{
+			tag:   b.order.Uint16(b.data[at : at+2]),
+			typ:   b.order.Uint16(b.data[at+2 : at+4]),
+			count: b.order.Uint32(b.data[at+4 : at+8]),
+		}
+		unit, known := exifTypeSizes[entry.typ]
+		if !known || uint64(entry.count)*uint64(unit) > uint64(len(b.data)) {
+			continue
+		}
+		entry.size = int(entry.count) * unit
+		entry.valueAt, entry.inline = at+8, entry.size <= 4
+		if !entry.inline {
+			entry.valueAt = int(b.order.Uint32(b.data[at+8 : at+12]))
+			if entry.valueAt+entry.size > len(b.data) {
+				continue
+			}
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// subIFD returns the offset of the directory a pointer tag in IFD0 refers to.
+func (b *exifBlob) subIFD(tag uint16) (int, bool) {
+	entries, err := b.entries(b.ifd0())
+	if err != nil {
+		return 0, false
+	}
+	for _, entry := range entries {
+		if entry.tag == tag && entry.inline && entry.size == 4 {
+			return int(b.order.Uint32(b.data[entry.valueAt : entry.valueAt+4])), true
+		}
+	}
+	return 0, false
+}
+
+// value decodes an entry: text for ASCII, numbers for integer and rational
+// types, a slice when count > 1. Opaque values are summarised by size.
+func (b *exifBlob) value(entry exifEntry) any {
+	raw := b.data[entry.valueAt : entry.valueAt+entry.size]
+	var values []any
+	switch entry.typ {
+	case 2:
+		return strings.TrimRight(string(raw), "\x00 ")
+	case 1, 6:
+		for _, v := range raw {
+			values = append(values, int(v))
+		}
+	case 3, 8:
+		for i := 0; i+2 <= len(raw); i += 2 {
+			v := b.order.Uint16(raw[i:])
+			if entry.typ == 8 {
+				values = append(values, int(int16(v)))
+			} else {
+				values = append(values, int(v))
+			}
+		}
+	case 4, 9:
+		for i := 0; i+4 <= len(raw); i += 4 {
+			v := b.order.Uint32(raw[i:])
+			if entry.typ == 9 {
+				values = append(values, int(int32(v)))
+			} else {
+				values = append(values, int(v))
+			}
+		}
+	case 5, 10:
+		for i := 0; i+8 <= len(raw); i += 8 {
+			num, den := b.order.Uint32(raw[i:]), b.order.Uint32(raw[i+4:])
+			if den == 0 {
+				values = append(values, 0.0)
+			} else if entry.typ == 10 {
+				values = append(values, float64(int32(num))/float64(int32(den)))
+			} else {
+				values = append(values, float64(num)/float64(den))
+			}
+		}
+	case 11:
+		for i := 0; i+4 <= len(raw); i += 4 {
+			values = append(values, float64(math.Float32frombits(b.order.Uint32(raw[i:]))))
+		}
+	case 12:
+		for i := 0; i+8 <= len(raw); i += 8 {
+			values = append(values, math.Float64frombits(b.order.Uint64(raw[i:])))
+		}
+	default:
+		text := strings.TrimRight(string(raw), "\x00 ")
+		if len(text) <= 64 && isPrintable(text) {
+			return text
+		}
+		return fmt.Sprintf("%d bytes", entry.size)
+	}
+
+	if len(values) == 1 {
+		return values[0]
+	}
+	return values
+}
+
+func isPrintable(text string) bool {
+	for _, r := range text {
+		if r < 0x20 || r > 0x7E {
+			return false
+		}
+	}
+	return true
+}
+
+// tags decodes the named tags of the directory at offset.
+func (b *exifBlob) tags(offset int, names map[uint16]string) map[string]any {
+	entries, err := b.entries(offset)
+	if err != nil {
+		return nil
+	}
+	out := make(map[string]any)
+	for _, entry := range entries {
+		if name, ok := names[entry.tag]; ok {
+			out[name] = b.value(entry)
+		}
+	}
+	return out
+}
+
+// removeEntries drops the entries matching remove from the directory at
+// offset, zeroing their out-of-line values. The directory keeps its place;
+// the freed tail of the entry table is zeroed.
+func (b *exifBlob) removeEntries(offset int, remove func(exifEntry) bool) error {
+	entries, err := b.entries(offset)
+	if err != nil {
+		return err
+	}
+	oldCount := int(b.order.Uint16(b.data[offset : offset+2]))
+	oldEnd := offset + 2 + oldCount*12 + 4
+	next := b.order.Uint32(b.data[offset+2+oldCount*12 : oldEnd])
+
+	var kept [][]byte
+	for i := 0; i < oldCount; i++ {
+		at := offset + 2 + i*12
+		tag := b.order.Uint16(b.data[at : at+2])
+		var matched *exifEntry
+		for j := range entries {
+			if entries[j].tag == tag {
+				matched = &entries[j]
+				break
+			}
+		}
+		if matched != nil && remove(*matched) {
+			if !matched.inline {
+				clear(b.data[matched.valueAt : matched.valueAt+matched.size])
+			}
+			continue
+		}
+		kept = append(kept, append([]byte(nil), b.data[at:at+12]...))
+	}
+
+	clear(b.data[offset:oldEnd])
+	b.order.PutUint16(b.data[offset:offset+2], uint16(len(kept)))
+	for i, entry := range kept {
+		copy(b.data[offset+2+i*12:], entry)
+	}
+	b.order.PutUint32(b.data[offset+2+len(kept)*12:], next)
+	return nil
+}
+
+// stripGPS removes the GPS directory and the IFD0 pointer to it.
+func (b *exifBlob) stripGPS() error {
+	gps, ok := b.subIFD(exifTagGPSIFD)
+	if !ok {
+		return nil
+	}
+	if err := b.removeEntries(gps, func(exifEntry) bool { return true }); err == nil {
+		clear(b.data[gps : gps+6])
+	}
+	return b.removeEntries(b.ifd0(), func(entry exifEntry) bool { return entry.tag == exifTagGPSIFD })
+}
+
+// stripCamera removes make, model, lens, serial numbers, owner and maker notes.
+func (b *exifBlob) stripCamera() error {
+	isCamera := func(entry exifEntry) bool { return exifCameraTags[entry.tag] }
+	if exifIFD, ok := b.subIFD(exifTagExifIFD); ok {
+		if err := b.removeEntries(exifIFD, isCamera); err != nil {
+			return err
+		}
+	}
+	return b.removeEntries(b.ifd0(), isCamera)
+}
+
+// resetOrientation marks the pixels as upright, for outputs whose pixels were
+// rotated according to the original orientation.
+func (b *exifBlob) resetOrientation() {
+	entries, err := b.entries(b.ifd0())
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		if entry.tag == exifTagOrientation && entry.typ == 3 && entry.inline {
+			b.order.PutUint16(b.data[entry.valueAt:entry.valueAt+2], 1)
+		}
+	}
+}
+
+// gpsCoordinates converts the GPS directory to signed decimal degrees.
+func (b *exifBlob) gpsCoordinates() (lat, lon float64, ok bool) {
+	offset, found := b.subIFD(exifTagGPSIFD)
+	if !found {
+		return 0, 0, false
+	}
+	tags := b.tags(offset, exifTagNames["gps"])
+	lat, latOK := dmsToDegrees(tags["GPSLatitude"], tags["GPSLatitudeRef"], "S")
+	lon, lonOK := dmsToDegrees(tags["GPSLongitude"], tags["GPSLongitudeRef"], "W")
+	return lat, lon, latOK && lonOK
+}
+
+func dmsToDegrees(dms, ref any, negativeRef string) (float64, bool) {
+	parts, ok := dms.([]any)
+	if !ok || len(parts) != 3 {
+		return 0, false
+	}
+	var degrees float64
+	for i, divisor := range []float64{1, 60, 3600} {
+		v, isFloat := parts[i].(float64)
+		if !isFloat {
+			return 0, false
+		}
+		degrees += v / divisor
+	}
+	if refText, _ := ref.(string); strings.EqualFold(refText, negativeRef) {
+		degrees = -degrees
+	}
+	return degrees, true
+}
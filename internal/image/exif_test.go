@@ -0,0 +1,249 @@
+package image
+
+import (
+	"encoding/binary"
+	"math"
+	"reflect"
+	"testing"
+)
+
+// testByteOrder reads and appends in the byte order of a test block.
+type testByteOrder interface {
+	binary.ByteOrder
+	binary.AppendByteOrder
+}
+
+type testExifEntry struct {
+	tag   uint16
+	typ   uint16
+	count uint32
+	value []byte
+}
+
+func exifASCII(tag uint16, text string) testExifEntry {
+	return testExifEntry{tag: tag, typ: 2, count: uint32(len(text) + 1), value: append([]byte(text), 0)}
+}
+
+func exifShort(order testByteOrder, tag uint16, v uint16) testExifEntry {
+	return testExifEntry{tag: tag, typ: 3, count: 1, value: order.AppendUint16(nil, v)}
+}
+
+func exifLong(order testByteOrder, tag uint16, v uint32) testExifEntry {
+	return testExifEntry{tag: tag, typ: 4, count: 1, value: order.AppendUint32(nil, v)}
+}
+
+func exifRationals(order testByteOrder, tag uint16, values ...uint32) testExifEntry {
+	var raw []byte
+	for _, v := range values {
+		raw = order.AppendUint32(raw, v)
+		raw = order.AppendUint32(raw, 1)
+	}
+	return testExifEntry{tag: tag, typ: 5, count: uint32(len(values)), value: raw}
+}
+
+// exifDirSize is the size of a directory and its out-of-line values.
+func exifDirSize(entries []testExifEntry) int {
+	size := 2 + len(entries)*12 + 4
+	for _, entry := range entries {
+		if len(entry.value) > 4 {
+			size += len(entry.value)
+		}
+	}
+	return size
+}
+
+// appendExifDir appends a directory at the end of data, followed by the
+// values that do not fit in their entries.
+func appendExifDir(data []byte, order testByteOrder, entries []testExifEntry) []byte {
+	valuesAt := len(data) + 2 + len(entries)*12 + 4
+	var values []byte
+	data = order.AppendUint16(data, uint16(len(entries)))
+	for _, entry := range entries {
+		data = order.AppendUint16(data, entry.tag)
+		data = order.AppendUint16(data, entry.typ)
+		data = order.AppendUint32(data, entry.count)
+		if len(entry.value) > 4 {
+			data = order.AppendUint32(data, uint32(valuesAt+len(values)))
+			values = append(values, entry.value...)
+			continue
+		}
+		var inline [4]byte
+		copy(inline[:], entry.value)
+		data = append(data, inline[:]...)
+	}
+	data = order.AppendUint32(data, 0)
+	return append(data, values...)
+}
+
+// testExif builds an EXIF block with camera, orientation and GPS tags in IFD0,
+// a camera tag in the Exif directory, and a GPS directory at 48°30'N 2°15'36"W.
+func testExif(order testByteOrder) []byte {
+	data := []byte("II*\x00")
+	if order == binary.BigEndian {
+		data = []byte("MM\x00*")
+	}
+	data = order.AppendUint32(data, 8)
+
+	exifDir := []testExifEntry{
+		exifASCII(0xA434, "50mm lens"),
+		exifShort(order, 0xA001, 1),
+	}
+	gpsDir := []testExifEntry{
+		exifASCII(0x0001, "N"),
+		exifRationals(order, 0x0002, 48, 30, 0),
+		exifASCII(0x0003, "W"),
+		exifRationals(order, 0x0004, 2, 15, 36),
+	}
+	ifd0 := []testExifEntry{
+		exifASCII(0x010F, "Canon"),
+		exifASCII(0x0110, "EOS"),
+		exifShort(order, exifTagOrientation, 6),
+		exifASCII(0x0131, "fileforge"),
+		{tag: exifTagExifIFD, typ: 4, count: 1},
+		{tag: exifTagGPSIFD, typ: 4, count: 1},
+	}
+	exifAt := 8 + exifDirSize(ifd0)
+	gpsAt := exifAt + exifDirSize(exifDir)
+	ifd0[4] = exifLong(order, exifTagExifIFD, uint32(exifAt))
+	ifd0[5] = exifLong(order, exifTagGPSIFD, uint32(gpsAt))
+
+	data = appendExifDir(data, order, ifd0)
+	data = appendExifDir(data, order, exifDir)
+	return appendExifDir(data, order, gpsDir)
+}
+
+func parseTestExif(t *testing.T, order testByteOrder) *exifBlob {
+	t.Helper()
+	blob, err := parseExifBlob(testExif(order))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return blob
+}
+
+func TestExifTags(t *testing.T) {
+	for name, order := range map[string]testByteOrder{"little endian": binary.LittleEndian, "big endian": binary.BigEndian} {
+		t.Run(name, func(t *testing.T) {
+			blob := parseTestExif(t, order)
+
+			want := map[string]any{"Make": "Canon", "Model": "EOS", "Orientation": 6, "Software": "fileforge"}
+			if got := blob.tags(blob.ifd0(), exifTagNames["ifd0"]); !reflect.DeepEqual(got, want) {
+				t.Fatalf("ifd0 tags = %v, want %v", got, want)
+			}
+			exifDir, ok := blob.subIFD(exifTagExifIFD)
+			if !ok {
+				t.Fatal("exif directory not found")
+			}
+			if got := blob.tags(exifDir, exifTagNames["exif"]); got["LensModel"] != "50mm lens" || got["ColorSpace"] != 1 {
+				t.Fatalf("exif tags = %v", got)
+			}
+
+			lat, lon, ok := blob.gpsCoordinates()
+			if !ok || math.Abs(lat-48.5) > 1e-9 || math.Abs(lon+2.26) > 1e-9 {
+				t.Fatalf("gpsCoordinates = %v, %v, %v, want 48.5, -2.26", lat, lon, ok)
+			}
+		})
+	}
+}
+
+func TestParseExifBlobRejectsInvalidData(t *testing.T) {
+	for _, data := range [][]byte{nil, []byte("II*\x00"), []byte("JFIF\x00\x00\x00\x00")} {
+		if _, err := parseExifBlob(data); err == nil {
+			t.Fatalf("parseExifBlob(%q) accepted invalid data", data)
+		}
+	}
+}
+
+// Values pointing outside the block are skipped rather than read.
+func TestExifEntriesSkipOutOfRangeValues(t *testing.T) {
+	data := testExif(binary.LittleEndian)
+	// The Make entry is the first one in IFD0; its value offset follows tag,
+	// type and count.
+	binary.LittleEndian.PutUint32(data[8+2+8:], uint32(len(data)))
+	blob, err := parseExifBlob(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tags := blob.tags(blob.ifd0(), exifTagNames["ifd0"])
+	if _, ok := tags["Make"]; ok || tags["Model"] != "EOS" {
+		t.Fatalf("ifd0 tags = %v, want Model without Make", tags)
+	}
+	if _, err := blob.entries(len(data)); err == nil {
+		t.Fatal("entries accepted an offset past the end of the block")
+	}
+}
+
+func TestExifStripGPS(t *testing.T) {
+	blob := parseTestExif(t, binary.LittleEndian)
+	size := len(blob.data)
+
+	if err := blob.stripGPS(); err != nil {
+		t.Fatal(err)
+	}
+	if len(blob.data) != size {
+		t.Fatalf("block size changed from %d to %d", size, len(blob.data))
+	}
+	if _, ok := blob.subIFD(exifTagGPSIFD); ok {
+		t.Fatal("GPS pointer kept")
+	}
+	if _, _, ok := blob.gpsCoordinates(); ok {
+		t.Fatal("GPS coordinates kept")
+	}
+	tags := blob.tags(blob.ifd0(), exifTagNames["ifd0"])
+	if tags["Make"] != "Canon" || tags["Software"] != "fileforge" {
+		t.Fatalf("ifd0 tags = %v, want the other tags kept", tags)
+	}
+	if _, ok := blob.subIFD(exifTagExifIFD); !ok {
+		t.Fatal("exif directory pointer removed")
+	}
+
+	// Stripping a block without GPS data is a no-op.
+	if err := blob.stripGPS(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestExifStripCamera(t *testing.T) {
+	blob := parseTestExif(t, binary.BigEndian)
+
+	if err := blob.stripCamera(); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]any{"Orientation": 6, "Software": "fileforge"}
+	if got := blob.tags(blob.ifd0(), exifTagNames["ifd0"]); !reflect.DeepEqual(got, want) {
+		t.Fatalf("ifd0 tags = %v, want %v", got, want)
+	}
+	exifDir, ok := blob.subIFD(exifTagExifIFD)
+	if !ok {
+		t.Fatal("exif directory pointer removed")
+	}
+	if got := blob.tags(exifDir, exifTagNames["exif"]); !reflect.DeepEqual(got, map[string]any{"ColorSpace": 1}) {
+		t.Fatalf("exif tags = %v, want only ColorSpace", got)
+	}
+	if _, _, ok := blob.gpsCoordinates(); !ok {
+		t.Fatal("GPS coordinates removed")
+	}
+}
+
+func TestExifResetOrientation(t *testing.T) {
+	blob := parseTestExif(t, binary.LittleEndian)
+
+	blob.resetOrientation()
+	if got := blob.tags(blob.ifd0(), exifTagNames["ifd0"])["Orientation"]; got != 1 {
+		t.Fatalf("orientation = %v, want 1", got)
+	}
+}
+
+func TestDMSToDegrees(t *testing.T) {
+	dms := []any{10.0, 30.0, 36.0}
+	if got, ok := dmsToDegrees(dms, "s", "S"); !ok || math.Abs(got+10.51) > 1e-9 {
+		t.Fatalf("dmsToDegrees = %v, %v, want -10.51", got, ok)
+	}
+	if _, ok := dmsToDegrees([]any{10.0, 30.0}, "N", "S"); ok {
+		t.Fatal("accepted two components")
+	}
+	if _, ok := dmsToDegrees(10.0, "N", "S"); ok {
+		t.Fatal("accepted a single number")
+	}
+}
@@ -0,0 +1,145 @@
+package image
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"strings"
+
+	"fileforge-desktop/internal/models"
+
+	"github.com/h2non/bimg"
+)
+
+// imageInputExtensions are the file extensions the image tools accept.
+var imageInputExtensions = []string{"jpg", "jpeg", "png", "gif", "webp", "bmp", "tiff", "tif", "avif", "heic", "heif"}
+
+// imageOutputFormats are the canonical output format names; imageFormatEnum
+// adds the extension aliases accepted by the format option.
+var (
+	imageOutputFormats = []string{"jpeg", "png", "webp", "gif", "tiff", "avif", "heif"}
+	imageFormatEnum    = []string{"jpeg", "jpg", "png", "webp", "gif", "tiff", "tif", "avif", "heif", "heic"}
+)
+
+// coreFormats must be readable and writable for an image tool to be healthy;
+// the others depend on optional libvips modules.
+var coreFormats = map[string]bool{"jpeg": true, "png": true}
+
+// canonicalFormat maps extension aliases to output format names.
+func canonicalFormat(name string) string {
+	name = strings.ToLower(strings.TrimSpace(name))
+	switch name {
+	case "jpg":
+		return "jpeg"
+	case "tif":
+		return "tiff"
+	case "heic":
+		return "heif"
+	}
+	return name
+}
+
+// formatSupport reports whether the linked libvips can load and save format.
+// BMP has no bimg type and is read through the generic loader.
+func formatSupport(format string) (read, write bool) {
+	format = canonicalFormat(format)
+	imageType := mapFormatToImageType(format)
+	if imageType == bimg.UNKNOWN {
+		return format == "bmp", false
+	}
+	support := bimg.IsImageTypeSupportedByVips(imageType)
+	return support.Load, support.Save
+}
+
+// imageRuntimeState lists the read/write support of every output format.
+// Missing optional formats degrade the tool; missing core formats make it
+// unhealthy.
+func imageRuntimeState() models.ToolRuntimeStateV1 {
+	state := models.ToolRuntimeStateV1{Status: "enabled", Healthy: true}
+	var missing []string
+	for _, format := range imageOutputFormats {
+		read, write := formatSupport(format)
+		state.Formats = append(state.Formats, models.FormatSupportV1{Format: format, Read: read, Write: write})
+		switch {
+		case read && write:
+			continue
+		case !read && !write:
+			missing = append(missing, format)
+		case !read:
+			missing = append(missing, format+" (read)")
+		default:
+			missing = append(missing, format+" (write)")
+		}
+		if coreFormats[format] {
+			state.Healthy = false
+		}
+	}
+
+	if len(missing) > 0 {
+		state.Status = "degraded"
+		state.Reason = "libvips lacks support for " + strings.Join(missing, ", ")
+	}
+	return state
+}
+
+// decodeImage decodes input with the Go decoders, going through a libvips PNG
+// conversion for formats they cannot read such as TIFF, AVIF and HEIF.
+func decodeImage(input []byte) (image.Image, error) {
+	if decoded, _, err := image.Decode(bytes.NewReader(input)); err == nil {
+		return decoded, nil
+	}
+
+	converted, err := bimg.NewImage(input).Convert(bimg.PNG)
+	if err != nil {
+		return nil, fmt.Errorf("decode image: %w", err)
+	}
+	decoded, _, err := image.Decode(bytes.NewReader(converted))
+	if err != nil {
+		return nil, fmt.Errorf("decode image: %w", err)
+	}
+	return decoded, nil
+}
+
+// optionPage reads the 1-based page option; 0 when it is not set.
+func optionPage(options map[string]any, codePrefix string) (int, *models.JobErrorV1) {
+	page, ok, err := optionalInt(options, "page", codePrefix+"_OPTION_INVALID")
+	if err != nil || !ok {
+		return 0, err
+	}
+	if page < 1 {
+		return 0, models.NewCanonicalJobError(codePrefix+"_PAGE_INVALID", "options.page must be >= 1", nil)
+	}
+	return page, nil
+}
+
+// pageJobError returns the job error for a page outside the input, or nil
+// when err is about something else.
+func pageJobError(err error, codePrefix, inputPath string) *models.JobErrorV1 {
+	var pageErr *pageRangeError
+	if !errors.As(err, &pageErr) {
+		return nil
+	}
+	return models.NewCanonicalJobError(codePrefix+"_PAGE_INVALID", pageErr.Error(), map[string]any{
+		"inputPath": inputPath,
+		"page":      pageErr.page,
+		"pageCount": pageErr.count,
+	})
+}
+
+// browserFormats are the formats the webview renders as they are.
+var browserFormats = map[string]bool{"jpeg": true, "png": true, "webp": true, "gif": true, "avif": true}
+
+// previewImage returns data ready for the webview with its MIME type; other
+// formats such as TIFF and HEIF are converted to PNG first.
+func previewImage(data []byte, format string) ([]byte, string, error) {
+	if browserFormats[format] {
+		return data, imageMimeByFormat[format], nil
+	}
+
+	converted, err := bimg.NewImage(data).Convert(bimg.PNG)
+	if err != nil {
+		return nil, "", fmt.Errorf("convert preview to png: %w", err)
+	}
+	return converted, imageMimeByFormat["png"], nil
+}
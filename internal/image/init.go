@@ -9,10 +9,18 @@ func init() {
 	adapter := NewImageToolAdapter(NewImageConverter())
 	cropTool := NewCropTool()
 	annotateTool := NewAnnotateTool()
+	resizeTool := NewResizeTool()
+	compressTool := NewCompressTool()
+	metadataTool := NewMetadataTool()
+	watermarkTool := NewWatermarkTool()
 
 	registry.GetGlobalRegistry().SafeRegisterToolV2(adapter)
 	registry.GetGlobalRegistry().SafeRegisterToolV2(cropTool)
 	registry.GetGlobalRegistry().SafeRegisterToolV2(annotateTool)
+	registry.GetGlobalRegistry().SafeRegisterToolV2(resizeTool)
+	registry.GetGlobalRegistry().SafeRegisterToolV2(compressTool)
+	registry.GetGlobalRegistry().SafeRegisterToolV2(metadataTool)
+	registry.GetGlobalRegistry().SafeRegisterToolV2(watermarkTool)
 
 	// Optionally log any initialization errors (non-blocking)
 	go func() {
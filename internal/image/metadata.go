@@ -0,0 +1,344 @@
+package image
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"unicode/utf16"
+
+	"fileforge-desktop/internal/models"
+
+	"github.com/h2non/bimg"
+)
+
+// Values of the metadata option shared by convert, crop and annotate.
+const (
+	metadataPreserve = "preserve"
+	metadataStrip    = "strip"
+	metadataStripGPS = "strip-gps"
+)
+
+var metadataModes = []string{metadataPreserve, metadataStrip, metadataStripGPS}
+
+// gpsScrubFormats are the outputs strip-gps can edit without re-encoding. GIF
+// output never carries EXIF.
+var gpsScrubFormats = map[string]bool{"jpeg": true, "png": true, "webp": true, "tiff": true, "gif": true}
+
+func metadataOptionSchema() models.OptionSchemaV1 {
+	return models.OptionSchemaV1{
+		Key:         "metadata",
+		Type:        models.OptionTypeString,
+		Label:       "Metadata",
+		Description: "Keep EXIF, ICC and XMP data, strip all of it, or strip only the GPS location",
+		Default:     metadataPreserve,
+		Enum:        metadataModes,
+	}
+}
+
+// optionMetadata reads the metadata option; preserve when it is not set.
+func optionMetadata(options map[string]any, codePrefix string) (string, *models.JobErrorV1) {
+	raw, _ := options["metadata"].(string)
+	mode := strings.ToLower(strings.TrimSpace(raw))
+	if mode == "" {
+		return metadataPreserve, nil
+	}
+	if !slices.Contains(metadataModes, mode) {
+		return "", models.NewCanonicalJobError(codePrefix+"_METADATA_INVALID", fmt.Sprintf("options.metadata must be one of %s", strings.Join(metadataModes, ", ")), nil)
+	}
+	return mode, nil
+}
+
+// validateMetadataFormat rejects strip-gps for outputs whose EXIF cannot be
+// edited after encoding, such as HEIF and AVIF.
+func validateMetadataFormat(mode, outputFmt, codePrefix string) *models.JobErrorV1 {
+	outputFmt = canonicalFormat(outputFmt)
+	if mode != metadataStripGPS || gpsScrubFormats[outputFmt] {
+		return nil
+	}
+	return models.NewCanonicalJobError(codePrefix+"_METADATA_UNSUPPORTED", fmt.Sprintf("metadata=strip-gps is not supported for %s output; use strip", outputFmt), nil)
+}
+
+// carriedMetadataFormats are the formats carryMetadata can read EXIF and ICC
+// data from and write them into.
+var carriedMetadataFormats = map[string]bool{"jpeg": true, "png": true, "webp": true}
+
+// validateCarriedMetadata is validateMetadataFormat for tools that re-encode
+// decoded pixels and copy the metadata across with carryMetadata. It also
+// rejects preserve when the source or the output would silently lose it.
+// Sources without metadata, such as GIF and BMP, have nothing to lose.
+func validateCarriedMetadata(mode, inputFmt, outputFmt, codePrefix string) *models.JobErrorV1 {
+	if formatErr := validateMetadataFormat(mode, outputFmt, codePrefix); formatErr != nil {
+		return formatErr
+	}
+	if mode != metadataPreserve {
+		return nil
+	}
+	inputFmt, outputFmt = canonicalFormat(inputFmt), canonicalFormat(outputFmt)
+	if !carriedMetadataFormats[outputFmt] && outputFmt != "gif" {
+		return models.NewCanonicalJobError(codePrefix+"_METADATA_UNSUPPORTED", fmt.Sprintf("metadata=preserve is not supported for %s output; use strip", outputFmt), map[string]any{"format": outputFmt})
+	}
+	switch inputFmt {
+	case "tiff", "heif", "avif":
+		return models.NewCanonicalJobError(codePrefix+"_METADATA_UNSUPPORTED", fmt.Sprintf("metadata=preserve is not supported for %s input; use strip", inputFmt), map[string]any{"format": inputFmt})
+	}
+	return nil
+}
+
+// finishMetadata applies strip-gps to an output libvips encoded with the
+// source metadata. preserve and strip are handled by the encoder.
+func finishMetadata(data []byte, mode string) ([]byte, error) {
+	if mode != metadataStripGPS || metadataContainer(data) == "" {
+		return data, nil
+	}
+	return editExif(data, (*exifBlob).stripGPS)
+}
+
+// carryMetadata copies the EXIF block and ICC profile of source into out, a
+// re-encoding of the same pixels that lost them. The orientation is reset
+// because source was auto-rotated. Only JPEG, PNG and WebP outputs carry
+// metadata across, and only EXIF from JPEG, PNG and WebP sources.
+func carryMetadata(source, out []byte, mode string) ([]byte, error) {
+	switch metadataContainer(out) {
+	case "jpeg", "png", "webp":
+	default:
+		return out, nil
+	}
+	if mode == metadataStrip {
+		return out, nil
+	}
+
+	var exif []byte
+	if metadataContainer(source) != "tiff" {
+		exif = exifPayload(source)
+	}
+	if exif != nil {
+		blob, err := parseExifBlob(exif)
+		if err != nil {
+			exif = nil
+		} else {
+			blob.resetOrientation()
+			if mode == metadataStripGPS {
+				if err := blob.stripGPS(); err != nil {
+					return nil, fmt.Errorf("strip gps: %w", err)
+				}
+			}
+		}
+	}
+	return injectMetadata(out, exif, iccProfile(source))
+}
+
+// Groups of the metadata tool's strip action.
+const (
+	metadataGroupGPS    = "gps"
+	metadataGroupCamera = "camera"
+	metadataGroupAll    = "all"
+)
+
+var metadataGroups = []string{metadataGroupGPS, metadataGroupCamera, metadataGroupAll}
+
+// stripMetadataGroups removes groups from an encoded image. GPS and camera
+// tags are removed in place, leaving the pixels untouched; all drops every
+// metadata block of a JPEG, PNG or WebP and re-encodes other formats. A
+// non-upright orientation survives all so the image still displays the same.
+func stripMetadataGroups(data []byte, groups []string) ([]byte, error) {
+	if slices.Contains(groups, metadataGroupAll) {
+		orientation := 0
+		if exif := exifPayload(data); exif != nil {
+			if blob, err := parseExifBlob(exif); err == nil {
+				orientation = blob.orientation()
+			}
+		}
+
+		out, err := stripAllMetadata(data)
+		if errors.Is(err, errMetadataContainer) {
+			return bimg.NewImage(data).Process(bimg.Options{StripMetadata: true})
+		}
+		if err != nil || orientation <= 1 {
+			return out, err
+		}
+		return injectMetadata(out, orientationExif(orientation), nil)
+	}
+
+	out := data
+	for _, group := range groups {
+		var edit func(*exifBlob) error
+		switch group {
+		case metadataGroupGPS:
+			edit = (*exifBlob).stripGPS
+		case metadataGroupCamera:
+			edit = (*exifBlob).stripCamera
+		default:
+			continue
+		}
+		edited, err := editExif(out, edit)
+		if err != nil {
+			return nil, err
+		}
+		out = edited
+	}
+	return out, nil
+}
+
+// orientationExif is a minimal EXIF block holding only the orientation.
+func orientationExif(orientation int) []byte {
+	blob := []byte("II*\x00\x08\x00\x00\x00\x01\x00")
+	blob = binary.LittleEndian.AppendUint16(blob, exifTagOrientation)
+	blob = binary.LittleEndian.AppendUint16(blob, 3)
+	blob = binary.LittleEndian.AppendUint32(blob, 1)
+	blob = binary.LittleEndian.AppendUint32(blob, uint32(orientation))
+	return binary.LittleEndian.AppendUint32(blob, 0)
+}
+
+// readImageMetadata reports the metadata of an encoded image. EXIF, GPS and
+// ICC are decoded from the container; HEIF and AVIF fall back to the fields
+// libvips exposes.
+func readImageMetadata(data []byte) (models.ImageMetadataV1, error) {
+	meta, err := bimg.NewImage(data).Metadata()
+	if err != nil {
+		return models.ImageMetadataV1{}, fmt.Errorf("read image metadata: %w", err)
+	}
+
+	report := models.ImageMetadataV1{
+		Format:      canonicalFormat(meta.Type),
+		Width:       meta.Size.Width,
+		Height:      meta.Size.Height,
+		Orientation: meta.Orientation,
+		Channels:    meta.Channels,
+		HasAlpha:    meta.Alpha,
+		HasICC:      meta.Profile,
+		HasXMP:      hasXMP(data),
+	}
+
+	container := metadataContainer(data)
+	if container == "" {
+		report.EXIF = libvipsExif(meta.EXIF)
+		report.HasEXIF = len(report.EXIF) > 0
+		report.HasGPS = meta.EXIF.GPSLatitude != ""
+		return report, nil
+	}
+
+	if exif := exifPayload(data); exif != nil {
+		if blob, parseErr := parseExifBlob(exif); parseErr == nil {
+			report.EXIF = blob.tags(blob.ifd0(), exifTagNames["ifd0"])
+			exifIFD, hasExifIFD := blob.subIFD(exifTagExifIFD)
+			if hasExifIFD {
+				for name, value := range blob.tags(exifIFD, exifTagNames["exif"]) {
+					report.EXIF[name] = value
+				}
+			}
+			_, report.HasGPS = blob.subIFD(exifTagGPSIFD)
+			report.GPS = blob.gps()
+			// A TIFF always has IFD0; it only carries EXIF with an Exif directory.
+			report.HasEXIF = container != "tiff" || hasExifIFD || report.HasGPS
+			if !report.HasEXIF {
+				report.EXIF = nil
+			}
+		}
+	}
+
+	if profile := iccProfile(data); profile != nil {
+		report.HasICC = true
+		report.ICC = describeICC(profile)
+	}
+	return report, nil
+}
+
+func libvipsExif(exif bimg.EXIF) map[string]any {
+	out := make(map[string]any)
+	for name, value := range map[string]string{
+		"Make":             exif.Make,
+		"Model":            exif.Model,
+		"Software":         exif.Software,
+		"DateTime":         exif.Datetime,
+		"DateTimeOriginal": exif.DateTimeOriginal,
+		"ExposureTime":     exif.ExposureTime,
+		"FNumber":          exif.FNumber,
+		"FocalLength":      exif.FocalLength,
+	} {
+		if value != "" {
+			out[name] = value
+		}
+	}
+	if exif.ISOSpeedRatings > 0 {
+		out["ISOSpeedRatings"] = exif.ISOSpeedRatings
+	}
+	return out
+}
+
+// gps decodes the GPS directory; nil when it has no usable coordinates.
+func (b *exifBlob) gps() *models.ImageGPSV1 {
+	lat, lon, ok := b.gpsCoordinates()
+	if !ok {
+		return nil
+	}
+
+	gps := &models.ImageGPSV1{Latitude: lat, Longitude: lon}
+	offset, _ := b.subIFD(exifTagGPSIFD)
+	tags := b.tags(offset, exifTagNames["gps"])
+	if altitude, isFloat := tags["GPSAltitude"].(float64); isFloat {
+		if ref, _ := tags["GPSAltitudeRef"].(int); ref == 1 {
+			altitude = -altitude
+		}
+		gps.Altitude = &altitude
+	}
+	date, _ := tags["GPSDateStamp"].(string)
+	if clock, isList := tags["GPSTimeStamp"].([]any); isList && len(clock) == 3 && date != "" {
+		h, _ := clock[0].(float64)
+		m, _ := clock[1].(float64)
+		s, _ := clock[2].(float64)
+		gps.Timestamp = fmt.Sprintf("%sT%02d:%02d:%02dZ", strings.ReplaceAll(date, ":", "-"), int(h), int(m), int(s))
+	}
+	return gps
+}
+
+func (b *exifBlob) orientation() int {
+	value, _ := b.tags(b.ifd0(), map[uint16]string{exifTagOrientation: "Orientation"})["Orientation"].(int)
+	return value
+}
+
+// describeICC reads the colour space and description from an ICC profile
+// header and its desc tag (v2 text or v4 multi-localised).
+func describeICC(profile []byte) *models.ImageICCProfileV1 {
+	out := &models.ImageICCProfileV1{Bytes: len(profile)}
+	if len(profile) < 132 {
+		return out
+	}
+	out.ColorSpace = strings.TrimSpace(string(profile[16:20]))
+
+	count := int(binary.BigEndian.Uint32(profile[128:132]))
+	for i := 0; i < count && 132+i*12+12 <= len(profile); i++ {
+		entry := profile[132+i*12:]
+		if string(entry[:4]) != "desc" {
+			continue
+		}
+		offset, size := int(binary.BigEndian.Uint32(entry[4:8])), int(binary.BigEndian.Uint32(entry[8:12]))
+		if offset < 0 || size < 12 || offset+size > len(profile) {
+			break
+		}
+		tag := profile[offset : offset+size]
+		switch string(tag[:4]) {
+		case "desc":
+			length := int(binary.BigEndian.Uint32(tag[8:12]))
+			if length > 0 && 12+length <= len(tag) {
+				out.Description = strings.TrimRight(string(tag[12:12+length]), "\x00")
+			}
+		case "mluc":
+			if len(tag) < 28 {
+				break
+			}
+			length, start := int(binary.BigEndian.Uint32(tag[20:24])), int(binary.BigEndian.Uint32(tag[24:28]))
+			if start+length <= len(tag) {
+				units := make([]uint16, length/2)
+				for j := range units {
+					units[j] = binary.BigEndian.Uint16(tag[start+j*2:])
+				}
+				out.Description = string(utf16.Decode(units))
+			}
+		}
+		break
+	}
+	out.Description = strings.TrimRight(out.Description, "\x00 ")
+	return out
+}
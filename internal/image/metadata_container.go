@@ -0,0 +1,570 @@
+package image
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"sort"
+)
+
+// errMetadataContainer is returned for containers whose metadata blocks
+// cannot be edited without re-encoding.
+var errMetadataContainer = errors.New("metadata cannot be edited in place for this format")
+
+var (
+	jpegExifPrefix = []byte("Exif\x00\x00")
+	jpegXMPPrefix  = []byte("http://ns.adobe.com/xap/1.0/\x00")
+	jpegICCPrefix  = []byte("ICC_PROFILE\x00")
+)
+
+const (
+	tiffTagXMP = 0x02BC
+	tiffTagICC = 0x8773
+
+	webpFlagICC  = 0x20
+	webpFlagEXIF = 0x08
+	webpFlagXMP  = 0x04
+
+	maxJPEGSegmentPayload = 65533
+)
+
+// metadataContainer sniffs the container of encoded image data: jpeg, png,
+// webp, tiff, or "" for everything else.
+func metadataContainer(data []byte) string {
+	switch {
+	case len(data) >= 3 && data[0] == 0xFF && data[1] == 0xD8 && data[2] == 0xFF:
+		return "jpeg"
+	case bytes.HasPrefix(data, pngSignature):
+		return "png"
+	case len(data) >= 12 && string(data[:4]) == "RIFF" && string(data[8:12]) == "WEBP":
+		return "webp"
+	case isTIFF(data):
+		return "tiff"
+	}
+	return ""
+}
+
+// containerBlock is a JPEG segment, PNG chunk or WebP chunk. start..end spans
+// the whole block; the payload starts at payloadAt and is size bytes long.
+type containerBlock struct {
+	kind      string // fourcc for PNG and WebP, "APPn"/"COM"/... for JPEG
+	marker    byte
+	start     int
+	end       int
+	payloadAt int
+	size      int
+}
+
+func (b containerBlock) payload(data []byte) []byte {
+	return data[b.payloadAt : b.payloadAt+b.size]
+}
+
+// jpegBlocks lists the segments up to the start of scan; rest is the offset
+// of the scan (or of EOI) where the walk stopped.
+func jpegBlocks(data []byte) (blocks []containerBlock, rest int, err error) {
+	offset := 2
+	for offset+4 <= len(data) {
+		if data[offset] != 0xFF {
+			return nil, 0, fmt.Errorf("invalid jpeg marker at %d", offset)
+		}
+		marker := data[offset+1]
+		if marker == 0xFF {
+			offset++
+			continue
+		}
+		if marker == 0xDA || marker == 0xD9 {
+			return blocks, offset, nil
+		}
+		length := int(binary.BigEndian.Uint16(data[offset+2 : offset+4]))
+		end := offset + 2 + length
+		if length < 2 || end > len(data) {
+			return nil, 0, fmt.Errorf("truncated jpeg segment at %d", offset)
+		}
+		kind := fmt.Sprintf("%02X", marker)
+		switch {
+		case marker >= 0xE0 && marker <= 0xEF:
+			kind = fmt.Sprintf("APP%d", marker-0xE0)
+		case marker == 0xFE:
+			kind = "COM"
+		}
+		blocks = append(blocks, containerBlock{kind: kind, marker: marker, start: offset, end: end, payloadAt: offset + 4, size: length - 2})
+		offset = end
+	}
+	return nil, 0, fmt.Errorf("jpeg stream has no image data")
+}
+
+func pngBlocks(data []byte) ([]containerBlock, error) {
+	var blocks []containerBlock
+	for offset := len(pngSignature); offset < len(data); {
+		if offset+12 > len(data) {
+			return nil, fmt.Errorf("truncated png chunk")
+		}
+		length := int(binary.BigEndian.Uint32(data[offset : offset+4]))
+		end := offset + 12 + length
+		if length < 0 || end > len(data) {
+			return nil, fmt.Errorf("truncated png chunk %s", data[offset+4:offset+8])
+		}
+		blocks = append(blocks, containerBlock{kind: string(data[offset+4 : offset+8]), start: offset, end: end, payloadAt: offset + 8, size: length})
+		offset = end
+	}
+	return blocks, nil
+}
+
+func webpBlocks(data []byte) ([]containerBlock, error) {
+	var blocks []containerBlock
+	for offset := 12; offset < len(data); {
+		if offset+8 > len(data) {
+			return nil, fmt.Errorf("truncated webp chunk")
+		}
+		size := int(binary.LittleEndian.Uint32(data[offset+4 : offset+8]))
+		end := offset + 8 + size + size%2
+		if size < 0 || offset+8+size > len(data) {
+			return nil, fmt.Errorf("truncated webp chunk %s", data[offset:offset+4])
+		}
+		blocks = append(blocks, containerBlock{kind: string(data[offset : offset+4]), start: offset, end: min(end, len(data)), payloadAt: offset + 8, size: size})
+		offset = end
+	}
+	return blocks, nil
+}
+
+// exifRange locates the TIFF-structured EXIF block inside data. crcBlock is
+// the PNG chunk whose CRC covers it, nil otherwise.
+func exifRange(data []byte) (start, end int, crcBlock *containerBlock, err error) {
+	switch metadataContainer(data) {
+	case "jpeg":
+		blocks, _, walkErr := jpegBlocks(data)
+		if walkErr != nil {
+			return 0, 0, nil, walkErr
+		}
+		for _, block := range blocks {
+			if block.kind == "APP1" && bytes.HasPrefix(block.payload(data), jpegExifPrefix) {
+				return block.payloadAt + len(jpegExifPrefix), block.payloadAt + block.size, nil, nil
+			}
+		}
+	case "png":
+		blocks, walkErr := pngBlocks(data)
+		if walkErr != nil {
+			return 0, 0, nil, walkErr
+		}
+		for _, block := range blocks {
+			if block.kind == "eXIf" {
+				return block.payloadAt, block.payloadAt + block.size, &block, nil
+			}
+		}
+	case "webp":
+		blocks, walkErr := webpBlocks(data)
+		if walkErr != nil {
+			return 0, 0, nil, walkErr
+		}
+		for _, block := range blocks {
+			if block.kind == "EXIF" {
+				start := block.payloadAt
+				if bytes.HasPrefix(block.payload(data), jpegExifPrefix) {
+					start += len(jpegExifPrefix)
+				}
+				return start, block.payloadAt + block.size, nil, nil
+			}
+		}
+	case "tiff":
+		return 0, len(data), nil, nil
+	default:
+		return 0, 0, nil, errMetadataContainer
+	}
+	return 0, 0, nil, nil
+}
+
+// exifPayload returns a copy of the EXIF block in data, nil when there is none.
+func exifPayload(data []byte) []byte {
+	start, end, _, err := exifRange(data)
+	if err != nil || end <= start {
+		return nil
+	}
+	return bytes.Clone(data[start:end])
+}
+
+// editExif applies edit to a copy of data's EXIF block in place. Data without
+// an EXIF block is returned unchanged.
+func editExif(data []byte, edit func(*exifBlob) error) ([]byte, error) {
+	start, end, crcBlock, err := exifRange(data)
+	if err != nil || end <= start {
+		return data, err
+	}
+
+	out := bytes.Clone(data)
+	blob, err := parseExifBlob(out[start:end])
+	if err != nil {
+		return nil, err
+	}
+	if err := edit(blob); err != nil {
+		return nil, err
+	}
+	if crcBlock != nil {
+		crcAt := crcBlock.end - 4
+		binary.BigEndian.PutUint32(out[crcAt:], crc32.ChecksumIEEE(out[crcBlock.start+4:crcAt]))
+	}
+	return out, nil
+}
+
+// iccProfile returns the embedded ICC profile, nil when there is none.
+func iccProfile(data []byte) []byte {
+	switch metadataContainer(data) {
+	case "jpeg":
+		blocks, _, err := jpegBlocks(data)
+		if err != nil {
+			return nil
+		}
+		parts := make(map[int][]byte)
+		for _, block := range blocks {
+			payload := block.payload(data)
+			if block.kind == "APP2" && bytes.HasPrefix(payload, jpegICCPrefix) && len(payload) > len(jpegICCPrefix)+2 {
+				parts[int(payload[len(jpegICCPrefix)])] = payload[len(jpegICCPrefix)+2:]
+			}
+		}
+		seqs := make([]int, 0, len(parts))
+		for seq := range parts {
+			seqs = append(seqs, seq)
+		}
+		sort.Ints(seqs)
+		var profile []byte
+		for _, seq := range seqs {
+			profile = append(profile, parts[seq]...)
+		}
+		return profile
+	case "png":
+		blocks, err := pngBlocks(data)
+		if err != nil {
+			return nil
+		}
+		for _, block := range blocks {
+			if block.kind != "iCCP" {
+				continue
+			}
+			payload := block.payload(data)
+			nameEnd := bytes.IndexByte(payload, 0)
+			if nameEnd < 0 || nameEnd+2 > len(payload) {
+				return nil
+			}
+			reader, zErr := zlib.NewReader(bytes.NewReader(payload[nameEnd+2:]))
+			if zErr != nil {
+				return nil
+			}
+			defer reader.Close()
+			profile, _ := io.ReadAll(reader)
+			return profile
+		}
+	case "webp":
+		blocks, err := webpBlocks(data)
+		if err != nil {
+			return nil
+		}
+		for _, block := range blocks {
+			if block.kind == "ICCP" {
+				return bytes.Clone(block.payload(data))
+			}
+		}
+	case "tiff":
+		return tiffTagBytes(data, tiffTagICC)
+	}
+	return nil
+}
+
+// hasXMP reports whether data carries an XMP packet.
+func hasXMP(data []byte) bool {
+	switch metadataContainer(data) {
+	case "jpeg":
+		blocks, _, err := jpegBlocks(data)
+		for _, block := range blocks {
+			if err == nil && block.kind == "APP1" && bytes.HasPrefix(block.payload(data), jpegXMPPrefix) {
+				return true
+			}
+		}
+	case "png":
+		blocks, err := pngBlocks(data)
+		for _, block := range blocks {
+			if err == nil && block.kind == "iTXt" && bytes.HasPrefix(block.payload(data), []byte("XML:com.adobe.xmp\x00")) {
+				return true
+			}
+		}
+	case "webp":
+		blocks, err := webpBlocks(data)
+		for _, block := range blocks {
+			if err == nil && block.kind == "XMP " {
+				return true
+			}
+		}
+	case "tiff":
+		return tiffTagBytes(data, tiffTagXMP) != nil
+	}
+	return false
+}
+
+func tiffTagBytes(data []byte, tag uint16) []byte {
+	blob, err := parseExifBlob(data)
+	if err != nil {
+		return nil
+	}
+	entries, err := blob.entries(blob.ifd0())
+	if err != nil {
+		return nil
+	}
+	for _, entry := range entries {
+		if entry.tag == tag {
+			return bytes.Clone(data[entry.valueAt : entry.valueAt+entry.size])
+		}
+	}
+	return nil
+}
+
+// stripAllMetadata drops the EXIF, XMP, ICC, IPTC, comment and text blocks of
+// a JPEG, PNG or WebP without touching the pixel data.
+func stripAllMetadata(data []byte) ([]byte, error) {
+	switch metadataContainer(data) {
+	case "jpeg":
+		blocks, rest, err := jpegBlocks(data)
+		if err != nil {
+			return nil, err
+		}
+		out := append(make([]byte, 0, len(data)), data[:2]...)
+		for _, block := range blocks {
+			// APP0 (JFIF) and APP14 (Adobe colour transform) affect decoding.
+			if (block.marker >= 0xE1 && block.marker <= 0xEF && block.marker != 0xEE) || block.marker == 0xFE {
+				continue
+			}
+			out = append(out, data[block.start:block.end]...)
+		}
+		return append(out, data[rest:]...), nil
+	case "png":
+		blocks, err := pngBlocks(data)
+		if err != nil {
+			return nil, err
+		}
+		drop := map[string]bool{"eXIf": true, "iCCP": true, "tEXt": true, "zTXt": true, "iTXt": true, "tIME": true}
+		out := append(make([]byte, 0, len(data)), pngSignature...)
+		for _, block := range blocks {
+			if !drop[block.kind] {
+				out = append(out, data[block.start:block.end]...)
+			}
+		}
+		return out, nil
+	case "webp":
+		blocks, err := webpBlocks(data)
+		if err != nil {
+			return nil, err
+		}
+		var kept []containerBlock
+		for _, block := range blocks {
+			if block.kind != "EXIF" && block.kind != "XMP " && block.kind != "ICCP" {
+				kept = append(kept, block)
+			}
+		}
+		return assembleWebP(data, kept, nil, nil, webpFlagICC|webpFlagEXIF|webpFlagXMP, 0), nil
+	}
+	return nil, errMetadataContainer
+}
+
+// injectMetadata writes exif and icc into a JPEG, PNG or WebP, replacing the
+// blocks of the same kind. Nil blocks are left out.
+func injectMetadata(data, exif, icc []byte) ([]byte, error) {
+	if exif == nil && icc == nil {
+		return data, nil
+	}
+
+	switch metadataContainer(data) {
+	case "jpeg":
+		return injectJPEGMetadata(data, exif, icc)
+	case "png":
+		blocks, err := pngBlocks(data)
+		if err != nil {
+			return nil, err
+		}
+		out := append(make([]byte, 0, len(data)+len(exif)+len(icc)+64), pngSignature...)
+		for _, block := range blocks {
+			switch {
+			case block.kind == "eXIf" && exif != nil, block.kind == "iCCP" && icc != nil, block.kind == "sRGB" && icc != nil:
+				continue
+			}
+			out = append(out, data[block.start:block.end]...)
+			if block.kind != "IHDR" {
+				continue
+			}
+			if icc != nil {
+				var compressed bytes.Buffer
+				writer := zlib.NewWriter(&compressed)
+				writer.Write(icc)
+				writer.Close()
+				out = appendPNGChunk(out, "iCCP", append([]byte("icc\x00\x00"), compressed.Bytes()...))
+			}
+			if exif != nil {
+				out = appendPNGChunk(out, "eXIf", exif)
+			}
+		}
+		return out, nil
+	case "webp":
+		blocks, err := webpBlocks(data)
+		if err != nil {
+			return nil, err
+		}
+		var kept []containerBlock
+		for _, block := range blocks {
+			if (block.kind == "EXIF" && exif != nil) || (block.kind == "ICCP" && icc != nil) {
+				continue
+			}
+			kept = append(kept, block)
+		}
+		var flags byte
+		if exif != nil {
+			flags |= webpFlagEXIF
+		}
+		if icc != nil {
+			flags |= webpFlagICC
+		}
+		return assembleWebP(data, kept, exif, icc, 0, flags), nil
+	}
+	return nil, errMetadataContainer
+}
+
+func injectJPEGMetadata(data, exif, icc []byte) ([]byte, error) {
+	blocks, rest, err := jpegBlocks(data)
+	if err != nil {
+		return nil, err
+	}
+	if exif != nil && len(jpegExifPrefix)+len(exif) > maxJPEGSegmentPayload {
+		return nil, fmt.Errorf("exif block of %d bytes does not fit a jpeg segment", len(exif))
+	}
+
+	segment := func(out []byte, marker byte, parts ...[]byte) []byte {
+		length := 2
+		for _, part := range parts {
+			length += len(part)
+		}
+		out = append(out, 0xFF, marker)
+		out = binary.BigEndian.AppendUint16(out, uint16(length))
+		for _, part := range parts {
+			out = append(out, part...)
+		}
+		return out
+	}
+
+	out := append(make([]byte, 0, len(data)+len(exif)+len(icc)+64), data[:2]...)
+	inserted := false
+	insert := func() {
+		if exif != nil {
+			out = segment(out, 0xE1, jpegExifPrefix, exif)
+		}
+		if icc != nil {
+			chunkSize := maxJPEGSegmentPayload - len(jpegICCPrefix) - 2
+			total := (len(icc) + chunkSize - 1) / chunkSize
+			for i := 0; i < total; i++ {
+				chunk := icc[i*chunkSize : min((i+1)*chunkSize, len(icc))]
+				out = segment(out, 0xE2, jpegICCPrefix, []byte{byte(i + 1), byte(total)}, chunk)
+			}
+		}
+		inserted = true
+	}
+
+	for i, block := range blocks {
+		payload := block.payload(data)
+		if (exif != nil && block.kind == "APP1" && bytes.HasPrefix(payload, jpegExifPrefix)) ||
+			(icc != nil && block.kind == "APP2" && bytes.HasPrefix(payload, jpegICCPrefix)) {
+			continue
+		}
+		if !inserted && !(i == 0 && block.kind == "APP0") {
+			insert()
+		}
+		out = append(out, data[block.start:block.end]...)
+	}
+	if !inserted {
+		insert()
+	}
+	return append(out, data[rest:]...), nil
+}
+
+// assembleWebP rebuilds a WebP from blocks in the extended (VP8X) layout,
+// adding icc after the header and exif at the end. clearFlags and setFlags
+// adjust the VP8X feature flags.
+func assembleWebP(data []byte, blocks []containerBlock, exif, icc []byte, clearFlags, setFlags byte) []byte {
+	chunk := func(out []byte, kind string, payload []byte) []byte {
+		out = append(out, kind...)
+		out = binary.LittleEndian.AppendUint32(out, uint32(len(payload)))
+		out = append(out, payload...)
+		if len(payload)%2 == 1 {
+			out = append(out, 0)
+		}
+		return out
+	}
+
+	out := append(make([]byte, 0, len(data)+len(exif)+len(icc)+32), data[:12]...)
+	var header []byte
+	if len(blocks) > 0 && blocks[0].kind == "VP8X" && blocks[0].size >= 10 {
+		header = bytes.Clone(blocks[0].payload(data))
+		blocks = blocks[1:]
+	} else if setFlags == 0 {
+		// A simple-format file without the added features stays simple.
+		for _, block := range blocks {
+			out = append(out, data[block.start:block.end]...)
+		}
+		binary.LittleEndian.PutUint32(out[4:8], uint32(len(out)-8))
+		return out
+	} else {
+		header = simpleWebPHeader(data, blocks)
+	}
+	header[0] = header[0]&^clearFlags | setFlags
+
+	// With no features left, a lone VP8 or VP8L bitstream goes back to the
+	// simple layout; the alpha flag is implied by VP8L.
+	if header[0]&^0x10 == 0 && len(blocks) == 1 && (blocks[0].kind == "VP8 " || blocks[0].kind == "VP8L") {
+		out = append(out, data[blocks[0].start:blocks[0].end]...)
+		binary.LittleEndian.PutUint32(out[4:8], uint32(len(out)-8))
+		return out
+	}
+
+	out = chunk(out, "VP8X", header)
+	if icc != nil {
+		out = chunk(out, "ICCP", icc)
+	}
+	var xmp []containerBlock
+	for _, block := range blocks {
+		if block.kind == "XMP " {
+			xmp = append(xmp, block)
+			continue
+		}
+		out = append(out, data[block.start:block.end]...)
+	}
+	if exif != nil {
+		out = chunk(out, "EXIF", exif)
+	}
+	for _, block := range xmp {
+		out = append(out, data[block.start:block.end]...)
+	}
+	binary.LittleEndian.PutUint32(out[4:8], uint32(len(out)-8))
+	return out
+}
+
+// simpleWebPHeader builds the VP8X payload for a simple lossy or lossless
+// WebP from its bitstream header.
+func simpleWebPHeader(data []byte, blocks []containerBlock) []byte {
+	header := make([]byte, 10)
+	var width, height int
+	for _, block := range blocks {
+		payload := block.payload(data)
+		switch {
+		case block.kind == "VP8 " && len(payload) >= 10:
+			width = int(binary.LittleEndian.Uint16(payload[6:8]) & 0x3FFF)
+			height = int(binary.LittleEndian.Uint16(payload[8:10]) & 0x3FFF)
+		case block.kind == "VP8L" && len(payload) >= 5:
+			bits := binary.LittleEndian.Uint32(payload[1:5])
+			width, height = int(bits&0x3FFF)+1, int(bits>>14&0x3FFF)+1
+			if bits>>28&1 == 1 {
+				header[0] |= 0x10
+			}
+		}
+	}
+	width, height = max(width, 1), max(height, 1)
+	header[4], header[5], header[6] = byte(width-1), byte((width-1)>>8), byte((width-1)>>16)
+	header[7], header[8], header[9] = byte(height-1), byte((height-1)>>8), byte((height-1)>>16)
+	return header
+}
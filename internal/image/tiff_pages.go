@@ -0,0 +1,141 @@
+package image
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+)
+
+const maxTIFFPages = 10000
+
+// isTIFF reports whether data starts with a classic or BigTIFF header.
+func isTIFF(data []byte) bool {
+	if len(data) < 8 {
+		return false
+	}
+	return bytes.HasPrefix(data, []byte("II*\x00")) || bytes.HasPrefix(data, []byte("MM\x00*")) ||
+		bytes.HasPrefix(data, []byte("II+\x00")) || bytes.HasPrefix(data, []byte("MM\x00+"))
+}
+
+// tiffPageOffsets walks the IFD chain of a TIFF and returns the offset of
+// every page's directory.
+func tiffPageOffsets(data []byte) ([]uint64, error) {
+	if !isTIFF(data) {
+		return nil, fmt.Errorf("not a tiff stream")
+	}
+
+	var order binary.ByteOrder = binary.LittleEndian
+	if data[0] == 'M' {
+		order = binary.BigEndian
+	}
+	big := order.Uint16(data[2:4]) == 43
+
+	var next uint64
+	if big {
+		if len(data) < 16 {
+			return nil, fmt.Errorf("truncated bigtiff header")
+		}
+		next = order.Uint64(data[8:16])
+	} else {
+		next = uint64(order.Uint32(data[4:8]))
+	}
+
+	var offsets []uint64
+	seen := make(map[uint64]bool)
+	for next != 0 {
+		if seen[next] || len(offsets) == maxTIFFPages {
+			return nil, fmt.Errorf("tiff directory chain loops or is too long")
+		}
+		seen[next] = true
+		offsets = append(offsets, next)
+
+		countSize, entrySize, nextSize := uint64(2), uint64(12), uint64(4)
+		if big {
+			countSize, entrySize, nextSize = 8, 20, 8
+		}
+		// Offsets and counts come from the file; compare before adding so a
+		// BigTIFF value near the uint64 limit cannot wrap around.
+		size := uint64(len(data))
+		if size < countSize || next > size-countSize {
+			return nil, fmt.Errorf("tiff directory %d is out of range", len(offsets))
+		}
+
+		var entries uint64
+		if big {
+			entries = order.Uint64(data[next : next+8])
+		} else {
+			entries = uint64(order.Uint16(data[next : next+2]))
+		}
+		if entries > (size-next-countSize)/entrySize {
+			return nil, fmt.Errorf("tiff directory %d is truncated", len(offsets))
+		}
+		nextAt := next + countSize + entries*entrySize
+		if nextSize > size-nextAt {
+			return nil, fmt.Errorf("tiff directory %d is truncated", len(offsets))
+		}
+		if big {
+			next = order.Uint64(data[nextAt : nextAt+8])
+		} else {
+			next = uint64(order.Uint32(data[nextAt : nextAt+4]))
+		}
+	}
+
+	if len(offsets) == 0 {
+		return nil, fmt.Errorf("tiff has no pages")
+	}
+	return offsets, nil
+}
+
+// imagePageCount returns the number of pages in data: the directory count
+// for TIFF, 1 for every other format.
+func imagePageCount(data []byte) (int, error) {
+	if !isTIFF(data) {
+		return 1, nil
+	}
+	offsets, err := tiffPageOffsets(data)
+	if err != nil {
+		return 0, err
+	}
+	return len(offsets), nil
+}
+
+// selectImagePage returns data with the 1-based page as its first page, so
+// decoders that only read the first page of a multi-page TIFF see it. The
+// header is repointed at the page's directory; nothing else is rewritten.
+func selectImagePage(data []byte, page int) ([]byte, error) {
+	if page <= 1 {
+		return data, nil
+	}
+	if !isTIFF(data) {
+		return nil, &pageRangeError{page: page, count: 1}
+	}
+
+	offsets, err := tiffPageOffsets(data)
+	if err != nil {
+		return nil, err
+	}
+	if page > len(offsets) {
+		return nil, &pageRangeError{page: page, count: len(offsets)}
+	}
+
+	var order binary.ByteOrder = binary.LittleEndian
+	if data[0] == 'M' {
+		order = binary.BigEndian
+	}
+	out := bytes.Clone(data)
+	if order.Uint16(out[2:4]) == 43 {
+		order.PutUint64(out[8:16], offsets[page-1])
+	} else {
+		order.PutUint32(out[4:8], uint32(offsets[page-1]))
+	}
+	return out, nil
+}
+
+type pageRangeError struct {
+	page  int
+	count int
+}
+
+func (e *pageRangeError) Error() string {
+	return fmt.Sprintf("page %d is out of range: the image has %d page(s)", e.page, e.count)
+}
@@ -0,0 +1,127 @@
+package image
+
+import (
+	"encoding/binary"
+	"errors"
+	"testing"
+)
+
+// classicTIFF builds a little-endian TIFF with pages empty directories chained
+// one after another, starting at offset 8.
+func classicTIFF(pages int) []byte {
+	data := []byte("II*\x00\x08\x00\x00\x00")
+	for i := 0; i < pages; i++ {
+		next := uint32(0)
+		if i < pages-1 {
+			next = uint32(len(data) + 6)
+		}
+		data = binary.LittleEndian.AppendUint16(data, 0)
+		data = binary.LittleEndian.AppendUint32(data, next)
+	}
+	return data
+}
+
+// bigTIFF builds a big-endian BigTIFF with pages empty directories chained
+// one after another, starting at offset 16.
+func bigTIFF(pages int) []byte {
+	data := []byte("MM\x00+\x00\x08\x00\x00")
+	data = binary.BigEndian.AppendUint64(data, 16)
+	for i := 0; i < pages; i++ {
+		next := uint64(0)
+		if i < pages-1 {
+			next = uint64(len(data) + 16)
+		}
+		data = binary.BigEndian.AppendUint64(data, 0)
+		data = binary.BigEndian.AppendUint64(data, next)
+	}
+	return data
+}
+
+func TestImagePageCount(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		want int
+	}{
+		{"classic", classicTIFF(3), 3},
+		{"bigtiff", bigTIFF(2), 2},
+		{"not a tiff", []byte("\x89PNG\r\n\x1a\n"), 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := imagePageCount(tt.data)
+			if err != nil || got != tt.want {
+				t.Fatalf("imagePageCount = %d, %v, want %d", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestTIFFPageOffsetsRejectsBrokenChains(t *testing.T) {
+	loop := classicTIFF(2)
+	binary.LittleEndian.PutUint32(loop[16:20], 8)
+
+	truncated := classicTIFF(1)
+	binary.LittleEndian.PutUint16(truncated[8:10], 5)
+
+	outOfRange := classicTIFF(1)
+	binary.LittleEndian.PutUint32(outOfRange[4:8], 4096)
+
+	// Adding the directory size to this offset wraps around uint64.
+	wrapping := bigTIFF(1)
+	binary.BigEndian.PutUint64(wrapping[8:16], ^uint64(0)-4)
+
+	hugeCount := bigTIFF(1)
+	binary.BigEndian.PutUint64(hugeCount[16:24], ^uint64(0)/10)
+
+	for name, data := range map[string][]byte{
+		"loop":          loop,
+		"truncated":     truncated,
+		"out of range":  outOfRange,
+		"wrapping":      wrapping,
+		"huge count":    hugeCount,
+		"short bigtiff": bigTIFF(1)[:12],
+	} {
+		t.Run(name, func(t *testing.T) {
+			if offsets, err := tiffPageOffsets(data); err == nil {
+				t.Fatalf("tiffPageOffsets = %v, want an error", offsets)
+			}
+		})
+	}
+}
+
+func TestSelectImagePage(t *testing.T) {
+	data := classicTIFF(3)
+
+	selected, err := selectImagePage(data, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := binary.LittleEndian.Uint32(selected[4:8]); got != 20 {
+		t.Fatalf("first directory = %d, want 20", got)
+	}
+	if binary.LittleEndian.Uint32(data[4:8]) != 8 {
+		t.Fatal("selectImagePage modified its input")
+	}
+
+	big, err := selectImagePage(bigTIFF(2), 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := binary.BigEndian.Uint64(big[8:16]); got != 32 {
+		t.Fatalf("bigtiff first directory = %d, want 32", got)
+	}
+
+	if first, err := selectImagePage(data, 1); err != nil || &first[0] != &data[0] {
+		t.Fatalf("page 1 should return the data unchanged, got err %v", err)
+	}
+
+	var rangeErr *pageRangeError
+	if _, err := selectImagePage(data, 4); !errors.As(err, &rangeErr) || rangeErr.count != 3 {
+		t.Fatalf("page 4 of 3: error = %v, want a page range error", err)
+	}
+	if _, err := selectImagePage([]byte("\xff\xd8\xff\xe0jpeg"), 2); !errors.As(err, &rangeErr) || rangeErr.count != 1 {
+		t.Fatalf("page 2 of a jpeg: error = %v, want a page range error", err)
+	}
+}
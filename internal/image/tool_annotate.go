@@ -18,6 +18,7 @@ import (
 	"strings"
 
 	"fileforge-desktop/internal/models"
+	"fileforge-desktop/internal/tools"
 
 	"github.com/disintegration/imaging"
 	"github.com/fogleman/gg"
@@ -47,13 +48,21 @@ type annotateRequest struct {
 	outputDir  string
 	format     string
 	operations []models.ImageAnnotateOperationV1
+	page       int
+	metadata   string
+	nameTmpl   string
+	conflicts  string
+	subdirs    map[string]string // output sub-directory of each input, from input expansion
 }
 
 type preparedAnnotate struct {
 	inputPath    string
 	outputPath   string
+	target       tools.OutputTarget
 	outputFmt    string
 	operations   []models.ImageAnnotateOperationV1
+	page         int
+	metadata     string
 	canvasWidth  int
 	canvasHeight int
 }
@@ -80,15 +89,55 @@ func (t *AnnotateTool) Manifest() models.ToolManifestV1 {
 		Version:          "v1",
 		SupportsSingle:   true,
 		SupportsBatch:    true,
-		InputExtensions:  []string{"jpg", "jpeg", "png", "gif", "webp", "bmp", "tiff", "tif"},
-		OutputExtensions: []string{"jpeg", "png", "webp", "gif", "tiff"},
+		InputExtensions:  imageInputExtensions,
+		OutputExtensions: imageOutputFormats,
 		RuntimeDeps:      []string{"libvips"},
 		Tags:             []string{"image", "annotate", "text", "arrow", "rect", "blur", "redact"},
+		Options: []models.OptionSchemaV1{
+			{
+				Key:         "operations",
+				Type:        models.OptionTypeArray,
+				Label:       "Annotations",
+				Description: "Applied in order; batch jobs apply the same annotations to every file",
+				Required:    true,
+				Items: &models.OptionSchemaV1{
+					Type: models.OptionTypeObject,
+					Fields: []models.OptionSchemaV1{
+						{Key: "type", Type: models.OptionTypeString, Label: "Type", Required: true, Enum: []string{annotateTypeText, annotateTypeArrow, annotateTypeRect, annotateTypeBlur, annotateTypeRedact}},
+						{Key: "x", Type: models.OptionTypeInteger, Label: "X"},
+						{Key: "y", Type: models.OptionTypeInteger, Label: "Y"},
+						{Key: "width", Type: models.OptionTypeInteger, Label: "Width", Description: "rect, blur and redact"},
+						{Key: "height", Type: models.OptionTypeInteger, Label: "Height", Description: "rect, blur and redact"},
+						{Key: "x2", Type: models.OptionTypeInteger, Label: "End X", Description: "arrow"},
+						{Key: "y2", Type: models.OptionTypeInteger, Label: "End Y", Description: "arrow"},
+						{Key: "text", Type: models.OptionTypeString, Label: "Text", Description: "text"},
+						{Key: "color", Type: models.OptionTypeString, Label: "Color", Format: "color"},
+						{Key: "opacity", Type: models.OptionTypeNumber, Label: "Opacity", Min: models.OptionBound(0), Max: models.OptionBound(1)},
+						{Key: "strokeWidth", Type: models.OptionTypeInteger, Label: "Stroke width", Description: "0 uses the default of 2", Min: models.OptionBound(0), Max: models.OptionBound(64)},
+						{Key: "fontSize", Type: models.OptionTypeInteger, Label: "Font size", Description: "0 uses the default of 18", Min: models.OptionBound(0), Max: models.OptionBound(256)},
+						{Key: "blurIntensity", Type: models.OptionTypeInteger, Label: "Blur intensity", Min: models.OptionBound(0), Max: models.OptionBound(100)},
+					},
+				},
+			},
+			{Key: "format", Type: models.OptionTypeString, Label: "Output format", Description: "Defaults to the input format", Enum: imageFormatEnum},
+			{Key: "page", Type: models.OptionTypeInteger, Label: "Page", Description: "Page of a multi-page TIFF to annotate, 1-based", Default: 1, Min: models.OptionBound(1)},
+			metadataOptionSchema(),
+			{Key: "outputPath", Type: models.OptionTypeString, Label: "Output file", Format: "path", Modes: []string{"single"}},
+			{Key: "outputDir", Type: models.OptionTypeString, Label: "Output folder", Description: "Overrides the job output folder", Format: "dir"},
+			tools.OutputNameTemplateSchema("{name}_annotated.{ext}"),
+		},
+	}
+}
+
+func (t *AnnotateTool) RetryPolicy() models.RetryPolicyV1 {
+	return models.RetryPolicyV1{
+		MaxAttempts:    3,
+		RetryableCodes: []string{"IMAGE_ANNOTATE_EXECUTION", "IMAGE_ANNOTATE_BATCH_ITEM"},
 	}
 }
 
 func (t *AnnotateTool) RuntimeState(_ context.Context) models.ToolRuntimeStateV1 {
-	return models.ToolRuntimeStateV1{Status: "enabled", Healthy: true}
+	return imageRuntimeState()
 }
 
 func (t *AnnotateTool) Validate(_ context.Context, req models.JobRequestV1) *models.JobErrorV1 {
@@ -116,7 +165,10 @@ func (t *AnnotateTool) Validate(_ context.Context, req models.JobRequestV1) *mod
 		}
 
 		for _, inputPath := range parsed.inputPaths {
-			bytes, _, width, height, err := readAndNormalize(inputPath)
+			bytes, _, width, height, err := readImagePage(inputPath, parsed.page)
+			if pageErr := pageJobError(err, "IMAGE_ANNOTATE", inputPath); pageErr != nil {
+				return pageErr
+			}
 			if err != nil {
 				return models.NewCanonicalJobError("IMAGE_ANNOTATE_READ_FAILED", err.Error(), map[string]any{"inputPath": inputPath})
 			}
@@ -133,6 +185,37 @@ func (t *AnnotateTool) Validate(_ context.Context, req models.JobRequestV1) *mod
 	return nil
 }
 
+// Plan resolves the output of every input the way the executors do, reading
+// images to check the operations against them but writing nothing.
+func (t *AnnotateTool) Plan(_ context.Context, req models.JobRequestV1) (models.JobPlanV1, *models.JobErrorV1) {
+	parsed, jobErr := parseAnnotateRequest(req)
+	if jobErr != nil {
+		return models.JobPlanV1{}, jobErr
+	}
+
+	usedOutputs := make(map[string]struct{}, len(parsed.inputPaths))
+	items := make([]models.PlanItemV1, 0, len(parsed.inputPaths))
+	for idx, inputPath := range parsed.inputPaths {
+		var prepared preparedAnnotate
+		var prepErr *models.JobErrorV1
+		if parsed.mode == "single" {
+			prepared, prepErr = t.prepareSingle(parsed)
+		} else {
+			prepared, prepErr = t.prepareForInput(parsed, inputPath, idx+1, usedOutputs)
+		}
+		if prepErr == nil && !prepared.target.Skip {
+			prepErr = validateOperationsForImage(prepared.operations, prepared.canvasWidth, prepared.canvasHeight)
+		}
+		if prepErr != nil {
+			items = append(items, tools.FailedPlanItem(inputPath, prepErr))
+			continue
+		}
+		items = append(items, tools.PlanItem(inputPath, []tools.OutputTarget{prepared.target}, tools.FileSize(inputPath)))
+	}
+
+	return tools.NewJobPlan(t.ID(), parsed.mode, items), nil
+}
+
 func (t *AnnotateTool) ExecuteSingle(ctx context.Context, req models.JobRequestV1) (models.JobResultItemV1, *models.JobErrorV1) {
 	parsed, jobErr := parseAnnotateRequest(req)
 	if jobErr != nil {
@@ -143,6 +226,9 @@ func (t *AnnotateTool) ExecuteSingle(ctx context.Context, req models.JobRequestV
 	if prepErr != nil {
 		return models.JobResultItemV1{InputPath: firstPath(parsed.inputPaths), OutputPath: parsed.outputPath, Success: false, Message: prepErr.Message, Error: prepErr}, prepErr
 	}
+	if prepared.target.Skip {
+		return models.SkippedItemV1(prepared.inputPath, prepared.outputPath), nil
+	}
 
 	if opErr := validateOperationsForImage(prepared.operations, prepared.canvasWidth, prepared.canvasHeight); opErr != nil {
 		return models.JobResultItemV1{InputPath: prepared.inputPath, OutputPath: prepared.outputPath, Success: false, Message: opErr.Message, Error: opErr}, opErr
@@ -185,14 +271,30 @@ func (t *AnnotateTool) ExecuteBatch(ctx context.Context, req models.JobRequestV1
 		default:
 		}
 
-		prepared, prepErr := t.prepareForInput(parsed, inputPath, usedOutputs)
+		if tools.ItemCancelled(ctx, inputPath) {
+			items = append(items, models.CancelledItemV1(inputPath, ""))
+			if onProgress != nil {
+				onProgress(models.JobProgressV1{Current: idx + 1, Total: len(parsed.inputPaths), Stage: models.JobStatusRunning, Message: fmt.Sprintf("processed %d/%d", idx+1, len(parsed.inputPaths)), Item: models.LastResultItemV1(items)})
+			}
+			continue
+		}
+
+		prepared, prepErr := t.prepareForInput(parsed, inputPath, idx+1, usedOutputs)
 		if prepErr != nil {
 			if firstErr == nil {
 				firstErr = prepErr
 			}
 			items = append(items, models.JobResultItemV1{InputPath: inputPath, OutputPath: "", Success: false, Message: prepErr.Message, Error: prepErr})
 			if onProgress != nil {
-				onProgress(models.JobProgressV1{Current: idx + 1, Total: len(parsed.inputPaths), Stage: models.JobStatusRunning, Message: fmt.Sprintf("processed %d/%d", idx+1, len(parsed.inputPaths))})
+				onProgress(models.JobProgressV1{Current: idx + 1, Total: len(parsed.inputPaths), Stage: models.JobStatusRunning, Message: fmt.Sprintf("processed %d/%d", idx+1, len(parsed.inputPaths)), Item: models.LastResultItemV1(items)})
+			}
+			continue
+		}
+
+		if prepared.target.Skip {
+			items = append(items, models.SkippedItemV1(prepared.inputPath, prepared.outputPath))
+			if onProgress != nil {
+				onProgress(models.JobProgressV1{Current: idx + 1, Total: len(parsed.inputPaths), Stage: models.JobStatusRunning, Message: fmt.Sprintf("processed %d/%d", idx+1, len(parsed.inputPaths)), Item: models.LastResultItemV1(items)})
 			}
 			continue
 		}
@@ -203,12 +305,17 @@ func (t *AnnotateTool) ExecuteBatch(ctx context.Context, req models.JobRequestV1
 			}
 			items = append(items, models.JobResultItemV1{InputPath: prepared.inputPath, OutputPath: prepared.outputPath, Success: false, Message: opErr.Message, Error: opErr})
 			if onProgress != nil {
-				onProgress(models.JobProgressV1{Current: idx + 1, Total: len(parsed.inputPaths), Stage: models.JobStatusRunning, Message: fmt.Sprintf("processed %d/%d", idx+1, len(parsed.inputPaths))})
+				onProgress(models.JobProgressV1{Current: idx + 1, Total: len(parsed.inputPaths), Stage: models.JobStatusRunning, Message: fmt.Sprintf("processed %d/%d", idx+1, len(parsed.inputPaths)), Item: models.LastResultItemV1(items)})
 			}
 			continue
 		}
 
-		if err := executeAnnotateToPath(ctx, prepared); err != nil {
+		itemCtx, stopItem := tools.ItemContext(ctx, prepared.inputPath)
+		err := executeAnnotateToPath(itemCtx, prepared)
+		stopItem()
+		if err != nil && ctx.Err() == nil && tools.ItemCancelled(ctx, prepared.inputPath) {
+			items = append(items, models.CancelledItemV1(prepared.inputPath, prepared.outputPath))
+		} else if err != nil {
 			itemErr := models.NewCanonicalJobError("IMAGE_ANNOTATE_BATCH_ITEM", err.Error(), map[string]any{"inputPath": prepared.inputPath})
 			if firstErr == nil {
 				firstErr = itemErr
@@ -219,7 +326,7 @@ func (t *AnnotateTool) ExecuteBatch(ctx context.Context, req models.JobRequestV1
 		}
 
 		if onProgress != nil {
-			onProgress(models.JobProgressV1{Current: idx + 1, Total: len(parsed.inputPaths), Stage: models.JobStatusRunning, Message: fmt.Sprintf("processed %d/%d", idx+1, len(parsed.inputPaths))})
+			onProgress(models.JobProgressV1{Current: idx + 1, Total: len(parsed.inputPaths), Stage: models.JobStatusRunning, Message: fmt.Sprintf("processed %d/%d", idx+1, len(parsed.inputPaths)), Item: models.LastResultItemV1(items)})
 		}
 	}
 
@@ -240,7 +347,7 @@ func (t *AnnotateTool) GetImageAnnotatePreviewV1(req models.ImageAnnotatePreview
 		return models.ImageAnnotatePreviewResponseV1{Success: false, Message: err.Message, Error: err}
 	}
 
-	bytes, fmtName, width, height, err := readAndNormalize(inputPath)
+	bytes, fmtName, width, height, err := readImagePage(inputPath, req.Page)
 	if err != nil {
 		jobErr := models.NewCanonicalJobError("IMAGE_ANNOTATE_PREVIEW_READ_FAILED", err.Error(), nil)
 		return models.ImageAnnotatePreviewResponseV1{Success: false, Message: "Cannot load image preview source.", Error: jobErr}
@@ -266,7 +373,11 @@ func (t *AnnotateTool) GetImageAnnotatePreviewV1(req models.ImageAnnotatePreview
 		return models.ImageAnnotatePreviewResponseV1{Success: false, Message: "Failed to generate annotate preview.", Error: jobErr}
 	}
 
-	mimeType := imageMimeByFormat[outputFmt]
+	annotatedBytes, mimeType, err := previewImage(annotatedBytes, outputFmt)
+	if err != nil {
+		jobErr := models.NewCanonicalJobError("IMAGE_ANNOTATE_PREVIEW_EXECUTION", err.Error(), nil)
+		return models.ImageAnnotatePreviewResponseV1{Success: false, Message: "Failed to generate annotate preview.", Error: jobErr}
+	}
 	if mimeType == "" {
 		mimeType = imageMimeByFormat[fmtName]
 	}
@@ -323,6 +434,20 @@ func parseAnnotateRequest(req models.JobRequestV1) (annotateRequest, *models.Job
 		return annotateRequest{}, normalizeErr
 	}
 
+	page, pageErr := optionPage(req.Options, "IMAGE_ANNOTATE")
+	if pageErr != nil {
+		return annotateRequest{}, pageErr
+	}
+
+	metadata, metaErr := optionMetadata(req.Options, "IMAGE_ANNOTATE")
+	if metaErr != nil {
+		return annotateRequest{}, metaErr
+	}
+
+	if tmplErr := tools.ValidateOutputNameTemplate(req.Options); tmplErr != nil {
+		return annotateRequest{}, tmplErr
+	}
+
 	parsed := annotateRequest{
 		mode:       mode,
 		inputPaths: inputPaths,
@@ -330,6 +455,11 @@ func parseAnnotateRequest(req models.JobRequestV1) (annotateRequest, *models.Job
 		outputDir:  strings.TrimSpace(req.OutputDir),
 		format:     strings.ToLower(strings.TrimSpace(annotateOptionString(req.Options, "format"))),
 		operations: normalized,
+		page:       page,
+		metadata:   metadata,
+		nameTmpl:   tools.OutputNameTemplate(req.Options, "{name}_annotated.{ext}"),
+		conflicts:  tools.ConflictPolicy(req),
+		subdirs:    req.InputSubdirs,
 	}
 
 	if mode == "single" {
@@ -548,35 +678,46 @@ func (t *AnnotateTool) prepareSingle(parsed annotateRequest) (preparedAnnotate,
 		return preparedAnnotate{}, fmtErr
 	}
 
+	_, inputFmt, width, height, err := readImagePage(inputPath, parsed.page)
+	if pageErr := pageJobError(err, "IMAGE_ANNOTATE", inputPath); pageErr != nil {
+		return preparedAnnotate{}, pageErr
+	}
+	if err != nil {
+		return preparedAnnotate{}, models.NewCanonicalJobError("IMAGE_ANNOTATE_READ_FAILED", err.Error(), map[string]any{"inputPath": inputPath})
+	}
+
+	if metaErr := validateCarriedMetadata(parsed.metadata, inputFmt, outputFmt, "IMAGE_ANNOTATE"); metaErr != nil {
+		return preparedAnnotate{}, metaErr
+	}
+
 	outputPath := strings.TrimSpace(parsed.outputPath)
 	if outputPath == "" {
-		outputDir := strings.TrimSpace(parsed.outputDir)
-		if outputDir == "" {
-			outputDir = filepath.Dir(inputPath)
-		}
-		outputPath = nextAvailableAnnotatedOutputPath(outputDir, inputPath, outputFmt, nil)
+		outputPath = parsed.resolveOutputPath(parsed.outputDir, inputPath, outputFmt, 1, width, height)
 	}
 
 	if sameFile(inputPath, outputPath) {
 		return preparedAnnotate{}, models.NewCanonicalJobError("IMAGE_ANNOTATE_OUTPUT_COLLIDES_INPUT", "output path cannot match input path", nil)
 	}
 
-	_, _, width, height, err := readAndNormalize(inputPath)
-	if err != nil {
-		return preparedAnnotate{}, models.NewCanonicalJobError("IMAGE_ANNOTATE_READ_FAILED", err.Error(), map[string]any{"inputPath": inputPath})
+	target, conflictErr := tools.ResolveOutputTarget(parsed.conflicts, outputPath, nil)
+	if conflictErr != nil {
+		return preparedAnnotate{}, conflictErr
 	}
 
 	return preparedAnnotate{
 		inputPath:    inputPath,
-		outputPath:   outputPath,
+		outputPath:   target.Path,
+		target:       target,
 		outputFmt:    outputFmt,
 		operations:   parsed.operations,
+		page:         parsed.page,
+		metadata:     parsed.metadata,
 		canvasWidth:  width,
 		canvasHeight: height,
 	}, nil
 }
 
-func (t *AnnotateTool) prepareForInput(parsed annotateRequest, inputPath string, usedOutputs map[string]struct{}) (preparedAnnotate, *models.JobErrorV1) {
+func (t *AnnotateTool) prepareForInput(parsed annotateRequest, inputPath string, index int, usedOutputs map[string]struct{}) (preparedAnnotate, *models.JobErrorV1) {
 	if err := validateInputImagePath(inputPath); err != nil {
 		return preparedAnnotate{}, err
 	}
@@ -586,21 +727,36 @@ func (t *AnnotateTool) prepareForInput(parsed annotateRequest, inputPath string,
 		return preparedAnnotate{}, fmtErr
 	}
 
-	outputPath := nextAvailableAnnotatedOutputPath(parsed.outputDir, inputPath, outputFmt, usedOutputs)
+	_, inputFmt, width, height, err := readImagePage(inputPath, parsed.page)
+	if pageErr := pageJobError(err, "IMAGE_ANNOTATE", inputPath); pageErr != nil {
+		return preparedAnnotate{}, pageErr
+	}
+	if err != nil {
+		return preparedAnnotate{}, models.NewCanonicalJobError("IMAGE_ANNOTATE_READ_FAILED", err.Error(), map[string]any{"inputPath": inputPath})
+	}
+
+	if metaErr := validateCarriedMetadata(parsed.metadata, inputFmt, outputFmt, "IMAGE_ANNOTATE"); metaErr != nil {
+		return preparedAnnotate{}, metaErr
+	}
+
+	outputPath := parsed.resolveOutputPath(parsed.outputDir, inputPath, outputFmt, index, width, height)
 	if sameFile(inputPath, outputPath) {
 		return preparedAnnotate{}, models.NewCanonicalJobError("IMAGE_ANNOTATE_OUTPUT_COLLIDES_INPUT", "output path cannot match input path", nil)
 	}
 
-	_, _, width, height, err := readAndNormalize(inputPath)
-	if err != nil {
-		return preparedAnnotate{}, models.NewCanonicalJobError("IMAGE_ANNOTATE_READ_FAILED", err.Error(), map[string]any{"inputPath": inputPath})
+	target, conflictErr := tools.ResolveOutputTarget(parsed.conflicts, outputPath, usedOutputs)
+	if conflictErr != nil {
+		return preparedAnnotate{}, conflictErr
 	}
 
 	return preparedAnnotate{
 		inputPath:    inputPath,
-		outputPath:   outputPath,
+		outputPath:   target.Path,
+		target:       target,
 		outputFmt:    outputFmt,
 		operations:   parsed.operations,
+		page:         parsed.page,
+		metadata:     parsed.metadata,
 		canvasWidth:  width,
 		canvasHeight: height,
 	}, nil
@@ -613,7 +769,7 @@ func executeAnnotateToPath(ctx context.Context, prepared preparedAnnotate) error
 	default:
 	}
 
-	bytes, _, _, _, err := readAndNormalize(prepared.inputPath)
+	bytes, _, _, _, err := readImagePage(prepared.inputPath, prepared.page)
 	if err != nil {
 		return fmt.Errorf("read input failed: %w", err)
 	}
@@ -623,6 +779,13 @@ func executeAnnotateToPath(ctx context.Context, prepared preparedAnnotate) error
 		return fmt.Errorf("annotate failed: %w", err)
 	}
 
+	// applyOperations draws on decoded pixels, so the encoded output has no
+	// metadata of its own; copy it back from the source.
+	annotated, err = carryMetadata(bytes, annotated, prepared.metadata)
+	if err != nil {
+		return fmt.Errorf("copy metadata failed: %w", err)
+	}
+
 	select {
 	case <-ctx.Done():
 		return fmt.Errorf("annotate cancelled: %w", ctx.Err())
@@ -633,7 +796,7 @@ func executeAnnotateToPath(ctx context.Context, prepared preparedAnnotate) error
 		return fmt.Errorf("create output dir failed: %w", mkErr)
 	}
 
-	if writeErr := os.WriteFile(prepared.outputPath, annotated, DefaultFilePermissions); writeErr != nil {
+	if writeErr := tools.WriteOutputFile(ctx, prepared.target, annotated, DefaultFilePermissions); writeErr != nil {
 		return fmt.Errorf("write output failed: %w", writeErr)
 	}
 
@@ -641,9 +804,9 @@ func executeAnnotateToPath(ctx context.Context, prepared preparedAnnotate) error
 }
 
 func applyOperations(input []byte, outputFmt string, operations []models.ImageAnnotateOperationV1) ([]byte, error) {
-	decoded, _, err := image.Decode(bytes.NewReader(input))
+	decoded, err := decodeImage(input)
 	if err != nil {
-		return nil, fmt.Errorf("decode image: %w", err)
+		return nil, err
 	}
 
 	canvas := imaging.Clone(decoded)
@@ -732,30 +895,16 @@ func blurIntensityToSigma(intensity int) float64 {
 	return 0.5 + (float64(intensity) * 0.2)
 }
 
-func nextAvailableAnnotatedOutputPath(outputDir, inputPath, format string, used map[string]struct{}) string {
-	baseName := strings.TrimSuffix(filepath.Base(inputPath), filepath.Ext(inputPath))
-	if strings.TrimSpace(baseName) == "" {
-		baseName = "image"
-	}
-
-	if strings.TrimSpace(outputDir) == "" {
-		outputDir = filepath.Dir(inputPath)
-	}
-
-	candidateBase := filepath.Join(outputDir, fmt.Sprintf("%s_annotated", baseName))
-	first := fmt.Sprintf("%s.%s", candidateBase, format)
-	if isOutputAvailable(first, used) {
-		markUsed(first, used)
-		return first
-	}
-
-	for suffix := 2; ; suffix++ {
-		candidate := fmt.Sprintf("%s-%d.%s", candidateBase, suffix, format)
-		if isOutputAvailable(candidate, used) {
-			markUsed(candidate, used)
-			return candidate
-		}
-	}
+func (r annotateRequest) resolveOutputPath(outputDir, inputPath, format string, index, width, height int) string {
+	return imageOutputPath(outputDir, r.nameTmpl, tools.OutputNameVars{
+		InputPath: inputPath,
+		Ext:       format,
+		Tool:      ToolIDImageAnnotateV1,
+		Index:     index,
+		Subdir:    r.subdirs[inputPath],
+		Width:     width,
+		Height:    height,
+	})
 }
 
 func annotateOptionString(options map[string]any, key string) string {
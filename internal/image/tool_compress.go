@@ -0,0 +1,362 @@
+package image
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"fileforge-desktop/internal/models"
+	"fileforge-desktop/internal/tools"
+
+	"github.com/h2non/bimg"
+)
+
+const ToolIDImageCompressV1 = "tool.image.compress"
+
+// compressFormats are the outputs the compress tool writes; inputs in any
+// other format are re-encoded as JPEG unless a format is requested.
+var compressFormats = map[string]bool{"jpeg": true, "png": true, "webp": true}
+
+type CompressTool struct{}
+
+type compressRequest struct {
+	mode       string
+	inputPaths []string
+	outputPath string
+	outputDir  string
+	format     string
+	target     sizeTarget
+	nameTmpl   string
+	conflicts  string
+	subdirs    map[string]string // output sub-directory of each input, from input expansion
+}
+
+type preparedCompress struct {
+	inputPath  string
+	outputPath string
+	target     tools.OutputTarget
+	outputFmt  string
+	size       sizeTarget
+}
+
+func NewCompressTool() *CompressTool {
+	return &CompressTool{}
+}
+
+func (t *CompressTool) ID() string {
+	return ToolIDImageCompressV1
+}
+
+func (t *CompressTool) Capability() string {
+	return ToolIDImageCompressV1
+}
+
+func (t *CompressTool) Manifest() models.ToolManifestV1 {
+	return models.ToolManifestV1{
+		ToolID:           t.ID(),
+		Name:             "Image Compress",
+		Description:      "Re-encode images to fit a target file size by lowering quality and, if needed, dimensions",
+		Domain:           "image",
+		Capability:       t.Capability(),
+		Version:          "v1",
+		SupportsSingle:   true,
+		SupportsBatch:    true,
+		InputExtensions:  imageInputExtensions,
+		OutputExtensions: []string{"jpeg", "png", "webp"},
+		RuntimeDeps:      []string{"libvips"},
+		Tags:             []string{"image", "compress", "optimize", "web", "batch"},
+		Options: []models.OptionSchemaV1{
+			{Key: "targetBytes", Type: models.OptionTypeInteger, Label: "Target size (bytes)", Description: "Every output must fit in this many bytes", Required: true, Min: models.OptionBound(1)},
+			{Key: "quality", Type: models.OptionTypeInteger, Label: "Maximum quality", Description: "Highest quality tried", Default: defaultTargetMaxQuality, Min: models.OptionBound(1), Max: models.OptionBound(100)},
+			{Key: "minQuality", Type: models.OptionTypeInteger, Label: "Minimum quality", Description: "Lowest quality tried before downscaling", Default: defaultTargetMinQuality, Min: models.OptionBound(1), Max: models.OptionBound(100)},
+			{Key: "allowDownscale", Type: models.OptionTypeBoolean, Label: "Allow downscaling", Description: "Shrink the image when the minimum quality still misses the target", Default: true},
+			{Key: "format", Type: models.OptionTypeString, Label: "Output format", Description: "Defaults to the input format, JPEG for formats without size control", Enum: []string{"jpeg", "jpg", "png", "webp"}},
+			{Key: "outputPath", Type: models.OptionTypeString, Label: "Output file", Format: "path", Modes: []string{"single"}},
+			{Key: "outputDir", Type: models.OptionTypeString, Label: "Output folder", Description: "Overrides the job output folder", Format: "dir"},
+			tools.OutputNameTemplateSchema("{name}_compressed.{ext}"),
+		},
+	}
+}
+
+func (t *CompressTool) RetryPolicy() models.RetryPolicyV1 {
+	return models.RetryPolicyV1{
+		MaxAttempts:    3,
+		RetryableCodes: []string{"IMAGE_COMPRESS_EXECUTION"},
+	}
+}
+
+func (t *CompressTool) RuntimeState(_ context.Context) models.ToolRuntimeStateV1 {
+	return imageRuntimeState()
+}
+
+func (t *CompressTool) Validate(_ context.Context, req models.JobRequestV1) *models.JobErrorV1 {
+	parsed, jobErr := parseCompressRequest(req)
+	if jobErr != nil {
+		return jobErr
+	}
+
+	if parsed.mode == "single" {
+		_, prepErr := t.prepare(parsed, firstPath(parsed.inputPaths), 1, nil)
+		return prepErr
+	}
+
+	if strings.TrimSpace(parsed.outputDir) != "" {
+		if _, statErr := os.Stat(parsed.outputDir); statErr != nil {
+			return models.NewCanonicalJobError("IMAGE_COMPRESS_OUTPUT_DIR_INVALID", fmt.Sprintf("outputDir is not accessible: %v", statErr), nil)
+		}
+	}
+
+	return nil
+}
+
+// Plan resolves the output of every input; each output is estimated at the
+// smaller of the input size and the target.
+func (t *CompressTool) Plan(_ context.Context, req models.JobRequestV1) (models.JobPlanV1, *models.JobErrorV1) {
+	parsed, jobErr := parseCompressRequest(req)
+	if jobErr != nil {
+		return models.JobPlanV1{}, jobErr
+	}
+
+	usedOutputs := make(map[string]struct{}, len(parsed.inputPaths))
+	items := make([]models.PlanItemV1, 0, len(parsed.inputPaths))
+	for idx, inputPath := range parsed.inputPaths {
+		prepared, prepErr := t.prepare(parsed, inputPath, idx+1, usedOutputs)
+		if prepErr != nil {
+			items = append(items, tools.FailedPlanItem(inputPath, prepErr))
+			continue
+		}
+		estimate := min(tools.FileSize(inputPath), parsed.target.bytes)
+		items = append(items, tools.PlanItem(inputPath, []tools.OutputTarget{prepared.target}, estimate))
+	}
+
+	return tools.NewJobPlan(t.ID(), parsed.mode, items), nil
+}
+
+func (t *CompressTool) ExecuteSingle(ctx context.Context, req models.JobRequestV1) (models.JobResultItemV1, *models.JobErrorV1) {
+	parsed, jobErr := parseCompressRequest(req)
+	if jobErr != nil {
+		return models.JobResultItemV1{Success: false, Message: jobErr.Message, Error: jobErr}, jobErr
+	}
+
+	prepared, prepErr := t.prepare(parsed, firstPath(parsed.inputPaths), 1, nil)
+	if prepErr != nil {
+		return models.JobResultItemV1{InputPath: firstPath(parsed.inputPaths), OutputPath: parsed.outputPath, Success: false, Message: prepErr.Message, Error: prepErr}, prepErr
+	}
+	if prepared.target.Skip {
+		return models.SkippedItemV1(prepared.inputPath, prepared.outputPath), nil
+	}
+
+	sized, err := executeCompressToPath(ctx, prepared)
+	if err != nil {
+		jobErr = imageItemError(err, "IMAGE_COMPRESS_EXECUTION", prepared.inputPath)
+		return models.JobResultItemV1{InputPath: prepared.inputPath, OutputPath: prepared.outputPath, Success: false, Message: "image compression failed", Error: jobErr}, jobErr
+	}
+
+	return compressedItem(prepared, sized), nil
+}
+
+func (t *CompressTool) ExecuteBatch(ctx context.Context, req models.JobRequestV1, onProgress func(models.JobProgressV1)) ([]models.JobResultItemV1, *models.JobErrorV1) {
+	parsed, jobErr := parseCompressRequest(req)
+	if jobErr != nil {
+		return nil, jobErr
+	}
+
+	if parsed.mode != "batch" {
+		return nil, models.NewCanonicalJobError("IMAGE_COMPRESS_MODE_INVALID", "mode must be batch", nil)
+	}
+
+	usedOutputs := make(map[string]struct{}, len(parsed.inputPaths))
+	items := make([]models.JobResultItemV1, 0, len(parsed.inputPaths))
+	var firstErr *models.JobErrorV1
+
+	for idx, inputPath := range parsed.inputPaths {
+		select {
+		case <-ctx.Done():
+			cancelErr := models.NewCanonicalJobError("IMAGE_COMPRESS_CANCELLED", ctx.Err().Error(), nil)
+			return items, cancelErr
+		default:
+		}
+
+		if tools.ItemCancelled(ctx, inputPath) {
+			items = append(items, models.CancelledItemV1(inputPath, ""))
+		} else if prepared, prepErr := t.prepare(parsed, inputPath, idx+1, usedOutputs); prepErr != nil {
+			if firstErr == nil {
+				firstErr = prepErr
+			}
+			items = append(items, models.JobResultItemV1{InputPath: inputPath, OutputPath: "", Success: false, Message: prepErr.Message, Error: prepErr})
+		} else if prepared.target.Skip {
+			items = append(items, models.SkippedItemV1(prepared.inputPath, prepared.outputPath))
+		} else {
+			itemCtx, stopItem := tools.ItemContext(ctx, prepared.inputPath)
+			sized, err := executeCompressToPath(itemCtx, prepared)
+			stopItem()
+			if err != nil && ctx.Err() == nil && tools.ItemCancelled(ctx, prepared.inputPath) {
+				items = append(items, models.CancelledItemV1(prepared.inputPath, prepared.outputPath))
+			} else if err != nil {
+				itemErr := imageItemError(err, "IMAGE_COMPRESS_BATCH_ITEM", prepared.inputPath)
+				if firstErr == nil {
+					firstErr = itemErr
+				}
+				items = append(items, models.JobResultItemV1{InputPath: prepared.inputPath, OutputPath: prepared.outputPath, Success: false, Message: "image compression failed", Error: itemErr})
+			} else {
+				items = append(items, compressedItem(prepared, sized))
+			}
+		}
+
+		if onProgress != nil {
+			onProgress(models.JobProgressV1{Current: idx + 1, Total: len(parsed.inputPaths), Stage: models.JobStatusRunning, Message: fmt.Sprintf("processed %d/%d", idx+1, len(parsed.inputPaths)), Item: models.LastResultItemV1(items)})
+		}
+	}
+
+	return items, firstErr
+}
+
+func compressedItem(prepared preparedCompress, sized sizedOutput) models.JobResultItemV1 {
+	message := fmt.Sprintf("compressed to %d bytes at %dx%d", len(sized.data), sized.width, sized.height)
+	if sized.quality > 0 {
+		message = fmt.Sprintf("compressed to %d bytes at quality %d, %dx%d", len(sized.data), sized.quality, sized.width, sized.height)
+	}
+	return models.JobResultItemV1{
+		InputPath:     prepared.inputPath,
+		OutputPath:    prepared.outputPath,
+		Outputs:       []string{prepared.outputPath},
+		OutputCount:   1,
+		OutputWidth:   sized.width,
+		OutputHeight:  sized.height,
+		OutputBytes:   int64(len(sized.data)),
+		OutputQuality: sized.quality,
+		Success:       true,
+		Message:       message,
+	}
+}
+
+func parseCompressRequest(req models.JobRequestV1) (compressRequest, *models.JobErrorV1) {
+	mode := strings.TrimSpace(req.Mode)
+	if mode != "single" && mode != "batch" {
+		return compressRequest{}, models.NewCanonicalJobError("IMAGE_COMPRESS_MODE_INVALID", "mode must be single or batch", nil)
+	}
+
+	if mode == "single" && len(req.InputPaths) != 1 {
+		return compressRequest{}, models.NewCanonicalJobError("IMAGE_COMPRESS_SINGLE_INPUT_COUNT", "single mode requires exactly one input", nil)
+	}
+
+	if mode == "batch" && len(req.InputPaths) < 1 {
+		return compressRequest{}, models.NewCanonicalJobError("IMAGE_COMPRESS_BATCH_INPUT_REQUIRED", "batch mode requires at least one input", nil)
+	}
+
+	inputPaths := make([]string, 0, len(req.InputPaths))
+	for _, rawPath := range req.InputPaths {
+		trimmed := strings.TrimSpace(rawPath)
+		if trimmed == "" {
+			return compressRequest{}, models.NewCanonicalJobError("IMAGE_COMPRESS_INPUT_REQUIRED", "inputPaths contains empty item", nil)
+		}
+		inputPaths = append(inputPaths, trimmed)
+	}
+
+	target, ok, targetErr := parseSizeTarget(req.Options, "IMAGE_COMPRESS", true)
+	if targetErr != nil {
+		return compressRequest{}, targetErr
+	}
+	if !ok {
+		return compressRequest{}, models.NewCanonicalJobError("IMAGE_COMPRESS_TARGET_MISSING", "options.targetBytes is required", nil)
+	}
+
+	if tmplErr := tools.ValidateOutputNameTemplate(req.Options); tmplErr != nil {
+		return compressRequest{}, tmplErr
+	}
+
+	parsed := compressRequest{
+		mode:       mode,
+		inputPaths: inputPaths,
+		outputPath: resizeOptionString(req.Options, "outputPath"),
+		outputDir:  strings.TrimSpace(req.OutputDir),
+		format:     strings.ToLower(resizeOptionString(req.Options, "format")),
+		target:     target,
+		nameTmpl:   tools.OutputNameTemplate(req.Options, "{name}_compressed.{ext}"),
+		conflicts:  tools.ConflictPolicy(req),
+		subdirs:    req.InputSubdirs,
+	}
+
+	if outputDir := resizeOptionString(req.Options, "outputDir"); outputDir != "" {
+		parsed.outputDir = outputDir
+	}
+
+	return parsed, nil
+}
+
+// prepare resolves the output of one input. In single mode an explicit
+// outputPath wins over the name template.
+func (t *CompressTool) prepare(parsed compressRequest, inputPath string, index int, usedOutputs map[string]struct{}) (preparedCompress, *models.JobErrorV1) {
+	if err := validateInputImagePath(inputPath); err != nil {
+		return preparedCompress{}, err
+	}
+
+	outputFmt := parsed.format
+	if outputFmt == "" {
+		outputFmt, _ = resolveOutputFormat(inputPath, "")
+		if !compressFormats[outputFmt] {
+			outputFmt = "jpeg"
+		}
+	}
+	if outputFmt == "jpg" {
+		outputFmt = "jpeg"
+	}
+	if !compressFormats[outputFmt] {
+		return preparedCompress{}, models.NewCanonicalJobError("IMAGE_COMPRESS_FORMAT_UNSUPPORTED", fmt.Sprintf("unsupported output format: %s", outputFmt), nil)
+	}
+
+	outputPath := ""
+	if parsed.mode == "single" {
+		outputPath = parsed.outputPath
+	}
+	if outputPath == "" {
+		outputPath = imageOutputPath(parsed.outputDir, parsed.nameTmpl, tools.OutputNameVars{
+			InputPath: inputPath,
+			Ext:       outputFmt,
+			Tool:      ToolIDImageCompressV1,
+			Index:     index,
+			Subdir:    parsed.subdirs[inputPath],
+		})
+	}
+
+	if sameFile(inputPath, outputPath) {
+		return preparedCompress{}, models.NewCanonicalJobError("IMAGE_COMPRESS_OUTPUT_COLLIDES_INPUT", "output path cannot match input path", nil)
+	}
+
+	target, conflictErr := tools.ResolveOutputTarget(parsed.conflicts, outputPath, usedOutputs)
+	if conflictErr != nil {
+		return preparedCompress{}, conflictErr
+	}
+
+	return preparedCompress{
+		inputPath:  inputPath,
+		outputPath: target.Path,
+		target:     target,
+		outputFmt:  outputFmt,
+		size:       parsed.target,
+	}, nil
+}
+
+func executeCompressToPath(ctx context.Context, prepared preparedCompress) (sizedOutput, error) {
+	input, _, _, _, err := readAndNormalize(prepared.inputPath)
+	if err != nil {
+		return sizedOutput{}, fmt.Errorf("read input failed: %w", err)
+	}
+
+	sized, err := encodeToTarget(ctx, input, bimg.Options{Type: mapFormatToImageType(prepared.outputFmt)}, prepared.outputFmt, prepared.size)
+	if err != nil {
+		return sizedOutput{}, err
+	}
+
+	if mkErr := os.MkdirAll(filepath.Dir(prepared.outputPath), 0o755); mkErr != nil {
+		return sizedOutput{}, fmt.Errorf("create output dir failed: %w", mkErr)
+	}
+
+	if writeErr := tools.WriteOutputFile(ctx, prepared.target, sized.data, DefaultFilePermissions); writeErr != nil {
+		return sizedOutput{}, fmt.Errorf("write output failed: %w", writeErr)
+	}
+
+	return sized, nil
+}
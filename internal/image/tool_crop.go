@@ -6,10 +6,12 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"strconv"
 	"strings"
 
 	"fileforge-desktop/internal/models"
+	"fileforge-desktop/internal/tools"
 
 	"github.com/h2non/bimg"
 )
@@ -22,6 +24,8 @@ var imageMimeByFormat = map[string]string{
 	"webp": "image/webp",
 	"gif":  "image/gif",
 	"tiff": "image/tiff",
+	"avif": "image/avif",
+	"heif": "image/heif",
 }
 
 type CropTool struct{}
@@ -37,17 +41,25 @@ type cropRequest struct {
 	height      int
 	ratioPreset string
 	format      string
+	page        int
+	metadata    string
+	nameTmpl    string
+	conflicts   string
+	subdirs     map[string]string // output sub-directory of each input, from input expansion
 }
 
 type preparedCrop struct {
 	inputPath   string
 	outputPath  string
+	target      tools.OutputTarget
 	outputFmt   string
 	x           int
 	y           int
 	width       int
 	height      int
 	ratioPreset string
+	page        int
+	metadata    string
 }
 
 func NewCropTool() *CropTool {
@@ -72,15 +84,35 @@ func (t *CropTool) Manifest() models.ToolManifestV1 {
 		Version:          "v1",
 		SupportsSingle:   true,
 		SupportsBatch:    true,
-		InputExtensions:  []string{"jpg", "jpeg", "png", "gif", "webp", "bmp", "tiff", "tif"},
-		OutputExtensions: []string{"jpeg", "png", "webp", "gif", "tiff"},
+		InputExtensions:  imageInputExtensions,
+		OutputExtensions: imageOutputFormats,
 		RuntimeDeps:      []string{"libvips"},
 		Tags:             []string{"image", "crop", "ratio", "batch"},
+		Options: []models.OptionSchemaV1{
+			{Key: "x", Type: models.OptionTypeInteger, Label: "X", Description: "Left edge of the crop area in pixels", Required: true, Min: models.OptionBound(0)},
+			{Key: "y", Type: models.OptionTypeInteger, Label: "Y", Description: "Top edge of the crop area in pixels", Required: true, Min: models.OptionBound(0)},
+			{Key: "width", Type: models.OptionTypeInteger, Label: "Width", Required: true, Min: models.OptionBound(1)},
+			{Key: "height", Type: models.OptionTypeInteger, Label: "Height", Required: true, Min: models.OptionBound(1)},
+			{Key: "ratioPreset", Type: models.OptionTypeString, Label: "Aspect ratio", Description: "free or W:H, such as 1:1, 4:3 or 16:9", Default: "free"},
+			{Key: "format", Type: models.OptionTypeString, Label: "Output format", Description: "Defaults to the input format", Enum: imageFormatEnum},
+			{Key: "page", Type: models.OptionTypeInteger, Label: "Page", Description: "Page of a multi-page TIFF to crop, 1-based", Default: 1, Min: models.OptionBound(1)},
+			metadataOptionSchema(),
+			{Key: "outputPath", Type: models.OptionTypeString, Label: "Output file", Format: "path", Modes: []string{"single"}},
+			{Key: "outputDir", Type: models.OptionTypeString, Label: "Output folder", Description: "Overrides the job output folder", Format: "dir", Modes: []string{"single"}},
+			tools.OutputNameTemplateSchema("{name}_cropped.{ext}"),
+		},
+	}
+}
+
+func (t *CropTool) RetryPolicy() models.RetryPolicyV1 {
+	return models.RetryPolicyV1{
+		MaxAttempts:    3,
+		RetryableCodes: []string{"IMAGE_CROP_EXECUTION"},
 	}
 }
 
 func (t *CropTool) RuntimeState(_ context.Context) models.ToolRuntimeStateV1 {
-	return models.ToolRuntimeStateV1{Status: "enabled", Healthy: true}
+	return imageRuntimeState()
 }
 
 func (t *CropTool) Validate(_ context.Context, req models.JobRequestV1) *models.JobErrorV1 {
@@ -95,7 +127,7 @@ func (t *CropTool) Validate(_ context.Context, req models.JobRequestV1) *models.
 			return prepErr
 		}
 
-		if areaErr := validateCropBounds(prepared.inputPath, prepared.x, prepared.y, prepared.width, prepared.height); areaErr != nil {
+		if areaErr := validateCropBounds(prepared.inputPath, prepared.page, prepared.x, prepared.y, prepared.width, prepared.height); areaErr != nil {
 			return areaErr
 		}
 	}
@@ -114,6 +146,37 @@ func (t *CropTool) Validate(_ context.Context, req models.JobRequestV1) *models.
 	return nil
 }
 
+// Plan resolves the output of every input the way the executors do, reading
+// image headers for the bounds check but writing nothing.
+func (t *CropTool) Plan(_ context.Context, req models.JobRequestV1) (models.JobPlanV1, *models.JobErrorV1) {
+	parsed, jobErr := parseCropRequest(req)
+	if jobErr != nil {
+		return models.JobPlanV1{}, jobErr
+	}
+
+	usedOutputs := make(map[string]struct{}, len(parsed.inputPaths))
+	items := make([]models.PlanItemV1, 0, len(parsed.inputPaths))
+	for idx, inputPath := range parsed.inputPaths {
+		var prepared preparedCrop
+		var prepErr *models.JobErrorV1
+		if parsed.mode == "single" {
+			prepared, prepErr = t.prepareSingle(parsed)
+		} else {
+			prepared, prepErr = t.prepareForInput(parsed, inputPath, idx+1, usedOutputs)
+		}
+		if prepErr == nil && !prepared.target.Skip {
+			prepErr = validateCropBounds(prepared.inputPath, prepared.page, prepared.x, prepared.y, prepared.width, prepared.height)
+		}
+		if prepErr != nil {
+			items = append(items, tools.FailedPlanItem(inputPath, prepErr))
+			continue
+		}
+		items = append(items, tools.PlanItem(inputPath, []tools.OutputTarget{prepared.target}, tools.FileSize(inputPath)))
+	}
+
+	return tools.NewJobPlan(t.ID(), parsed.mode, items), nil
+}
+
 func (t *CropTool) ExecuteSingle(ctx context.Context, req models.JobRequestV1) (models.JobResultItemV1, *models.JobErrorV1) {
 	parsed, jobErr := parseCropRequest(req)
 	if jobErr != nil {
@@ -124,8 +187,11 @@ func (t *CropTool) ExecuteSingle(ctx context.Context, req models.JobRequestV1) (
 	if prepErr != nil {
 		return models.JobResultItemV1{InputPath: firstPath(parsed.inputPaths), OutputPath: parsed.outputPath, Success: false, Message: prepErr.Message, Error: prepErr}, prepErr
 	}
+	if prepared.target.Skip {
+		return models.SkippedItemV1(prepared.inputPath, prepared.outputPath), nil
+	}
 
-	if areaErr := validateCropBounds(prepared.inputPath, prepared.x, prepared.y, prepared.width, prepared.height); areaErr != nil {
+	if areaErr := validateCropBounds(prepared.inputPath, prepared.page, prepared.x, prepared.y, prepared.width, prepared.height); areaErr != nil {
 		return models.JobResultItemV1{InputPath: prepared.inputPath, OutputPath: prepared.outputPath, Success: false, Message: areaErr.Message, Error: areaErr}, areaErr
 	}
 
@@ -166,7 +232,15 @@ func (t *CropTool) ExecuteBatch(ctx context.Context, req models.JobRequestV1, on
 		default:
 		}
 
-		prepared, prepErr := t.prepareForInput(parsed, inputPath, usedOutputs)
+		if tools.ItemCancelled(ctx, inputPath) {
+			items = append(items, models.CancelledItemV1(inputPath, ""))
+			if onProgress != nil {
+				onProgress(models.JobProgressV1{Current: idx + 1, Total: len(parsed.inputPaths), Stage: models.JobStatusRunning, Message: fmt.Sprintf("processed %d/%d", idx+1, len(parsed.inputPaths)), Item: models.LastResultItemV1(items)})
+			}
+			continue
+		}
+
+		prepared, prepErr := t.prepareForInput(parsed, inputPath, idx+1, usedOutputs)
 		if prepErr != nil {
 			if firstErr == nil {
 				firstErr = prepErr
@@ -179,12 +253,20 @@ func (t *CropTool) ExecuteBatch(ctx context.Context, req models.JobRequestV1, on
 				Error:      prepErr,
 			})
 			if onProgress != nil {
-				onProgress(models.JobProgressV1{Current: idx + 1, Total: len(parsed.inputPaths), Stage: models.JobStatusRunning, Message: fmt.Sprintf("processed %d/%d", idx+1, len(parsed.inputPaths))})
+				onProgress(models.JobProgressV1{Current: idx + 1, Total: len(parsed.inputPaths), Stage: models.JobStatusRunning, Message: fmt.Sprintf("processed %d/%d", idx+1, len(parsed.inputPaths)), Item: models.LastResultItemV1(items)})
 			}
 			continue
 		}
 
-		if areaErr := validateCropBounds(prepared.inputPath, prepared.x, prepared.y, prepared.width, prepared.height); areaErr != nil {
+		if prepared.target.Skip {
+			items = append(items, models.SkippedItemV1(prepared.inputPath, prepared.outputPath))
+			if onProgress != nil {
+				onProgress(models.JobProgressV1{Current: idx + 1, Total: len(parsed.inputPaths), Stage: models.JobStatusRunning, Message: fmt.Sprintf("processed %d/%d", idx+1, len(parsed.inputPaths)), Item: models.LastResultItemV1(items)})
+			}
+			continue
+		}
+
+		if areaErr := validateCropBounds(prepared.inputPath, prepared.page, prepared.x, prepared.y, prepared.width, prepared.height); areaErr != nil {
 			if firstErr == nil {
 				firstErr = areaErr
 			}
@@ -196,12 +278,17 @@ func (t *CropTool) ExecuteBatch(ctx context.Context, req models.JobRequestV1, on
 				Error:      areaErr,
 			})
 			if onProgress != nil {
-				onProgress(models.JobProgressV1{Current: idx + 1, Total: len(parsed.inputPaths), Stage: models.JobStatusRunning, Message: fmt.Sprintf("processed %d/%d", idx+1, len(parsed.inputPaths))})
+				onProgress(models.JobProgressV1{Current: idx + 1, Total: len(parsed.inputPaths), Stage: models.JobStatusRunning, Message: fmt.Sprintf("processed %d/%d", idx+1, len(parsed.inputPaths)), Item: models.LastResultItemV1(items)})
 			}
 			continue
 		}
 
-		if err := executeCropToPath(ctx, prepared); err != nil {
+		itemCtx, stopItem := tools.ItemContext(ctx, prepared.inputPath)
+		err := executeCropToPath(itemCtx, prepared)
+		stopItem()
+		if err != nil && ctx.Err() == nil && tools.ItemCancelled(ctx, prepared.inputPath) {
+			items = append(items, models.CancelledItemV1(prepared.inputPath, prepared.outputPath))
+		} else if err != nil {
 			itemErr := models.NewCanonicalJobError("IMAGE_CROP_BATCH_ITEM", err.Error(), map[string]any{"inputPath": prepared.inputPath})
 			if firstErr == nil {
 				firstErr = itemErr
@@ -225,7 +312,7 @@ func (t *CropTool) ExecuteBatch(ctx context.Context, req models.JobRequestV1, on
 		}
 
 		if onProgress != nil {
-			onProgress(models.JobProgressV1{Current: idx + 1, Total: len(parsed.inputPaths), Stage: models.JobStatusRunning, Message: fmt.Sprintf("processed %d/%d", idx+1, len(parsed.inputPaths))})
+			onProgress(models.JobProgressV1{Current: idx + 1, Total: len(parsed.inputPaths), Stage: models.JobStatusRunning, Message: fmt.Sprintf("processed %d/%d", idx+1, len(parsed.inputPaths)), Item: models.LastResultItemV1(items)})
 		}
 	}
 
@@ -248,7 +335,18 @@ func (t *CropTool) GetImagePreviewSource(inputPath string) models.ImagePreviewSo
 		return models.ImagePreviewSourceResponseV1{Success: false, Message: "Cannot load image preview source.", Error: jobErr}
 	}
 
-	mimeType := imageMimeByFormat[fmtName]
+	pageCount := 1
+	if raw, readErr := os.ReadFile(path); readErr == nil {
+		if count, countErr := imagePageCount(raw); countErr == nil {
+			pageCount = count
+		}
+	}
+
+	bytes, mimeType, err := previewImage(bytes, fmtName)
+	if err != nil {
+		jobErr := models.NewCanonicalJobError("IMAGE_PREVIEW_READ_FAILED", err.Error(), nil)
+		return models.ImagePreviewSourceResponseV1{Success: false, Message: "Cannot load image preview source.", Error: jobErr}
+	}
 	if mimeType == "" {
 		mimeType = "application/octet-stream"
 	}
@@ -260,6 +358,7 @@ func (t *CropTool) GetImagePreviewSource(inputPath string) models.ImagePreviewSo
 		MimeType:   mimeType,
 		Width:      width,
 		Height:     height,
+		PageCount:  pageCount,
 	}
 }
 
@@ -289,7 +388,7 @@ func (t *CropTool) GetImageCropPreview(req models.ImageCropPreviewRequestV1) mod
 		return models.ImageCropPreviewResponseV1{Success: false, Message: ratioErr.Message, Error: ratioErr}
 	}
 
-	bytes, fmtName, _, _, err := readAndNormalize(inputPath)
+	bytes, fmtName, _, _, err := readImagePage(inputPath, req.Page)
 	if err != nil {
 		jobErr := models.NewCanonicalJobError("IMAGE_CROP_PREVIEW_READ_FAILED", err.Error(), nil)
 		return models.ImageCropPreviewResponseV1{Success: false, Message: "Cannot load image preview source.", Error: jobErr}
@@ -311,13 +410,17 @@ func (t *CropTool) GetImageCropPreview(req models.ImageCropPreviewRequestV1) mod
 		return models.ImageCropPreviewResponseV1{Success: false, Message: fmtErr.Message, Error: fmtErr}
 	}
 
-	croppedBytes, err := cropInMemory(bytes, req.X, req.Y, req.Width, req.Height, outputFmt)
+	croppedBytes, err := cropInMemory(bytes, req.X, req.Y, req.Width, req.Height, outputFmt, metadataPreserve)
 	if err != nil {
 		jobErr := models.NewCanonicalJobError("IMAGE_CROP_PREVIEW_EXECUTION", err.Error(), nil)
 		return models.ImageCropPreviewResponseV1{Success: false, Message: "Failed to generate preview.", Error: jobErr}
 	}
 
-	mimeType := imageMimeByFormat[outputFmt]
+	croppedBytes, mimeType, err := previewImage(croppedBytes, outputFmt)
+	if err != nil {
+		jobErr := models.NewCanonicalJobError("IMAGE_CROP_PREVIEW_EXECUTION", err.Error(), nil)
+		return models.ImageCropPreviewResponseV1{Success: false, Message: "Failed to generate preview.", Error: jobErr}
+	}
 	if mimeType == "" {
 		mimeType = imageMimeByFormat[fmtName]
 	}
@@ -389,6 +492,20 @@ func parseCropRequest(req models.JobRequestV1) (cropRequest, *models.JobErrorV1)
 		return cropRequest{}, ratioErr
 	}
 
+	page, pageErr := optionPage(req.Options, "IMAGE_CROP")
+	if pageErr != nil {
+		return cropRequest{}, pageErr
+	}
+
+	metadata, metaErr := optionMetadata(req.Options, "IMAGE_CROP")
+	if metaErr != nil {
+		return cropRequest{}, metaErr
+	}
+
+	if tmplErr := tools.ValidateOutputNameTemplate(req.Options); tmplErr != nil {
+		return cropRequest{}, tmplErr
+	}
+
 	parsed := cropRequest{
 		mode:        mode,
 		inputPaths:  inputs,
@@ -400,6 +517,11 @@ func parseCropRequest(req models.JobRequestV1) (cropRequest, *models.JobErrorV1)
 		height:      height,
 		ratioPreset: ratioPreset,
 		format:      strings.ToLower(strings.TrimSpace(cropOptionString(req.Options, "format"))),
+		page:        page,
+		metadata:    metadata,
+		nameTmpl:    tools.OutputNameTemplate(req.Options, "{name}_cropped.{ext}"),
+		conflicts:   tools.ConflictPolicy(req),
+		subdirs:     req.InputSubdirs,
 	}
 
 	if parsed.mode == "single" {
@@ -426,32 +548,44 @@ func (t *CropTool) prepareSingle(parsed cropRequest) (preparedCrop, *models.JobE
 		return preparedCrop{}, fmtErr
 	}
 
+	if metaErr := validateMetadataFormat(parsed.metadata, outputFmt, "IMAGE_CROP"); metaErr != nil {
+		return preparedCrop{}, metaErr
+	}
+
 	outputPath := strings.TrimSpace(parsed.outputPath)
 	if outputPath == "" {
 		outputDir := strings.TrimSpace(parsed.outputDir)
 		if outputDir == "" {
 			outputDir = filepath.Dir(inputPath)
 		}
-		outputPath = nextAvailableOutputPath(outputDir, inputPath, outputFmt, nil)
+		outputPath = parsed.resolveOutputPath(outputDir, inputPath, outputFmt, 1)
 	}
 
 	if sameFile(inputPath, outputPath) {
 		return preparedCrop{}, models.NewCanonicalJobError("IMAGE_CROP_OUTPUT_COLLIDES_INPUT", "output path cannot match input path", nil)
 	}
 
+	target, conflictErr := tools.ResolveOutputTarget(parsed.conflicts, outputPath, nil)
+	if conflictErr != nil {
+		return preparedCrop{}, conflictErr
+	}
+
 	return preparedCrop{
 		inputPath:   inputPath,
-		outputPath:  outputPath,
+		outputPath:  target.Path,
+		target:      target,
 		outputFmt:   outputFmt,
 		x:           parsed.x,
 		y:           parsed.y,
 		width:       parsed.width,
 		height:      parsed.height,
 		ratioPreset: parsed.ratioPreset,
+		page:        parsed.page,
+		metadata:    parsed.metadata,
 	}, nil
 }
 
-func (t *CropTool) prepareForInput(parsed cropRequest, inputPath string, usedOutputs map[string]struct{}) (preparedCrop, *models.JobErrorV1) {
+func (t *CropTool) prepareForInput(parsed cropRequest, inputPath string, index int, usedOutputs map[string]struct{}) (preparedCrop, *models.JobErrorV1) {
 	if err := validateInputImagePath(inputPath); err != nil {
 		return preparedCrop{}, err
 	}
@@ -461,20 +595,32 @@ func (t *CropTool) prepareForInput(parsed cropRequest, inputPath string, usedOut
 		return preparedCrop{}, fmtErr
 	}
 
-	outputPath := nextAvailableOutputPath(parsed.outputDir, inputPath, outputFmt, usedOutputs)
+	if metaErr := validateMetadataFormat(parsed.metadata, outputFmt, "IMAGE_CROP"); metaErr != nil {
+		return preparedCrop{}, metaErr
+	}
+
+	outputPath := parsed.resolveOutputPath(parsed.outputDir, inputPath, outputFmt, index)
 	if sameFile(inputPath, outputPath) {
 		return preparedCrop{}, models.NewCanonicalJobError("IMAGE_CROP_OUTPUT_COLLIDES_INPUT", "output path cannot match input path", nil)
 	}
 
+	target, conflictErr := tools.ResolveOutputTarget(parsed.conflicts, outputPath, usedOutputs)
+	if conflictErr != nil {
+		return preparedCrop{}, conflictErr
+	}
+
 	return preparedCrop{
 		inputPath:   inputPath,
-		outputPath:  outputPath,
+		outputPath:  target.Path,
+		target:      target,
 		outputFmt:   outputFmt,
 		x:           parsed.x,
 		y:           parsed.y,
 		width:       parsed.width,
 		height:      parsed.height,
 		ratioPreset: parsed.ratioPreset,
+		page:        parsed.page,
+		metadata:    parsed.metadata,
 	}, nil
 }
 
@@ -485,7 +631,7 @@ func executeCropToPath(ctx context.Context, prepared preparedCrop) error {
 	default:
 	}
 
-	bytes, _, _, _, err := readAndNormalize(prepared.inputPath)
+	bytes, _, _, _, err := readImagePage(prepared.inputPath, prepared.page)
 	if err != nil {
 		return fmt.Errorf("read input failed: %w", err)
 	}
@@ -494,7 +640,7 @@ func executeCropToPath(ctx context.Context, prepared preparedCrop) error {
 		return fmt.Errorf("bounds validation failed: %s", err.Message)
 	}
 
-	cropped, err := cropInMemory(bytes, prepared.x, prepared.y, prepared.width, prepared.height, prepared.outputFmt)
+	cropped, err := cropInMemory(bytes, prepared.x, prepared.y, prepared.width, prepared.height, prepared.outputFmt, prepared.metadata)
 	if err != nil {
 		return fmt.Errorf("crop failed: %w", err)
 	}
@@ -509,7 +655,7 @@ func executeCropToPath(ctx context.Context, prepared preparedCrop) error {
 		return fmt.Errorf("create output dir failed: %w", mkErr)
 	}
 
-	if writeErr := os.WriteFile(prepared.outputPath, cropped, DefaultFilePermissions); writeErr != nil {
+	if writeErr := tools.WriteOutputFile(ctx, prepared.target, cropped, DefaultFilePermissions); writeErr != nil {
 		return fmt.Errorf("write output failed: %w", writeErr)
 	}
 
@@ -517,6 +663,12 @@ func executeCropToPath(ctx context.Context, prepared preparedCrop) error {
 }
 
 func readAndNormalize(inputPath string) ([]byte, string, int, int, error) {
+	return readImagePage(inputPath, 1)
+}
+
+// readImagePage is readAndNormalize for one 1-based page of a multi-page
+// TIFF; other formats only have page 1.
+func readImagePage(inputPath string, page int) ([]byte, string, int, int, error) {
 	if strings.TrimSpace(inputPath) == "" {
 		return nil, "", 0, 0, fmt.Errorf("inputPath is required")
 	}
@@ -526,6 +678,11 @@ func readAndNormalize(inputPath string) ([]byte, string, int, int, error) {
 		return nil, "", 0, 0, fmt.Errorf("read input file: %w", err)
 	}
 
+	inputBytes, err = selectImagePage(inputBytes, page)
+	if err != nil {
+		return nil, "", 0, 0, err
+	}
+
 	img := bimg.NewImage(inputBytes)
 	oriented, rotateErr := img.AutoRotate()
 	if rotateErr != nil {
@@ -545,18 +702,31 @@ func readAndNormalize(inputPath string) ([]byte, string, int, int, error) {
 	return oriented, fmtName, size.Width, size.Height, nil
 }
 
-func cropInMemory(input []byte, x, y, width, height int, outputFmt string) ([]byte, error) {
+// cropInMemory crops input, which readImagePage has already rotated upright,
+// and encodes the area as outputFmt. The source metadata is kept unless the
+// metadata mode strips it.
+func cropInMemory(input []byte, x, y, width, height int, outputFmt, metadata string) ([]byte, error) {
 	img := bimg.NewImage(input)
 	extracted, err := img.Extract(y, x, width, height)
 	if err != nil {
 		return nil, err
 	}
 
-	return bimg.NewImage(extracted).Convert(mapFormatToImageType(outputFmt))
+	cropped, err := bimg.NewImage(extracted).Process(bimg.Options{
+		Type:          mapFormatToImageType(outputFmt),
+		StripMetadata: metadata == metadataStrip,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return finishMetadata(cropped, metadata)
 }
 
-func validateCropBounds(inputPath string, x, y, width, height int) *models.JobErrorV1 {
-	bytes, _, _, _, err := readAndNormalize(inputPath)
+func validateCropBounds(inputPath string, page, x, y, width, height int) *models.JobErrorV1 {
+	bytes, _, _, _, err := readImagePage(inputPath, page)
+	if pageErr := pageJobError(err, "IMAGE_CROP", inputPath); pageErr != nil {
+		return pageErr
+	}
 	if err != nil {
 		return models.NewCanonicalJobError("IMAGE_CROP_READ_FAILED", err.Error(), map[string]any{"inputPath": inputPath})
 	}
@@ -643,37 +813,31 @@ func validateInputImagePath(inputPath string) *models.JobErrorV1 {
 	}
 
 	ext := strings.ToLower(strings.TrimPrefix(filepath.Ext(inputPath), "."))
-	supported := map[string]bool{
-		"jpg": true, "jpeg": true, "png": true, "gif": true, "webp": true, "bmp": true, "tiff": true, "tif": true,
-	}
-	if !supported[ext] {
+	if !slices.Contains(imageInputExtensions, ext) {
 		return models.NewCanonicalJobError("IMAGE_CROP_INPUT_UNSUPPORTED", fmt.Sprintf("unsupported input extension: %s", ext), nil)
 	}
 
+	if read, _ := formatSupport(ext); !read {
+		return models.NewCanonicalJobError("IMAGE_CROP_INPUT_UNSUPPORTED", fmt.Sprintf("libvips on this machine cannot read %s files", canonicalFormat(ext)), nil)
+	}
+
 	return nil
 }
 
 func resolveOutputFormat(inputPath, requested string) (string, *models.JobErrorV1) {
-	format := strings.ToLower(strings.TrimSpace(requested))
-	if format == "jpg" {
-		format = "jpeg"
-	}
-
+	format := canonicalFormat(requested)
 	if format == "" {
-		ext := strings.ToLower(strings.TrimPrefix(filepath.Ext(inputPath), "."))
-		if ext == "jpg" {
-			ext = "jpeg"
-		}
-		if ext == "tif" {
-			ext = "tiff"
-		}
-		format = ext
+		format = canonicalFormat(strings.TrimPrefix(filepath.Ext(inputPath), "."))
 	}
 
 	if mapFormatToImageType(format) == bimg.UNKNOWN {
 		return "", models.NewCanonicalJobError("IMAGE_CROP_FORMAT_UNSUPPORTED", fmt.Sprintf("unsupported output format: %s", format), nil)
 	}
 
+	if _, write := formatSupport(format); !write {
+		return "", models.NewCanonicalJobError("IMAGE_CROP_FORMAT_UNSUPPORTED", fmt.Sprintf("libvips on this machine cannot write %s files", format), map[string]any{"format": format})
+	}
+
 	return format, nil
 }
 
@@ -689,57 +853,41 @@ func mapFormatToImageType(format string) bimg.ImageType {
 		return bimg.GIF
 	case "tiff", "tif":
 		return bimg.TIFF
+	case "avif":
+		return bimg.AVIF
+	case "heif", "heic":
+		return bimg.HEIF
 	default:
 		return bimg.UNKNOWN
 	}
 }
 
-func nextAvailableOutputPath(outputDir, inputPath, format string, used map[string]struct{}) string {
-	baseName := strings.TrimSuffix(filepath.Base(inputPath), filepath.Ext(inputPath))
-	if strings.TrimSpace(baseName) == "" {
-		baseName = "image"
-	}
+// resolveOutputPath names the cropped output; its size is the crop rectangle.
+func (r cropRequest) resolveOutputPath(outputDir, inputPath, format string, index int) string {
+	return imageOutputPath(outputDir, r.nameTmpl, tools.OutputNameVars{
+		InputPath: inputPath,
+		Ext:       format,
+		Tool:      ToolIDImageCropV1,
+		Index:     index,
+		Subdir:    r.subdirs[inputPath],
+		Width:     r.width,
+		Height:    r.height,
+	})
+}
 
+// imageOutputPath renders the output name of vars.InputPath, next to the input
+// when outputDir is empty. Templates using {width} or {height} without a known
+// output size get the dimensions of the input image.
+func imageOutputPath(outputDir, template string, vars tools.OutputNameVars) string {
 	if strings.TrimSpace(outputDir) == "" {
-		outputDir = filepath.Dir(inputPath)
-	}
-
-	candidateBase := filepath.Join(outputDir, fmt.Sprintf("%s_cropped", baseName))
-	first := fmt.Sprintf("%s.%s", candidateBase, format)
-	if isOutputAvailable(first, used) {
-		markUsed(first, used)
-		return first
-	}
-
-	for suffix := 2; ; suffix++ {
-		candidate := fmt.Sprintf("%s-%d.%s", candidateBase, suffix, format)
-		if isOutputAvailable(candidate, used) {
-			markUsed(candidate, used)
-			return candidate
-		}
+		outputDir, vars.Subdir = filepath.Dir(vars.InputPath), ""
 	}
-}
-
-func isOutputAvailable(outputPath string, used map[string]struct{}) bool {
-	cleaned := strings.ToLower(filepath.Clean(outputPath))
-	if used != nil {
-		if _, exists := used[cleaned]; exists {
-			return false
+	if vars.Width == 0 && (tools.OutputNameUses(template, "width") || tools.OutputNameUses(template, "height")) {
+		if _, _, width, height, err := readAndNormalize(vars.InputPath); err == nil {
+			vars.Width, vars.Height = width, height
 		}
 	}
-
-	if _, err := os.Stat(outputPath); err == nil {
-		return false
-	}
-
-	return true
-}
-
-func markUsed(outputPath string, used map[string]struct{}) {
-	if used == nil {
-		return
-	}
-	used[strings.ToLower(filepath.Clean(outputPath))] = struct{}{}
+	return tools.RenderOutputPath(outputDir, template, vars)
 }
 
 func sameFile(inputPath, outputPath string) bool {
@@ -0,0 +1,482 @@
+package image
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"fileforge-desktop/internal/models"
+	"fileforge-desktop/internal/tools"
+)
+
+const ToolIDImageMetadataV1 = "tool.image.metadata"
+
+const (
+	metadataActionRead  = "read"
+	metadataActionStrip = "strip"
+)
+
+// inPlaceMetadataFormats are the inputs whose GPS and camera tags can be
+// removed without re-encoding.
+var inPlaceMetadataFormats = map[string]bool{"jpeg": true, "png": true, "webp": true, "tiff": true}
+
+type MetadataTool struct{}
+
+type metadataRequest struct {
+	mode       string
+	inputPaths []string
+	outputPath string
+	outputDir  string
+	action     string
+	groups     []string
+	nameTmpl   string
+	conflicts  string
+	subdirs    map[string]string // output sub-directory of each input, from input expansion
+}
+
+type preparedMetadata struct {
+	inputPath  string
+	outputPath string
+	target     tools.OutputTarget
+	action     string
+	groups     []string
+}
+
+func NewMetadataTool() *MetadataTool {
+	return &MetadataTool{}
+}
+
+func (t *MetadataTool) ID() string {
+	return ToolIDImageMetadataV1
+}
+
+func (t *MetadataTool) Capability() string {
+	return ToolIDImageMetadataV1
+}
+
+func (t *MetadataTool) Manifest() models.ToolManifestV1 {
+	nameTemplate := tools.OutputNameTemplateSchema("{name}_metadata.{ext}")
+	nameTemplate.Description += "; strip defaults to {name}_clean.{ext}"
+
+	return models.ToolManifestV1{
+		ToolID:           t.ID(),
+		Name:             "Image Metadata",
+		Description:      "Read EXIF, GPS and ICC metadata as JSON, or strip GPS, camera or all metadata",
+		Domain:           "image",
+		Capability:       t.Capability(),
+		Version:          "v1",
+		SupportsSingle:   true,
+		SupportsBatch:    true,
+		InputExtensions:  imageInputExtensions,
+		OutputExtensions: append([]string{"json"}, imageOutputFormats...),
+		RuntimeDeps:      []string{"libvips"},
+		Tags:             []string{"image", "metadata", "exif", "gps", "privacy", "batch"},
+		Options: []models.OptionSchemaV1{
+			{Key: "action", Type: models.OptionTypeString, Label: "Action", Description: "read writes a JSON report per image; strip writes a copy without the selected groups", Default: metadataActionRead, Enum: []string{metadataActionRead, metadataActionStrip}},
+			{
+				Key:         "groups",
+				Type:        models.OptionTypeArray,
+				Label:       "Groups to strip",
+				Description: "gps and camera edit JPEG, PNG, WebP and TIFF in place; all also removes ICC, XMP and comments",
+				Default:     []string{metadataGroupAll},
+				Items:       &models.OptionSchemaV1{Key: "group", Type: models.OptionTypeString, Enum: metadataGroups},
+			},
+			{Key: "outputPath", Type: models.OptionTypeString, Label: "Output file", Format: "path", Modes: []string{"single"}},
+			{Key: "outputDir", Type: models.OptionTypeString, Label: "Output folder", Description: "Overrides the job output folder", Format: "dir"},
+			nameTemplate,
+		},
+	}
+}
+
+func (t *MetadataTool) RetryPolicy() models.RetryPolicyV1 {
+	return models.RetryPolicyV1{
+		MaxAttempts:    3,
+		RetryableCodes: []string{"IMAGE_METADATA_EXECUTION"},
+	}
+}
+
+func (t *MetadataTool) RuntimeState(_ context.Context) models.ToolRuntimeStateV1 {
+	return imageRuntimeState()
+}
+
+func (t *MetadataTool) Validate(_ context.Context, req models.JobRequestV1) *models.JobErrorV1 {
+	parsed, jobErr := parseMetadataRequest(req)
+	if jobErr != nil {
+		return jobErr
+	}
+
+	if parsed.mode == "single" {
+		_, prepErr := t.prepare(parsed, firstPath(parsed.inputPaths), 1, nil)
+		return prepErr
+	}
+
+	if strings.TrimSpace(parsed.outputDir) != "" {
+		if _, statErr := os.Stat(parsed.outputDir); statErr != nil {
+			return models.NewCanonicalJobError("IMAGE_METADATA_OUTPUT_DIR_INVALID", fmt.Sprintf("outputDir is not accessible: %v", statErr), nil)
+		}
+	}
+
+	return nil
+}
+
+// Plan resolves the output of every input. Reports are estimated at a few
+// kilobytes, stripped copies at the input size.
+func (t *MetadataTool) Plan(_ context.Context, req models.JobRequestV1) (models.JobPlanV1, *models.JobErrorV1) {
+	parsed, jobErr := parseMetadataRequest(req)
+	if jobErr != nil {
+		return models.JobPlanV1{}, jobErr
+	}
+
+	usedOutputs := make(map[string]struct{}, len(parsed.inputPaths))
+	items := make([]models.PlanItemV1, 0, len(parsed.inputPaths))
+	for idx, inputPath := range parsed.inputPaths {
+		prepared, prepErr := t.prepare(parsed, inputPath, idx+1, usedOutputs)
+		if prepErr != nil {
+			items = append(items, tools.FailedPlanItem(inputPath, prepErr))
+			continue
+		}
+		estimate := int64(4096)
+		if parsed.action == metadataActionStrip {
+			estimate = tools.FileSize(inputPath)
+		}
+		items = append(items, tools.PlanItem(inputPath, []tools.OutputTarget{prepared.target}, estimate))
+	}
+
+	return tools.NewJobPlan(t.ID(), parsed.mode, items), nil
+}
+
+func (t *MetadataTool) ExecuteSingle(ctx context.Context, req models.JobRequestV1) (models.JobResultItemV1, *models.JobErrorV1) {
+	parsed, jobErr := parseMetadataRequest(req)
+	if jobErr != nil {
+		return models.JobResultItemV1{Success: false, Message: jobErr.Message, Error: jobErr}, jobErr
+	}
+
+	prepared, prepErr := t.prepare(parsed, firstPath(parsed.inputPaths), 1, nil)
+	if prepErr != nil {
+		return models.JobResultItemV1{InputPath: firstPath(parsed.inputPaths), OutputPath: parsed.outputPath, Success: false, Message: prepErr.Message, Error: prepErr}, prepErr
+	}
+	if prepared.target.Skip {
+		return models.SkippedItemV1(prepared.inputPath, prepared.outputPath), nil
+	}
+
+	message, err := executeMetadataToPath(ctx, prepared)
+	if err != nil {
+		jobErr = metadataItemError(err, "IMAGE_METADATA_EXECUTION", prepared.inputPath)
+		return models.JobResultItemV1{InputPath: prepared.inputPath, OutputPath: prepared.outputPath, Success: false, Message: "image metadata failed", Error: jobErr}, jobErr
+	}
+
+	return metadataItem(prepared, message), nil
+}
+
+func (t *MetadataTool) ExecuteBatch(ctx context.Context, req models.JobRequestV1, onProgress func(models.JobProgressV1)) ([]models.JobResultItemV1, *models.JobErrorV1) {
+	parsed, jobErr := parseMetadataRequest(req)
+	if jobErr != nil {
+		return nil, jobErr
+	}
+
+	if parsed.mode != "batch" {
+		return nil, models.NewCanonicalJobError("IMAGE_METADATA_MODE_INVALID", "mode must be batch", nil)
+	}
+
+	usedOutputs := make(map[string]struct{}, len(parsed.inputPaths))
+	items := make([]models.JobResultItemV1, 0, len(parsed.inputPaths))
+	var firstErr *models.JobErrorV1
+
+	for idx, inputPath := range parsed.inputPaths {
+		select {
+		case <-ctx.Done():
+			cancelErr := models.NewCanonicalJobError("IMAGE_METADATA_CANCELLED", ctx.Err().Error(), nil)
+			return items, cancelErr
+		default:
+		}
+
+		if tools.ItemCancelled(ctx, inputPath) {
+			items = append(items, models.CancelledItemV1(inputPath, ""))
+		} else if prepared, prepErr := t.prepare(parsed, inputPath, idx+1, usedOutputs); prepErr != nil {
+			if firstErr == nil {
+				firstErr = prepErr
+			}
+			items = append(items, models.JobResultItemV1{InputPath: inputPath, OutputPath: "", Success: false, Message: prepErr.Message, Error: prepErr})
+		} else if prepared.target.Skip {
+			items = append(items, models.SkippedItemV1(prepared.inputPath, prepared.outputPath))
+		} else {
+			itemCtx, stopItem := tools.ItemContext(ctx, prepared.inputPath)
+			message, err := executeMetadataToPath(itemCtx, prepared)
+			stopItem()
+			if err != nil && ctx.Err() == nil && tools.ItemCancelled(ctx, prepared.inputPath) {
+				items = append(items, models.CancelledItemV1(prepared.inputPath, prepared.outputPath))
+			} else if err != nil {
+				itemErr := metadataItemError(err, "IMAGE_METADATA_BATCH_ITEM", prepared.inputPath)
+				if firstErr == nil {
+					firstErr = itemErr
+				}
+				items = append(items, models.JobResultItemV1{InputPath: prepared.inputPath, OutputPath: prepared.outputPath, Success: false, Message: "image metadata failed", Error: itemErr})
+			} else {
+				items = append(items, metadataItem(prepared, message))
+			}
+		}
+
+		if onProgress != nil {
+			onProgress(models.JobProgressV1{Current: idx + 1, Total: len(parsed.inputPaths), Stage: models.JobStatusRunning, Message: fmt.Sprintf("processed %d/%d", idx+1, len(parsed.inputPaths)), Item: models.LastResultItemV1(items)})
+		}
+	}
+
+	return items, firstErr
+}
+
+// ReadImageMetadata returns the metadata report of one image for the UI
+// without writing anything.
+func (t *MetadataTool) ReadImageMetadata(inputPath string) models.ImageMetadataResponseV1 {
+	path := strings.TrimSpace(inputPath)
+	if path == "" {
+		return models.ImageMetadataResponseV1{
+			Success: false,
+			Message: "Select a valid image path and retry.",
+			Error:   models.NewCanonicalJobError("IMAGE_METADATA_INVALID_PATH", "inputPath is required", nil),
+		}
+	}
+
+	if pathErr := validateInputImagePath(path); pathErr != nil {
+		return models.ImageMetadataResponseV1{Success: false, Message: pathErr.Message, Error: pathErr}
+	}
+
+	data, err := os.ReadFile(path)
+	if err == nil {
+		var report models.ImageMetadataV1
+		report, err = readImageMetadata(data)
+		if err == nil {
+			report.InputPath = path
+			return models.ImageMetadataResponseV1{Success: true, Message: "image metadata loaded", Metadata: &report}
+		}
+	}
+
+	jobErr := models.NewCanonicalJobError("IMAGE_METADATA_READ_FAILED", err.Error(), map[string]any{"inputPath": path})
+	return models.ImageMetadataResponseV1{Success: false, Message: "Cannot read image metadata.", Error: jobErr}
+}
+
+func metadataItem(prepared preparedMetadata, message string) models.JobResultItemV1 {
+	return models.JobResultItemV1{
+		InputPath:   prepared.inputPath,
+		OutputPath:  prepared.outputPath,
+		Outputs:     []string{prepared.outputPath},
+		OutputCount: 1,
+		Success:     true,
+		Message:     message,
+	}
+}
+
+func metadataItemError(err error, detailCode, inputPath string) *models.JobErrorV1 {
+	if errors.Is(err, errMetadataContainer) {
+		return models.NewCanonicalJobError("IMAGE_METADATA_FORMAT_UNSUPPORTED", err.Error(), map[string]any{"inputPath": inputPath})
+	}
+	return models.NewCanonicalJobError(detailCode, err.Error(), map[string]any{"inputPath": inputPath})
+}
+
+func parseMetadataRequest(req models.JobRequestV1) (metadataRequest, *models.JobErrorV1) {
+	mode := strings.TrimSpace(req.Mode)
+	if mode != "single" && mode != "batch" {
+		return metadataRequest{}, models.NewCanonicalJobError("IMAGE_METADATA_MODE_INVALID", "mode must be single or batch", nil)
+	}
+
+	if mode == "single" && len(req.InputPaths) != 1 {
+		return metadataRequest{}, models.NewCanonicalJobError("IMAGE_METADATA_SINGLE_INPUT_COUNT", "single mode requires exactly one input", nil)
+	}
+
+	if mode == "batch" && len(req.InputPaths) < 1 {
+		return metadataRequest{}, models.NewCanonicalJobError("IMAGE_METADATA_BATCH_INPUT_REQUIRED", "batch mode requires at least one input", nil)
+	}
+
+	inputPaths := make([]string, 0, len(req.InputPaths))
+	for _, rawPath := range req.InputPaths {
+		trimmed := strings.TrimSpace(rawPath)
+		if trimmed == "" {
+			return metadataRequest{}, models.NewCanonicalJobError("IMAGE_METADATA_INPUT_REQUIRED", "inputPaths contains empty item", nil)
+		}
+		inputPaths = append(inputPaths, trimmed)
+	}
+
+	action := strings.ToLower(resizeOptionString(req.Options, "action"))
+	if action == "" {
+		action = metadataActionRead
+	}
+	if action != metadataActionRead && action != metadataActionStrip {
+		return metadataRequest{}, models.NewCanonicalJobError("IMAGE_METADATA_ACTION_INVALID", "options.action must be read or strip", nil)
+	}
+
+	groups, groupErr := metadataGroupsOption(req.Options)
+	if groupErr != nil {
+		return metadataRequest{}, groupErr
+	}
+
+	if tmplErr := tools.ValidateOutputNameTemplate(req.Options); tmplErr != nil {
+		return metadataRequest{}, tmplErr
+	}
+
+	defaultTemplate := "{name}_metadata.{ext}"
+	if action == metadataActionStrip {
+		defaultTemplate = "{name}_clean.{ext}"
+	}
+
+	parsed := metadataRequest{
+		mode:       mode,
+		inputPaths: inputPaths,
+		outputPath: resizeOptionString(req.Options, "outputPath"),
+		outputDir:  strings.TrimSpace(req.OutputDir),
+		action:     action,
+		groups:     groups,
+		nameTmpl:   tools.OutputNameTemplate(req.Options, defaultTemplate),
+		conflicts:  tools.ConflictPolicy(req),
+		subdirs:    req.InputSubdirs,
+	}
+
+	if outputDir := resizeOptionString(req.Options, "outputDir"); outputDir != "" {
+		parsed.outputDir = outputDir
+	}
+
+	return parsed, nil
+}
+
+// metadataGroupsOption reads the groups to strip; all when none are given.
+func metadataGroupsOption(options map[string]any) ([]string, *models.JobErrorV1) {
+	var raw []string
+	switch value := options["groups"].(type) {
+	case nil:
+	case []string:
+		raw = value
+	case []any:
+		for _, item := range value {
+			group, ok := item.(string)
+			if !ok {
+				return nil, models.NewCanonicalJobError("IMAGE_METADATA_GROUP_INVALID", "options.groups must be a list of strings", nil)
+			}
+			raw = append(raw, group)
+		}
+	default:
+		return nil, models.NewCanonicalJobError("IMAGE_METADATA_GROUP_INVALID", "options.groups must be a list of strings", nil)
+	}
+
+	var groups []string
+	for _, group := range raw {
+		group = strings.ToLower(strings.TrimSpace(group))
+		if !slices.Contains(metadataGroups, group) {
+			return nil, models.NewCanonicalJobError("IMAGE_METADATA_GROUP_INVALID", fmt.Sprintf("unknown metadata group '%s': use %s", group, strings.Join(metadataGroups, ", ")), nil)
+		}
+		if !slices.Contains(groups, group) {
+			groups = append(groups, group)
+		}
+	}
+	if len(groups) == 0 {
+		groups = []string{metadataGroupAll}
+	}
+	return groups, nil
+}
+
+// prepare resolves the output of one input: a JSON report for read, a copy
+// in the input format for strip.
+func (t *MetadataTool) prepare(parsed metadataRequest, inputPath string, index int, usedOutputs map[string]struct{}) (preparedMetadata, *models.JobErrorV1) {
+	if err := validateInputImagePath(inputPath); err != nil {
+		return preparedMetadata{}, err
+	}
+
+	ext := "json"
+	if parsed.action == metadataActionStrip {
+		ext = strings.ToLower(strings.TrimPrefix(filepath.Ext(inputPath), "."))
+		if !slices.Contains(parsed.groups, metadataGroupAll) && !inPlaceMetadataFormats[canonicalFormat(ext)] {
+			return preparedMetadata{}, models.NewCanonicalJobError("IMAGE_METADATA_FORMAT_UNSUPPORTED", fmt.Sprintf("gps and camera groups cannot be stripped from %s files; use the all group", canonicalFormat(ext)), map[string]any{"inputPath": inputPath})
+		}
+	}
+
+	outputPath := ""
+	if parsed.mode == "single" {
+		outputPath = parsed.outputPath
+	}
+	if outputPath == "" {
+		outputPath = imageOutputPath(parsed.outputDir, parsed.nameTmpl, tools.OutputNameVars{
+			InputPath: inputPath,
+			Ext:       ext,
+			Tool:      ToolIDImageMetadataV1,
+			Index:     index,
+			Subdir:    parsed.subdirs[inputPath],
+		})
+	}
+
+	if sameFile(inputPath, outputPath) {
+		return preparedMetadata{}, models.NewCanonicalJobError("IMAGE_METADATA_OUTPUT_COLLIDES_INPUT", "output path cannot match input path", nil)
+	}
+
+	target, conflictErr := tools.ResolveOutputTarget(parsed.conflicts, outputPath, usedOutputs)
+	if conflictErr != nil {
+		return preparedMetadata{}, conflictErr
+	}
+
+	return preparedMetadata{
+		inputPath:  inputPath,
+		outputPath: target.Path,
+		target:     target,
+		action:     parsed.action,
+		groups:     parsed.groups,
+	}, nil
+}
+
+// executeMetadataToPath writes the report or the stripped copy and returns
+// the item message.
+func executeMetadataToPath(ctx context.Context, prepared preparedMetadata) (string, error) {
+	select {
+	case <-ctx.Done():
+		return "", fmt.Errorf("metadata cancelled: %w", ctx.Err())
+	default:
+	}
+
+	input, err := os.ReadFile(prepared.inputPath)
+	if err != nil {
+		return "", fmt.Errorf("read input failed: %w", err)
+	}
+
+	var output []byte
+	var message string
+	if prepared.action == metadataActionRead {
+		report, readErr := readImageMetadata(input)
+		if readErr != nil {
+			return "", readErr
+		}
+		report.InputPath = prepared.inputPath
+		if output, err = json.MarshalIndent(report, "", "  "); err != nil {
+			return "", fmt.Errorf("encode report failed: %w", err)
+		}
+		message = metadataSummary(report)
+	} else {
+		if output, err = stripMetadataGroups(input, prepared.groups); err != nil {
+			return "", fmt.Errorf("strip metadata failed: %w", err)
+		}
+		message = fmt.Sprintf("stripped %s metadata", strings.Join(prepared.groups, ", "))
+	}
+
+	if mkErr := os.MkdirAll(filepath.Dir(prepared.outputPath), 0o755); mkErr != nil {
+		return "", fmt.Errorf("create output dir failed: %w", mkErr)
+	}
+
+	if writeErr := tools.WriteOutputFile(ctx, prepared.target, output, DefaultFilePermissions); writeErr != nil {
+		return "", fmt.Errorf("write output failed: %w", writeErr)
+	}
+
+	return message, nil
+}
+
+func metadataSummary(report models.ImageMetadataV1) string {
+	var found []string
+	for name, present := range map[string]bool{"exif": report.HasEXIF, "gps": report.HasGPS, "icc": report.HasICC, "xmp": report.HasXMP} {
+		if present {
+			found = append(found, name)
+		}
+	}
+	if len(found) == 0 {
+		return "no metadata found"
+	}
+	slices.Sort(found)
+	return "found " + strings.Join(found, ", ") + " metadata"
+}
@@ -0,0 +1,691 @@
+package image
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"fileforge-desktop/internal/models"
+	"fileforge-desktop/internal/tools"
+
+	"github.com/h2non/bimg"
+	_ "golang.org/x/image/bmp"
+)
+
+const ToolIDImageResizeV1 = "tool.image.resize"
+
+const (
+	resizeFitContain = "contain" // fit inside the box, padded to exactly width x height
+	resizeFitCover   = "cover"   // fill the box, cropping the overflow around the centre
+	resizeFitFill    = "fill"    // stretch to exactly width x height
+	resizeFitInside  = "inside"  // fit inside the box, no padding
+	resizeFitOutside = "outside" // cover the box, no cropping
+)
+
+// resizeKernels maps the kernel option to the libvips interpolator used when
+// enlarging; libvips shrinks with its own Lanczos reduction. Only the
+// interpolators bimg exposes are offered.
+var resizeKernels = map[string]bimg.Interpolator{
+	"nearest": bimg.Nearest,
+	"linear":  bimg.Bilinear,
+	"cubic":   bimg.Bicubic,
+	"nohalo":  bimg.Nohalo,
+}
+
+type ResizeTool struct{}
+
+type resizeRequest struct {
+	mode       string
+	inputPaths []string
+	outputPath string
+	outputDir  string
+	format     string
+	size       resizeSize
+	kernel     string
+	background string
+	dpi        int
+	nameTmpl   string
+	conflicts  string
+	subdirs    map[string]string // output sub-directory of each input, from input expansion
+}
+
+// resizeSize holds the requested target; exactly one of width/height, long
+// edge, short edge or scale is set.
+type resizeSize struct {
+	width     int
+	height    int
+	fit       string
+	longEdge  int
+	shortEdge int
+	scale     float64 // percent
+	noUpscale bool
+}
+
+// resizeGeometry is the result of applying a resizeSize to one image: the
+// image is resampled to scaledWidth x scaledHeight and then cropped or padded
+// to width x height.
+type resizeGeometry struct {
+	width        int
+	height       int
+	scaledWidth  int
+	scaledHeight int
+}
+
+type preparedResize struct {
+	inputPath  string
+	input      []byte // the input file, read once by prepare
+	srcWidth   int
+	srcHeight  int
+	outputPath string
+	target     tools.OutputTarget
+	outputFmt  string
+	geometry   resizeGeometry
+	kernel     string
+	background string
+	dpi        int
+}
+
+func NewResizeTool() *ResizeTool {
+	return &ResizeTool{}
+}
+
+func (t *ResizeTool) ID() string {
+	return ToolIDImageResizeV1
+}
+
+func (t *ResizeTool) Capability() string {
+	return ToolIDImageResizeV1
+}
+
+func (t *ResizeTool) Manifest() models.ToolManifestV1 {
+	return models.ToolManifestV1{
+		ToolID:           t.ID(),
+		Name:             "Image Resize",
+		Description:      "Resize images to a box, an edge length or a percentage with fit modes and kernel choice",
+		Domain:           "image",
+		Capability:       t.Capability(),
+		Version:          "v1",
+		SupportsSingle:   true,
+		SupportsBatch:    true,
+		InputExtensions:  imageInputExtensions,
+		OutputExtensions: imageOutputFormats,
+		RuntimeDeps:      []string{"libvips"},
+		Tags:             []string{"image", "resize", "scale", "thumbnail", "batch"},
+		Options: []models.OptionSchemaV1{
+			{Key: "width", Type: models.OptionTypeInteger, Label: "Width", Description: "Target width in pixels; alone it keeps the aspect ratio", Min: models.OptionBound(1), Max: models.OptionBound(65535)},
+			{Key: "height", Type: models.OptionTypeInteger, Label: "Height", Description: "Target height in pixels; alone it keeps the aspect ratio", Min: models.OptionBound(1), Max: models.OptionBound(65535)},
+			{Key: "fit", Type: models.OptionTypeString, Label: "Fit", Description: "How the image fits a width x height box", Default: resizeFitInside, Enum: []string{resizeFitContain, resizeFitCover, resizeFitFill, resizeFitInside, resizeFitOutside}},
+			{Key: "longEdge", Type: models.OptionTypeInteger, Label: "Long edge", Description: "Length of the longer side in pixels", Min: models.OptionBound(1), Max: models.OptionBound(65535)},
+			{Key: "shortEdge", Type: models.OptionTypeInteger, Label: "Short edge", Description: "Length of the shorter side in pixels", Min: models.OptionBound(1), Max: models.OptionBound(65535)},
+			{Key: "scale", Type: models.OptionTypeNumber, Label: "Scale (%)", Description: "Percentage of the original size", Min: models.OptionBound(0.1), Max: models.OptionBound(1000)},
+			{Key: "noUpscale", Type: models.OptionTypeBoolean, Label: "Never upscale", Description: "Keep images that are already smaller than the target at their size", Default: false},
+			{Key: "kernel", Type: models.OptionTypeString, Label: "Resampling", Default: "nohalo", Enum: []string{"nearest", "linear", "cubic", "nohalo"}},
+			{Key: "background", Type: models.OptionTypeString, Label: "Padding color", Description: "Fills the padding of fit=contain; transparent when empty, white for JPEG", Format: "color"},
+			{Key: "dpi", Type: models.OptionTypeInteger, Label: "DPI", Description: "Pixel density written to JPEG and PNG outputs", Min: models.OptionBound(1), Max: models.OptionBound(65535)},
+			{Key: "format", Type: models.OptionTypeString, Label: "Output format", Description: "Defaults to the input format", Enum: imageFormatEnum},
+			{Key: "outputPath", Type: models.OptionTypeString, Label: "Output file", Format: "path", Modes: []string{"single"}},
+			{Key: "outputDir", Type: models.OptionTypeString, Label: "Output folder", Description: "Overrides the job output folder", Format: "dir"},
+			tools.OutputNameTemplateSchema("{name}_{width}x{height}.{ext}"),
+		},
+	}
+}
+
+func (t *ResizeTool) RetryPolicy() models.RetryPolicyV1 {
+	return models.RetryPolicyV1{
+		MaxAttempts:    3,
+		RetryableCodes: []string{"IMAGE_RESIZE_EXECUTION"},
+	}
+}
+
+func (t *ResizeTool) RuntimeState(_ context.Context) models.ToolRuntimeStateV1 {
+	return imageRuntimeState()
+}
+
+func (t *ResizeTool) Validate(_ context.Context, req models.JobRequestV1) *models.JobErrorV1 {
+	parsed, jobErr := parseResizeRequest(req)
+	if jobErr != nil {
+		return jobErr
+	}
+
+	if parsed.mode == "single" {
+		_, prepErr := t.prepare(parsed, firstPath(parsed.inputPaths), 1, nil)
+		return prepErr
+	}
+
+	if strings.TrimSpace(parsed.outputDir) != "" {
+		if _, statErr := os.Stat(parsed.outputDir); statErr != nil {
+			return models.NewCanonicalJobError("IMAGE_RESIZE_OUTPUT_DIR_INVALID", fmt.Sprintf("outputDir is not accessible: %v", statErr), nil)
+		}
+	}
+
+	return nil
+}
+
+// Plan resolves the output of every input the way the executors do. The size
+// estimate scales the input file by the change in pixel count.
+func (t *ResizeTool) Plan(_ context.Context, req models.JobRequestV1) (models.JobPlanV1, *models.JobErrorV1) {
+	parsed, jobErr := parseResizeRequest(req)
+	if jobErr != nil {
+		return models.JobPlanV1{}, jobErr
+	}
+
+	usedOutputs := make(map[string]struct{}, len(parsed.inputPaths))
+	items := make([]models.PlanItemV1, 0, len(parsed.inputPaths))
+	for idx, inputPath := range parsed.inputPaths {
+		prepared, prepErr := t.prepare(parsed, inputPath, idx+1, usedOutputs)
+		if prepErr != nil {
+			items = append(items, tools.FailedPlanItem(inputPath, prepErr))
+			continue
+		}
+
+		ratio := float64(prepared.geometry.width*prepared.geometry.height) / float64(prepared.srcWidth*prepared.srcHeight)
+		estimate := int64(float64(len(prepared.input)) * ratio)
+		items = append(items, tools.PlanItem(inputPath, []tools.OutputTarget{prepared.target}, estimate))
+	}
+
+	return tools.NewJobPlan(t.ID(), parsed.mode, items), nil
+}
+
+func (t *ResizeTool) ExecuteSingle(ctx context.Context, req models.JobRequestV1) (models.JobResultItemV1, *models.JobErrorV1) {
+	parsed, jobErr := parseResizeRequest(req)
+	if jobErr != nil {
+		return models.JobResultItemV1{Success: false, Message: jobErr.Message, Error: jobErr}, jobErr
+	}
+
+	prepared, prepErr := t.prepare(parsed, firstPath(parsed.inputPaths), 1, nil)
+	if prepErr != nil {
+		return models.JobResultItemV1{InputPath: firstPath(parsed.inputPaths), OutputPath: parsed.outputPath, Success: false, Message: prepErr.Message, Error: prepErr}, prepErr
+	}
+	if prepared.target.Skip {
+		return models.SkippedItemV1(prepared.inputPath, prepared.outputPath), nil
+	}
+
+	if err := executeResizeToPath(ctx, prepared); err != nil {
+		jobErr = models.NewCanonicalJobError("IMAGE_RESIZE_EXECUTION", err.Error(), map[string]any{"inputPath": prepared.inputPath})
+		return models.JobResultItemV1{InputPath: prepared.inputPath, OutputPath: prepared.outputPath, Success: false, Message: "image resize failed", Error: jobErr}, jobErr
+	}
+
+	return resizedItem(prepared), nil
+}
+
+func (t *ResizeTool) ExecuteBatch(ctx context.Context, req models.JobRequestV1, onProgress func(models.JobProgressV1)) ([]models.JobResultItemV1, *models.JobErrorV1) {
+	parsed, jobErr := parseResizeRequest(req)
+	if jobErr != nil {
+		return nil, jobErr
+	}
+
+	if parsed.mode != "batch" {
+		return nil, models.NewCanonicalJobError("IMAGE_RESIZE_MODE_INVALID", "mode must be batch", nil)
+	}
+
+	usedOutputs := make(map[string]struct{}, len(parsed.inputPaths))
+	items := make([]models.JobResultItemV1, 0, len(parsed.inputPaths))
+	var firstErr *models.JobErrorV1
+
+	for idx, inputPath := range parsed.inputPaths {
+		select {
+		case <-ctx.Done():
+			cancelErr := models.NewCanonicalJobError("IMAGE_RESIZE_CANCELLED", ctx.Err().Error(), nil)
+			return items, cancelErr
+		default:
+		}
+
+		if tools.ItemCancelled(ctx, inputPath) {
+			items = append(items, models.CancelledItemV1(inputPath, ""))
+		} else if prepared, prepErr := t.prepare(parsed, inputPath, idx+1, usedOutputs); prepErr != nil {
+			if firstErr == nil {
+				firstErr = prepErr
+			}
+			items = append(items, models.JobResultItemV1{InputPath: inputPath, OutputPath: "", Success: false, Message: prepErr.Message, Error: prepErr})
+		} else if prepared.target.Skip {
+			items = append(items, models.SkippedItemV1(prepared.inputPath, prepared.outputPath))
+		} else {
+			itemCtx, stopItem := tools.ItemContext(ctx, prepared.inputPath)
+			err := executeResizeToPath(itemCtx, prepared)
+			stopItem()
+			if err != nil && ctx.Err() == nil && tools.ItemCancelled(ctx, prepared.inputPath) {
+				items = append(items, models.CancelledItemV1(prepared.inputPath, prepared.outputPath))
+			} else if err != nil {
+				itemErr := models.NewCanonicalJobError("IMAGE_RESIZE_BATCH_ITEM", err.Error(), map[string]any{"inputPath": prepared.inputPath})
+				if firstErr == nil {
+					firstErr = itemErr
+				}
+				items = append(items, models.JobResultItemV1{InputPath: prepared.inputPath, OutputPath: prepared.outputPath, Success: false, Message: "image resize failed", Error: itemErr})
+			} else {
+				items = append(items, resizedItem(prepared))
+			}
+		}
+
+		if onProgress != nil {
+			onProgress(models.JobProgressV1{Current: idx + 1, Total: len(parsed.inputPaths), Stage: models.JobStatusRunning, Message: fmt.Sprintf("processed %d/%d", idx+1, len(parsed.inputPaths)), Item: models.LastResultItemV1(items)})
+		}
+	}
+
+	return items, firstErr
+}
+
+func resizedItem(prepared preparedResize) models.JobResultItemV1 {
+	return models.JobResultItemV1{
+		InputPath:    prepared.inputPath,
+		OutputPath:   prepared.outputPath,
+		Outputs:      []string{prepared.outputPath},
+		OutputCount:  1,
+		OutputWidth:  prepared.geometry.width,
+		OutputHeight: prepared.geometry.height,
+		Success:      true,
+		Message:      fmt.Sprintf("image resized to %dx%d", prepared.geometry.width, prepared.geometry.height),
+	}
+}
+
+func parseResizeRequest(req models.JobRequestV1) (resizeRequest, *models.JobErrorV1) {
+	mode := strings.TrimSpace(req.Mode)
+	if mode != "single" && mode != "batch" {
+		return resizeRequest{}, models.NewCanonicalJobError("IMAGE_RESIZE_MODE_INVALID", "mode must be single or batch", nil)
+	}
+
+	if mode == "single" && len(req.InputPaths) != 1 {
+		return resizeRequest{}, models.NewCanonicalJobError("IMAGE_RESIZE_SINGLE_INPUT_COUNT", "single mode requires exactly one input", nil)
+	}
+
+	if mode == "batch" && len(req.InputPaths) < 1 {
+		return resizeRequest{}, models.NewCanonicalJobError("IMAGE_RESIZE_BATCH_INPUT_REQUIRED", "batch mode requires at least one input", nil)
+	}
+
+	inputPaths := make([]string, 0, len(req.InputPaths))
+	for _, rawPath := range req.InputPaths {
+		trimmed := strings.TrimSpace(rawPath)
+		if trimmed == "" {
+			return resizeRequest{}, models.NewCanonicalJobError("IMAGE_RESIZE_INPUT_REQUIRED", "inputPaths contains empty item", nil)
+		}
+		inputPaths = append(inputPaths, trimmed)
+	}
+
+	size, sizeErr := parseResizeSize(req.Options)
+	if sizeErr != nil {
+		return resizeRequest{}, sizeErr
+	}
+
+	kernel := strings.ToLower(resizeOptionString(req.Options, "kernel"))
+	if kernel == "" {
+		kernel = "nohalo"
+	}
+	if _, ok := resizeKernels[kernel]; !ok {
+		return resizeRequest{}, models.NewCanonicalJobError("IMAGE_RESIZE_KERNEL_INVALID", fmt.Sprintf("unsupported kernel: %s", kernel), nil)
+	}
+
+	background := resizeOptionString(req.Options, "background")
+	if background != "" && normalizeColor(background) == "" {
+		return resizeRequest{}, models.NewCanonicalJobError("IMAGE_RESIZE_BACKGROUND_INVALID", fmt.Sprintf("options.background must be a hex color, got %q", background), nil)
+	}
+
+	dpi, _, dpiErr := optionalInt(req.Options, "dpi", "IMAGE_RESIZE_OPTION_INVALID")
+	if dpiErr != nil {
+		return resizeRequest{}, dpiErr
+	}
+	if dpi < 0 {
+		return resizeRequest{}, models.NewCanonicalJobError("IMAGE_RESIZE_DPI_INVALID", "options.dpi must be >= 1", nil)
+	}
+
+	if tmplErr := tools.ValidateOutputNameTemplate(req.Options); tmplErr != nil {
+		return resizeRequest{}, tmplErr
+	}
+
+	parsed := resizeRequest{
+		mode:       mode,
+		inputPaths: inputPaths,
+		outputPath: resizeOptionString(req.Options, "outputPath"),
+		outputDir:  strings.TrimSpace(req.OutputDir),
+		format:     strings.ToLower(resizeOptionString(req.Options, "format")),
+		size:       size,
+		kernel:     kernel,
+		background: background,
+		dpi:        dpi,
+		nameTmpl:   tools.OutputNameTemplate(req.Options, "{name}_{width}x{height}.{ext}"),
+		conflicts:  tools.ConflictPolicy(req),
+		subdirs:    req.InputSubdirs,
+	}
+
+	if outputDir := resizeOptionString(req.Options, "outputDir"); outputDir != "" {
+		parsed.outputDir = outputDir
+	}
+
+	return parsed, nil
+}
+
+func parseResizeSize(options map[string]any) (resizeSize, *models.JobErrorV1) {
+	var size resizeSize
+	targets := 0
+	for _, field := range []struct {
+		key   string
+		value *int
+	}{
+		{"width", &size.width},
+		{"height", &size.height},
+		{"longEdge", &size.longEdge},
+		{"shortEdge", &size.shortEdge},
+	} {
+		value, ok, err := optionalInt(options, field.key, "IMAGE_RESIZE_OPTION_INVALID")
+		if err != nil {
+			return resizeSize{}, err
+		}
+		if !ok {
+			continue
+		}
+		if value < 1 {
+			return resizeSize{}, models.NewCanonicalJobError("IMAGE_RESIZE_DIMENSIONS_INVALID", fmt.Sprintf("options.%s must be >= 1", field.key), nil)
+		}
+		*field.value = value
+	}
+	if size.width > 0 || size.height > 0 {
+		targets++
+	}
+	if size.longEdge > 0 {
+		targets++
+	}
+	if size.shortEdge > 0 {
+		targets++
+	}
+
+	if raw, ok := options["scale"]; ok && raw != nil {
+		scale := anyFloat(raw)
+		if scale <= 0 {
+			return resizeSize{}, models.NewCanonicalJobError("IMAGE_RESIZE_SCALE_INVALID", "options.scale must be a percentage > 0", nil)
+		}
+		size.scale = scale
+		targets++
+	}
+
+	if targets == 0 {
+		return resizeSize{}, models.NewCanonicalJobError("IMAGE_RESIZE_SIZE_MISSING", "one of width/height, longEdge, shortEdge or scale is required", nil)
+	}
+	if targets > 1 {
+		return resizeSize{}, models.NewCanonicalJobError("IMAGE_RESIZE_SIZE_INVALID", "use only one of width/height, longEdge, shortEdge or scale", nil)
+	}
+
+	size.fit = strings.ToLower(resizeOptionString(options, "fit"))
+	if size.fit == "" {
+		size.fit = resizeFitInside
+	}
+	switch size.fit {
+	case resizeFitContain, resizeFitCover, resizeFitFill, resizeFitInside, resizeFitOutside:
+	default:
+		return resizeSize{}, models.NewCanonicalJobError("IMAGE_RESIZE_FIT_INVALID", fmt.Sprintf("unsupported fit: %s", size.fit), nil)
+	}
+
+	size.noUpscale, _ = options["noUpscale"].(bool)
+	return size, nil
+}
+
+// geometry works out the output size for an image of srcWidth x srcHeight.
+// With noUpscale, an image the target would enlarge keeps its own size.
+func (s resizeSize) geometry(srcWidth, srcHeight int) resizeGeometry {
+	sw, sh := float64(srcWidth), float64(srcHeight)
+	scaled := func(ratio float64) resizeGeometry {
+		w, h := roundPixels(sw*ratio), roundPixels(sh*ratio)
+		return resizeGeometry{width: w, height: h, scaledWidth: w, scaledHeight: h}
+	}
+
+	var g resizeGeometry
+	switch {
+	case s.scale > 0:
+		g = scaled(s.scale / 100)
+	case s.longEdge > 0:
+		g = scaled(float64(s.longEdge) / math.Max(sw, sh))
+	case s.shortEdge > 0:
+		g = scaled(float64(s.shortEdge) / math.Min(sw, sh))
+	case s.width > 0 && s.height > 0:
+		rw, rh := float64(s.width)/sw, float64(s.height)/sh
+		switch s.fit {
+		case resizeFitFill:
+			g = resizeGeometry{width: s.width, height: s.height, scaledWidth: s.width, scaledHeight: s.height}
+		case resizeFitInside:
+			g = scaled(math.Min(rw, rh))
+		case resizeFitOutside:
+			g = scaled(math.Max(rw, rh))
+		case resizeFitContain:
+			g = scaled(math.Min(rw, rh))
+			g.width, g.height = s.width, s.height
+		case resizeFitCover:
+			g = scaled(math.Max(rw, rh))
+			g.width, g.height = s.width, s.height
+		}
+	case s.width > 0:
+		g = scaled(float64(s.width) / sw)
+	default:
+		g = scaled(float64(s.height) / sh)
+	}
+
+	if s.noUpscale && (g.scaledWidth > srcWidth || g.scaledHeight > srcHeight) {
+		return resizeGeometry{width: srcWidth, height: srcHeight, scaledWidth: srcWidth, scaledHeight: srcHeight}
+	}
+	return g
+}
+
+func roundPixels(v float64) int {
+	return max(1, int(math.Round(v)))
+}
+
+// prepare resolves the output of one input. In single mode an explicit
+// outputPath wins over the name template.
+func (t *ResizeTool) prepare(parsed resizeRequest, inputPath string, index int, usedOutputs map[string]struct{}) (preparedResize, *models.JobErrorV1) {
+	if err := validateInputImagePath(inputPath); err != nil {
+		return preparedResize{}, err
+	}
+
+	outputFmt, fmtErr := resolveOutputFormat(inputPath, parsed.format)
+	if fmtErr != nil {
+		return preparedResize{}, fmtErr
+	}
+
+	if parsed.dpi > 0 && !dpiFormats[outputFmt] {
+		return preparedResize{}, models.NewCanonicalJobError("IMAGE_RESIZE_DPI_UNSUPPORTED", fmt.Sprintf("dpi can only be written to jpeg and png outputs, not %s", outputFmt), nil)
+	}
+
+	input, width, height, err := readImageHeader(inputPath)
+	if err != nil {
+		return preparedResize{}, models.NewCanonicalJobError("IMAGE_RESIZE_READ_FAILED", err.Error(), map[string]any{"inputPath": inputPath})
+	}
+	geometry := parsed.size.geometry(width, height)
+
+	outputPath := ""
+	if parsed.mode == "single" {
+		outputPath = parsed.outputPath
+	}
+	if outputPath == "" {
+		outputPath = imageOutputPath(parsed.outputDir, parsed.nameTmpl, tools.OutputNameVars{
+			InputPath: inputPath,
+			Ext:       outputFmt,
+			Tool:      ToolIDImageResizeV1,
+			Index:     index,
+			Subdir:    parsed.subdirs[inputPath],
+			Width:     geometry.width,
+			Height:    geometry.height,
+		})
+	}
+
+	if sameFile(inputPath, outputPath) {
+		return preparedResize{}, models.NewCanonicalJobError("IMAGE_RESIZE_OUTPUT_COLLIDES_INPUT", "output path cannot match input path", nil)
+	}
+
+	target, conflictErr := tools.ResolveOutputTarget(parsed.conflicts, outputPath, usedOutputs)
+	if conflictErr != nil {
+		return preparedResize{}, conflictErr
+	}
+
+	return preparedResize{
+		inputPath:  inputPath,
+		input:      input,
+		srcWidth:   width,
+		srcHeight:  height,
+		outputPath: target.Path,
+		target:     target,
+		outputFmt:  outputFmt,
+		geometry:   geometry,
+		kernel:     parsed.kernel,
+		background: parsed.background,
+		dpi:        parsed.dpi,
+	}, nil
+}
+
+func executeResizeToPath(ctx context.Context, prepared preparedResize) error {
+	select {
+	case <-ctx.Done():
+		return fmt.Errorf("resize cancelled: %w", ctx.Err())
+	default:
+	}
+
+	resized, err := resizeInMemory(prepared.input, prepared)
+	if err != nil {
+		return fmt.Errorf("resize failed: %w", err)
+	}
+
+	select {
+	case <-ctx.Done():
+		return fmt.Errorf("resize cancelled: %w", ctx.Err())
+	default:
+	}
+
+	if mkErr := os.MkdirAll(filepath.Dir(prepared.outputPath), 0o755); mkErr != nil {
+		return fmt.Errorf("create output dir failed: %w", mkErr)
+	}
+
+	if writeErr := tools.WriteOutputFile(ctx, prepared.target, resized, DefaultFilePermissions); writeErr != nil {
+		return fmt.Errorf("write output failed: %w", writeErr)
+	}
+
+	return nil
+}
+
+// readImageHeader reads inputPath and the size of its first page as it is
+// shown upright, from the image header only.
+func readImageHeader(inputPath string) ([]byte, int, int, error) {
+	input, err := os.ReadFile(inputPath)
+	if err != nil {
+		return nil, 0, 0, fmt.Errorf("read input file: %w", err)
+	}
+
+	metadata, err := bimg.NewImage(input).Metadata()
+	if err != nil {
+		return nil, 0, 0, fmt.Errorf("read image size: %w", err)
+	}
+	width, height := metadata.Size.Width, metadata.Size.Height
+	if metadata.Orientation >= 5 && metadata.Orientation <= 8 {
+		width, height = height, width
+	}
+	if width <= 0 || height <= 0 {
+		return nil, 0, 0, fmt.Errorf("image has no pixels")
+	}
+	return input, width, height, nil
+}
+
+// resizeInMemory resamples input, rotated upright, to the scaled size of the
+// geometry and then crops the centre or pads it to the final size. Only the
+// crop and pad steps decode an intermediate image, the lossless scaled one.
+func resizeInMemory(input []byte, prepared preparedResize) ([]byte, error) {
+	g := prepared.geometry
+	outputType := mapFormatToImageType(prepared.outputFmt)
+	crop := g.scaledWidth > g.width || g.scaledHeight > g.height
+	pad := !crop && (g.scaledWidth < g.width || g.scaledHeight < g.height)
+
+	scaledType := outputType
+	if crop || pad {
+		scaledType = bimg.PNG
+	}
+	encoded, err := bimg.NewImage(input).Process(bimg.Options{
+		Width:         g.scaledWidth,
+		Height:        g.scaledHeight,
+		Force:         true,
+		Interpolator:  resizeKernels[prepared.kernel],
+		Type:          scaledType,
+		StripMetadata: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case crop:
+		encoded, err = bimg.NewImage(encoded).Process(bimg.Options{
+			Left:          (g.scaledWidth - g.width) / 2,
+			Top:           (g.scaledHeight - g.height) / 2,
+			AreaWidth:     g.width,
+			AreaHeight:    g.height,
+			Type:          outputType,
+			StripMetadata: true,
+		})
+	case pad:
+		encoded, err = padImage(encoded, prepared)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if prepared.dpi > 0 {
+		return setImageDPI(encoded, prepared.outputFmt, prepared.dpi)
+	}
+	return encoded, nil
+}
+
+// padImage centres the scaled image on a canvas of the background colour,
+// transparent unless a background is set or the output is JPEG.
+func padImage(scaled []byte, prepared preparedResize) ([]byte, error) {
+	g := prepared.geometry
+	var fill color.Color = color.Transparent
+	if prepared.background != "" {
+		col, colErr := parseColorWithOpacity(prepared.background, 1)
+		if colErr != nil {
+			return nil, colErr
+		}
+		fill = col
+	} else if prepared.outputFmt == "jpeg" {
+		fill = color.White
+	}
+
+	canvas := image.NewNRGBA(image.Rect(0, 0, g.width, g.height))
+	draw.Draw(canvas, canvas.Bounds(), image.NewUniform(fill), image.Point{}, draw.Src)
+	var buf bytes.Buffer
+	encoder := png.Encoder{CompressionLevel: png.BestSpeed}
+	if err := encoder.Encode(&buf, canvas); err != nil {
+		return nil, fmt.Errorf("encode padding canvas: %w", err)
+	}
+
+	return bimg.NewImage(buf.Bytes()).Process(bimg.Options{
+		WatermarkImage: bimg.WatermarkImage{
+			Left: (g.width - g.scaledWidth) / 2,
+			Top:  (g.height - g.scaledHeight) / 2,
+			Buf:  scaled,
+		},
+		Type:          mapFormatToImageType(prepared.outputFmt),
+		StripMetadata: true,
+	})
+}
+
+func resizeOptionString(options map[string]any, key string) string {
+	if options == nil {
+		return ""
+	}
+	v, _ := options[key].(string)
+	return strings.TrimSpace(v)
+}
+
+// optionalInt reads an optional integer option; ok is false when the key is
+// absent. A non-integer value fails with detailCode.
+func optionalInt(options map[string]any, key, detailCode string) (int, bool, *models.JobErrorV1) {
+	raw, ok := options[key]
+	if !ok || raw == nil {
+		return 0, false, nil
+	}
+	value := anyFloat(raw)
+	if value != math.Trunc(value) {
+		return 0, false, models.NewCanonicalJobError(detailCode, fmt.Sprintf("options.%s must be an integer", key), nil)
+	}
+	return int(value), true, nil
+}
@@ -0,0 +1,695 @@
+package image
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+
+	"fileforge-desktop/internal/models"
+	"fileforge-desktop/internal/tools"
+
+	"github.com/disintegration/imaging"
+	"github.com/fogleman/gg"
+	"github.com/h2non/bimg"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/opentype"
+)
+
+const ToolIDImageWatermarkV1 = "tool.image.watermark"
+
+const (
+	watermarkRepeatSingle   = "single"
+	watermarkRepeatTile     = "tile"
+	watermarkRepeatDiagonal = "diagonal"
+
+	watermarkDiagonalRotation = 45.0
+	watermarkTextMeasureSize  = 100.0 // points; text is measured at this size, then scaled to fit
+)
+
+var (
+	watermarkAnchors = []string{"top-left", "top", "top-right", "left", "center", "right", "bottom-left", "bottom", "bottom-right"}
+	watermarkRepeats = []string{watermarkRepeatSingle, watermarkRepeatTile, watermarkRepeatDiagonal}
+
+	watermarkFont = sync.OnceValues(func() (*opentype.Font, error) { return opentype.Parse(goregular.TTF) })
+)
+
+type WatermarkTool struct{}
+
+// watermarkStamp describes the watermark relative to the image it is applied
+// to, so a batch places it proportionally on differently sized images.
+type watermarkStamp struct {
+	text      string
+	imagePath string
+	logo      image.Image // decoded once per request by loadLogo
+	color     string
+	scale     float64 // watermark width as a fraction of the image width
+	anchor    string
+	margin    float64 // percent of the image size kept from the anchored edges
+	offsetX   float64 // percent of the image width, positive moves right
+	offsetY   float64 // percent of the image height, positive moves down
+	opacity   float64
+	rotation  float64 // degrees counter-clockwise
+	repeat    string
+	spacing   float64 // gap between repeats, percent of the watermark size
+}
+
+type watermarkRequest struct {
+	mode       string
+	inputPaths []string
+	outputPath string
+	outputDir  string
+	format     string
+	stamp      watermarkStamp
+	page       int
+	metadata   string
+	nameTmpl   string
+	conflicts  string
+	subdirs    map[string]string // output sub-directory of each input, from input expansion
+}
+
+type preparedWatermark struct {
+	inputPath  string
+	outputPath string
+	target     tools.OutputTarget
+	outputFmt  string
+	stamp      watermarkStamp
+	page       int
+	metadata   string
+}
+
+func NewWatermarkTool() *WatermarkTool {
+	return &WatermarkTool{}
+}
+
+func (t *WatermarkTool) ID() string {
+	return ToolIDImageWatermarkV1
+}
+
+func (t *WatermarkTool) Capability() string {
+	return ToolIDImageWatermarkV1
+}
+
+func (t *WatermarkTool) Manifest() models.ToolManifestV1 {
+	return models.ToolManifestV1{
+		ToolID:           t.ID(),
+		Name:             "Image Watermark",
+		Description:      "Stamp text or a logo on images, anchored or repeated, scaled to each image",
+		Domain:           "image",
+		Capability:       t.Capability(),
+		Version:          "v1",
+		SupportsSingle:   true,
+		SupportsBatch:    true,
+		InputExtensions:  imageInputExtensions,
+		OutputExtensions: imageOutputFormats,
+		RuntimeDeps:      []string{"libvips"},
+		Tags:             []string{"image", "watermark", "logo", "branding", "batch"},
+		Options: []models.OptionSchemaV1{
+			{Key: "text", Type: models.OptionTypeString, Label: "Text", Description: "Watermark text; set text or imagePath"},
+			{Key: "imagePath", Type: models.OptionTypeString, Label: "Logo", Description: "Watermark image; a PNG with transparency works best", Format: "path"},
+			{Key: "color", Type: models.OptionTypeString, Label: "Text color", Default: "#ffffff", Format: "color"},
+			{Key: "scale", Type: models.OptionTypeNumber, Label: "Scale", Description: "Watermark width as a fraction of each image's width", Default: 0.25, Min: models.OptionBound(0.01), Max: models.OptionBound(1)},
+			{Key: "anchor", Type: models.OptionTypeString, Label: "Anchor", Default: "bottom-right", Enum: watermarkAnchors},
+			{Key: "margin", Type: models.OptionTypeNumber, Label: "Margin (%)", Description: "Distance from the anchored edges in percent of the image size", Default: 3, Min: models.OptionBound(0), Max: models.OptionBound(50)},
+			{Key: "offsetX", Type: models.OptionTypeNumber, Label: "Offset X (%)", Description: "Shift in percent of the image width, positive moves right", Default: 0, Min: models.OptionBound(-100), Max: models.OptionBound(100)},
+			{Key: "offsetY", Type: models.OptionTypeNumber, Label: "Offset Y (%)", Description: "Shift in percent of the image height, positive moves down", Default: 0, Min: models.OptionBound(-100), Max: models.OptionBound(100)},
+			{Key: "opacity", Type: models.OptionTypeNumber, Label: "Opacity", Default: 0.5, Min: models.OptionBound(0), Max: models.OptionBound(1)},
+			{Key: "rotation", Type: models.OptionTypeNumber, Label: "Rotation", Description: "Degrees counter-clockwise; diagonal repeat defaults to 45", Min: models.OptionBound(-360), Max: models.OptionBound(360)},
+			{Key: "repeat", Type: models.OptionTypeString, Label: "Repeat", Description: "single uses the anchor; tile and diagonal cover the image, diagonal staggering every other row", Default: watermarkRepeatSingle, Enum: watermarkRepeats},
+			{Key: "spacing", Type: models.OptionTypeNumber, Label: "Spacing (%)", Description: "Gap between repeated watermarks in percent of the watermark size", Default: 50, Min: models.OptionBound(0), Max: models.OptionBound(1000)},
+			{Key: "format", Type: models.OptionTypeString, Label: "Output format", Description: "Defaults to the input format", Enum: imageFormatEnum},
+			{Key: "page", Type: models.OptionTypeInteger, Label: "Page", Description: "Page of a multi-page TIFF to watermark, 1-based", Default: 1, Min: models.OptionBound(1)},
+			metadataOptionSchema(),
+			{Key: "outputPath", Type: models.OptionTypeString, Label: "Output file", Format: "path", Modes: []string{"single"}},
+			{Key: "outputDir", Type: models.OptionTypeString, Label: "Output folder", Description: "Overrides the job output folder", Format: "dir"},
+			tools.OutputNameTemplateSchema("{name}_watermarked.{ext}"),
+		},
+	}
+}
+
+func (t *WatermarkTool) RetryPolicy() models.RetryPolicyV1 {
+	return models.RetryPolicyV1{
+		MaxAttempts:    3,
+		RetryableCodes: []string{"IMAGE_WATERMARK_EXECUTION"},
+	}
+}
+
+func (t *WatermarkTool) RuntimeState(_ context.Context) models.ToolRuntimeStateV1 {
+	return imageRuntimeState()
+}
+
+func (t *WatermarkTool) Validate(_ context.Context, req models.JobRequestV1) *models.JobErrorV1 {
+	parsed, jobErr := parseWatermarkRequest(req)
+	if jobErr != nil {
+		return jobErr
+	}
+
+	if parsed.mode == "single" {
+		_, prepErr := t.prepare(parsed, firstPath(parsed.inputPaths), 1, nil)
+		return prepErr
+	}
+
+	if strings.TrimSpace(parsed.outputDir) != "" {
+		if _, statErr := os.Stat(parsed.outputDir); statErr != nil {
+			return models.NewCanonicalJobError("IMAGE_WATERMARK_OUTPUT_DIR_INVALID", fmt.Sprintf("outputDir is not accessible: %v", statErr), nil)
+		}
+	}
+
+	return nil
+}
+
+// Plan resolves the output of every input; each output is estimated at the
+// input size.
+func (t *WatermarkTool) Plan(_ context.Context, req models.JobRequestV1) (models.JobPlanV1, *models.JobErrorV1) {
+	parsed, jobErr := parseWatermarkRequest(req)
+	if jobErr != nil {
+		return models.JobPlanV1{}, jobErr
+	}
+
+	usedOutputs := make(map[string]struct{}, len(parsed.inputPaths))
+	items := make([]models.PlanItemV1, 0, len(parsed.inputPaths))
+	for idx, inputPath := range parsed.inputPaths {
+		prepared, prepErr := t.prepare(parsed, inputPath, idx+1, usedOutputs)
+		if prepErr != nil {
+			items = append(items, tools.FailedPlanItem(inputPath, prepErr))
+			continue
+		}
+		items = append(items, tools.PlanItem(inputPath, []tools.OutputTarget{prepared.target}, tools.FileSize(inputPath)))
+	}
+
+	return tools.NewJobPlan(t.ID(), parsed.mode, items), nil
+}
+
+func (t *WatermarkTool) ExecuteSingle(ctx context.Context, req models.JobRequestV1) (models.JobResultItemV1, *models.JobErrorV1) {
+	parsed, jobErr := parseWatermarkRequest(req)
+	if jobErr != nil {
+		return models.JobResultItemV1{Success: false, Message: jobErr.Message, Error: jobErr}, jobErr
+	}
+
+	if logoErr := parsed.stamp.loadLogo(); logoErr != nil {
+		return models.JobResultItemV1{InputPath: firstPath(parsed.inputPaths), Success: false, Message: logoErr.Message, Error: logoErr}, logoErr
+	}
+
+	prepared, prepErr := t.prepare(parsed, firstPath(parsed.inputPaths), 1, nil)
+	if prepErr != nil {
+		return models.JobResultItemV1{InputPath: firstPath(parsed.inputPaths), OutputPath: parsed.outputPath, Success: false, Message: prepErr.Message, Error: prepErr}, prepErr
+	}
+	if prepared.target.Skip {
+		return models.SkippedItemV1(prepared.inputPath, prepared.outputPath), nil
+	}
+
+	stamps, err := executeWatermarkToPath(ctx, prepared)
+	if err != nil {
+		jobErr = imageItemError(err, "IMAGE_WATERMARK_EXECUTION", prepared.inputPath)
+		return models.JobResultItemV1{InputPath: prepared.inputPath, OutputPath: prepared.outputPath, Success: false, Message: "image watermark failed", Error: jobErr}, jobErr
+	}
+
+	return watermarkedItem(prepared, stamps), nil
+}
+
+func (t *WatermarkTool) ExecuteBatch(ctx context.Context, req models.JobRequestV1, onProgress func(models.JobProgressV1)) ([]models.JobResultItemV1, *models.JobErrorV1) {
+	parsed, jobErr := parseWatermarkRequest(req)
+	if jobErr != nil {
+		return nil, jobErr
+	}
+
+	if parsed.mode != "batch" {
+		return nil, models.NewCanonicalJobError("IMAGE_WATERMARK_MODE_INVALID", "mode must be batch", nil)
+	}
+
+	if logoErr := parsed.stamp.loadLogo(); logoErr != nil {
+		return nil, logoErr
+	}
+
+	usedOutputs := make(map[string]struct{}, len(parsed.inputPaths))
+	items := make([]models.JobResultItemV1, 0, len(parsed.inputPaths))
+	var firstErr *models.JobErrorV1
+
+	for idx, inputPath := range parsed.inputPaths {
+		select {
+		case <-ctx.Done():
+			cancelErr := models.NewCanonicalJobError("IMAGE_WATERMARK_CANCELLED", ctx.Err().Error(), nil)
+			return items, cancelErr
+		default:
+		}
+
+		if tools.ItemCancelled(ctx, inputPath) {
+			items = append(items, models.CancelledItemV1(inputPath, ""))
+		} else if prepared, prepErr := t.prepare(parsed, inputPath, idx+1, usedOutputs); prepErr != nil {
+			if firstErr == nil {
+				firstErr = prepErr
+			}
+			items = append(items, models.JobResultItemV1{InputPath: inputPath, OutputPath: "", Success: false, Message: prepErr.Message, Error: prepErr})
+		} else if prepared.target.Skip {
+			items = append(items, models.SkippedItemV1(prepared.inputPath, prepared.outputPath))
+		} else {
+			itemCtx, stopItem := tools.ItemContext(ctx, prepared.inputPath)
+			stamps, err := executeWatermarkToPath(itemCtx, prepared)
+			stopItem()
+			if err != nil && ctx.Err() == nil && tools.ItemCancelled(ctx, prepared.inputPath) {
+				items = append(items, models.CancelledItemV1(prepared.inputPath, prepared.outputPath))
+			} else if err != nil {
+				itemErr := imageItemError(err, "IMAGE_WATERMARK_BATCH_ITEM", prepared.inputPath)
+				if firstErr == nil {
+					firstErr = itemErr
+				}
+				items = append(items, models.JobResultItemV1{InputPath: prepared.inputPath, OutputPath: prepared.outputPath, Success: false, Message: "image watermark failed", Error: itemErr})
+			} else {
+				items = append(items, watermarkedItem(prepared, stamps))
+			}
+		}
+
+		if onProgress != nil {
+			onProgress(models.JobProgressV1{Current: idx + 1, Total: len(parsed.inputPaths), Stage: models.JobStatusRunning, Message: fmt.Sprintf("processed %d/%d", idx+1, len(parsed.inputPaths)), Item: models.LastResultItemV1(items)})
+		}
+	}
+
+	return items, firstErr
+}
+
+func watermarkedItem(prepared preparedWatermark, stamps int) models.JobResultItemV1 {
+	message := "watermark applied"
+	if stamps > 1 {
+		message = fmt.Sprintf("watermark applied %d times", stamps)
+	}
+	return models.JobResultItemV1{
+		InputPath:   prepared.inputPath,
+		OutputPath:  prepared.outputPath,
+		Outputs:     []string{prepared.outputPath},
+		OutputCount: 1,
+		Success:     true,
+		Message:     message,
+	}
+}
+
+func parseWatermarkRequest(req models.JobRequestV1) (watermarkRequest, *models.JobErrorV1) {
+	mode := strings.TrimSpace(req.Mode)
+	if mode != "single" && mode != "batch" {
+		return watermarkRequest{}, models.NewCanonicalJobError("IMAGE_WATERMARK_MODE_INVALID", "mode must be single or batch", nil)
+	}
+
+	if mode == "single" && len(req.InputPaths) != 1 {
+		return watermarkRequest{}, models.NewCanonicalJobError("IMAGE_WATERMARK_SINGLE_INPUT_COUNT", "single mode requires exactly one input", nil)
+	}
+
+	if mode == "batch" && len(req.InputPaths) < 1 {
+		return watermarkRequest{}, models.NewCanonicalJobError("IMAGE_WATERMARK_BATCH_INPUT_REQUIRED", "batch mode requires at least one input", nil)
+	}
+
+	inputPaths := make([]string, 0, len(req.InputPaths))
+	for _, rawPath := range req.InputPaths {
+		trimmed := strings.TrimSpace(rawPath)
+		if trimmed == "" {
+			return watermarkRequest{}, models.NewCanonicalJobError("IMAGE_WATERMARK_INPUT_REQUIRED", "inputPaths contains empty item", nil)
+		}
+		inputPaths = append(inputPaths, trimmed)
+	}
+
+	stamp, stampErr := parseWatermarkStamp(req.Options)
+	if stampErr != nil {
+		return watermarkRequest{}, stampErr
+	}
+
+	page, pageErr := optionPage(req.Options, "IMAGE_WATERMARK")
+	if pageErr != nil {
+		return watermarkRequest{}, pageErr
+	}
+
+	metadata, metaErr := optionMetadata(req.Options, "IMAGE_WATERMARK")
+	if metaErr != nil {
+		return watermarkRequest{}, metaErr
+	}
+
+	if tmplErr := tools.ValidateOutputNameTemplate(req.Options); tmplErr != nil {
+		return watermarkRequest{}, tmplErr
+	}
+
+	parsed := watermarkRequest{
+		mode:       mode,
+		inputPaths: inputPaths,
+		outputPath: resizeOptionString(req.Options, "outputPath"),
+		outputDir:  strings.TrimSpace(req.OutputDir),
+		format:     strings.ToLower(resizeOptionString(req.Options, "format")),
+		stamp:      stamp,
+		page:       page,
+		metadata:   metadata,
+		nameTmpl:   tools.OutputNameTemplate(req.Options, "{name}_watermarked.{ext}"),
+		conflicts:  tools.ConflictPolicy(req),
+		subdirs:    req.InputSubdirs,
+	}
+
+	if outputDir := resizeOptionString(req.Options, "outputDir"); outputDir != "" {
+		parsed.outputDir = outputDir
+	}
+
+	return parsed, nil
+}
+
+// parseWatermarkStamp reads the watermark source and placement. Exactly one
+// of text and imagePath must be set.
+func parseWatermarkStamp(options map[string]any) (watermarkStamp, *models.JobErrorV1) {
+	stamp := watermarkStamp{
+		text:      strings.TrimSpace(annotateOptionString(options, "text")),
+		imagePath: resizeOptionString(options, "imagePath"),
+		color:     resizeOptionString(options, "color"),
+		anchor:    strings.ToLower(resizeOptionString(options, "anchor")),
+		repeat:    strings.ToLower(resizeOptionString(options, "repeat")),
+	}
+
+	switch {
+	case stamp.text == "" && stamp.imagePath == "":
+		return watermarkStamp{}, models.NewCanonicalJobError("IMAGE_WATERMARK_SOURCE_MISSING", "options.text or options.imagePath is required", nil)
+	case stamp.text != "" && stamp.imagePath != "":
+		return watermarkStamp{}, models.NewCanonicalJobError("IMAGE_WATERMARK_SOURCE_INVALID", "set either options.text or options.imagePath, not both", nil)
+	}
+
+	if stamp.imagePath != "" {
+		if pathErr := validateInputImagePath(stamp.imagePath); pathErr != nil {
+			return watermarkStamp{}, models.NewCanonicalJobError("IMAGE_WATERMARK_LOGO_INVALID", pathErr.Message, map[string]any{"imagePath": stamp.imagePath})
+		}
+	}
+
+	if stamp.color == "" {
+		stamp.color = "#ffffff"
+	}
+	if normalizeColor(stamp.color) == "" {
+		return watermarkStamp{}, models.NewCanonicalJobError("IMAGE_WATERMARK_COLOR_INVALID", fmt.Sprintf("options.color must be a hex color, got %q", stamp.color), nil)
+	}
+
+	if stamp.anchor == "" {
+		stamp.anchor = "bottom-right"
+	}
+	if !slices.Contains(watermarkAnchors, stamp.anchor) {
+		return watermarkStamp{}, models.NewCanonicalJobError("IMAGE_WATERMARK_ANCHOR_INVALID", fmt.Sprintf("options.anchor must be one of %s", strings.Join(watermarkAnchors, ", ")), nil)
+	}
+
+	if stamp.repeat == "" {
+		stamp.repeat = watermarkRepeatSingle
+	}
+	if !slices.Contains(watermarkRepeats, stamp.repeat) {
+		return watermarkStamp{}, models.NewCanonicalJobError("IMAGE_WATERMARK_REPEAT_INVALID", fmt.Sprintf("options.repeat must be one of %s", strings.Join(watermarkRepeats, ", ")), nil)
+	}
+
+	defaultRotation := 0.0
+	if stamp.repeat == watermarkRepeatDiagonal {
+		defaultRotation = watermarkDiagonalRotation
+	}
+
+	numbers := []struct {
+		key      string
+		target   *float64
+		fallback float64
+		min, max float64
+	}{
+		{"scale", &stamp.scale, 0.25, 0.01, 1},
+		{"margin", &stamp.margin, 3, 0, 50},
+		{"offsetX", &stamp.offsetX, 0, -100, 100},
+		{"offsetY", &stamp.offsetY, 0, -100, 100},
+		{"opacity", &stamp.opacity, 0.5, 0, 1},
+		{"rotation", &stamp.rotation, defaultRotation, -360, 360},
+		{"spacing", &stamp.spacing, 50, 0, 1000},
+	}
+	for _, number := range numbers {
+		*number.target = number.fallback
+		if raw, ok := options[number.key]; ok && raw != nil {
+			*number.target = anyFloat(raw)
+		}
+		if *number.target < number.min || *number.target > number.max {
+			return watermarkStamp{}, models.NewCanonicalJobError("IMAGE_WATERMARK_OPTION_INVALID", fmt.Sprintf("options.%s must be between %g and %g", number.key, number.min, number.max), nil)
+		}
+	}
+
+	return stamp, nil
+}
+
+// loadLogo decodes the watermark image, so a batch reads it once and only
+// resizes it per input.
+func (s *watermarkStamp) loadLogo() *models.JobErrorV1 {
+	if s.imagePath == "" || s.logo != nil {
+		return nil
+	}
+	logoBytes, err := os.ReadFile(s.imagePath)
+	if err != nil {
+		return models.NewCanonicalJobError("IMAGE_WATERMARK_LOGO_INVALID", fmt.Sprintf("read watermark image: %v", err), map[string]any{"imagePath": s.imagePath})
+	}
+	logo, err := decodeImage(logoBytes)
+	if err != nil {
+		return models.NewCanonicalJobError("IMAGE_WATERMARK_LOGO_INVALID", fmt.Sprintf("watermark image: %v", err), map[string]any{"imagePath": s.imagePath})
+	}
+	s.logo = logo
+	return nil
+}
+
+// prepare resolves the output of one input. In single mode an explicit
+// outputPath wins over the name template.
+func (t *WatermarkTool) prepare(parsed watermarkRequest, inputPath string, index int, usedOutputs map[string]struct{}) (preparedWatermark, *models.JobErrorV1) {
+	if err := validateInputImagePath(inputPath); err != nil {
+		return preparedWatermark{}, err
+	}
+
+	outputFmt, fmtErr := resolveOutputFormat(inputPath, parsed.format)
+	if fmtErr != nil {
+		return preparedWatermark{}, fmtErr
+	}
+
+	inputFmt := strings.TrimPrefix(filepath.Ext(inputPath), ".")
+	if metaErr := validateCarriedMetadata(parsed.metadata, inputFmt, outputFmt, "IMAGE_WATERMARK"); metaErr != nil {
+		return preparedWatermark{}, metaErr
+	}
+
+	outputPath := ""
+	if parsed.mode == "single" {
+		outputPath = parsed.outputPath
+	}
+	if outputPath == "" {
+		outputPath = imageOutputPath(parsed.outputDir, parsed.nameTmpl, tools.OutputNameVars{
+			InputPath: inputPath,
+			Ext:       outputFmt,
+			Tool:      ToolIDImageWatermarkV1,
+			Index:     index,
+			Subdir:    parsed.subdirs[inputPath],
+		})
+	}
+
+	if sameFile(inputPath, outputPath) {
+		return preparedWatermark{}, models.NewCanonicalJobError("IMAGE_WATERMARK_OUTPUT_COLLIDES_INPUT", "output path cannot match input path", nil)
+	}
+
+	target, conflictErr := tools.ResolveOutputTarget(parsed.conflicts, outputPath, usedOutputs)
+	if conflictErr != nil {
+		return preparedWatermark{}, conflictErr
+	}
+
+	return preparedWatermark{
+		inputPath:  inputPath,
+		outputPath: target.Path,
+		target:     target,
+		outputFmt:  outputFmt,
+		stamp:      parsed.stamp,
+		page:       parsed.page,
+		metadata:   parsed.metadata,
+	}, nil
+}
+
+// executeWatermarkToPath writes the watermarked input and returns how many
+// times the watermark was drawn.
+func executeWatermarkToPath(ctx context.Context, prepared preparedWatermark) (int, error) {
+	select {
+	case <-ctx.Done():
+		return 0, fmt.Errorf("watermark cancelled: %w", ctx.Err())
+	default:
+	}
+
+	input, _, _, _, err := readImagePage(prepared.inputPath, prepared.page)
+	if err != nil {
+		return 0, fmt.Errorf("read input failed: %w", err)
+	}
+
+	output, stamps, err := applyWatermark(input, prepared.outputFmt, prepared.stamp)
+	if err != nil {
+		return 0, fmt.Errorf("watermark failed: %w", err)
+	}
+
+	output, err = carryMetadata(input, output, prepared.metadata)
+	if err != nil {
+		return 0, fmt.Errorf("copy metadata failed: %w", err)
+	}
+
+	select {
+	case <-ctx.Done():
+		return 0, fmt.Errorf("watermark cancelled: %w", ctx.Err())
+	default:
+	}
+
+	if mkErr := os.MkdirAll(filepath.Dir(prepared.outputPath), 0o755); mkErr != nil {
+		return 0, fmt.Errorf("create output dir failed: %w", mkErr)
+	}
+
+	if writeErr := tools.WriteOutputFile(ctx, prepared.target, output, DefaultFilePermissions); writeErr != nil {
+		return 0, fmt.Errorf("write output failed: %w", writeErr)
+	}
+
+	return stamps, nil
+}
+
+// applyWatermark draws stamp on input and encodes the result as outputFmt.
+func applyWatermark(input []byte, outputFmt string, stamp watermarkStamp) ([]byte, int, error) {
+	decoded, err := decodeImage(input)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	canvas := imaging.Clone(decoded)
+	width, height := canvas.Bounds().Dx(), canvas.Bounds().Dy()
+	mark, err := stamp.render(width)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	positions := stamp.positions(width, height, mark.Bounds().Dx(), mark.Bounds().Dy())
+	for _, at := range positions {
+		draw.Draw(canvas, mark.Bounds().Add(at), mark, image.Point{}, draw.Over)
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, canvas); err != nil {
+		return nil, 0, fmt.Errorf("encode intermediate png: %w", err)
+	}
+
+	output, err := bimg.NewImage(buf.Bytes()).Convert(mapFormatToImageType(outputFmt))
+	if err != nil {
+		return nil, 0, err
+	}
+	return output, len(positions), nil
+}
+
+// render draws the watermark for an image imageWidth pixels wide: scaled,
+// rotated and faded.
+func (s watermarkStamp) render(imageWidth int) (*image.NRGBA, error) {
+	markWidth := max(1, roundPixels(float64(imageWidth)*s.scale))
+
+	var mark *image.NRGBA
+	if s.text != "" {
+		rendered, err := renderWatermarkText(s.text, s.color, markWidth)
+		if err != nil {
+			return nil, err
+		}
+		mark = rendered
+	} else {
+		if s.logo == nil {
+			return nil, fmt.Errorf("watermark image %s was not loaded", s.imagePath)
+		}
+		mark = imaging.Resize(s.logo, markWidth, 0, imaging.Lanczos)
+	}
+
+	if s.rotation != 0 {
+		mark = imaging.Rotate(mark, s.rotation, color.Transparent)
+	}
+	if s.opacity < 1 {
+		for i := 3; i < len(mark.Pix); i += 4 {
+			mark.Pix[i] = uint8(math.Round(float64(mark.Pix[i]) * s.opacity))
+		}
+	}
+	return mark, nil
+}
+
+// renderWatermarkText draws text on a transparent image markWidth pixels
+// wide, picking the font size from a measurement at a reference size.
+func renderWatermarkText(text, hexColor string, markWidth int) (*image.NRGBA, error) {
+	col, err := parseColorWithOpacity(hexColor, 1)
+	if err != nil {
+		return nil, err
+	}
+	font, err := watermarkFont()
+	if err != nil {
+		return nil, fmt.Errorf("parse font: %w", err)
+	}
+
+	lines := strings.Split(text, "\n")
+	measureFace, err := opentype.NewFace(font, &opentype.FaceOptions{Size: watermarkTextMeasureSize, DPI: 72})
+	if err != nil {
+		return nil, fmt.Errorf("create font face: %w", err)
+	}
+	measure := gg.NewContext(1, 1)
+	measure.SetFontFace(measureFace)
+	var widest float64
+	for _, line := range lines {
+		lineWidth, _ := measure.MeasureString(line)
+		widest = math.Max(widest, lineWidth)
+	}
+	if widest <= 0 {
+		return nil, fmt.Errorf("watermark text has no visible characters")
+	}
+
+	size := watermarkTextMeasureSize * float64(markWidth) / widest
+	face, err := opentype.NewFace(font, &opentype.FaceOptions{Size: size, DPI: 72})
+	if err != nil {
+		return nil, fmt.Errorf("create font face: %w", err)
+	}
+	metrics := face.Metrics()
+	ascent, descent := float64(metrics.Ascent.Ceil()), float64(metrics.Descent.Ceil())
+	lineHeight := size * 1.3
+
+	gc := gg.NewContext(markWidth+2, int(math.Ceil(ascent+descent+lineHeight*float64(len(lines)-1))))
+	gc.SetFontFace(face)
+	gc.SetRGBA255(int(col.R), int(col.G), int(col.B), int(col.A))
+	for i, line := range lines {
+		gc.DrawString(line, 1, ascent+lineHeight*float64(i))
+	}
+	return imaging.Clone(gc.Image()), nil
+}
+
+// positions returns the top-left corner of every copy of a markWidth x
+// markHeight watermark. Margins and offsets are percentages of the image
+// size, so the placement scales with the image.
+func (s watermarkStamp) positions(width, height, markWidth, markHeight int) []image.Point {
+	percent := func(size int, pct float64) int { return int(math.Round(float64(size) * pct / 100)) }
+	shiftX, shiftY := percent(width, s.offsetX), percent(height, s.offsetY)
+
+	if s.repeat == watermarkRepeatSingle {
+		marginX, marginY := percent(width, s.margin), percent(height, s.margin)
+
+		x := (width - markWidth) / 2
+		switch {
+		case strings.HasSuffix(s.anchor, "left"):
+			x = marginX
+		case strings.HasSuffix(s.anchor, "right"):
+			x = width - markWidth - marginX
+		}
+		y := (height - markHeight) / 2
+		switch {
+		case strings.HasPrefix(s.anchor, "top"):
+			y = marginY
+		case strings.HasPrefix(s.anchor, "bottom"):
+			y = height - markHeight - marginY
+		}
+		return []image.Point{{X: x + shiftX, Y: y + shiftY}}
+	}
+
+	stepX := markWidth + percent(markWidth, s.spacing)
+	stepY := markHeight + percent(markHeight, s.spacing)
+	wrap := func(v, step int) int { return (v%step+step)%step - step }
+
+	var points []image.Point
+	for row, y := 0, wrap(shiftY, stepY); y < height; row, y = row+1, y+stepY {
+		rowShift := 0
+		if s.repeat == watermarkRepeatDiagonal && row%2 == 1 {
+			rowShift = stepX / 2
+		}
+		for x := wrap(shiftX+rowShift, stepX); x < width; x += stepX {
+			if x+markWidth > 0 && y+markHeight > 0 {
+				points = append(points, image.Point{X: x, Y: y})
+			}
+		}
+	}
+	return points
+}
@@ -0,0 +1,328 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"fileforge-desktop/internal/models"
+	"fileforge-desktop/internal/registry"
+	"fileforge-desktop/internal/tools"
+)
+
+type dependencyVerdict int
+
+const (
+	dependenciesPending dependencyVerdict = iota
+	dependenciesSatisfied
+	dependenciesFailed
+	dependenciesCancelled
+)
+
+// normalizeDependencies trims and de-duplicates DependsOn and checks that every
+// listed job exists. A new job can only depend on jobs that already have IDs,
+// so the graph cannot contain cycles.
+func (o *Orchestrator) normalizeDependencies(req *models.JobRequestV1) *models.JobErrorV1 {
+	if len(req.DependsOn) == 0 {
+		req.DependencyRule = ""
+		return nil
+	}
+
+	rule := strings.ToLower(strings.TrimSpace(req.DependencyRule))
+	switch rule {
+	case "":
+		rule = models.JobDependencyAllSucceeded
+	case models.JobDependencyAllSucceeded, models.JobDependencyAllFinished, models.JobDependencyAnyFinished:
+	default:
+		return models.NewJobError(models.ErrorCodeValidationInvalidInput, "JOB_DEPENDENCY_RULE_INVALID", fmt.Sprintf("dependencyRule must be all_succeeded, all_finished or any_finished, got '%s'", req.DependencyRule), map[string]any{"dependencyRule": req.DependencyRule})
+	}
+	req.DependencyRule = rule
+
+	seen := make(map[string]struct{}, len(req.DependsOn))
+	dependsOn := make([]string, 0, len(req.DependsOn))
+	for _, jobID := range req.DependsOn {
+		jobID = strings.TrimSpace(jobID)
+		if jobID == "" {
+			continue
+		}
+		if _, ok := seen[jobID]; ok {
+			continue
+		}
+		seen[jobID] = struct{}{}
+
+		if _, _, found := o.dependencyStatus(jobID); !found {
+			return models.NewJobError(models.ErrorCodeValidationInvalidInput, "JOB_DEPENDENCY_NOT_FOUND", fmt.Sprintf("dependency '%s' not found", jobID), map[string]any{"dependency": jobID})
+		}
+		dependsOn = append(dependsOn, jobID)
+	}
+	req.DependsOn = dependsOn
+
+	return nil
+}
+
+// completionSignal returns a channel that is closed the next time any job
+// completes.
+func (o *Orchestrator) completionSignal() <-chan struct{} {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.completed == nil {
+		o.completed = make(chan struct{})
+	}
+	return o.completed
+}
+
+func (o *Orchestrator) signalCompletion() {
+	o.mu.Lock()
+	if o.completed != nil {
+		close(o.completed)
+		o.completed = nil
+	}
+	o.mu.Unlock()
+}
+
+// dependencyStatus reports the status of a dependency. An interrupted job that
+// was resumed is represented by the job that resumed it, so dependents keep
+// waiting across a restart once the user resumes the work.
+func (o *Orchestrator) dependencyStatus(jobID string) (string, string, bool) {
+	resolvedID := o.resumedBy(jobID)
+
+	o.mu.RLock()
+	job, ok := o.jobs[resolvedID]
+	history := o.history
+	o.mu.RUnlock()
+	if ok {
+		return job.snapshot().Status, resolvedID, true
+	}
+
+	if history != nil {
+		if record, found := history.Get(resolvedID); found {
+			return record.Status, resolvedID, true
+		}
+	}
+	return "", resolvedID, false
+}
+
+// resumedBy follows ResumedFrom links forward to the newest job that resumed
+// jobID, or returns jobID itself.
+func (o *Orchestrator) resumedBy(jobID string) string {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+
+	current := jobID
+	for range len(o.jobs) {
+		next := ""
+		nextStartedAt := int64(0)
+		for _, job := range o.jobs {
+			job.mu.RLock()
+			if job.result.ResumedFrom == current && job.result.StartedAt >= nextStartedAt {
+				next, nextStartedAt = job.result.JobID, job.result.StartedAt
+			}
+			job.mu.RUnlock()
+		}
+		if next == "" {
+			break
+		}
+		current = next
+	}
+	return current
+}
+
+// evaluateDependencies returns the verdict for req together with the
+// dependency that decided it when the verdict is a failure. Under the
+// any_finished rule the first dependency in a final state satisfies req.
+func (o *Orchestrator) evaluateDependencies(req models.JobRequestV1) (dependencyVerdict, string, string) {
+	verdict := dependenciesSatisfied
+	if req.DependencyRule == models.JobDependencyAnyFinished {
+		verdict = dependenciesPending
+	}
+	for _, jobID := range req.DependsOn {
+		status, resolvedID, found := o.dependencyStatus(jobID)
+		switch {
+		case !found:
+			return dependenciesFailed, jobID, "not_found"
+		case isActiveStatus(status), status == StatusInterrupted:
+			verdict = dependenciesPending
+		case req.DependencyRule == models.JobDependencyAnyFinished:
+			return dependenciesSatisfied, "", ""
+		case status == StatusSuccess, req.DependencyRule == models.JobDependencyAllFinished:
+		case status == StatusCancelled:
+			return dependenciesCancelled, resolvedID, status
+		default:
+			return dependenciesFailed, resolvedID, status
+		}
+	}
+	return verdict, "", ""
+}
+
+// awaitDependencies holds a waiting job until its dependencies allow it to run,
+// then validates and starts it. A dependency that fails or is cancelled under
+// the all_succeeded rule fails or cancels the dependent as well, which in turn
+// cascades to jobs waiting on it. Under any_finished the job starts as soon as
+// one dependency is done, whatever its outcome.
+func (o *Orchestrator) awaitDependencies(job *trackedJob, tool tools.Tool, req models.JobRequestV1) {
+	for {
+		signal := o.completionSignal()
+
+		verdict, dependency, status := o.evaluateDependencies(req)
+		switch verdict {
+		case dependenciesSatisfied:
+			if expandErr := expandInputs(tool, &req); expandErr != nil {
+				job.complete(StatusFailed, "job validation failed", nil, expandErr, time.Now().UnixMilli())
+				return
+			}
+			job.setRequest(req)
+			if validationErr := registry.ValidateRequest(job.ctx, tool, validationRequest(req)); validationErr != nil {
+				job.complete(StatusFailed, "job validation failed", nil, normalizeJobError(validationErr), time.Now().UnixMilli())
+				return
+			}
+			job.transition(StatusQueued, "queued")
+			o.start(job, tool, req)
+			return
+		case dependenciesFailed:
+			details := map[string]any{"dependency": dependency, "dependencyStatus": status}
+			job.complete(StatusFailed, "dependency failed", nil, models.NewJobError(models.ErrorCodeDependencyFailed, "JOB_DEPENDENCY_FAILED", fmt.Sprintf("dependency '%s' finished with status %s", dependency, status), details), time.Now().UnixMilli())
+			return
+		case dependenciesCancelled:
+			details := map[string]any{"dependency": dependency, "dependencyStatus": status}
+			job.complete(StatusCancelled, "dependency cancelled", nil, models.NewJobError(models.ErrorCodeCancelledByUser, "JOB_DEPENDENCY_CANCELLED", fmt.Sprintf("dependency '%s' was cancelled", dependency), details), time.Now().UnixMilli())
+			return
+		}
+
+		select {
+		case <-signal:
+		case <-job.ctx.Done():
+			job.complete(StatusCancelled, "job cancelled", nil, models.NewJobError(models.ErrorCodeCancelledByUser, "JOB_CANCELLED", job.ctx.Err().Error(), nil), time.Now().UnixMilli())
+			return
+		}
+	}
+}
+
+// restoreWaiting registers a job that was waiting on dependencies when the app
+// stopped, without persisting it, and returns the function that re-arms it.
+// It returns false when the job cannot be restored and should be recovered as
+// interrupted instead.
+func (o *Orchestrator) restoreWaiting(state persistedJobV1) (func(), bool) {
+	if state.Request == nil {
+		return nil, false
+	}
+
+	tool, err := o.registry.GetToolV2(state.Request.ToolID)
+	if err != nil {
+		return nil, false
+	}
+
+	req := *state.Request
+	job := o.newTrackedJob(context.Background(), state.JobID, req.ToolID, len(req.InputPaths), jobOrigin{request: &req})
+	job.result = state.JobResultV1
+	job.result.Progress.Item = nil
+
+	o.mu.Lock()
+	o.jobs[state.JobID] = job
+	o.mu.Unlock()
+
+	return func() { go o.awaitDependencies(job, tool, req) }, true
+}
+
+// DependencyGraph returns every job linked to jobID through DependsOn, in both
+// directions. It reports false when the job has no dependencies and no
+// dependents.
+func (o *Orchestrator) DependencyGraph(jobID string) (models.JobGraphV1, bool) {
+	nodes := make(map[string]models.JobGraphNodeV1)
+	order := make([]string, 0)
+	dependents := o.dependentsIndex()
+
+	queue := []string{jobID}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		if _, seen := nodes[current]; seen {
+			continue
+		}
+
+		node, found := o.graphNode(current)
+		if !found {
+			continue
+		}
+		nodes[current] = node
+		order = append(order, current)
+
+		queue = append(queue, node.DependsOn...)
+		queue = append(queue, dependents[current]...)
+	}
+
+	if len(nodes) <= 1 {
+		return models.JobGraphV1{}, false
+	}
+
+	graph := models.JobGraphV1{JobID: jobID, Nodes: make([]models.JobGraphNodeV1, 0, len(order))}
+	for _, id := range order {
+		graph.Nodes = append(graph.Nodes, nodes[id])
+	}
+	return graph, true
+}
+
+func (o *Orchestrator) graphNode(jobID string) (models.JobGraphNodeV1, bool) {
+	node := models.JobGraphNodeV1{JobID: jobID}
+	if resumedBy := o.resumedBy(jobID); resumedBy != jobID {
+		node.ResumedBy = resumedBy
+	}
+
+	o.mu.RLock()
+	job, ok := o.jobs[jobID]
+	history := o.history
+	o.mu.RUnlock()
+
+	var req *models.JobRequestV1
+	switch {
+	case ok:
+		job.mu.RLock()
+		node.ToolID, node.Status, req = job.result.ToolID, job.result.Status, job.request
+		job.mu.RUnlock()
+	case history != nil:
+		record, found := history.Get(jobID)
+		if !found {
+			return node, false
+		}
+		node.ToolID, node.Status, req = record.ToolID, record.Status, record.Request
+	default:
+		return node, false
+	}
+
+	if req != nil {
+		node.DependsOn = req.DependsOn
+		node.DependencyRule = req.DependencyRule
+	}
+	return node, true
+}
+
+// dependentsIndex maps each job ID to the jobs that list it in DependsOn.
+func (o *Orchestrator) dependentsIndex() map[string][]string {
+	index := make(map[string][]string)
+	add := func(jobID string, req *models.JobRequestV1) {
+		if req == nil {
+			return
+		}
+		for _, dependency := range req.DependsOn {
+			index[dependency] = append(index[dependency], jobID)
+		}
+	}
+
+	o.mu.RLock()
+	history := o.history
+	for jobID, job := range o.jobs {
+		job.mu.RLock()
+		add(jobID, job.request)
+		job.mu.RUnlock()
+	}
+	o.mu.RUnlock()
+
+	if history != nil {
+		for _, record := range history.WithDependencies() {
+			if _, err := o.lookupJob(record.JobID); err != nil {
+				add(record.JobID, record.Request)
+			}
+		}
+	}
+	return index
+}
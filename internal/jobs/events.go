@@ -0,0 +1,150 @@
+package jobs
+
+import (
+	"sync"
+	"time"
+
+	"fileforge-desktop/internal/models"
+)
+
+const (
+	DefaultEventBufferSize = 4096
+	defaultEventPageSize   = 500
+)
+
+// eventLog numbers lifecycle events and keeps the most recent ones so that a
+// subscriber that missed some can replay them. Listeners are called under the
+// log lock, which keeps delivery in sequence order.
+type eventLog struct {
+	mu       sync.Mutex
+	capacity int
+	sequence uint64
+	events   []models.JobEventV1
+	emit     func(models.JobEventV1)
+}
+
+func newEventLog(capacity int) *eventLog {
+	if capacity <= 0 {
+		capacity = DefaultEventBufferSize
+	}
+	return &eventLog{capacity: capacity}
+}
+
+func (l *eventLog) setEmitter(emit func(models.JobEventV1)) {
+	l.mu.Lock()
+	l.emit = emit
+	l.mu.Unlock()
+}
+
+func (l *eventLog) publish(evt models.JobEventV1) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.sequence++
+	evt.Version = models.JobEventVersionV1
+	evt.Sequence = l.sequence
+	if evt.Timestamp == 0 {
+		evt.Timestamp = time.Now().UnixMilli()
+	}
+
+	if len(l.events) >= l.capacity {
+		copy(l.events, l.events[1:])
+		l.events = l.events[:len(l.events)-1]
+	}
+	l.events = append(l.events, evt)
+
+	if l.emit != nil {
+		l.emit(evt)
+	}
+}
+
+// since returns events after the given sequence, optionally for one job, along
+// with the latest sequence and whether nothing after afterSequence has been
+// dropped from the buffer yet.
+func (l *eventLog) since(query models.JobEventsQueryV1) ([]models.JobEventV1, uint64, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	complete := len(l.events) == 0 || l.events[0].Sequence <= query.AfterSequence+1
+	limit := query.Limit
+	if limit <= 0 {
+		limit = defaultEventPageSize
+	}
+
+	events := make([]models.JobEventV1, 0)
+	for _, evt := range l.events {
+		if evt.Sequence <= query.AfterSequence {
+			continue
+		}
+		if query.JobID != "" && evt.JobID != query.JobID {
+			continue
+		}
+		events = append(events, evt)
+		if len(events) >= limit {
+			break
+		}
+	}
+	return events, l.sequence, complete
+}
+
+// SetEventEmitter registers the listener for lifecycle events. It replaces any
+// previous listener.
+func (o *Orchestrator) SetEventEmitter(emit func(models.JobEventV1)) {
+	o.events.setEmitter(emit)
+}
+
+// JobEvents replays buffered lifecycle events after query.AfterSequence.
+func (o *Orchestrator) JobEvents(query models.JobEventsQueryV1) ([]models.JobEventV1, uint64, bool) {
+	return o.events.since(query)
+}
+
+// emitEvent publishes a lifecycle event stamped with the job's current state.
+func (j *trackedJob) emitEvent(evt models.JobEventV1) {
+	if j.publish == nil {
+		return
+	}
+
+	j.mu.RLock()
+	evt.JobID = j.result.JobID
+	evt.ToolID = j.result.ToolID
+	if evt.Status == "" {
+		evt.Status = j.result.Status
+	}
+	j.mu.RUnlock()
+
+	j.publish(evt)
+}
+
+func (j *trackedJob) emitQueued() {
+	progress := j.snapshot().Progress
+	j.emitEvent(models.JobEventV1{Type: models.JobEventQueued, Progress: &progress})
+}
+
+func (j *trackedJob) emitRetrying(retry models.JobRetryV1) {
+	j.emitEvent(models.JobEventV1{Type: models.JobEventRetrying, Retry: &retry})
+}
+
+// emitItems publishes job.item.completed for items that have not been reported
+// through progress yet, such as single-mode results or pipeline outputs.
+func (j *trackedJob) emitItems(items []models.JobResultItemV1) {
+	for i := range items {
+		j.mu.Lock()
+		_, emitted := j.emittedItems[items[i].ItemID]
+		if !emitted {
+			j.markItemEmittedLocked(items[i].ItemID)
+		}
+		j.mu.Unlock()
+
+		if !emitted {
+			item := items[i]
+			j.emitEvent(models.JobEventV1{Type: models.JobEventItemCompleted, Item: &item})
+		}
+	}
+}
+
+func (j *trackedJob) markItemEmittedLocked(itemID string) {
+	if j.emittedItems == nil {
+		j.emittedItems = make(map[string]struct{})
+	}
+	j.emittedItems[itemID] = struct{}{}
+}
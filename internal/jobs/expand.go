@@ -0,0 +1,348 @@
+package jobs
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"fileforge-desktop/internal/models"
+	"fileforge-desktop/internal/tools"
+)
+
+type expandedInput struct {
+	path   string
+	subdir string // directory of path relative to the listed directory or glob base
+}
+
+// expandInputs replaces the directories and glob patterns in req.InputPaths
+// with the files they contain, filtered by req.Expand and the tool's input
+// extensions. An input is only treated as a pattern when no file or directory
+// exists at that path. A request that lists only files is left untouched.
+func expandInputs(tool tools.Tool, req *models.JobRequestV1) *models.JobErrorV1 {
+	var opts models.InputExpansionV1
+	if req.Expand != nil {
+		opts = *req.Expand
+	}
+	// Sub-directories only come from an earlier expansion, such as the request
+	// of a resumed job, but they are joined to the output directory.
+	for inputPath, subdir := range req.InputSubdirs {
+		if !isOutputSubdir(subdir) {
+			return models.NewCanonicalJobError("JOB_INPUT_SUBDIR_INVALID", fmt.Sprintf("output sub-directory '%s' must be a relative path inside the output directory", subdir), map[string]any{"input": inputPath, "subdir": subdir})
+		}
+	}
+	for _, pattern := range append(append([]string(nil), opts.Include...), opts.Exclude...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return models.NewCanonicalJobError("JOB_INPUT_PATTERN_INVALID", fmt.Sprintf("invalid filter pattern '%s'", pattern), map[string]any{"pattern": pattern})
+		}
+	}
+
+	extensions := opts.Extensions
+	if len(extensions) == 0 {
+		extensions = tool.Manifest().InputExtensions
+	}
+	filter := inputFilter{opts: opts, extensions: make(map[string]struct{}, len(extensions))}
+	for _, ext := range extensions {
+		filter.extensions[strings.ToLower(strings.TrimPrefix(strings.TrimSpace(ext), "."))] = struct{}{}
+	}
+
+	expanded := make([]string, 0, len(req.InputPaths))
+	subdirs := make(map[string]string)
+	seen := make(map[string]struct{}, len(req.InputPaths))
+	changed := false
+	add := func(inputPath, subdir string) {
+		if _, ok := seen[inputPath]; ok {
+			return
+		}
+		seen[inputPath] = struct{}{}
+		expanded = append(expanded, inputPath)
+		if isOutputSubdir(subdir) {
+			subdirs[inputPath] = subdir
+		}
+	}
+
+	for _, rawInput := range req.InputPaths {
+		input := strings.TrimSpace(rawInput)
+
+		// An existing path is used as it is, so a file such as "photo [1].jpg"
+		// is not mistaken for a pattern.
+		var matches []expandedInput
+		var jobErr *models.JobErrorV1
+		info, statErr := os.Stat(input)
+		switch {
+		case statErr == nil && info.IsDir():
+			matches, jobErr = filter.expandDir(input, "")
+		case statErr != nil && hasGlobMeta(input):
+			matches, jobErr = filter.expandGlob(input)
+		default:
+			add(rawInput, req.InputSubdirs[rawInput])
+			continue
+		}
+		if jobErr != nil {
+			return jobErr
+		}
+		if len(matches) == 0 {
+			return models.NewCanonicalJobError("JOB_INPUT_NOT_FOUND", fmt.Sprintf("no matching input files in '%s'", input), map[string]any{"input": input})
+		}
+
+		changed = true
+		for _, match := range matches {
+			add(match.path, match.subdir)
+		}
+	}
+
+	if !changed {
+		return nil
+	}
+
+	req.InputPaths = expanded
+	req.InputSubdirs = nil
+	if len(subdirs) > 0 && !opts.Flatten && req.Mode != "single" && planOutputDir(*req) != "" {
+		req.InputSubdirs = subdirs
+	}
+	return nil
+}
+
+// isOutputSubdir reports whether subdir is a clean relative path that stays
+// inside the directory it is joined to.
+func isOutputSubdir(subdir string) bool {
+	return subdir != "" && subdir != "." && subdir == filepath.Clean(subdir) && filepath.IsLocal(subdir)
+}
+
+type inputFilter struct {
+	opts       models.InputExpansionV1
+	extensions map[string]struct{}
+}
+
+// expandDir lists the files under root, walking sub-directories when the
+// expansion is recursive. Hidden files and directories are skipped, as are
+// directories matching an exclude pattern.
+func (f inputFilter) expandDir(root, prefix string) ([]expandedInput, *models.JobErrorV1) {
+	matches := make([]expandedInput, 0)
+	err := filepath.WalkDir(root, func(current string, entry fs.DirEntry, walkErr error) error {
+		if walkErr != nil {
+			return walkErr
+		}
+		if current == root {
+			return nil
+		}
+
+		rel, err := filepath.Rel(root, current)
+		if err != nil {
+			return err
+		}
+		rel = filepath.Join(prefix, rel)
+
+		if strings.HasPrefix(entry.Name(), ".") {
+			if entry.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if entry.IsDir() {
+			if !f.opts.Recursive || matchesAny(f.opts.Exclude, rel) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !entry.Type().IsRegular() || !f.accepts(rel) {
+			return nil
+		}
+
+		matches = append(matches, expandedInput{path: current, subdir: filepath.Dir(rel)})
+		return nil
+	})
+	if err != nil {
+		return nil, models.NewCanonicalJobError("JOB_INPUT_LIST_FAILED", fmt.Sprintf("list '%s': %v", root, err), map[string]any{"input": root})
+	}
+	return matches, nil
+}
+
+// expandGlob matches pattern and keeps sub-directories relative to the part of
+// the pattern before its first wildcard.
+func (f inputFilter) expandGlob(pattern string) ([]expandedInput, *models.JobErrorV1) {
+	found, err := filepath.Glob(pattern)
+	if err != nil {
+		return nil, models.NewCanonicalJobError("JOB_INPUT_PATTERN_INVALID", fmt.Sprintf("invalid input pattern '%s'", pattern), map[string]any{"input": pattern})
+	}
+
+	base := globBase(pattern)
+	matches := make([]expandedInput, 0, len(found))
+	for _, match := range found {
+		rel, err := filepath.Rel(base, match)
+		if err != nil || isHiddenPath(rel) {
+			continue
+		}
+
+		info, err := os.Stat(match)
+		if err != nil {
+			continue
+		}
+		if info.IsDir() {
+			if !f.opts.Recursive || matchesAny(f.opts.Exclude, rel) {
+				continue
+			}
+			nested, jobErr := f.expandDir(match, rel)
+			if jobErr != nil {
+				return nil, jobErr
+			}
+			matches = append(matches, nested...)
+			continue
+		}
+		if info.Mode().IsRegular() && f.accepts(rel) {
+			matches = append(matches, expandedInput{path: match, subdir: filepath.Dir(rel)})
+		}
+	}
+	return matches, nil
+}
+
+func (f inputFilter) accepts(rel string) bool {
+	if len(f.extensions) > 0 {
+		ext := strings.ToLower(strings.TrimPrefix(filepath.Ext(rel), "."))
+		if _, ok := f.extensions[ext]; !ok {
+			return false
+		}
+	}
+	if len(f.opts.Include) > 0 && !matchesAny(f.opts.Include, rel) {
+		return false
+	}
+	// A file below an excluded directory is excluded too, as the directory
+	// walk never enters it.
+	for dir := rel; dir != "." && dir != string(filepath.Separator); dir = filepath.Dir(dir) {
+		if matchesAny(f.opts.Exclude, dir) {
+			return false
+		}
+	}
+	return true
+}
+
+// matchesAny reports whether rel matches one of the patterns, ignoring case.
+// Patterns containing a slash are matched against the whole relative path,
+// others against the file name only.
+func matchesAny(patterns []string, rel string) bool {
+	rel = strings.ToLower(filepath.ToSlash(rel))
+	for _, pattern := range patterns {
+		pattern = strings.ToLower(strings.TrimSpace(pattern))
+		target := path.Base(rel)
+		if strings.Contains(pattern, "/") {
+			target = rel
+		}
+		if matched, _ := path.Match(pattern, target); matched {
+			return true
+		}
+	}
+	return false
+}
+
+func isHiddenPath(rel string) bool {
+	for _, element := range strings.Split(filepath.ToSlash(rel), "/") {
+		if strings.HasPrefix(element, ".") && element != "." && element != ".." {
+			return true
+		}
+	}
+	return false
+}
+
+func hasGlobMeta(input string) bool {
+	return strings.ContainsAny(input, "*?[")
+}
+
+func isDirectory(input string) bool {
+	info, err := os.Stat(input)
+	return err == nil && info.IsDir()
+}
+
+// globBase returns the directory made of the pattern elements before the
+// first one containing a wildcard.
+func globBase(pattern string) string {
+	dir := pattern
+	for hasGlobMeta(dir) {
+		dir = filepath.Dir(dir)
+	}
+	return dir
+}
+
+// outputSubdirs lists the distinct output sub-directories of req in the order
+// they first appear.
+func outputSubdirs(req models.JobRequestV1) []string {
+	subdirs := make([]string, 0)
+	seen := make(map[string]struct{})
+	for _, inputPath := range req.InputPaths {
+		subdir := req.InputSubdirs[inputPath]
+		if _, ok := seen[subdir]; ok || subdir == "" {
+			continue
+		}
+		seen[subdir] = struct{}{}
+		subdirs = append(subdirs, subdir)
+	}
+	return subdirs
+}
+
+// validationRequest is req as the tool validates it before the job runs.
+func validationRequest(req models.JobRequestV1) models.JobRequestV1 {
+	validated, _ := withExistingSubdirs(req)
+	return validated
+}
+
+// withExistingSubdirs drops the output sub-directories that do not exist yet.
+// They are only created when the job runs, so until then the inputs bound for
+// them are validated and planned against the output directory itself.
+func withExistingSubdirs(req models.JobRequestV1) (models.JobRequestV1, map[string]string) {
+	if len(req.InputSubdirs) == 0 {
+		return req, nil
+	}
+
+	root := planOutputDir(req)
+	existing := make(map[string]string, len(req.InputSubdirs))
+	missing := make(map[string]string)
+	for inputPath, subdir := range req.InputSubdirs {
+		if isDirectory(filepath.Join(root, subdir)) {
+			existing[inputPath] = subdir
+		} else {
+			missing[inputPath] = subdir
+		}
+	}
+	req.InputSubdirs = existing
+	return req, missing
+}
+
+// inputSubdirList returns the output sub-directory of every input in order,
+// or nil when all outputs go directly into OutputDir.
+func inputSubdirList(req models.JobRequestV1) []string {
+	if len(req.InputSubdirs) == 0 {
+		return nil
+	}
+	subdirs := make([]string, len(req.InputPaths))
+	for i, inputPath := range req.InputPaths {
+		subdirs[i] = filepath.ToSlash(req.InputSubdirs[inputPath])
+	}
+	return subdirs
+}
+
+// requestOutputDirs lists the directories a request writes its outputs to,
+// where an interrupted run may have left temporary files.
+func requestOutputDirs(req models.JobRequestV1) []string {
+	dirs := []string{req.OutputDir}
+	if outputDir, ok := req.Options["outputDir"].(string); ok {
+		dirs = append(dirs, outputDir)
+	}
+	root := planOutputDir(req)
+	for _, subdir := range outputSubdirs(req) {
+		dirs = append(dirs, filepath.Join(root, subdir))
+	}
+	if outputPath, ok := req.Options["outputPath"].(string); ok && strings.TrimSpace(outputPath) != "" {
+		dirs = append(dirs, filepath.Dir(strings.TrimSpace(outputPath)))
+	}
+	return dirs
+}
+
+// planOutputDir is the directory a batch request writes to, preferring the
+// outputDir option of the tools that read it.
+func planOutputDir(req models.JobRequestV1) string {
+	if outputDir, ok := req.Options["outputDir"].(string); ok && strings.TrimSpace(outputDir) != "" {
+		return strings.TrimSpace(outputDir)
+	}
+	return req.OutputDir
+}
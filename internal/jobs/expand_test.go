@@ -0,0 +1,146 @@
+package jobs
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"fileforge-desktop/internal/models"
+)
+
+func TestExpandInputsDirectory(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, "a.jpg", "b.PNG", "notes.txt", ".hidden.jpg", "sub/c.jpg", "sub/skip/d.jpg", ".cache/e.jpg")
+	out := filepath.Join(dir, "out")
+
+	tests := []struct {
+		name        string
+		expand      *models.InputExpansionV1
+		wantInputs  []string
+		wantSubdirs map[string]string
+	}{
+		{
+			name:       "top level only",
+			wantInputs: []string{filepath.Join(dir, "a.jpg"), filepath.Join(dir, "b.PNG")},
+		},
+		{
+			name:       "recursive",
+			expand:     &models.InputExpansionV1{Recursive: true},
+			wantInputs: []string{filepath.Join(dir, "a.jpg"), filepath.Join(dir, "b.PNG"), filepath.Join(dir, "sub", "c.jpg"), filepath.Join(dir, "sub", "skip", "d.jpg")},
+			wantSubdirs: map[string]string{
+				filepath.Join(dir, "sub", "c.jpg"):         "sub",
+				filepath.Join(dir, "sub", "skip", "d.jpg"): filepath.Join("sub", "skip"),
+			},
+		},
+		{
+			name:        "exclude directory",
+			expand:      &models.InputExpansionV1{Recursive: true, Exclude: []string{"skip"}},
+			wantInputs:  []string{filepath.Join(dir, "a.jpg"), filepath.Join(dir, "b.PNG"), filepath.Join(dir, "sub", "c.jpg")},
+			wantSubdirs: map[string]string{filepath.Join(dir, "sub", "c.jpg"): "sub"},
+		},
+		{
+			name:       "include and flatten",
+			expand:     &models.InputExpansionV1{Recursive: true, Include: []string{"*.JPG"}, Flatten: true},
+			wantInputs: []string{filepath.Join(dir, "a.jpg"), filepath.Join(dir, "sub", "c.jpg"), filepath.Join(dir, "sub", "skip", "d.jpg")},
+		},
+		{
+			name:       "extensions override",
+			expand:     &models.InputExpansionV1{Extensions: []string{".txt"}},
+			wantInputs: []string{filepath.Join(dir, "notes.txt")},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := models.JobRequestV1{Mode: "batch", InputPaths: []string{dir}, OutputDir: out, Expand: tt.expand}
+			if err := expandInputs(newFakeTool("tool.image.test", "jpg", "png"), &req); err != nil {
+				t.Fatalf("unexpected error: %+v", err)
+			}
+			if !reflect.DeepEqual(req.InputPaths, tt.wantInputs) {
+				t.Fatalf("inputs = %v, want %v", req.InputPaths, tt.wantInputs)
+			}
+			if len(req.InputSubdirs) != 0 || len(tt.wantSubdirs) != 0 {
+				if !reflect.DeepEqual(req.InputSubdirs, tt.wantSubdirs) {
+					t.Fatalf("subdirs = %v, want %v", req.InputSubdirs, tt.wantSubdirs)
+				}
+			}
+		})
+	}
+}
+
+func TestExpandInputsGlob(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, "2024/a.jpg", "2024/b.jpg", "2025/c.jpg", "2025/d.png")
+
+	req := models.JobRequestV1{Mode: "batch", InputPaths: []string{filepath.Join(dir, "*", "*.jpg")}, OutputDir: filepath.Join(dir, "out")}
+	if err := expandInputs(newFakeTool("tool.image.test", "jpg"), &req); err != nil {
+		t.Fatalf("unexpected error: %+v", err)
+	}
+
+	want := []string{filepath.Join(dir, "2024", "a.jpg"), filepath.Join(dir, "2024", "b.jpg"), filepath.Join(dir, "2025", "c.jpg")}
+	if !reflect.DeepEqual(req.InputPaths, want) {
+		t.Fatalf("inputs = %v, want %v", req.InputPaths, want)
+	}
+	if got := req.InputSubdirs[filepath.Join(dir, "2025", "c.jpg")]; got != "2025" {
+		t.Fatalf("subdir of c.jpg = %q, want 2025", got)
+	}
+}
+
+// A file whose name contains glob characters is an input, not a pattern.
+func TestExpandInputsExistingPathWithGlobCharacters(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, "photo [1].jpg", "photo 1.jpg")
+	literal := filepath.Join(dir, "photo [1].jpg")
+
+	req := models.JobRequestV1{Mode: "single", InputPaths: []string{literal}}
+	if err := expandInputs(newFakeTool("tool.image.test", "jpg"), &req); err != nil {
+		t.Fatalf("unexpected error: %+v", err)
+	}
+	if !reflect.DeepEqual(req.InputPaths, []string{literal}) {
+		t.Fatalf("inputs = %v, want %v", req.InputPaths, []string{literal})
+	}
+
+	pattern := filepath.Join(dir, "photo [1]?.jpg")
+	req = models.JobRequestV1{Mode: "batch", InputPaths: []string{pattern}}
+	if err := expandInputs(newFakeTool("tool.image.test", "jpg"), &req); err == nil || err.DetailCode != "JOB_INPUT_NOT_FOUND" {
+		t.Fatalf("pattern without matches: error = %+v, want JOB_INPUT_NOT_FOUND", err)
+	}
+}
+
+func TestExpandInputsLeavesFilesUntouched(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, "a.jpg")
+	files := []string{filepath.Join(dir, "a.jpg"), filepath.Join(dir, "missing.jpg"), filepath.Join(dir, "a.jpg")}
+
+	req := models.JobRequestV1{Mode: "batch", InputPaths: append([]string(nil), files...)}
+	if err := expandInputs(newFakeTool("tool.image.test", "jpg"), &req); err != nil {
+		t.Fatalf("unexpected error: %+v", err)
+	}
+	if !reflect.DeepEqual(req.InputPaths, files) {
+		t.Fatalf("inputs = %v, want %v", req.InputPaths, files)
+	}
+}
+
+func TestExpandInputsRejectsInvalidRequests(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, "notes.txt")
+
+	tests := []struct {
+		name string
+		req  models.JobRequestV1
+		want string
+	}{
+		{"empty directory", models.JobRequestV1{InputPaths: []string{dir}}, "JOB_INPUT_NOT_FOUND"},
+		{"invalid filter", models.JobRequestV1{InputPaths: []string{dir}, Expand: &models.InputExpansionV1{Include: []string{"[a-"}}}, "JOB_INPUT_PATTERN_INVALID"},
+		{"escaping subdir", models.JobRequestV1{InputPaths: []string{"a.jpg"}, InputSubdirs: map[string]string{"a.jpg": "../up"}}, "JOB_INPUT_SUBDIR_INVALID"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := tt.req
+			if err := expandInputs(newFakeTool("tool.image.test", "jpg"), &req); err == nil || err.DetailCode != tt.want {
+				t.Fatalf("error = %+v, want %s", err, tt.want)
+			}
+		})
+	}
+}
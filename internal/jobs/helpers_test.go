@@ -0,0 +1,105 @@
+package jobs
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"fileforge-desktop/internal/models"
+	"fileforge-desktop/internal/registry"
+)
+
+// fakeTool is a tool with a fixed manifest whose validation always passes.
+// Batches succeed for every input once release, when set, is closed.
+type fakeTool struct {
+	manifest models.ToolManifestV1
+	started  chan string
+	release  chan struct{}
+}
+
+func newFakeTool(toolID string, extensions ...string) *fakeTool {
+	return &fakeTool{manifest: models.ToolManifestV1{
+		ToolID:          toolID,
+		Version:         "1",
+		SupportsSingle:  true,
+		SupportsBatch:   true,
+		InputExtensions: extensions,
+	}}
+}
+
+func (t *fakeTool) ID() string                      { return t.manifest.ToolID }
+func (t *fakeTool) Capability() string              { return "test" }
+func (t *fakeTool) Manifest() models.ToolManifestV1 { return t.manifest }
+
+func (t *fakeTool) RuntimeState(ctx context.Context) models.ToolRuntimeStateV1 {
+	return models.ToolRuntimeStateV1{Status: "enabled", Healthy: true}
+}
+
+func (t *fakeTool) Validate(ctx context.Context, req models.JobRequestV1) *models.JobErrorV1 {
+	return nil
+}
+
+func (t *fakeTool) ExecuteBatch(ctx context.Context, req models.JobRequestV1, onProgress func(models.JobProgressV1)) ([]models.JobResultItemV1, *models.JobErrorV1) {
+	if t.started != nil {
+		t.started <- req.InputPaths[0]
+	}
+	if t.release != nil {
+		select {
+		case <-t.release:
+		case <-ctx.Done():
+			return nil, models.NewCanonicalJobError("JOB_CANCELLED", ctx.Err().Error(), nil)
+		}
+	}
+
+	items := make([]models.JobResultItemV1, 0, len(req.InputPaths))
+	for _, inputPath := range req.InputPaths {
+		items = append(items, models.JobResultItemV1{InputPath: inputPath, Success: true, OutputPath: inputPath + ".out"})
+	}
+	return items, nil
+}
+
+// newTestOrchestrator returns an orchestrator running one job at a time with
+// tool registered and its state persisted under dir.
+func newTestOrchestrator(t *testing.T, dir string, tool *fakeTool) *Orchestrator {
+	t.Helper()
+	reg := registry.NewRegistry()
+	if err := reg.RegisterToolV2(tool); err != nil {
+		t.Fatal(err)
+	}
+	o := NewOrchestrator(reg, 1)
+	o.SetPersistencePath(filepath.Join(dir, "jobs_state_v1.json"))
+	return o
+}
+
+// waitForStatus polls until the job reaches status or the test times out.
+func waitForStatus(t *testing.T, o *Orchestrator, jobID, status string) models.JobResultV1 {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		result, _ := o.GetJob(jobID)
+		if result.Status == status {
+			return result
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("job %s has status %s, want %s", jobID, result.Status, status)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// writeFiles creates the given files, relative to dir, with their names as
+// content.
+func writeFiles(t *testing.T, dir string, names ...string) {
+	t.Helper()
+	for _, name := range names {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(name), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
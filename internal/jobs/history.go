@@ -0,0 +1,380 @@
+package jobs
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"fileforge-desktop/internal/models"
+)
+
+const (
+	DefaultHistoryMaxAge     = 90 * 24 * time.Hour
+	DefaultHistoryMaxRecords = 5000
+	defaultHistoryPageSize   = 50
+
+	// Appends let the log grow past MaxRecords by this share, and check
+	// MaxAge at most once per interval, so that a full history is compacted
+	// in batches rather than rewritten after every finished job.
+	historyPruneSlackPercent = 10
+	historyAgePruneInterval  = time.Hour
+)
+
+// HistoryRetention bounds how much finished-job history is kept. Zero values
+// disable the corresponding limit.
+type HistoryRetention struct {
+	MaxAge     time.Duration
+	MaxRecords int
+}
+
+func DefaultHistoryRetention() HistoryRetention {
+	return HistoryRetention{MaxAge: DefaultHistoryMaxAge, MaxRecords: DefaultHistoryMaxRecords}
+}
+
+// HistoryStore is an append-only JSON Lines log of finished jobs with an
+// in-memory index. Pruning rewrites the file without the expired records.
+type HistoryStore struct {
+	path      string
+	retention HistoryRetention
+
+	mu           sync.RWMutex
+	records      []models.JobHistoryRecordV1
+	byID         map[string]int
+	lastPrunedAt time.Time
+}
+
+func OpenHistoryStore(path string, retention HistoryRetention) (*HistoryStore, error) {
+	store := &HistoryStore{
+		path:      path,
+		retention: retention,
+		byID:      make(map[string]int),
+	}
+
+	if err := store.load(); err != nil {
+		return nil, err
+	}
+
+	if _, err := store.Prune(time.Now()); err != nil {
+		return nil, err
+	}
+
+	return store, nil
+}
+
+func (s *HistoryStore) load() error {
+	file, err := os.Open(s.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("open job history: %w", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+
+		var record models.JobHistoryRecordV1
+		if err := json.Unmarshal(line, &record); err != nil {
+			// A torn trailing line from a crash must not hide the rest of the history.
+			continue
+		}
+		s.put(record)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("read job history: %w", err)
+	}
+
+	return nil
+}
+
+func (s *HistoryStore) put(record models.JobHistoryRecordV1) {
+	if index, exists := s.byID[record.JobID]; exists {
+		s.records[index] = record
+		return
+	}
+	s.byID[record.JobID] = len(s.records)
+	s.records = append(s.records, record)
+}
+
+func (s *HistoryStore) Append(record models.JobHistoryRecordV1) error {
+	if record.RecordedAt == 0 {
+		record.RecordedAt = time.Now().UnixMilli()
+	}
+
+	payload, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("encode job history record: %w", err)
+	}
+
+	s.mu.Lock()
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		s.mu.Unlock()
+		return fmt.Errorf("create job history directory: %w", err)
+	}
+
+	file, err := os.OpenFile(s.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		s.mu.Unlock()
+		return fmt.Errorf("open job history: %w", err)
+	}
+
+	_, writeErr := file.Write(append(payload, '\n'))
+	closeErr := file.Close()
+	if writeErr != nil {
+		s.mu.Unlock()
+		return fmt.Errorf("append job history: %w", writeErr)
+	}
+	if closeErr != nil {
+		s.mu.Unlock()
+		return fmt.Errorf("close job history: %w", closeErr)
+	}
+
+	s.put(record)
+	needsPrune := s.exceedsRetentionLocked(time.Now())
+	s.mu.Unlock()
+
+	if needsPrune {
+		if _, err := s.Prune(time.Now()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *HistoryStore) Get(jobID string) (models.JobHistoryRecordV1, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	index, ok := s.byID[jobID]
+	if !ok {
+		return models.JobHistoryRecordV1{}, false
+	}
+	return s.records[index], true
+}
+
+// FindByFingerprint returns the successful records with the given fingerprint,
+// newest first.
+func (s *HistoryStore) FindByFingerprint(fingerprint string) []models.JobHistoryRecordV1 {
+	if fingerprint == "" {
+		return nil
+	}
+
+	s.mu.RLock()
+	matches := make([]models.JobHistoryRecordV1, 0)
+	for _, record := range s.records {
+		if record.Fingerprint == fingerprint && record.Status == models.JobStatusSuccess {
+			matches = append(matches, record)
+		}
+	}
+	s.mu.RUnlock()
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		return historyRecordTime(matches[i]) > historyRecordTime(matches[j])
+	})
+	return matches
+}
+
+// WithDependencies returns the records of jobs that depended on other jobs.
+func (s *HistoryStore) WithDependencies() []models.JobHistoryRecordV1 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	matches := make([]models.JobHistoryRecordV1, 0)
+	for _, record := range s.records {
+		if record.Request != nil && len(record.Request.DependsOn) > 0 {
+			matches = append(matches, record)
+		}
+	}
+	return matches
+}
+
+// List returns matching records newest first along with the total number of
+// matches before pagination.
+func (s *HistoryStore) List(query models.JobHistoryQueryV1) ([]models.JobHistoryRecordV1, int) {
+	s.mu.RLock()
+	matches := make([]models.JobHistoryRecordV1, 0)
+	for _, record := range s.records {
+		if historyRecordMatches(record, query) {
+			matches = append(matches, record)
+		}
+	}
+	s.mu.RUnlock()
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].StartedAt > matches[j].StartedAt
+	})
+
+	total := len(matches)
+	offset := max(0, query.Offset)
+	if offset >= total {
+		return []models.JobHistoryRecordV1{}, total
+	}
+
+	limit := query.Limit
+	if limit <= 0 {
+		limit = defaultHistoryPageSize
+	}
+
+	end := min(total, offset+limit)
+	return matches[offset:end], total
+}
+
+// Prune drops records outside the retention policy and compacts the file.
+func (s *HistoryStore) Prune(now time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.lastPrunedAt = now
+	kept := make([]models.JobHistoryRecordV1, 0, len(s.records))
+	cutoff := int64(0)
+	if s.retention.MaxAge > 0 {
+		cutoff = now.Add(-s.retention.MaxAge).UnixMilli()
+	}
+	for _, record := range s.records {
+		if cutoff > 0 && historyRecordTime(record) < cutoff {
+			continue
+		}
+		kept = append(kept, record)
+	}
+
+	if s.retention.MaxRecords > 0 && len(kept) > s.retention.MaxRecords {
+		sort.SliceStable(kept, func(i, j int) bool {
+			return historyRecordTime(kept[i]) < historyRecordTime(kept[j])
+		})
+		kept = kept[len(kept)-s.retention.MaxRecords:]
+	}
+
+	removed := len(s.records) - len(kept)
+	if removed == 0 {
+		return 0, nil
+	}
+
+	if err := s.rewriteLocked(kept); err != nil {
+		return 0, err
+	}
+
+	s.records = kept
+	s.byID = make(map[string]int, len(kept))
+	for index, record := range kept {
+		s.byID[record.JobID] = index
+	}
+
+	return removed, nil
+}
+
+// exceedsRetentionLocked reports whether an append should prune: the log holds
+// more than MaxRecords plus the slack, or the oldest record has expired and
+// the last prune was over historyAgePruneInterval ago.
+func (s *HistoryStore) exceedsRetentionLocked(now time.Time) bool {
+	if s.retention.MaxRecords > 0 && len(s.records) > s.retention.MaxRecords+s.retention.MaxRecords*historyPruneSlackPercent/100 {
+		return true
+	}
+	if s.retention.MaxAge > 0 && len(s.records) > 0 && now.Sub(s.lastPrunedAt) >= historyAgePruneInterval {
+		return historyRecordTime(s.records[0]) < now.Add(-s.retention.MaxAge).UnixMilli()
+	}
+	return false
+}
+
+func (s *HistoryStore) rewriteLocked(records []models.JobHistoryRecordV1) error {
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return fmt.Errorf("create job history directory: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), ".jobs-history-*.tmp")
+	if err != nil {
+		return fmt.Errorf("create job history temp file: %w", err)
+	}
+
+	writer := bufio.NewWriter(tmp)
+	for _, record := range records {
+		payload, err := json.Marshal(record)
+		if err != nil {
+			tmp.Close()
+			os.Remove(tmp.Name())
+			return fmt.Errorf("encode job history record: %w", err)
+		}
+		writer.Write(payload)
+		writer.WriteByte('\n')
+	}
+
+	if err := writer.Flush(); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return fmt.Errorf("write job history: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("close job history temp file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("replace job history: %w", err)
+	}
+
+	return nil
+}
+
+func historyRecordTime(record models.JobHistoryRecordV1) int64 {
+	if record.EndedAt > 0 {
+		return record.EndedAt
+	}
+	if record.StartedAt > 0 {
+		return record.StartedAt
+	}
+	return record.RecordedAt
+}
+
+func historyRecordMatches(record models.JobHistoryRecordV1, query models.JobHistoryQueryV1) bool {
+	if toolID := strings.TrimSpace(query.ToolID); toolID != "" && record.ToolID != toolID {
+		return false
+	}
+	if status := strings.TrimSpace(query.Status); status != "" && record.Status != status {
+		return false
+	}
+	if query.From > 0 && record.StartedAt < query.From {
+		return false
+	}
+	if query.To > 0 && record.StartedAt > query.To {
+		return false
+	}
+
+	needle := strings.ToLower(strings.TrimSpace(query.InputPath))
+	if needle == "" {
+		return true
+	}
+
+	for _, inputPath := range historyRecordInputs(record) {
+		if strings.Contains(strings.ToLower(inputPath), needle) {
+			return true
+		}
+	}
+	return false
+}
+
+func historyRecordInputs(record models.JobHistoryRecordV1) []string {
+	inputs := make([]string, 0)
+	if record.Request != nil {
+		inputs = append(inputs, record.Request.InputPaths...)
+	}
+	if record.Pipeline != nil {
+		inputs = append(inputs, record.Pipeline.InputPaths...)
+	}
+	for _, item := range record.Result.Items {
+		inputs = append(inputs, item.InputPath)
+	}
+	return inputs
+}
@@ -0,0 +1,61 @@
+package jobs
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"fileforge-desktop/internal/models"
+)
+
+func historyLines(t *testing.T, path string) int {
+	t.Helper()
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return bytes.Count(content, []byte("\n"))
+}
+
+// A full history is compacted once the slack is used up, not on every append.
+func TestHistoryAppendPrunesInBatches(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "jobs_history_v1.jsonl")
+	store, err := OpenHistoryStore(path, HistoryRetention{MaxRecords: 20})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now().UnixMilli()
+	appendJob := func(i int) {
+		t.Helper()
+		record := models.JobHistoryRecordV1{JobID: fmt.Sprintf("job_%d", i), Status: StatusSuccess, EndedAt: now + int64(i)}
+		if err := store.Append(record); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for i := range 22 {
+		appendJob(i)
+	}
+	if got := historyLines(t, path); got != 22 {
+		t.Fatalf("history holds %d lines within the slack, want 22", got)
+	}
+
+	appendJob(22)
+	if got := historyLines(t, path); got != 20 {
+		t.Fatalf("history holds %d lines after pruning, want 20", got)
+	}
+	for i, want := range map[int]bool{0: false, 2: false, 3: true, 22: true} {
+		if _, found := store.Get(fmt.Sprintf("job_%d", i)); found != want {
+			t.Fatalf("job_%d found = %v, want %v", i, found, want)
+		}
+	}
+
+	appendJob(23)
+	if got := historyLines(t, path); got != 21 {
+		t.Fatalf("history holds %d lines, want 21 without another rewrite", got)
+	}
+}
@@ -22,16 +22,24 @@ const (
 	StatusPartialSuccess = models.JobStatusPartialSuccess
 	StatusCancelled      = models.JobStatusCancelled
 	StatusInterrupted    = models.JobStatusInterrupted
+	StatusPaused         = models.JobStatusPaused
+	StatusTimedOut       = models.JobStatusTimedOut
+	StatusWaiting        = models.JobStatusWaiting
 )
 
 type Orchestrator struct {
-	registry    *registry.Registry
-	concurrency chan struct{}
-	onProgress  func(models.JobProgressEventV1)
-	storePath   string
-
-	mu   sync.RWMutex
-	jobs map[string]*trackedJob
+	registry   *registry.Registry
+	scheduler  *scheduler
+	onProgress func(models.JobProgressEventV1)
+	storePath  string
+	history    *HistoryStore
+	presets    *PresetStore
+	hashes     *fileHashCache
+	events     *eventLog
+
+	mu        sync.RWMutex
+	jobs      map[string]*trackedJob
+	completed chan struct{}
 }
 
 func NewOrchestrator(reg *registry.Registry, maxConcurrent int) *Orchestrator {
@@ -47,10 +55,12 @@ func NewOrchestratorWithProgressEmitter(reg *registry.Registry, maxConcurrent in
 	}
 
 	return &Orchestrator{
-		registry:    reg,
-		concurrency: make(chan struct{}, maxConcurrent),
-		onProgress:  onProgress,
-		jobs:        make(map[string]*trackedJob),
+		registry:   reg,
+		scheduler:  newScheduler(maxConcurrent, DefaultDomainLimits()),
+		onProgress: onProgress,
+		hashes:     newFileHashCache(),
+		events:     newEventLog(DefaultEventBufferSize),
+		jobs:       make(map[string]*trackedJob),
 	}
 }
 
@@ -60,6 +70,12 @@ func (o *Orchestrator) SetPersistencePath(path string) {
 	o.mu.Unlock()
 }
 
+func (o *Orchestrator) SetHistoryStore(store *HistoryStore) {
+	o.mu.Lock()
+	o.history = store
+	o.mu.Unlock()
+}
+
 func (o *Orchestrator) RecoverInterruptedJobs() error {
 	o.mu.RLock()
 	storePath := o.storePath
@@ -79,86 +95,289 @@ func (o *Orchestrator) RecoverInterruptedJobs() error {
 	}
 
 	now := time.Now().UnixMilli()
+	recovered := make([]*trackedJob, 0, len(persisted))
+	var sweepDirs []string
+
+	waiting := make([]persistedJobV1, 0)
 
 	o.mu.Lock()
-	for _, result := range persisted {
-		if result.Status != StatusQueued && result.Status != StatusRunning {
+	for _, state := range persisted {
+		state.Request = state.restoredRequest()
+		result := state.JobResultV1
+		if !isActiveStatus(result.Status) {
+			continue
+		}
+		if result.Status == StatusWaiting && state.Request != nil {
+			waiting = append(waiting, state)
 			continue
 		}
+		if state.Request != nil {
+			sweepDirs = append(sweepDirs, requestOutputDirs(*state.Request)...)
+		}
 
 		result.Success = false
 		result.Status = StatusInterrupted
 		result.Message = "job interrupted after restart"
 		result.EndedAt = now
 		result.Progress.Stage = StatusInterrupted
+		result.Progress.Item = nil
+		result.Progress.QueuePosition = 0
 		if result.Progress.Total > 0 && result.Progress.Current > result.Progress.Total {
 			result.Progress.Current = result.Progress.Total
 		}
 		if result.Progress.Message == "" {
 			result.Progress.Message = "interrupted"
 		}
+		// Completed items are kept on the interrupted result so ResumeJob can skip them.
+		if len(result.Items) == 0 {
+			result.Items = state.Checkpoints
+		}
 
-		o.jobs[result.JobID] = &trackedJob{result: result}
+		job := &trackedJob{result: result, request: state.Request, pipeline: state.Pipeline, checkpoints: state.Checkpoints}
+		o.jobs[result.JobID] = job
+		recovered = append(recovered, job)
 	}
 	o.mu.Unlock()
 
+	// Jobs that were running when the app stopped may have left the temporary
+	// files of unfinished writes next to their outputs.
+	tools.SweepTempOutputs(sweepDirs)
+
+	for _, job := range recovered {
+		o.recordHistory(job.request, job.pipeline, "", job.snapshot())
+	}
+
+	// Waiting jobs are re-armed after the interrupted ones are registered, so
+	// they see their dependencies as interrupted and keep waiting for a resume.
+	// They only start waiting once all of them are registered and persisted.
+	rearmed := make([]func(), 0, len(waiting))
+	for _, state := range waiting {
+		if rearm, ok := o.restoreWaiting(state); ok {
+			rearmed = append(rearmed, rearm)
+			continue
+		}
+		result := state.JobResultV1
+		result.Status = StatusInterrupted
+		result.Message = "job interrupted after restart"
+		result.EndedAt = now
+		result.Progress.Stage = StatusInterrupted
+		job := &trackedJob{result: result, request: state.Request}
+		o.mu.Lock()
+		o.jobs[result.JobID] = job
+		o.mu.Unlock()
+		o.recordHistory(job.request, nil, "", result)
+	}
+
 	o.persistJobsSnapshot()
+	for _, rearm := range rearmed {
+		rearm()
+	}
 	return nil
 }
 
 func (o *Orchestrator) Submit(ctx context.Context, req models.JobRequestV1) (models.RunJobResponseV1, error) {
+	return o.submit(ctx, req, "")
+}
+
+func (o *Orchestrator) submit(ctx context.Context, req models.JobRequestV1, resumedFrom string) (models.RunJobResponseV1, error) {
 	tool, err := o.registry.GetToolV2(req.ToolID)
 	if err != nil {
 		return models.RunJobResponseV1{}, fmt.Errorf("tool lookup failed: %w", err)
 	}
 
-	if validationErr := tool.Validate(ctx, req); validationErr != nil {
+	// A stored request already carries its preset's options, so resuming does
+	// not depend on the preset still existing.
+	if resumedFrom == "" {
+		if presetErr := o.applyPreset(&req); presetErr != nil {
+			return models.RunJobResponseV1{
+				Success: false,
+				Message: "job validation failed",
+				Status:  StatusFailed,
+				Error:   presetErr,
+			}, nil
+		}
+	}
+
+	if priorityErr := validatePriority(req.Priority); priorityErr != nil {
 		return models.RunJobResponseV1{
 			Success: false,
 			Message: "job validation failed",
 			Status:  StatusFailed,
-			Error:   normalizeJobError(validationErr),
+			Error:   priorityErr,
+		}, nil
+	}
+
+	if timeoutErr := validateTimeouts(req); timeoutErr != nil {
+		return models.RunJobResponseV1{
+			Success: false,
+			Message: "job validation failed",
+			Status:  StatusFailed,
+			Error:   timeoutErr,
 		}, nil
 	}
 
-	jobID := fmt.Sprintf("job_%d", time.Now().UnixNano())
-	startedAt := time.Now().UnixMilli()
-	jobCtx, cancel := context.WithCancel(ctx)
+	if dependencyErr := o.normalizeDependencies(&req); dependencyErr != nil {
+		return models.RunJobResponseV1{
+			Success: false,
+			Message: "job validation failed",
+			Status:  StatusFailed,
+			Error:   dependencyErr,
+		}, nil
+	}
+
+	// Inputs of a dependent job may be produced by its dependencies, so it is
+	// expanded and validated once they are done.
+	if len(req.DependsOn) == 0 {
+		if expandErr := expandInputs(tool, &req); expandErr != nil {
+			return models.RunJobResponseV1{
+				Success: false,
+				Message: "job validation failed",
+				Status:  StatusFailed,
+				Error:   expandErr,
+			}, nil
+		}
+		if validationErr := registry.ValidateRequest(ctx, tool, validationRequest(req)); validationErr != nil {
+			return models.RunJobResponseV1{
+				Success: false,
+				Message: "job validation failed",
+				Status:  StatusFailed,
+				Error:   normalizeJobError(validationErr),
+			}, nil
+		}
+	}
+
+	jobID := newJobID()
+	tracked := o.track(ctx, jobID, req.ToolID, len(req.InputPaths), jobOrigin{request: &req, resumedFrom: resumedFrom})
+
+	if len(req.DependsOn) > 0 {
+		tracked.transition(StatusWaiting, "waiting for dependencies")
+		tracked.emitQueued()
+		go o.awaitDependencies(tracked, tool, req)
+		return models.RunJobResponseV1{
+			Success: true,
+			Message: "job waiting for dependencies",
+			JobID:   jobID,
+			Status:  StatusWaiting,
+		}, nil
+	}
+
+	tracked.emitQueued()
+	return o.start(tracked, tool, req), nil
+}
+
+// start hands the job to its run goroutine; reuse is checked there so that
+// hashing large inputs never blocks the submitting caller.
+func (o *Orchestrator) start(job *trackedJob, tool tools.Tool, req models.JobRequestV1) models.RunJobResponseV1 {
+	go o.run(job, tool, req)
+
+	return models.RunJobResponseV1{
+		Success: true,
+		Message: "job submitted",
+		JobID:   job.snapshot().JobID,
+		Status:  StatusQueued,
+	}
+}
+
+// reuse completes job with the result of an identical earlier job when one
+// is still current.
+func (o *Orchestrator) reuse(job *trackedJob, tool tools.Tool, req models.JobRequestV1) bool {
+	if req.DisableReuse {
+		return false
+	}
+
+	// Inputs that cannot be hashed simply run without reuse.
+	fingerprint, _ := o.jobFingerprint(tool, req)
+	job.mu.Lock()
+	job.fingerprint = fingerprint
+	job.mu.Unlock()
+
+	previous, ok := o.findReusable(fingerprint)
+	if !ok || job.ctx.Err() != nil {
+		return false
+	}
+	items, ok := reusedItems(previous, req)
+	if !ok {
+		return false
+	}
+	job.mu.Lock()
+	job.result.ReusedFrom = previous.JobID
+	job.mu.Unlock()
+	job.complete(StatusSuccess, "job reused previous result", items, nil, time.Now().UnixMilli())
+	return true
+}
+
+func newJobID() string {
+	return fmt.Sprintf("job_%d", time.Now().UnixNano())
+}
+
+// jobOrigin is what a job was submitted from. track stores it before the job
+// is first persisted, so a queued job can be resumed after a crash.
+type jobOrigin struct {
+	request     *models.JobRequestV1
+	pipeline    *models.PipelineRequestV1
+	resumedFrom string
+}
+
+func (o *Orchestrator) track(ctx context.Context, jobID, toolID string, total int, origin jobOrigin) *trackedJob {
+	tracked := o.newTrackedJob(ctx, jobID, toolID, total, origin)
+
+	o.mu.Lock()
+	o.jobs[jobID] = tracked
+	o.mu.Unlock()
+	o.persistJobsSnapshot()
+
+	return tracked
+}
+
+// newTrackedJob builds a queued job without registering or persisting it.
+func (o *Orchestrator) newTrackedJob(ctx context.Context, jobID, toolID string, total int, origin jobOrigin) *trackedJob {
+	itemCancels := tools.NewItemCancellation()
+	tempOutputs := tools.NewTempOutputs()
+	jobCtx, cancel := context.WithCancel(tools.WithTempOutputs(tools.WithItemCancellation(ctx, itemCancels), tempOutputs))
 
 	tracked := &trackedJob{
 		ctx:             jobCtx,
 		cancel:          cancel,
+		itemCancels:     itemCancels,
+		tempOutputs:     tempOutputs,
 		onProgressEvent: o.onProgress,
 		onStateChanged:  o.persistJobsSnapshot,
+		publish:         o.events.publish,
+		request:         origin.request,
+		pipeline:        origin.pipeline,
 		result: models.JobResultV1{
-			JobID:     jobID,
-			Success:   false,
-			Message:   "job queued",
-			ToolID:    req.ToolID,
-			Status:    StatusQueued,
-			Progress:  models.JobProgressV1{Current: 0, Total: len(req.InputPaths), Stage: StatusQueued, Message: "queued"},
-			StartedAt: startedAt,
+			JobID:       jobID,
+			Success:     false,
+			Message:     "job queued",
+			ToolID:      toolID,
+			Status:      StatusQueued,
+			Progress:    models.JobProgressV1{Current: 0, Total: total, Stage: StatusQueued, Message: "queued"},
+			StartedAt:   time.Now().UnixMilli(),
+			ResumedFrom: origin.resumedFrom,
 		},
 	}
 
-	o.mu.Lock()
-	o.jobs[jobID] = tracked
-	o.mu.Unlock()
-	o.persistJobsSnapshot()
-
-	go o.run(tracked, tool, req)
+	tracked.onCompleted = func(result models.JobResultV1) {
+		o.recordHistory(tracked.request, tracked.pipeline, tracked.fingerprint, result)
+		o.signalCompletion()
+	}
 
-	return models.RunJobResponseV1{
-		Success: true,
-		Message: "job submitted",
-		JobID:   jobID,
-		Status:  StatusQueued,
-	}, nil
+	return tracked
 }
 
 func (o *Orchestrator) run(job *trackedJob, tool tools.Tool, req models.JobRequestV1) {
-	o.concurrency <- struct{}{}
-	defer func() { <-o.concurrency }()
+	if o.reuse(job, tool, req) {
+		return
+	}
+
+	slot, err := o.scheduler.acquire(job.ctx, job, toolDomain(req.ToolID), priorityRank(req.Priority))
+	if err != nil {
+		job.complete(StatusCancelled, "job cancelled", nil, models.NewJobError(models.ErrorCodeCancelledByUser, "JOB_CANCELLED", err.Error(), nil), time.Now().UnixMilli())
+		return
+	}
+	defer o.scheduler.release(slot)
+	stopDeadline := job.startDeadline(resolveTimeouts(tool, req).job)
+	defer stopDeadline()
 
 	job.updateProgress(models.JobProgressV1{
 		Current: 0,
@@ -167,95 +386,76 @@ func (o *Orchestrator) run(job *trackedJob, tool tools.Tool, req models.JobReque
 		Message: "running",
 	})
 
-	select {
-	case <-job.ctx.Done():
-		job.complete(StatusCancelled, "job cancelled", nil, models.NewJobError(models.ErrorCodeCancelledByUser, "JOB_CANCELLED", job.ctx.Err().Error(), nil), time.Now().UnixMilli())
+	if job.ctx.Err() != nil {
+		stopped := stoppedOutcome(job, nil, nil)
+		job.complete(stopped.status, stopped.message, nil, stopped.err, time.Now().UnixMilli())
 		return
-	default:
 	}
 
+	outcome := o.execute(job, tool, req, job.updateProgress)
+	job.complete(outcome.status, outcome.message, outcome.items, outcome.err, time.Now().UnixMilli())
+}
+
+// jobOutcome is the terminal state of one tool execution, before it is
+// recorded on the tracked job.
+type jobOutcome struct {
+	status  string
+	message string
+	items   []models.JobResultItemV1
+	err     *models.JobErrorV1
+}
+
+func (o *Orchestrator) execute(job *trackedJob, tool tools.Tool, req models.JobRequestV1, onProgress func(models.JobProgressV1)) jobOutcome {
 	if req.Mode == "single" {
-		o.runSingle(job, tool, req)
-		return
+		return o.runSingle(job, tool, req, onProgress)
 	}
 
-	o.runBatch(job, tool, req)
+	return o.runBatch(job, tool, req, onProgress)
 }
 
-func (o *Orchestrator) runSingle(job *trackedJob, tool tools.Tool, req models.JobRequestV1) {
+func (o *Orchestrator) runSingle(job *trackedJob, tool tools.Tool, req models.JobRequestV1, onProgress func(models.JobProgressV1)) jobOutcome {
+	var execute func(ctx context.Context) (models.JobResultItemV1, *models.JobErrorV1)
 	if execWithProgress, ok := tool.(tools.SingleExecutorWithProgress); ok {
-		item, err, attempts := o.executeSingleWithRetry(job, req.ToolID, func() (models.JobResultItemV1, *models.JobErrorV1) {
-			return execWithProgress.ExecuteSingleWithProgress(job.ctx, req, func(progress models.JobProgressV1) {
-				job.updateProgress(progress)
-			})
-		})
-		item = normalizeItemError(item)
-		item.Attempts = attempts
-		item.RetryCount = max(0, attempts-1)
-		if err != nil {
-			job.complete(StatusFailed, "job failed", []models.JobResultItemV1{item}, normalizeJobError(err), time.Now().UnixMilli())
-			return
-		}
-
-		job.complete(StatusSuccess, "job success", []models.JobResultItemV1{item}, nil, time.Now().UnixMilli())
-		return
-	}
-
-	exec, ok := tool.(tools.SingleExecutor)
-	if !ok {
-		job.complete(StatusFailed, "tool does not support single execution", nil, models.NewCanonicalJobError("UNSUPPORTED_MODE", "single execution not supported", nil), time.Now().UnixMilli())
-		return
+		execute = func(ctx context.Context) (models.JobResultItemV1, *models.JobErrorV1) {
+			return execWithProgress.ExecuteSingleWithProgress(ctx, req, onProgress)
+		}
+	} else if exec, ok := tool.(tools.SingleExecutor); ok {
+		execute = func(ctx context.Context) (models.JobResultItemV1, *models.JobErrorV1) {
+			return exec.ExecuteSingle(ctx, req)
+		}
+	} else {
+		return jobOutcome{status: StatusFailed, message: "tool does not support single execution", err: models.NewCanonicalJobError("UNSUPPORTED_MODE", "single execution not supported", nil)}
 	}
 
-	item, err, attempts := o.executeSingleWithRetry(job, req.ToolID, func() (models.JobResultItemV1, *models.JobErrorV1) {
-		return exec.ExecuteSingle(job.ctx, req)
-	})
+	item, err, attempts := o.executeSingleWithRetry(job, resolveRetryPolicy(tool, req), withItemTimeout(resolveTimeouts(tool, req).item, execute))
 	item = normalizeItemError(item)
 	item.Attempts = attempts
 	item.RetryCount = max(0, attempts-1)
+	if err != nil && job.ctx.Err() != nil {
+		return stoppedOutcome(job, []models.JobResultItemV1{item}, retryMetadata(attempts, err))
+	}
 	if err != nil {
-		job.complete(StatusFailed, "job failed", []models.JobResultItemV1{item}, normalizeJobError(err), time.Now().UnixMilli())
-		return
+		return jobOutcome{status: StatusFailed, message: "job failed", items: []models.JobResultItemV1{item}, err: normalizeJobError(err)}
 	}
 
-	job.complete(StatusSuccess, "job success", []models.JobResultItemV1{item}, nil, time.Now().UnixMilli())
+	return jobOutcome{status: StatusSuccess, message: "job success", items: []models.JobResultItemV1{item}}
 }
 
-func (o *Orchestrator) runBatch(job *trackedJob, tool tools.Tool, req models.JobRequestV1) {
+func (o *Orchestrator) runBatch(job *trackedJob, tool tools.Tool, req models.JobRequestV1, onProgress func(models.JobProgressV1)) jobOutcome {
 	exec, ok := tool.(tools.BatchExecutor)
 	if !ok {
-		job.complete(StatusFailed, "tool does not support batch execution", nil, models.NewCanonicalJobError("UNSUPPORTED_MODE", "batch execution not supported", nil), time.Now().UnixMilli())
-		return
-	}
-
-	attempts := 0
-	var items []models.JobResultItemV1
-	var err *models.JobErrorV1
-
-	for attempts < maxRetryAttempts {
-		attempts++
-		items, err = exec.ExecuteBatch(job.ctx, req, func(progress models.JobProgressV1) {
-			job.updateProgress(progress)
-		})
-
-		if err == nil || !isRetryableError(req.ToolID, err) || attempts >= maxRetryAttempts {
-			break
-		}
-
-		if !waitRetryBackoff(job.ctx, attempts) {
-			items = normalizeItemsErrors(items)
-			items = o.decorateBatchRetryMetadata(items, attempts)
-			job.complete(StatusCancelled, "job cancelled", items, models.NewCanonicalJobError("JOB_CANCELLED", job.ctx.Err().Error(), retryMetadata(attempts, err)), time.Now().UnixMilli())
-			return
-		}
+		return jobOutcome{status: StatusFailed, message: "tool does not support batch execution", err: models.NewCanonicalJobError("UNSUPPORTED_MODE", "batch execution not supported", nil)}
 	}
 
+	items, err, attempts := o.executeBatchInSubdirs(job, resolveRetryPolicy(tool, req), resolveTimeouts(tool, req).item, exec, req, onProgress)
 	items = normalizeItemsErrors(items)
-	items = o.decorateBatchRetryMetadata(items, attempts)
+	if job.ctx.Err() == context.DeadlineExceeded {
+		// Tools report the items interrupted by the deadline as plain failures.
+		return stoppedOutcome(job, items, retryMetadata(attempts, err))
+	}
 	if err != nil {
 		if job.ctx.Err() != nil {
-			job.complete(StatusCancelled, "job cancelled", items, models.NewCanonicalJobError("JOB_CANCELLED", job.ctx.Err().Error(), retryMetadata(attempts, err)), time.Now().UnixMilli())
-			return
+			return stoppedOutcome(job, items, retryMetadata(attempts, err))
 		}
 		status, message := deriveBatchFinalState(items)
 		jobErr := normalizeJobError(err)
@@ -267,29 +467,31 @@ func (o *Orchestrator) runBatch(job *trackedJob, tool tools.Tool, req models.Job
 				jobErr.Details[k] = v
 			}
 		}
-		job.complete(status, message, items, jobErr, time.Now().UnixMilli())
-		return
+		return jobOutcome{status: status, message: message, items: items, err: jobErr}
 	}
 
 	status, message := deriveBatchFinalState(items)
-	job.complete(status, message, items, nil, time.Now().UnixMilli())
+	return jobOutcome{status: status, message: message, items: items}
 }
 
-func (o *Orchestrator) executeSingleWithRetry(job *trackedJob, toolID string, execute func() (models.JobResultItemV1, *models.JobErrorV1)) (models.JobResultItemV1, *models.JobErrorV1, int) {
+func (o *Orchestrator) executeSingleWithRetry(job *trackedJob, policy retryPolicy, execute func(ctx context.Context) (models.JobResultItemV1, *models.JobErrorV1)) (models.JobResultItemV1, *models.JobErrorV1, int) {
 	attempts := 0
 	var item models.JobResultItemV1
 	var jobErr *models.JobErrorV1
 
-	for attempts < maxRetryAttempts {
+	for attempts < policy.maxAttempts {
 		attempts++
-		item, jobErr = execute()
+		attemptCtx, cancel := policy.attemptContext(job.ctx)
+		item, jobErr = execute(attemptCtx)
+		jobErr = policy.attemptTimeoutError(job.ctx, attemptCtx, jobErr)
+		cancel()
 
 		if jobErr == nil {
 			return item, nil, attempts
 		}
 
-		if !isRetryableError(toolID, jobErr) || attempts >= maxRetryAttempts {
-			if item.Error == nil {
+		if !policy.isRetryable(jobErr) || attempts >= policy.maxAttempts {
+			if item.Error == nil || jobErr.DetailCode == attemptTimeoutDetailCode {
 				item.Error = jobErr
 			}
 			if item.Error.Details == nil {
@@ -301,7 +503,7 @@ func (o *Orchestrator) executeSingleWithRetry(job *trackedJob, toolID string, ex
 			return item, jobErr, attempts
 		}
 
-		if !waitRetryBackoff(job.ctx, attempts) {
+		if !waitRetryBackoff(job, policy, attempts, nil, jobErr) {
 			cancelErr := models.NewCanonicalJobError("JOB_CANCELLED", job.ctx.Err().Error(), retryMetadata(attempts, jobErr))
 			if item.Error == nil {
 				item.Error = cancelErr
@@ -319,28 +521,238 @@ func (o *Orchestrator) executeSingleWithRetry(job *trackedJob, toolID string, ex
 	return item, jobErr, attempts
 }
 
-func (o *Orchestrator) decorateBatchRetryMetadata(items []models.JobResultItemV1, attempts int) []models.JobResultItemV1 {
-	decorated := make([]models.JobResultItemV1, len(items))
+// executeBatchInSubdirs creates the output sub-directories of inputs expanded
+// from a directory tree and then runs all inputs as one batch, each writing
+// into its own sub-directory. Inputs whose sub-directory cannot be created
+// fail without running.
+func (o *Orchestrator) executeBatchInSubdirs(job *trackedJob, policy retryPolicy, itemTimeout time.Duration, exec tools.BatchExecutor, req models.JobRequestV1, onProgress func(models.JobProgressV1)) ([]models.JobResultItemV1, *models.JobErrorV1, int) {
+	root := planOutputDir(req)
+	unavailable := make(map[string]*models.JobErrorV1)
+	var firstErr *models.JobErrorV1
+	for _, subdir := range outputSubdirs(req) {
+		dir := filepath.Join(root, subdir)
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			unavailable[subdir] = models.NewCanonicalJobError("JOB_OUTPUT_DIR_CREATE_FAILED", err.Error(), map[string]any{"outputDir": dir})
+			if firstErr == nil {
+				firstErr = unavailable[subdir]
+			}
+		}
+	}
+	if len(unavailable) == 0 {
+		return o.executeBatchWithRetry(job, policy, itemTimeout, exec, req, onProgress)
+	}
+
+	total := len(req.InputPaths)
+	items := make([]models.JobResultItemV1, 0, total)
+	runnable := make([]string, 0, total)
+	for _, inputPath := range req.InputPaths {
+		if jobErr, ok := unavailable[req.InputSubdirs[inputPath]]; ok {
+			items = append(items, models.JobResultItemV1{InputPath: inputPath, Success: false, Message: "output directory could not be created", Error: jobErr})
+			continue
+		}
+		runnable = append(runnable, inputPath)
+	}
+	if len(runnable) == 0 {
+		return items, firstErr, 0
+	}
+
+	runReq := req
+	runReq.InputPaths = runnable
+	done := len(items)
+	batchItems, batchErr, attempts := o.executeBatchWithRetry(job, policy, itemTimeout, exec, runReq, func(progress models.JobProgressV1) {
+		progress.Current += done
+		progress.Total = total
+		onProgress(progress)
+	})
+	if batchErr != nil {
+		firstErr = batchErr
+	}
+	return append(items, batchItems...), firstErr, attempts
+}
+
+// executeBatchWithRetry runs the whole batch once and then re-runs only the
+// inputs whose items failed with a retryable error, merging the new items in
+// place. The returned attempt count is the number of ExecuteBatch calls.
+func (o *Orchestrator) executeBatchWithRetry(job *trackedJob, policy retryPolicy, itemTimeout time.Duration, exec tools.BatchExecutor, req models.JobRequestV1, onProgress func(models.JobProgressV1)) ([]models.JobResultItemV1, *models.JobErrorV1, int) {
+	total := len(req.InputPaths)
+	pending := req.InputPaths
+	items := make([]models.JobResultItemV1, 0, total)
+	indexByID := make(map[string]int, total)
+	attemptsByID := make(map[string]int, total)
+	attempts := 0
+	var lastErr *models.JobErrorV1
+
+	for {
+		attempts++
+		attemptReq := req
+		attemptReq.InputPaths = pending
+		attemptProgress := onProgress
+		if attempts > 1 {
+			done := total - len(pending)
+			attemptProgress = func(progress models.JobProgressV1) {
+				progress.Current += done
+				progress.Total = total
+				onProgress(progress)
+			}
+		}
+
+		attemptCtx, cancel := policy.attemptContext(job.ctx)
+		batchItems, batchErr := exec.ExecuteBatch(tools.WithItemTimeout(attemptCtx, itemTimeout), attemptReq, attemptProgress)
+		timedOut := job.ctx.Err() == nil && attemptCtx.Err() == context.DeadlineExceeded
+		batchErr = policy.attemptTimeoutError(job.ctx, attemptCtx, batchErr)
+		cancel()
+		markTimedOutItems(job, itemTimeout, batchItems)
+		lastErr = batchErr
+
+		for _, inputPath := range pending {
+			attemptsByID[models.ItemIDV1(inputPath)]++
+		}
+		for _, item := range batchItems {
+			itemID := models.ItemIDV1(item.InputPath)
+			if index, ok := indexByID[itemID]; ok && itemID != "" {
+				items[index] = item
+				continue
+			}
+			indexByID[itemID] = len(items)
+			items = append(items, item)
+		}
+
+		if job.ctx.Err() != nil || attempts >= policy.maxAttempts {
+			break
+		}
+
+		retry := retryableInputs(policy, pending, batchItems, batchErr, timedOut)
+		if len(retry) == 0 {
+			break
+		}
+
+		retryErr := batchErr
+		if retryErr == nil {
+			retryErr = firstItemFailure(batchItems)
+		}
+		if !waitRetryBackoff(job, policy, attempts, retry, retryErr) {
+			lastErr = models.NewCanonicalJobError("JOB_CANCELLED", job.ctx.Err().Error(), retryMetadata(attempts, lastErr))
+			break
+		}
+		pending = retry
+	}
+
 	for i := range items {
-		item := items[i]
-		item.Attempts = attempts
-		item.RetryCount = max(0, attempts-1)
-		decorated[i] = item
+		itemAttempts := max(attemptsByID[models.ItemIDV1(items[i].InputPath)], 1)
+		items[i].Attempts = itemAttempts
+		items[i].RetryCount = itemAttempts - 1
+	}
+
+	if lastErr == nil && attempts > 1 {
+		lastErr = firstItemFailure(items)
+	}
+
+	return items, lastErr, attempts
+}
+
+func retryableInputs(policy retryPolicy, pending []string, items []models.JobResultItemV1, batchErr *models.JobErrorV1, timedOut bool) []string {
+	byID := make(map[string]models.JobResultItemV1, len(items))
+	for _, item := range items {
+		byID[models.ItemIDV1(item.InputPath)] = item
+	}
+
+	retry := make([]string, 0)
+	for _, inputPath := range pending {
+		item, ok := byID[models.ItemIDV1(inputPath)]
+		switch {
+		case !ok:
+			if policy.isRetryable(batchErr) {
+				retry = append(retry, inputPath)
+			}
+		case item.Success || item.Status == models.JobItemStatusCancelled:
+		case timedOut || policy.isRetryable(item.Error):
+			retry = append(retry, inputPath)
+		}
+	}
+	return retry
+}
+
+func firstItemFailure(items []models.JobResultItemV1) *models.JobErrorV1 {
+	for _, item := range items {
+		if !item.Success && item.Error != nil && item.Status != models.JobItemStatusCancelled {
+			return item.Error
+		}
 	}
-	return decorated
+	return nil
 }
 
 func (o *Orchestrator) GetJob(jobID string) (models.JobResultV1, bool) {
 	o.mu.RLock()
 	job, ok := o.jobs[jobID]
+	history := o.history
 	o.mu.RUnlock()
 	if !ok {
+		if history != nil {
+			if record, found := history.Get(jobID); found {
+				return record.Result, true
+			}
+		}
 		return models.JobResultV1{}, false
 	}
 
 	return job.snapshot(), true
 }
 
+// ListJobHistory returns finished jobs from the history store, newest first.
+func (o *Orchestrator) ListJobHistory(query models.JobHistoryQueryV1) ([]models.JobHistoryRecordV1, int) {
+	o.mu.RLock()
+	history := o.history
+	o.mu.RUnlock()
+	if history == nil {
+		return []models.JobHistoryRecordV1{}, 0
+	}
+
+	return history.List(query)
+}
+
+func (o *Orchestrator) GetJobHistory(jobID string) (models.JobHistoryRecordV1, bool) {
+	o.mu.RLock()
+	history := o.history
+	o.mu.RUnlock()
+	if history == nil {
+		return models.JobHistoryRecordV1{}, false
+	}
+
+	return history.Get(jobID)
+}
+
+func (o *Orchestrator) recordHistory(req *models.JobRequestV1, pipeline *models.PipelineRequestV1, fingerprint string, result models.JobResultV1) {
+	o.mu.RLock()
+	history := o.history
+	o.mu.RUnlock()
+	if history == nil {
+		return
+	}
+
+	record := models.JobHistoryRecordV1{
+		JobID:     result.JobID,
+		ToolID:    result.ToolID,
+		Status:    result.Status,
+		Request:   req,
+		Pipeline:  pipeline,
+		Result:    result,
+		StartedAt: result.StartedAt,
+		EndedAt:   result.EndedAt,
+	}
+	if result.EndedAt > result.StartedAt && result.StartedAt > 0 {
+		record.DurationMillis = result.EndedAt - result.StartedAt
+	}
+	if req != nil {
+		record.InputSubdirs = req.InputSubdirs
+	}
+	if fingerprint != "" && result.Status == StatusSuccess {
+		record.Fingerprint = fingerprint
+		record.OutputStamps = stampOutputs(result.Items)
+	}
+
+	_ = history.Append(record)
+}
+
 func (o *Orchestrator) Cancel(jobID string) error {
 	o.mu.RLock()
 	job, ok := o.jobs[jobID]
@@ -355,6 +767,109 @@ func (o *Orchestrator) Cancel(jobID string) error {
 	return nil
 }
 
+// persistedJobV1 is the on-disk form of an active job. The embedded result keeps
+// the file compatible with snapshots written before requests were stored.
+type persistedJobV1 struct {
+	models.JobResultV1
+	Request     *models.JobRequestV1      `json:"request,omitempty"`
+	Pipeline    *models.PipelineRequestV1 `json:"pipeline,omitempty"`
+	Checkpoints []models.JobResultItemV1  `json:"checkpoints,omitempty"`
+	// InputSubdirs holds Request.InputSubdirs, which the request does not serialize.
+	InputSubdirs map[string]string `json:"inputSubdirs,omitempty"`
+}
+
+// restoredRequest returns the persisted request with its input sub-directories.
+func (state persistedJobV1) restoredRequest() *models.JobRequestV1 {
+	if state.Request == nil || len(state.InputSubdirs) == 0 {
+		return state.Request
+	}
+	req := *state.Request
+	req.InputSubdirs = state.InputSubdirs
+	return &req
+}
+
+// CancelItem drops one input of an active batch job. The executor reports the
+// item as cancelled and keeps processing the remaining inputs.
+func (o *Orchestrator) CancelItem(jobID, inputPath string) (string, error) {
+	job, err := o.lookupJob(jobID)
+	if err != nil {
+		return "", err
+	}
+
+	job.mu.RLock()
+	status := job.result.Status
+	req := job.request
+	job.mu.RUnlock()
+
+	if !isActiveStatus(status) || job.itemCancels == nil {
+		return "", fmt.Errorf("job '%s' is not active", jobID)
+	}
+
+	if req != nil {
+		itemID := models.ItemIDV1(inputPath)
+		known := false
+		for _, candidate := range req.InputPaths {
+			if models.ItemIDV1(candidate) == itemID {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return "", fmt.Errorf("input '%s' is not part of job '%s'", inputPath, jobID)
+		}
+	}
+
+	return job.itemCancels.Cancel(inputPath), nil
+}
+
+// MoveQueued places a waiting job at a 1-based position in the scheduler queue
+// and returns the position it ended up at.
+func (o *Orchestrator) MoveQueued(jobID string, position int) (int, error) {
+	job, err := o.lookupJob(jobID)
+	if err != nil {
+		return 0, err
+	}
+
+	final, ok := o.scheduler.move(job, position)
+	if !ok {
+		return 0, fmt.Errorf("job '%s' is not queued", jobID)
+	}
+	return final, nil
+}
+
+// PauseQueued keeps a waiting job in the queue without letting it start.
+func (o *Orchestrator) PauseQueued(jobID string) (int, error) {
+	return o.setQueuedPaused(jobID, true)
+}
+
+// ResumeQueued makes a paused job eligible to start again.
+func (o *Orchestrator) ResumeQueued(jobID string) (int, error) {
+	return o.setQueuedPaused(jobID, false)
+}
+
+func (o *Orchestrator) setQueuedPaused(jobID string, paused bool) (int, error) {
+	job, err := o.lookupJob(jobID)
+	if err != nil {
+		return 0, err
+	}
+
+	position, ok := o.scheduler.setPaused(job, paused)
+	if !ok {
+		return 0, fmt.Errorf("job '%s' is not queued", jobID)
+	}
+	return position, nil
+}
+
+func (o *Orchestrator) lookupJob(jobID string) (*trackedJob, error) {
+	o.mu.RLock()
+	job, ok := o.jobs[jobID]
+	o.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("job '%s' not found", jobID)
+	}
+	return job, nil
+}
+
 func (o *Orchestrator) persistJobsSnapshot() {
 	o.mu.RLock()
 	storePath := o.storePath
@@ -363,11 +878,11 @@ func (o *Orchestrator) persistJobsSnapshot() {
 		return
 	}
 
-	persisted := make([]models.JobResultV1, 0)
+	persisted := make([]persistedJobV1, 0)
 	for _, job := range o.jobs {
-		snapshot := job.snapshot()
-		if snapshot.Status == StatusQueued || snapshot.Status == StatusRunning {
-			persisted = append(persisted, snapshot)
+		state := job.persisted()
+		if isActiveStatus(state.Status) {
+			persisted = append(persisted, state)
 		}
 	}
 	o.mu.RUnlock()
@@ -375,7 +890,7 @@ func (o *Orchestrator) persistJobsSnapshot() {
 	_ = o.savePersistedJobs(storePath, persisted)
 }
 
-func (o *Orchestrator) loadPersistedJobs(path string) ([]models.JobResultV1, error) {
+func (o *Orchestrator) loadPersistedJobs(path string) ([]persistedJobV1, error) {
 	content, err := os.ReadFile(path)
 	if err != nil {
 		if os.IsNotExist(err) {
@@ -388,7 +903,7 @@ func (o *Orchestrator) loadPersistedJobs(path string) ([]models.JobResultV1, err
 		return nil, nil
 	}
 
-	jobs := make([]models.JobResultV1, 0)
+	jobs := make([]persistedJobV1, 0)
 	if err := json.Unmarshal(content, &jobs); err != nil {
 		return nil, fmt.Errorf("decode persisted jobs: %w", err)
 	}
@@ -396,7 +911,7 @@ func (o *Orchestrator) loadPersistedJobs(path string) ([]models.JobResultV1, err
 	return jobs, nil
 }
 
-func (o *Orchestrator) savePersistedJobs(path string, persisted []models.JobResultV1) error {
+func (o *Orchestrator) savePersistedJobs(path string, persisted []persistedJobV1) error {
 	directory := filepath.Dir(path)
 	if err := os.MkdirAll(directory, 0o755); err != nil {
 		return fmt.Errorf("create persistence directory: %w", err)
@@ -425,7 +940,25 @@ func (o *Orchestrator) Validate(ctx context.Context, req models.JobRequestV1) mo
 		}
 	}
 
-	if validationErr := tool.Validate(ctx, req); validationErr != nil {
+	if presetErr := o.applyPreset(&req); presetErr != nil {
+		return models.ValidateJobResponseV1{
+			Success: false,
+			Message: "validation failed",
+			Valid:   false,
+			Error:   presetErr,
+		}
+	}
+
+	if expandErr := expandInputs(tool, &req); expandErr != nil {
+		return models.ValidateJobResponseV1{
+			Success: false,
+			Message: "validation failed",
+			Valid:   false,
+			Error:   expandErr,
+		}
+	}
+
+	if validationErr := registry.ValidateRequest(ctx, tool, validationRequest(req)); validationErr != nil {
 		return models.ValidateJobResponseV1{
 			Success: false,
 			Message: "validation failed",
@@ -441,6 +974,91 @@ func (o *Orchestrator) Validate(ctx context.Context, req models.JobRequestV1) mo
 	}
 }
 
+// Plan works out what req would write without running it. Problems with
+// single inputs of a batch are reported on their plan items rather than
+// failing the whole request, which is why the tool's Validate is not run.
+func (o *Orchestrator) Plan(ctx context.Context, req models.JobRequestV1) models.PlanJobResponseV1 {
+	tool, err := o.registry.GetToolV2(req.ToolID)
+	if err != nil {
+		return models.PlanJobResponseV1{
+			Success: false,
+			Message: "tool not found",
+			Error:   models.NewCanonicalJobError("TOOL_NOT_FOUND", err.Error(), nil),
+		}
+	}
+
+	planner, ok := tool.(tools.Planner)
+	if !ok {
+		return models.PlanJobResponseV1{
+			Success: false,
+			Message: "tool does not support planning",
+			Error:   models.NewCanonicalJobError("PLAN_UNSUPPORTED", "dry run not supported by "+tool.ID(), nil),
+		}
+	}
+
+	if presetErr := o.applyPreset(&req); presetErr != nil {
+		return models.PlanJobResponseV1{Success: false, Message: "plan failed", Error: presetErr}
+	}
+
+	if expandErr := expandInputs(tool, &req); expandErr != nil {
+		return models.PlanJobResponseV1{Success: false, Message: "plan failed", Error: expandErr}
+	}
+
+	if requestErr := registry.ValidateRequestOptions(tool, req); requestErr != nil {
+		return models.PlanJobResponseV1{Success: false, Message: "plan failed", Error: normalizeJobError(requestErr)}
+	}
+
+	planReq, missing := withExistingSubdirs(req)
+	toolPlan, planErr := planner.Plan(ctx, planReq)
+	if planErr != nil {
+		return models.PlanJobResponseV1{Success: false, Message: "plan failed", Error: normalizeJobError(planErr)}
+	}
+	items := toolPlan.Items
+	relocatePlannedOutputs(items, planOutputDir(req), missing)
+	for i := range items {
+		items[i].Error = normalizeJobError(items[i].Error)
+	}
+
+	plan := tools.NewJobPlan(tool.ID(), req.Mode, items)
+	return models.PlanJobResponseV1{
+		Success: true,
+		Message: fmt.Sprintf("%d outputs planned", plan.OutputCount),
+		Plan:    &plan,
+	}
+}
+
+// relocatePlannedOutputs moves the planned outputs of inputs whose output
+// sub-directory does not exist yet from root, where they were planned, into
+// that sub-directory. Nothing exists there yet, so each output is created.
+func relocatePlannedOutputs(items []models.PlanItemV1, root string, missing map[string]string) {
+	for i := range items {
+		item := &items[i]
+		subdir, ok := missing[item.InputPath]
+		if !ok {
+			continue
+		}
+		for j := range item.Outputs {
+			output := &item.Outputs[j]
+			path := output.Path
+			if output.RequestedPath != "" {
+				path = output.RequestedPath
+			}
+			if rel, relErr := filepath.Rel(root, path); relErr == nil {
+				output.Path = filepath.Join(root, subdir, rel)
+			}
+			output.Action = models.PlanActionCreate
+			output.RequestedPath = ""
+		}
+		if len(item.Outputs) > 0 {
+			item.Warnings = []string{"output directory will be created: " + filepath.Join(root, subdir)}
+		}
+	}
+}
+
+func isActiveStatus(status string) bool {
+	return status == StatusQueued || status == StatusPaused || status == StatusWaiting || status == StatusRunning
+}
+
 func normalizeJobError(jobErr *models.JobErrorV1) *models.JobErrorV1 {
 	if jobErr == nil {
 		return nil
@@ -470,6 +1088,30 @@ func normalizeItemError(item models.JobResultItemV1) models.JobResultItemV1 {
 	return item
 }
 
+func identifyItem(item models.JobResultItemV1) models.JobResultItemV1 {
+	if item.ItemID == "" {
+		item.ItemID = models.ItemIDV1(item.InputPath)
+	}
+	if item.Status == "" {
+		item.Status = models.JobItemStatusFailed
+		if item.Success {
+			item.Status = models.JobItemStatusSuccess
+		}
+	}
+	return item
+}
+
+func identifyItems(items []models.JobResultItemV1) []models.JobResultItemV1 {
+	if items == nil {
+		return nil
+	}
+	identified := make([]models.JobResultItemV1, len(items))
+	for i := range items {
+		identified[i] = identifyItem(items[i])
+	}
+	return identified
+}
+
 func normalizeItemsErrors(items []models.JobResultItemV1) []models.JobResultItemV1 {
 	normalized := make([]models.JobResultItemV1, len(items))
 	for i := range items {
@@ -478,20 +1120,28 @@ func normalizeItemsErrors(items []models.JobResultItemV1) []models.JobResultItem
 	return normalized
 }
 
+// deriveBatchFinalState ignores items cancelled one by one: dropping a file
+// from a batch does not turn an otherwise successful run into a failure.
 func deriveBatchFinalState(items []models.JobResultItemV1) (string, string) {
 	if len(items) == 0 {
 		return StatusFailed, "job failed"
 	}
 
 	successCount := 0
+	cancelledCount := 0
 	for _, item := range items {
-		if item.Success {
+		switch {
+		case item.Success:
 			successCount++
+		case item.Status == models.JobItemStatusCancelled:
+			cancelledCount++
 		}
 	}
 
 	switch {
-	case successCount == len(items):
+	case cancelledCount == len(items):
+		return StatusCancelled, "job cancelled"
+	case successCount+cancelledCount == len(items):
 		return StatusSuccess, "job success"
 	case successCount == 0:
 		return StatusFailed, "job failed"
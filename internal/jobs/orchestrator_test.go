@@ -0,0 +1,217 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"fileforge-desktop/internal/models"
+)
+
+func persistedByID(t *testing.T, o *Orchestrator) map[string]persistedJobV1 {
+	t.Helper()
+	persisted, err := o.loadPersistedJobs(o.storePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	byID := make(map[string]persistedJobV1, len(persisted))
+	for _, state := range persisted {
+		byID[state.JobID] = state
+	}
+	return byID
+}
+
+func writePersisted(t *testing.T, path string, persisted []persistedJobV1) {
+	t.Helper()
+	payload, err := json.Marshal(persisted)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, payload, 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// A job still waiting in the queue when the app crashes is persisted with its
+// request, so it can be resumed after the restart.
+func TestQueuedJobResumesAfterRestart(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, "a.jpg", "b.jpg")
+
+	tool := newFakeTool("tool.image.test", "jpg")
+	tool.started = make(chan string, 4)
+	tool.release = make(chan struct{})
+	o := newTestOrchestrator(t, dir, tool)
+
+	first, err := o.Submit(context.Background(), models.JobRequestV1{ToolID: tool.ID(), Mode: "batch", InputPaths: []string{filepath.Join(dir, "a.jpg")}, OutputDir: dir})
+	if err != nil || !first.Success {
+		t.Fatalf("submit first: %+v %v", first, err)
+	}
+	<-tool.started
+	queued, err := o.Submit(context.Background(), models.JobRequestV1{ToolID: tool.ID(), Mode: "batch", InputPaths: []string{filepath.Join(dir, "b.jpg")}, OutputDir: dir})
+	if err != nil || !queued.Success {
+		t.Fatalf("submit second: %+v %v", queued, err)
+	}
+
+	// Take the snapshot the crashed app would have left behind.
+	state, ok := persistedByID(t, o)[queued.JobID]
+	if !ok || state.Request == nil {
+		t.Fatalf("queued job persisted without its request: %+v", state)
+	}
+	if want := []string{filepath.Join(dir, "b.jpg")}; !reflect.DeepEqual(state.Request.InputPaths, want) {
+		t.Fatalf("persisted inputs = %v, want %v", state.Request.InputPaths, want)
+	}
+	restartDir := t.TempDir()
+	snapshot, err := os.ReadFile(o.storePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(restartDir, "jobs_state_v1.json"), snapshot, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	close(tool.release)
+	waitForStatus(t, o, queued.JobID, StatusSuccess)
+
+	restarted := newTestOrchestrator(t, restartDir, newFakeTool("tool.image.test", "jpg"))
+	if err := restarted.RecoverInterruptedJobs(); err != nil {
+		t.Fatal(err)
+	}
+	waitForStatus(t, restarted, queued.JobID, StatusInterrupted)
+
+	resumed, err := restarted.Resume(context.Background(), queued.JobID)
+	if err != nil || !resumed.Success {
+		t.Fatalf("resume: %+v %v", resumed, err)
+	}
+	result := waitForStatus(t, restarted, resumed.JobID, StatusSuccess)
+	if result.ResumedFrom != queued.JobID {
+		t.Fatalf("resumedFrom = %s, want %s", result.ResumedFrom, queued.JobID)
+	}
+	if len(result.Items) != 1 || result.Items[0].InputPath != filepath.Join(dir, "b.jpg") {
+		t.Fatalf("resumed items = %+v", result.Items)
+	}
+}
+
+// Every job waiting on dependencies is re-armed and persisted on recovery,
+// and starts once its resumed dependency succeeds.
+func TestRecoverRearmsWaitingJobs(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, "a.jpg", "b.jpg", "c.jpg")
+	tool := newFakeTool("tool.image.test", "jpg")
+	o := newTestOrchestrator(t, dir, tool)
+
+	request := func(input string, dependsOn ...string) *models.JobRequestV1 {
+		return &models.JobRequestV1{ToolID: tool.ID(), Mode: "batch", InputPaths: []string{filepath.Join(dir, input)}, OutputDir: dir, DependsOn: dependsOn, DependencyRule: models.JobDependencyAllSucceeded}
+	}
+	job := func(jobID, status string, req *models.JobRequestV1) persistedJobV1 {
+		return persistedJobV1{
+			JobResultV1: models.JobResultV1{JobID: jobID, ToolID: tool.ID(), Status: status, Progress: models.JobProgressV1{Total: 1, Stage: status}, StartedAt: 1},
+			Request:     req,
+		}
+	}
+	writePersisted(t, o.storePath, []persistedJobV1{
+		job("job_a", StatusRunning, request("a.jpg")),
+		job("job_b", StatusWaiting, request("b.jpg", "job_a")),
+		job("job_c", StatusWaiting, request("c.jpg", "job_a")),
+	})
+
+	if err := o.RecoverInterruptedJobs(); err != nil {
+		t.Fatal(err)
+	}
+
+	persisted := persistedByID(t, o)
+	if _, ok := persisted["job_a"]; ok {
+		t.Fatal("interrupted job is still persisted as active")
+	}
+	for _, jobID := range []string{"job_b", "job_c"} {
+		if state, ok := persisted[jobID]; !ok || state.Status != StatusWaiting || state.Request == nil {
+			t.Fatalf("%s persisted as %+v, want waiting with its request", jobID, state)
+		}
+	}
+
+	resumed, err := o.Resume(context.Background(), "job_a")
+	if err != nil || !resumed.Success {
+		t.Fatalf("resume: %+v %v", resumed, err)
+	}
+	waitForStatus(t, o, resumed.JobID, StatusSuccess)
+	waitForStatus(t, o, "job_b", StatusSuccess)
+	waitForStatus(t, o, "job_c", StatusSuccess)
+}
+
+// A job under the any_finished rule leaves the waiting state as soon as one
+// dependency is done, while all_finished keeps waiting for the others.
+func TestAnyFinishedDependencyReleasesOnFirstFinalState(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, "a.jpg", "b.jpg", "c.jpg", "d.jpg")
+	tool := newFakeTool("tool.image.test", "jpg")
+	tool.started = make(chan string, 4)
+	tool.release = make(chan struct{})
+	o := newTestOrchestrator(t, dir, tool)
+
+	submit := func(input, rule string, dependsOn ...string) string {
+		t.Helper()
+		res, err := o.Submit(context.Background(), models.JobRequestV1{ToolID: tool.ID(), Mode: "batch", InputPaths: []string{filepath.Join(dir, input)}, OutputDir: dir, DependsOn: dependsOn, DependencyRule: rule})
+		if err != nil || !res.Success {
+			t.Fatalf("submit %s: %+v %v", input, res, err)
+		}
+		return res.JobID
+	}
+	running := submit("a.jpg", "")
+	<-tool.started
+	queued := submit("b.jpg", "")
+	anyFinished := submit("c.jpg", models.JobDependencyAnyFinished, running, queued)
+	allFinished := submit("d.jpg", models.JobDependencyAllFinished, running, queued)
+	waitForStatus(t, o, anyFinished, StatusWaiting)
+
+	if err := o.Cancel(queued); err != nil {
+		t.Fatal(err)
+	}
+	waitForStatus(t, o, anyFinished, StatusQueued)
+	if result, _ := o.GetJob(allFinished); result.Status != StatusWaiting {
+		t.Fatalf("all_finished job has status %s, want waiting", result.Status)
+	}
+	if graph, ok := o.DependencyGraph(anyFinished); !ok || graph.Nodes[0].DependencyRule != models.JobDependencyAnyFinished {
+		t.Fatalf("graph = %+v, want the any_finished rule on the job", graph)
+	}
+
+	close(tool.release)
+	waitForStatus(t, o, anyFinished, StatusSuccess)
+	waitForStatus(t, o, allFinished, StatusSuccess)
+}
+
+func TestResumeRejectsPipelines(t *testing.T) {
+	dir := t.TempDir()
+	tool := newFakeTool("tool.image.test", "jpg")
+	o := newTestOrchestrator(t, dir, tool)
+
+	pipeline := &models.PipelineRequestV1{Steps: []models.PipelineStepV1{{ToolID: tool.ID()}}, InputPaths: []string{filepath.Join(dir, "a.jpg")}, OutputDir: dir}
+	writePersisted(t, o.storePath, []persistedJobV1{{
+		JobResultV1: models.JobResultV1{JobID: "job_p", ToolID: models.PipelineToolIDV1, Status: StatusRunning, StartedAt: 1},
+		Pipeline:    pipeline,
+	}})
+	if err := o.RecoverInterruptedJobs(); err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := o.Resume(context.Background(), "job_p")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Success || res.Error == nil || res.Error.DetailCode != "JOB_RESUME_PIPELINE_UNSUPPORTED" {
+		t.Fatalf("resume = %+v, want JOB_RESUME_PIPELINE_UNSUPPORTED", res)
+	}
+}
+
+func TestResumeSkipsSucceededInputs(t *testing.T) {
+	inputs := []string{"/in/a.jpg", "/in/b.jpg", "/in/c.jpg"}
+	items := []models.JobResultItemV1{
+		{InputPath: "/in/a.jpg", Success: true},
+		{InputPath: "/in/b.jpg", Success: false},
+	}
+
+	if got, want := pendingInputs(inputs, items), []string{"/in/b.jpg", "/in/c.jpg"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("pendingInputs = %v, want %v", got, want)
+	}
+}
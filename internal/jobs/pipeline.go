@@ -0,0 +1,402 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"fileforge-desktop/internal/models"
+	"fileforge-desktop/internal/registry"
+	"fileforge-desktop/internal/tools"
+)
+
+const pipelineProgressUnitsPerStep = 100
+
+type pipelineStep struct {
+	index     int
+	tool      tools.Tool
+	spec      models.PipelineStepV1
+	mode      string
+	outputDir string
+	final     bool
+}
+
+type pipelinePlan struct {
+	req       models.PipelineRequestV1
+	workspace string
+	steps     []pipelineStep
+}
+
+// SubmitPipeline queues an ordered chain of tool executions where the outputs
+// of every step become the inputs of the next one. Intermediate files are
+// written to a per-job workspace under the OS temp dir. The pipeline request is
+// kept for history, but pipelines are never resumed or reused by fingerprint:
+// items of the final step cannot be mapped back to the pipeline inputs.
+func (o *Orchestrator) SubmitPipeline(ctx context.Context, req models.PipelineRequestV1) (models.RunJobResponseV1, error) {
+	if len(req.Steps) == 0 {
+		return rejectedPipeline(models.NewCanonicalJobError("PIPELINE_STEPS_REQUIRED", "pipeline requires at least one step", nil)), nil
+	}
+
+	if len(req.InputPaths) == 0 {
+		return rejectedPipeline(models.NewCanonicalJobError("PIPELINE_INPUT_REQUIRED", "inputPaths cannot be empty", nil)), nil
+	}
+
+	if priorityErr := validatePriority(req.Priority); priorityErr != nil {
+		return rejectedPipeline(priorityErr), nil
+	}
+
+	if timeoutErr := validateTimeouts(models.JobRequestV1{TimeoutMillis: req.TimeoutMillis}); timeoutErr != nil {
+		return rejectedPipeline(timeoutErr), nil
+	}
+
+	if policyErr := tools.ValidateConflictPolicy(models.JobRequestV1{ConflictPolicy: req.ConflictPolicy}); policyErr != nil {
+		return rejectedPipeline(policyErr), nil
+	}
+
+	jobID := newJobID()
+	plan, err := o.planPipeline(jobID, req)
+	if err != nil {
+		return models.RunJobResponseV1{}, err
+	}
+
+	if len(plan.steps) > 1 {
+		if err := plan.createWorkspace(); err != nil {
+			return models.RunJobResponseV1{}, fmt.Errorf("create pipeline workspace: %w", err)
+		}
+	}
+
+	first := plan.steps[0]
+	if validationErr := registry.ValidateRequest(ctx, first.tool, plan.stepRequest(first, req.InputPaths)); validationErr != nil {
+		plan.cleanup()
+		jobErr := normalizeJobError(validationErr)
+		jobErr.Details = withDetail(jobErr.Details, "stepIndex", first.index)
+		return rejectedPipeline(jobErr), nil
+	}
+
+	tracked := o.track(ctx, jobID, models.PipelineToolIDV1, len(plan.steps)*pipelineProgressUnitsPerStep, jobOrigin{pipeline: &req})
+	tracked.emitQueued()
+
+	go o.runPipeline(tracked, plan)
+
+	return models.RunJobResponseV1{
+		Success: true,
+		Message: "pipeline submitted",
+		JobID:   jobID,
+		Status:  StatusQueued,
+	}, nil
+}
+
+func rejectedPipeline(jobErr *models.JobErrorV1) models.RunJobResponseV1 {
+	return models.RunJobResponseV1{
+		Success: false,
+		Message: "pipeline validation failed",
+		Status:  StatusFailed,
+		Error:   jobErr,
+	}
+}
+
+func (o *Orchestrator) planPipeline(jobID string, req models.PipelineRequestV1) (pipelinePlan, error) {
+	plan := pipelinePlan{
+		req:       req,
+		workspace: filepath.Join(os.TempDir(), "fileforge-pipelines", jobID),
+		steps:     make([]pipelineStep, 0, len(req.Steps)),
+	}
+
+	for index, spec := range req.Steps {
+		tool, err := o.registry.GetToolV2(spec.ToolID)
+		if err != nil {
+			return pipelinePlan{}, fmt.Errorf("pipeline step %d: tool lookup failed: %w", index, err)
+		}
+
+		manifest := tool.Manifest()
+		mode := strings.TrimSpace(spec.Mode)
+		if mode == "" {
+			mode = "single"
+			if manifest.SupportsBatch {
+				mode = "batch"
+			}
+		}
+
+		if (mode == "batch" && !manifest.SupportsBatch) || (mode == "single" && !manifest.SupportsSingle) {
+			return pipelinePlan{}, fmt.Errorf("pipeline step %d: tool '%s' does not support %s mode", index, spec.ToolID, mode)
+		}
+
+		step := pipelineStep{
+			index: index,
+			tool:  tool,
+			spec:  spec,
+			mode:  mode,
+			final: index == len(req.Steps)-1,
+		}
+		if step.final {
+			step.outputDir = strings.TrimSpace(req.OutputDir)
+		} else {
+			step.outputDir = filepath.Join(plan.workspace, fmt.Sprintf("step-%02d-%s", index+1, toolShortName(spec.ToolID)))
+		}
+
+		plan.steps = append(plan.steps, step)
+	}
+
+	return plan, nil
+}
+
+func (p pipelinePlan) createWorkspace() error {
+	for _, step := range p.steps {
+		if step.final {
+			continue
+		}
+		if err := os.MkdirAll(step.outputDir, 0o755); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p pipelinePlan) cleanup() {
+	if p.req.KeepIntermediate || len(p.steps) < 2 {
+		return
+	}
+	_ = os.RemoveAll(p.workspace)
+}
+
+// stepRequest builds the JobRequestV1 for one step. Output locations are always
+// forced to the step directory so intermediate files stay in the workspace.
+func (p pipelinePlan) stepRequest(step pipelineStep, inputPaths []string) models.JobRequestV1 {
+	options := make(map[string]any, len(step.spec.Options)+2)
+	for k, v := range step.spec.Options {
+		options[k] = v
+	}
+
+	if !step.final {
+		delete(options, "outputPath")
+	}
+
+	if step.outputDir != "" {
+		if _, ok := options["outputDir"]; !ok || !step.final {
+			options["outputDir"] = step.outputDir
+		}
+	}
+
+	if step.mode == "single" && optionStringValue(options, "outputPath") == "" && len(inputPaths) > 0 {
+		outputDir := step.outputDir
+		if outputDir == "" {
+			outputDir = filepath.Dir(inputPaths[0])
+		}
+		stem := strings.TrimSuffix(filepath.Base(inputPaths[0]), filepath.Ext(inputPaths[0]))
+		options["outputPath"] = filepath.Join(outputDir, fmt.Sprintf("%s_%s.%s", stem, toolShortName(step.spec.ToolID), stepOutputExtension(step, inputPaths[0])))
+	}
+
+	req := models.JobRequestV1{
+		ToolID:     step.spec.ToolID,
+		Mode:       step.mode,
+		InputPaths: append([]string(nil), inputPaths...),
+		OutputDir:  step.outputDir,
+		Options:    options,
+		Workers:    p.req.Workers,
+	}
+	if step.final {
+		req.ConflictPolicy = p.req.ConflictPolicy
+	}
+	return req
+}
+
+func (o *Orchestrator) runPipeline(job *trackedJob, plan pipelinePlan) {
+	defer plan.cleanup()
+
+	// The job timeout starts once the first step holds a slot, so queue time
+	// before the pipeline starts does not count.
+	stopDeadline := func() {}
+	defer func() { stopDeadline() }()
+	started := false
+
+	inputs := append([]string(nil), plan.req.InputPaths...)
+	partial := false
+	var last jobOutcome
+
+	for _, step := range plan.steps {
+		if job.ctx.Err() != nil {
+			completeStoppedPipeline(job, last.items, step.index)
+			return
+		}
+
+		stepReq := plan.stepRequest(step, inputs)
+		stepResult := models.PipelineStepResultV1{
+			Index:      step.index,
+			ToolID:     step.spec.ToolID,
+			Mode:       step.mode,
+			InputPaths: stepReq.InputPaths,
+			OutputDir:  step.outputDir,
+			StartedAt:  time.Now().UnixMilli(),
+		}
+
+		if step.index > 0 {
+			if validationErr := registry.ValidateRequest(job.ctx, step.tool, stepReq); validationErr != nil {
+				last = jobOutcome{status: StatusFailed, message: "pipeline step validation failed", err: normalizeJobError(validationErr)}
+				job.recordStep(finishStep(stepResult, last))
+				job.complete(StatusFailed, fmt.Sprintf("pipeline failed at step %d (%s)", step.index+1, step.spec.ToolID), nil, withStepIndex(last.err, step.index), time.Now().UnixMilli())
+				return
+			}
+		}
+
+		// Every step takes a slot in its own tool domain, so ffmpeg and
+		// LibreOffice steps are held to the same limits as standalone jobs.
+		slot, err := o.scheduler.acquire(job.ctx, job, toolDomain(step.spec.ToolID), priorityRank(plan.req.Priority))
+		if err != nil {
+			completeStoppedPipeline(job, last.items, step.index)
+			return
+		}
+		if !started {
+			started = true
+			stopDeadline = job.startDeadline(time.Duration(plan.req.TimeoutMillis) * time.Millisecond)
+			job.updateProgress(models.JobProgressV1{Current: 0, Total: len(plan.steps) * pipelineProgressUnitsPerStep, Stage: StatusRunning, Message: "running"})
+		}
+
+		stepResult.StartedAt = time.Now().UnixMilli()
+		last = o.execute(job, step.tool, stepReq, pipelineProgressScaler(job, step, len(plan.steps)))
+		o.scheduler.release(slot)
+		job.recordStep(finishStep(stepResult, last))
+
+		switch last.status {
+		case StatusCancelled:
+			job.complete(StatusCancelled, "pipeline cancelled", last.items, withStepIndex(last.err, step.index), time.Now().UnixMilli())
+			return
+		case StatusTimedOut:
+			job.complete(StatusTimedOut, "pipeline timed out", last.items, withStepIndex(last.err, step.index), time.Now().UnixMilli())
+			return
+		case StatusFailed:
+			jobErr := last.err
+			if jobErr == nil {
+				jobErr = models.NewCanonicalJobError("PIPELINE_STEP_FAILED", "pipeline step produced no successful items", nil)
+			}
+			job.complete(StatusFailed, fmt.Sprintf("pipeline failed at step %d (%s)", step.index+1, step.spec.ToolID), last.items, withStepIndex(jobErr, step.index), time.Now().UnixMilli())
+			return
+		case StatusPartialSuccess:
+			partial = true
+		}
+
+		inputs = collectStepOutputs(last.items)
+		if len(inputs) == 0 && !step.final {
+			job.complete(StatusFailed, fmt.Sprintf("pipeline failed at step %d (%s)", step.index+1, step.spec.ToolID), last.items, models.NewCanonicalJobError("PIPELINE_STEP_NO_OUTPUTS", "pipeline step produced no outputs for the next step", map[string]any{"stepIndex": step.index}), time.Now().UnixMilli())
+			return
+		}
+	}
+
+	if partial {
+		job.complete(StatusPartialSuccess, "pipeline partial success", last.items, last.err, time.Now().UnixMilli())
+		return
+	}
+
+	job.complete(StatusSuccess, "pipeline success", last.items, nil, time.Now().UnixMilli())
+}
+
+// completeStoppedPipeline ends a pipeline whose context ended between steps.
+func completeStoppedPipeline(job *trackedJob, items []models.JobResultItemV1, stepIndex int) {
+	stopped := stoppedOutcome(job, items, map[string]any{"stepIndex": stepIndex})
+	message := "pipeline cancelled"
+	if stopped.status == StatusTimedOut {
+		message = "pipeline timed out"
+	}
+	job.complete(stopped.status, message, stopped.items, stopped.err, time.Now().UnixMilli())
+}
+
+func pipelineProgressScaler(job *trackedJob, step pipelineStep, stepCount int) func(models.JobProgressV1) {
+	return func(progress models.JobProgressV1) {
+		fraction := 0.0
+		if progress.Total > 0 {
+			fraction = float64(progress.Current) / float64(progress.Total)
+		}
+		fraction = min(max(fraction, 0), 1)
+
+		message := strings.TrimSpace(progress.Message)
+		if message == "" {
+			message = progress.Stage
+		}
+
+		job.updateProgress(models.JobProgressV1{
+			Current: step.index*pipelineProgressUnitsPerStep + int(fraction*pipelineProgressUnitsPerStep),
+			Total:   stepCount * pipelineProgressUnitsPerStep,
+			Stage:   StatusRunning,
+			Message: fmt.Sprintf("step %d/%d (%s): %s", step.index+1, stepCount, step.spec.ToolID, message),
+		})
+	}
+}
+
+func finishStep(result models.PipelineStepResultV1, outcome jobOutcome) models.PipelineStepResultV1 {
+	result.Status = outcome.status
+	result.Message = outcome.message
+	result.Items = outcome.items
+	result.Error = outcome.err
+	result.EndedAt = time.Now().UnixMilli()
+	return result
+}
+
+func collectStepOutputs(items []models.JobResultItemV1) []string {
+	outputs := make([]string, 0, len(items))
+	for _, item := range items {
+		if !item.Success {
+			continue
+		}
+		if len(item.Outputs) > 0 {
+			outputs = append(outputs, item.Outputs...)
+			continue
+		}
+		if strings.TrimSpace(item.OutputPath) != "" {
+			outputs = append(outputs, item.OutputPath)
+		}
+	}
+	return outputs
+}
+
+func stepOutputExtension(step pipelineStep, inputPath string) string {
+	for _, key := range []string{"targetFormat", "format"} {
+		if format := strings.ToLower(optionStringValue(step.spec.Options, key)); format != "" {
+			return format
+		}
+	}
+
+	extensions := step.tool.Manifest().OutputExtensions
+	inputExt := strings.ToLower(strings.TrimPrefix(filepath.Ext(inputPath), "."))
+	for _, ext := range extensions {
+		if ext == inputExt {
+			return ext
+		}
+	}
+	if len(extensions) > 0 {
+		return extensions[0]
+	}
+
+	return inputExt
+}
+
+func toolShortName(toolID string) string {
+	parts := strings.Split(toolID, ".")
+	return parts[len(parts)-1]
+}
+
+func optionStringValue(options map[string]any, key string) string {
+	if options == nil {
+		return ""
+	}
+	v, _ := options[key].(string)
+	return strings.TrimSpace(v)
+}
+
+func withStepIndex(jobErr *models.JobErrorV1, index int) *models.JobErrorV1 {
+	if jobErr == nil {
+		return nil
+	}
+	copied := normalizeJobError(jobErr)
+	copied.Details = withDetail(copied.Details, "stepIndex", index)
+	return copied
+}
+
+func withDetail(details map[string]any, key string, value any) map[string]any {
+	if details == nil {
+		details = map[string]any{}
+	}
+	details[key] = value
+	return details
+}
@@ -0,0 +1,52 @@
+package jobs
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"fileforge-desktop/internal/models"
+)
+
+// A pipeline step takes a slot in its tool's domain, so it waits for a
+// standalone job of the single-slot video domain even with global capacity
+// left.
+func TestPipelineStepsHonorDomainLimits(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, "a.mp4", "b.mp4")
+
+	tool := newFakeTool("tool.video.test", "mp4")
+	tool.started = make(chan string, 4)
+	tool.release = make(chan struct{})
+	o := newTestOrchestrator(t, dir, tool)
+	o.scheduler.maxRunning = 2
+
+	standalone, err := o.Submit(context.Background(), models.JobRequestV1{ToolID: tool.ID(), Mode: "batch", InputPaths: []string{filepath.Join(dir, "a.mp4")}, OutputDir: dir, DisableReuse: true})
+	if err != nil || !standalone.Success {
+		t.Fatalf("submit: %+v %v", standalone, err)
+	}
+	<-tool.started
+
+	pipeline, err := o.SubmitPipeline(context.Background(), models.PipelineRequestV1{
+		Steps:      []models.PipelineStepV1{{ToolID: tool.ID()}},
+		InputPaths: []string{filepath.Join(dir, "b.mp4")},
+		OutputDir:  dir,
+	})
+	if err != nil || !pipeline.Success {
+		t.Fatalf("submit pipeline: %+v %v", pipeline, err)
+	}
+
+	select {
+	case input := <-tool.started:
+		t.Fatalf("pipeline step started on %s while the video slot was taken", input)
+	case <-time.After(100 * time.Millisecond):
+	}
+	if result, _ := o.GetJob(pipeline.JobID); result.Status != StatusQueued {
+		t.Fatalf("pipeline status = %s, want queued", result.Status)
+	}
+
+	close(tool.release)
+	waitForStatus(t, o, standalone.JobID, StatusSuccess)
+	waitForStatus(t, o, pipeline.JobID, StatusSuccess)
+}
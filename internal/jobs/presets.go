@@ -0,0 +1,267 @@
+package jobs
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"fileforge-desktop/internal/models"
+)
+
+// PresetStore keeps named tool presets in a single JSON file that is rewritten
+// on every change.
+type PresetStore struct {
+	path string
+
+	mu      sync.RWMutex
+	presets map[string]models.PresetV1
+}
+
+func OpenPresetStore(path string) (*PresetStore, error) {
+	store := &PresetStore{path: path, presets: make(map[string]models.PresetV1)}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return store, nil
+		}
+		return nil, fmt.Errorf("read presets: %w", err)
+	}
+	if len(content) == 0 {
+		return store, nil
+	}
+
+	presets := make([]models.PresetV1, 0)
+	if err := json.Unmarshal(content, &presets); err != nil {
+		return nil, fmt.Errorf("decode presets: %w", err)
+	}
+	for _, preset := range presets {
+		store.presets[presetKey(preset.ToolID, preset.Name)] = preset
+	}
+	return store, nil
+}
+
+func presetKey(toolID, name string) string {
+	return toolID + "\x00" + strings.ToLower(strings.TrimSpace(name))
+}
+
+// List returns the presets for toolID, or all presets when toolID is empty,
+// sorted by tool and name.
+func (s *PresetStore) List(toolID string) []models.PresetV1 {
+	s.mu.RLock()
+	presets := make([]models.PresetV1, 0, len(s.presets))
+	for _, preset := range s.presets {
+		if toolID == "" || preset.ToolID == toolID {
+			presets = append(presets, preset)
+		}
+	}
+	s.mu.RUnlock()
+
+	sort.Slice(presets, func(i, j int) bool {
+		if presets[i].ToolID != presets[j].ToolID {
+			return presets[i].ToolID < presets[j].ToolID
+		}
+		return strings.ToLower(presets[i].Name) < strings.ToLower(presets[j].Name)
+	})
+	return presets
+}
+
+func (s *PresetStore) Get(toolID, name string) (models.PresetV1, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	preset, ok := s.presets[presetKey(toolID, name)]
+	return preset, ok
+}
+
+// Save creates or replaces a preset, keeping the creation time of the one it
+// replaces.
+func (s *PresetStore) Save(preset models.PresetV1) (models.PresetV1, error) {
+	preset.ToolID = strings.TrimSpace(preset.ToolID)
+	preset.Name = strings.TrimSpace(preset.Name)
+	if preset.Options == nil {
+		preset.Options = map[string]any{}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now().UnixMilli()
+	key := presetKey(preset.ToolID, preset.Name)
+	previous, existed := s.presets[key]
+	preset.CreatedAt, preset.UpdatedAt = now, now
+	if existed && previous.CreatedAt > 0 {
+		preset.CreatedAt = previous.CreatedAt
+	}
+
+	s.presets[key] = preset
+	if err := s.saveLocked(); err != nil {
+		if existed {
+			s.presets[key] = previous
+		} else {
+			delete(s.presets, key)
+		}
+		return models.PresetV1{}, err
+	}
+	return preset, nil
+}
+
+func (s *PresetStore) Delete(toolID, name string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := presetKey(toolID, name)
+	if _, ok := s.presets[key]; !ok {
+		return false, nil
+	}
+	delete(s.presets, key)
+	return true, s.saveLocked()
+}
+
+// Import adds presets from a bundle. Existing presets with the same tool and
+// name are kept unless overwrite is set; the keys of presets left out are
+// returned as toolId/name.
+func (s *PresetStore) Import(presets []models.PresetV1, overwrite bool) (int, []string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now().UnixMilli()
+	imported := 0
+	skipped := make([]string, 0)
+	for _, preset := range presets {
+		preset.ToolID = strings.TrimSpace(preset.ToolID)
+		preset.Name = strings.TrimSpace(preset.Name)
+		key := presetKey(preset.ToolID, preset.Name)
+		if _, exists := s.presets[key]; exists && !overwrite {
+			skipped = append(skipped, preset.ToolID+"/"+preset.Name)
+			continue
+		}
+		if preset.Options == nil {
+			preset.Options = map[string]any{}
+		}
+		if preset.CreatedAt == 0 {
+			preset.CreatedAt = now
+		}
+		preset.UpdatedAt = now
+		s.presets[key] = preset
+		imported++
+	}
+
+	if imported == 0 {
+		return 0, skipped, nil
+	}
+	return imported, skipped, s.saveLocked()
+}
+
+func (s *PresetStore) saveLocked() error {
+	presets := make([]models.PresetV1, 0, len(s.presets))
+	for _, preset := range s.presets {
+		presets = append(presets, preset)
+	}
+	sort.Slice(presets, func(i, j int) bool {
+		return presetKey(presets[i].ToolID, presets[i].Name) < presetKey(presets[j].ToolID, presets[j].Name)
+	})
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return fmt.Errorf("create presets directory: %w", err)
+	}
+
+	payload, err := json.MarshalIndent(presets, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode presets: %w", err)
+	}
+
+	// Replace the file through a rename so a crash mid-write keeps the
+	// previous presets readable.
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), ".presets-*.tmp")
+	if err != nil {
+		return fmt.Errorf("create presets temp file: %w", err)
+	}
+	if _, err := tmp.Write(payload); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return fmt.Errorf("write presets: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("close presets temp file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("replace presets: %w", err)
+	}
+	return nil
+}
+
+func WritePresetBundle(path string, presets []models.PresetV1) error {
+	payload, err := json.MarshalIndent(models.PresetBundleV1{
+		Version:    models.PresetBundleVersionV1,
+		ExportedAt: time.Now().UnixMilli(),
+		Presets:    presets,
+	}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode preset bundle: %w", err)
+	}
+
+	if err := os.WriteFile(path, payload, 0o644); err != nil {
+		return fmt.Errorf("write preset bundle: %w", err)
+	}
+	return nil
+}
+
+func ReadPresetBundle(path string) (models.PresetBundleV1, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return models.PresetBundleV1{}, fmt.Errorf("read preset bundle: %w", err)
+	}
+
+	var bundle models.PresetBundleV1
+	if err := json.Unmarshal(content, &bundle); err != nil {
+		return models.PresetBundleV1{}, fmt.Errorf("decode preset bundle: %w", err)
+	}
+	if bundle.Version != models.PresetBundleVersionV1 {
+		return models.PresetBundleV1{}, fmt.Errorf("unsupported preset bundle version %d", bundle.Version)
+	}
+	return bundle, nil
+}
+
+func (o *Orchestrator) SetPresetStore(store *PresetStore) {
+	o.mu.Lock()
+	o.presets = store
+	o.mu.Unlock()
+}
+
+// applyPreset merges the options of the preset named by req.Preset under
+// req.Options, so fields set on the request override the preset.
+func (o *Orchestrator) applyPreset(req *models.JobRequestV1) *models.JobErrorV1 {
+	name := strings.TrimSpace(req.Preset)
+	if name == "" {
+		return nil
+	}
+
+	o.mu.RLock()
+	store := o.presets
+	o.mu.RUnlock()
+
+	var preset models.PresetV1
+	found := false
+	if store != nil {
+		preset, found = store.Get(req.ToolID, name)
+	}
+	if !found {
+		return models.NewCanonicalJobError("JOB_PRESET_NOT_FOUND", fmt.Sprintf("preset '%s' not found for tool '%s'", name, req.ToolID), map[string]any{"preset": name, "toolId": req.ToolID})
+	}
+
+	options := make(map[string]any, len(preset.Options)+len(req.Options))
+	maps.Copy(options, preset.Options)
+	maps.Copy(options, req.Options)
+	req.Options = options
+	req.Preset = preset.Name
+	return nil
+}
@@ -0,0 +1,95 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+
+	"fileforge-desktop/internal/models"
+)
+
+// Resume re-submits the inputs of a finished job that have no successful item,
+// using the job's original request. The new job records the old one in
+// ResumedFrom.
+func (o *Orchestrator) Resume(ctx context.Context, jobID string) (models.RunJobResponseV1, error) {
+	previous, req, found := o.resumeSource(jobID)
+	if !found {
+		return rejectedResume(models.NewCanonicalJobError("JOB_RESUME_NOT_FOUND", fmt.Sprintf("job '%s' not found", jobID), nil)), nil
+	}
+
+	switch previous.Status {
+	case StatusQueued, StatusPaused, StatusWaiting, StatusRunning:
+		return rejectedResume(models.NewCanonicalJobError("JOB_RESUME_ACTIVE", "job is still active", map[string]any{"jobId": jobID, "status": previous.Status})), nil
+	case StatusSuccess:
+		return rejectedResume(models.NewCanonicalJobError("JOB_RESUME_NOTHING_TO_DO", "job already completed successfully", map[string]any{"jobId": jobID})), nil
+	}
+
+	if previous.ToolID == models.PipelineToolIDV1 {
+		return rejectedResume(models.NewCanonicalJobError("JOB_RESUME_PIPELINE_UNSUPPORTED", "pipelines cannot be resumed; submit the pipeline again", map[string]any{"jobId": jobID})), nil
+	}
+
+	if req == nil {
+		return rejectedResume(models.NewCanonicalJobError("JOB_RESUME_UNSUPPORTED", "job has no stored request to resume", map[string]any{"jobId": jobID, "toolId": previous.ToolID})), nil
+	}
+
+	remaining := pendingInputs(req.InputPaths, previous.Items)
+	if len(remaining) == 0 {
+		return rejectedResume(models.NewCanonicalJobError("JOB_RESUME_NOTHING_TO_DO", "every input already has a successful item", map[string]any{"jobId": jobID})), nil
+	}
+
+	resumed := *req
+	resumed.InputPaths = remaining
+	return o.submit(ctx, resumed, jobID)
+}
+
+func (o *Orchestrator) resumeSource(jobID string) (models.JobResultV1, *models.JobRequestV1, bool) {
+	o.mu.RLock()
+	job, ok := o.jobs[jobID]
+	o.mu.RUnlock()
+	if ok {
+		job.mu.RLock()
+		defer job.mu.RUnlock()
+		result := job.result
+		if len(result.Items) == 0 {
+			result.Items = job.checkpoints
+		}
+		return result, job.request, true
+	}
+
+	record, found := o.GetJobHistory(jobID)
+	if !found {
+		return models.JobResultV1{}, nil, false
+	}
+	req := record.Request
+	if req != nil && len(record.InputSubdirs) > 0 {
+		restored := *req
+		restored.InputSubdirs = record.InputSubdirs
+		req = &restored
+	}
+	return record.Result, req, true
+}
+
+func pendingInputs(inputPaths []string, items []models.JobResultItemV1) []string {
+	done := make(map[string]struct{}, len(items))
+	for _, item := range items {
+		if item.Success {
+			done[item.InputPath] = struct{}{}
+		}
+	}
+
+	pending := make([]string, 0, len(inputPaths))
+	for _, inputPath := range inputPaths {
+		if _, ok := done[inputPath]; !ok {
+			pending = append(pending, inputPath)
+		}
+	}
+	return pending
+}
+
+func rejectedResume(jobErr *models.JobErrorV1) models.RunJobResponseV1 {
+	return models.RunJobResponseV1{
+		Success: false,
+		Message: "job resume rejected",
+		Status:  StatusFailed,
+		Error:   jobErr,
+	}
+}
@@ -2,78 +2,156 @@ package jobs
 
 import (
 	"context"
+	"math"
+	"math/rand/v2"
 	"strings"
 	"time"
 
 	"fileforge-desktop/internal/models"
+	"fileforge-desktop/internal/tools"
 )
 
-const maxRetryAttempts = 3
+const (
+	defaultRetryMaxAttempts    = 3
+	defaultRetryInitialBackoff = 250 * time.Millisecond
+	defaultRetryMaxBackoff     = 1000 * time.Millisecond
+	defaultRetryMultiplier     = 2.0
+	defaultRetryJitterRatio    = 0.2
+	maxRetryAttemptsLimit      = 10
+)
+
+type retryPolicy struct {
+	maxAttempts    int
+	retryable      map[string]struct{}
+	initialBackoff time.Duration
+	maxBackoff     time.Duration
+	multiplier     float64
+	jitter         float64
+	attemptTimeout time.Duration
+}
+
+// resolveRetryPolicy layers the request override on top of the tool's declared
+// policy. Tools that do not declare a policy are not retried unless the request
+// names retryable codes itself.
+func resolveRetryPolicy(tool tools.Tool, req models.JobRequestV1) retryPolicy {
+	declared := models.RetryPolicyV1{MaxAttempts: 1}
+	if provider, ok := tool.(tools.RetryPolicyProvider); ok {
+		declared = provider.RetryPolicy()
+		if declared.MaxAttempts == 0 {
+			declared.MaxAttempts = defaultRetryMaxAttempts
+		}
+	}
+
+	if override := req.Retry; override != nil {
+		if override.MaxAttempts > 0 {
+			declared.MaxAttempts = override.MaxAttempts
+		}
+		if override.RetryableCodes != nil {
+			declared.RetryableCodes = override.RetryableCodes
+			if override.MaxAttempts == 0 && declared.MaxAttempts <= 1 {
+				declared.MaxAttempts = defaultRetryMaxAttempts
+			}
+		}
+		if override.InitialBackoffMillis > 0 {
+			declared.InitialBackoffMillis = override.InitialBackoffMillis
+		}
+		if override.MaxBackoffMillis > 0 {
+			declared.MaxBackoffMillis = override.MaxBackoffMillis
+		}
+		if override.BackoffMultiplier > 0 {
+			declared.BackoffMultiplier = override.BackoffMultiplier
+		}
+		if override.JitterRatio > 0 {
+			declared.JitterRatio = override.JitterRatio
+		}
+		if override.AttemptTimeoutMillis > 0 {
+			declared.AttemptTimeoutMillis = override.AttemptTimeoutMillis
+		}
+	}
+
+	policy := retryPolicy{
+		maxAttempts:    min(max(declared.MaxAttempts, 1), maxRetryAttemptsLimit),
+		retryable:      make(map[string]struct{}, len(declared.RetryableCodes)),
+		initialBackoff: defaultRetryInitialBackoff,
+		maxBackoff:     defaultRetryMaxBackoff,
+		multiplier:     defaultRetryMultiplier,
+		jitter:         defaultRetryJitterRatio,
+		attemptTimeout: time.Duration(declared.AttemptTimeoutMillis) * time.Millisecond,
+	}
+	for _, code := range declared.RetryableCodes {
+		if normalized := strings.ToUpper(strings.TrimSpace(code)); normalized != "" {
+			policy.retryable[normalized] = struct{}{}
+		}
+	}
+	if declared.InitialBackoffMillis > 0 {
+		policy.initialBackoff = time.Duration(declared.InitialBackoffMillis) * time.Millisecond
+	}
+	if declared.MaxBackoffMillis > 0 {
+		policy.maxBackoff = time.Duration(declared.MaxBackoffMillis) * time.Millisecond
+	}
+	policy.maxBackoff = max(policy.maxBackoff, policy.initialBackoff)
+	if declared.BackoffMultiplier >= 1 {
+		policy.multiplier = declared.BackoffMultiplier
+	}
+	if declared.JitterRatio > 0 {
+		policy.jitter = min(declared.JitterRatio, 1)
+	}
 
-var retryBackoffSchedule = []time.Duration{
-	250 * time.Millisecond,
-	500 * time.Millisecond,
-	1000 * time.Millisecond,
+	return policy
 }
 
-func isRetryableError(toolID string, jobErr *models.JobErrorV1) bool {
+func (p retryPolicy) isRetryable(jobErr *models.JobErrorV1) bool {
 	if jobErr == nil {
 		return false
 	}
+	// Timeouts are transient by contract, whatever codes the policy lists.
+	if jobErr.Code == models.ErrorCodeExecTimeoutTransient {
+		return true
+	}
+	if len(p.retryable) == 0 {
+		return false
+	}
 
 	detailCode := strings.ToUpper(strings.TrimSpace(jobErr.DetailCode))
 	if detailCode == "" {
 		detailCode = strings.ToUpper(strings.TrimSpace(jobErr.Code))
 	}
+	if _, ok := p.retryable[detailCode]; ok {
+		return true
+	}
 
-	transientByTool := map[string]map[string]struct{}{
-		"tool.doc.docx_to_pdf": {
-			"DOC_DOCX_TO_PDF_EXECUTION_FAILED":          {},
-			"DOC_DOCX_TO_PDF_PRIMARY_EXECUTION_FAILED":  {},
-			"DOC_DOCX_TO_PDF_FALLBACK_EXECUTION_FAILED": {},
-		},
-		"tool.doc.md_to_pdf": {
-			"DOC_MD_TO_PDF_RENDER_FAILED": {},
-		},
-		"tool.pdf.crop": {
-			"PDF_CROP_EXECUTION": {},
-			"PDF_CROP_FAILED":    {},
-		},
-		"tool.pdf.merge": {
-			"PDF_MERGE_EXECUTION": {},
-			"PDF_MERGE_FAILED":    {},
-		},
-		"tool.pdf.split": {
-			"PDF_SPLIT_EXECUTION": {},
-			"PDF_SPLIT_FAILED":    {},
-		},
-		"tool.image.crop": {
-			"IMAGE_CROP_EXECUTION": {},
-		},
-		"tool.image.annotate": {
-			"IMAGE_ANNOTATE_EXECUTION":  {},
-			"IMAGE_ANNOTATE_BATCH_ITEM": {},
-		},
-		"tool.video.convert": {
-			"VIDEO_CONVERT_EXECUTION": {},
-			"VIDEO_CONVERT_FAILED":    {},
-		},
-		"tool.video.trim": {
-			"VIDEO_TRIM_EXECUTION": {},
-			"VIDEO_TRIM_FAILED":    {},
-		},
-		"tool.video.merge": {
-			"VIDEO_MERGE_EXECUTION": {},
-			"VIDEO_MERGE_FAILED":    {},
-		},
-	}
-
-	if byDetail, ok := transientByTool[toolID]; ok {
-		_, matched := byDetail[detailCode]
-		return matched
-	}
-
-	return false
+	_, ok := p.retryable[strings.ToUpper(strings.TrimSpace(jobErr.Code))]
+	return ok
+}
+
+// backoff returns the delay before the attempt following the given one:
+// exponential growth capped at maxBackoff, with +/- jitter.
+func (p retryPolicy) backoff(attempt int) time.Duration {
+	delay := float64(p.initialBackoff) * math.Pow(p.multiplier, float64(max(attempt-1, 0)))
+	delay = min(delay, float64(p.maxBackoff))
+	if p.jitter > 0 {
+		delay += delay * p.jitter * (rand.Float64()*2 - 1)
+	}
+	return time.Duration(max(delay, 0))
+}
+
+const attemptTimeoutDetailCode = "JOB_ATTEMPT_TIMEOUT"
+
+// attemptTimeoutError replaces whatever error a tool returned after its attempt
+// context expired, so the failure is classified as a transient timeout.
+func (p retryPolicy) attemptTimeoutError(jobCtx, attemptCtx context.Context, jobErr *models.JobErrorV1) *models.JobErrorV1 {
+	if jobErr == nil || jobCtx.Err() != nil || attemptCtx.Err() != context.DeadlineExceeded {
+		return jobErr
+	}
+	return models.NewJobError(models.ErrorCodeExecTimeoutTransient, attemptTimeoutDetailCode, "attempt exceeded its timeout", map[string]any{"timeoutMillis": p.attemptTimeout.Milliseconds()})
+}
+
+// attemptContext bounds a single attempt by the policy's per-attempt timeout.
+func (p retryPolicy) attemptContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if p.attemptTimeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, p.attemptTimeout)
 }
 
 func retryMetadata(attempts int, jobErr *models.JobErrorV1) map[string]any {
@@ -96,16 +174,23 @@ func retryMetadata(attempts int, jobErr *models.JobErrorV1) map[string]any {
 	return metadata
 }
 
-func waitRetryBackoff(ctx context.Context, attempt int) bool {
-	if attempt <= 0 || attempt > len(retryBackoffSchedule) {
-		return true
-	}
-
-	timer := time.NewTimer(retryBackoffSchedule[attempt-1])
+// waitRetryBackoff announces the retry and sleeps for the backoff delay. It
+// returns false when the job ends while waiting.
+func waitRetryBackoff(job *trackedJob, policy retryPolicy, attempt int, inputPaths []string, jobErr *models.JobErrorV1) bool {
+	delay := policy.backoff(attempt)
+	job.emitRetrying(models.JobRetryV1{
+		Attempt:     attempt,
+		MaxAttempts: policy.maxAttempts,
+		DelayMillis: delay.Milliseconds(),
+		InputPaths:  inputPaths,
+		Error:       jobErr,
+	})
+
+	timer := time.NewTimer(delay)
 	defer timer.Stop()
 
 	select {
-	case <-ctx.Done():
+	case <-job.ctx.Done():
 		return false
 	case <-timer.C:
 		return true
@@ -0,0 +1,201 @@
+package jobs
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"fileforge-desktop/internal/models"
+	"fileforge-desktop/internal/tools"
+)
+
+// fileHashCache remembers content hashes by path, size and modification time so
+// that re-submitting the same large inputs does not hash them again.
+type fileHashCache struct {
+	mu      sync.Mutex
+	entries map[string]fileHashEntry
+}
+
+type fileHashEntry struct {
+	size    int64
+	modTime int64
+	hash    string
+}
+
+func newFileHashCache() *fileHashCache {
+	return &fileHashCache{entries: make(map[string]fileHashEntry)}
+}
+
+func (c *fileHashCache) hash(path string) (string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+	if info.IsDir() {
+		return "", fmt.Errorf("%s is a directory", path)
+	}
+
+	key := filepath.Clean(path)
+	c.mu.Lock()
+	entry, ok := c.entries[key]
+	c.mu.Unlock()
+	if ok && entry.size == info.Size() && entry.modTime == info.ModTime().UnixNano() {
+		return entry.hash, nil
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	hasher := sha256.New()
+	if _, err := io.Copy(hasher, file); err != nil {
+		return "", err
+	}
+	sum := hex.EncodeToString(hasher.Sum(nil))
+
+	c.mu.Lock()
+	c.entries[key] = fileHashEntry{size: info.Size(), modTime: info.ModTime().UnixNano(), hash: sum}
+	c.mu.Unlock()
+
+	return sum, nil
+}
+
+// jobFingerprint identifies the work a request describes: the tool and its
+// version, the mode, the output directory, the options and the content of
+// every input in order. Input paths are left out so renamed or copied inputs
+// still match.
+func (o *Orchestrator) jobFingerprint(tool tools.Tool, req models.JobRequestV1) (string, error) {
+	inputHashes := make([]string, 0, len(req.InputPaths))
+	for _, inputPath := range req.InputPaths {
+		sum, err := o.hashes.hash(inputPath)
+		if err != nil {
+			return "", fmt.Errorf("hash input %s: %w", inputPath, err)
+		}
+		inputHashes = append(inputHashes, sum)
+	}
+
+	// encoding/json sorts map keys, which makes the options encoding canonical.
+	payload, err := json.Marshal(struct {
+		ToolID    string         `json:"toolId"`
+		Version   string         `json:"version"`
+		Mode      string         `json:"mode"`
+		OutputDir string         `json:"outputDir"`
+		Options   map[string]any `json:"options"`
+		Inputs    []string       `json:"inputs"`
+		Subdirs   []string       `json:"subdirs,omitempty"`
+		Conflicts string         `json:"conflicts,omitempty"`
+	}{
+		ToolID:    req.ToolID,
+		Version:   tool.Manifest().Version,
+		Mode:      strings.ToLower(strings.TrimSpace(req.Mode)),
+		OutputDir: filepath.Clean(req.OutputDir),
+		Options:   req.Options,
+		Inputs:    inputHashes,
+		Subdirs:   inputSubdirList(req),
+		Conflicts: conflictPolicyKey(req),
+	})
+	if err != nil {
+		return "", fmt.Errorf("encode fingerprint: %w", err)
+	}
+
+	sum := sha256.Sum256(payload)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// findReusable returns the newest successful job with the same fingerprint
+// whose outputs are all still present and unchanged.
+func (o *Orchestrator) findReusable(fingerprint string) (models.JobHistoryRecordV1, bool) {
+	o.mu.RLock()
+	history := o.history
+	o.mu.RUnlock()
+	if history == nil || fingerprint == "" {
+		return models.JobHistoryRecordV1{}, false
+	}
+
+	for _, record := range history.FindByFingerprint(fingerprint) {
+		if len(record.OutputStamps) > 0 && outputStampsCurrent(record.OutputStamps) {
+			return record, true
+		}
+	}
+	return models.JobHistoryRecordV1{}, false
+}
+
+// reusedItems returns the items of a reused job reported against the inputs of
+// req. The fingerprint covers input content in order, so the i-th input of the
+// earlier request has the same content as the i-th input of req; the outputs
+// stay those of the earlier job. It reports false when an item cannot be
+// matched to an input.
+func reusedItems(previous models.JobHistoryRecordV1, req models.JobRequestV1) ([]models.JobResultItemV1, bool) {
+	if previous.Request == nil || len(previous.Request.InputPaths) != len(req.InputPaths) {
+		return nil, false
+	}
+
+	inputs := make(map[string]string, len(req.InputPaths))
+	for index, inputPath := range previous.Request.InputPaths {
+		inputs[models.ItemIDV1(inputPath)] = req.InputPaths[index]
+	}
+
+	items := make([]models.JobResultItemV1, 0, len(previous.Result.Items))
+	for _, item := range previous.Result.Items {
+		inputPath, ok := inputs[models.ItemIDV1(item.InputPath)]
+		if !ok {
+			return nil, false
+		}
+		item.InputPath = inputPath
+		item.ItemID = ""
+		items = append(items, item)
+	}
+	return items, true
+}
+
+func stampOutputs(items []models.JobResultItemV1) []models.JobOutputStampV1 {
+	stamps := make([]models.JobOutputStampV1, 0, len(items))
+	seen := make(map[string]struct{}, len(items))
+	for _, item := range items {
+		outputs := item.Outputs
+		if len(outputs) == 0 && item.OutputPath != "" {
+			outputs = []string{item.OutputPath}
+		}
+		for _, outputPath := range outputs {
+			if _, ok := seen[outputPath]; ok {
+				continue
+			}
+			seen[outputPath] = struct{}{}
+
+			info, err := os.Stat(outputPath)
+			if err != nil {
+				// An output that is already gone cannot be reused later.
+				return nil
+			}
+			stamps = append(stamps, models.JobOutputStampV1{Path: outputPath, Size: info.Size(), ModTime: info.ModTime().UnixNano()})
+		}
+	}
+	return stamps
+}
+
+func outputStampsCurrent(stamps []models.JobOutputStampV1) bool {
+	for _, stamp := range stamps {
+		info, err := os.Stat(stamp.Path)
+		if err != nil || info.Size() != stamp.Size || info.ModTime().UnixNano() != stamp.ModTime {
+			return false
+		}
+	}
+	return true
+}
+
+// conflictPolicyKey leaves the default policy out of the fingerprint, so jobs
+// recorded before conflict policies existed still match.
+func conflictPolicyKey(req models.JobRequestV1) string {
+	if policy := tools.ConflictPolicy(req); policy != models.ConflictPolicyRename {
+		return policy
+	}
+	return ""
+}
@@ -0,0 +1,59 @@
+package jobs
+
+import (
+	"path/filepath"
+	"testing"
+
+	"fileforge-desktop/internal/models"
+)
+
+// Inputs with the same content share a fingerprint, and a reuse hit reports
+// the items against the new job's inputs.
+func TestReuseReportsCurrentInputs(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, "first/a.jpg", "first/b.jpg")
+	writeFiles(t, filepath.Join(dir, "copy"), "first/a.jpg", "first/b.jpg")
+
+	tool := newFakeTool("tool.image.test", "jpg")
+	o := NewOrchestrator(nil, 1)
+	previousReq := models.JobRequestV1{ToolID: tool.ID(), Mode: "batch", InputPaths: []string{filepath.Join(dir, "first", "a.jpg"), filepath.Join(dir, "first", "b.jpg")}, OutputDir: dir}
+	currentReq := previousReq
+	currentReq.InputPaths = []string{filepath.Join(dir, "copy", "first", "a.jpg"), filepath.Join(dir, "copy", "first", "b.jpg")}
+
+	previousFingerprint, err := o.jobFingerprint(tool, previousReq)
+	if err != nil {
+		t.Fatal(err)
+	}
+	currentFingerprint, err := o.jobFingerprint(tool, currentReq)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if previousFingerprint != currentFingerprint {
+		t.Fatal("copied inputs do not share the fingerprint")
+	}
+
+	// Batch items may complete out of input order.
+	previous := models.JobHistoryRecordV1{
+		Request: &previousReq,
+		Result: models.JobResultV1{Items: []models.JobResultItemV1{
+			{ItemID: models.ItemIDV1(previousReq.InputPaths[1]), InputPath: previousReq.InputPaths[1], Success: true, OutputPath: filepath.Join(dir, "b.png")},
+			{ItemID: models.ItemIDV1(previousReq.InputPaths[0]), InputPath: previousReq.InputPaths[0], Success: true, OutputPath: filepath.Join(dir, "a.png")},
+		}},
+	}
+	items, ok := reusedItems(previous, currentReq)
+	if !ok {
+		t.Fatal("reusedItems rejected a matching request")
+	}
+	for i, want := range []struct{ input, output string }{
+		{currentReq.InputPaths[1], filepath.Join(dir, "b.png")},
+		{currentReq.InputPaths[0], filepath.Join(dir, "a.png")},
+	} {
+		if items[i].InputPath != want.input || items[i].OutputPath != want.output || items[i].ItemID != "" {
+			t.Fatalf("item %d = %+v, want input %s and output %s", i, items[i], want.input, want.output)
+		}
+	}
+
+	if _, ok := reusedItems(models.JobHistoryRecordV1{Result: previous.Result}, currentReq); ok {
+		t.Fatal("reusedItems accepted a record without a request")
+	}
+}
@@ -0,0 +1,250 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"fileforge-desktop/internal/models"
+)
+
+// DefaultDomainLimits caps concurrent jobs per tool domain on top of the global
+// limit. ffmpeg and LibreOffice jobs are heavy enough to run one at a time.
+func DefaultDomainLimits() map[string]int {
+	return map[string]int{
+		"video": 1,
+		"doc":   1,
+		"pdf":   2,
+		"image": 4,
+	}
+}
+
+type queueEntry struct {
+	job      *trackedJob
+	domain   string
+	priority int
+	paused   bool
+	ready    chan struct{}
+}
+
+// scheduler hands out execution slots. Waiting jobs are kept in one ordered
+// queue: new jobs are inserted behind every job of equal or higher priority,
+// and the first entry whose domain still has capacity is started next.
+type scheduler struct {
+	mu              sync.Mutex
+	maxRunning      int
+	domainLimits    map[string]int
+	running         int
+	runningByDomain map[string]int
+	queue           []*queueEntry
+	positionSeq     uint64
+}
+
+// positionUpdate is a queue position reported to a job after the scheduler
+// lock is released. seq orders updates taken under the lock, so a job drops
+// one that arrives after a newer update.
+type positionUpdate struct {
+	job      *trackedJob
+	seq      uint64
+	position int
+	paused   bool
+}
+
+func newScheduler(maxRunning int, domainLimits map[string]int) *scheduler {
+	return &scheduler{
+		maxRunning:      maxRunning,
+		domainLimits:    domainLimits,
+		runningByDomain: make(map[string]int),
+	}
+}
+
+func toolDomain(toolID string) string {
+	parts := strings.Split(toolID, ".")
+	if len(parts) >= 3 && parts[0] == "tool" {
+		return parts[1]
+	}
+	return toolID
+}
+
+func priorityRank(priority string) int {
+	switch strings.ToLower(strings.TrimSpace(priority)) {
+	case models.JobPriorityHigh:
+		return 2
+	case models.JobPriorityLow:
+		return 0
+	default:
+		return 1
+	}
+}
+
+func validatePriority(priority string) *models.JobErrorV1 {
+	switch strings.ToLower(strings.TrimSpace(priority)) {
+	case "", models.JobPriorityLow, models.JobPriorityNormal, models.JobPriorityHigh:
+		return nil
+	default:
+		return models.NewCanonicalJobError("JOB_PRIORITY_INVALID", fmt.Sprintf("priority must be low, normal or high, got '%s'", priority), map[string]any{"priority": priority})
+	}
+}
+
+// acquire blocks until the job may run. It returns the context error when the
+// job is cancelled while still queued.
+func (s *scheduler) acquire(ctx context.Context, job *trackedJob, domain string, priority int) (*queueEntry, error) {
+	entry := &queueEntry{job: job, domain: domain, priority: priority, ready: make(chan struct{})}
+
+	s.mu.Lock()
+	insertAt := len(s.queue)
+	for index, queued := range s.queue {
+		if queued.priority < priority {
+			insertAt = index
+			break
+		}
+	}
+	s.queue = append(s.queue, nil)
+	copy(s.queue[insertAt+1:], s.queue[insertAt:])
+	s.queue[insertAt] = entry
+	s.dispatchLocked()
+	updates := s.positionsLocked()
+	s.mu.Unlock()
+	publishPositions(updates)
+
+	select {
+	case <-entry.ready:
+		return entry, nil
+	case <-ctx.Done():
+	}
+
+	s.mu.Lock()
+	select {
+	case <-entry.ready:
+		// Dispatched while the cancellation was racing in; give the slot back.
+		s.releaseLocked(entry)
+	default:
+		s.removeLocked(entry)
+	}
+	updates = s.positionsLocked()
+	s.mu.Unlock()
+	publishPositions(updates)
+
+	return nil, ctx.Err()
+}
+
+func (s *scheduler) release(entry *queueEntry) {
+	s.mu.Lock()
+	s.releaseLocked(entry)
+	updates := s.positionsLocked()
+	s.mu.Unlock()
+	publishPositions(updates)
+}
+
+func (s *scheduler) releaseLocked(entry *queueEntry) {
+	s.running--
+	s.runningByDomain[entry.domain]--
+	s.dispatchLocked()
+}
+
+func (s *scheduler) dispatchLocked() {
+	remaining := s.queue[:0]
+	for _, entry := range s.queue {
+		if !entry.paused && s.hasCapacityLocked(entry.domain) {
+			s.running++
+			s.runningByDomain[entry.domain]++
+			close(entry.ready)
+			continue
+		}
+		remaining = append(remaining, entry)
+	}
+	for index := len(remaining); index < len(s.queue); index++ {
+		s.queue[index] = nil
+	}
+	s.queue = remaining
+}
+
+func (s *scheduler) hasCapacityLocked(domain string) bool {
+	if s.maxRunning > 0 && s.running >= s.maxRunning {
+		return false
+	}
+	if limit, ok := s.domainLimits[domain]; ok && limit > 0 && s.runningByDomain[domain] >= limit {
+		return false
+	}
+	return true
+}
+
+func (s *scheduler) removeLocked(entry *queueEntry) {
+	for index, queued := range s.queue {
+		if queued == entry {
+			s.queue = append(s.queue[:index], s.queue[index+1:]...)
+			return
+		}
+	}
+}
+
+func (s *scheduler) indexLocked(job *trackedJob) int {
+	for index, queued := range s.queue {
+		if queued.job == job {
+			return index
+		}
+	}
+	return -1
+}
+
+// move places a queued job at the given 1-based position and returns the
+// position it ended up at.
+func (s *scheduler) move(job *trackedJob, position int) (int, bool) {
+	s.mu.Lock()
+	index := s.indexLocked(job)
+	if index < 0 {
+		s.mu.Unlock()
+		return 0, false
+	}
+
+	entry := s.queue[index]
+	s.queue = append(s.queue[:index], s.queue[index+1:]...)
+	target := min(max(position-1, 0), len(s.queue))
+	s.queue = append(s.queue, nil)
+	copy(s.queue[target+1:], s.queue[target:])
+	s.queue[target] = entry
+	s.dispatchLocked()
+	final := s.indexLocked(job) + 1
+	updates := s.positionsLocked()
+	s.mu.Unlock()
+	publishPositions(updates)
+
+	return final, true
+}
+
+func (s *scheduler) setPaused(job *trackedJob, paused bool) (int, bool) {
+	s.mu.Lock()
+	index := s.indexLocked(job)
+	if index < 0 {
+		s.mu.Unlock()
+		return 0, false
+	}
+
+	s.queue[index].paused = paused
+	s.dispatchLocked()
+	final := s.indexLocked(job) + 1
+	updates := s.positionsLocked()
+	s.mu.Unlock()
+	publishPositions(updates)
+
+	return final, true
+}
+
+// positionsLocked takes the position of every queued job. The updates are
+// published once the lock is released, because listeners emit events and
+// persist the job state.
+func (s *scheduler) positionsLocked() []positionUpdate {
+	s.positionSeq++
+	updates := make([]positionUpdate, 0, len(s.queue))
+	for index, entry := range s.queue {
+		updates = append(updates, positionUpdate{job: entry.job, seq: s.positionSeq, position: index + 1, paused: entry.paused})
+	}
+	return updates
+}
+
+func publishPositions(updates []positionUpdate) {
+	for _, update := range updates {
+		update.job.setQueuePosition(update.seq, update.position, update.paused)
+	}
+}
@@ -0,0 +1,133 @@
+package jobs
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"fileforge-desktop/internal/models"
+)
+
+func dispatched(entry chan *queueEntry) bool {
+	select {
+	case <-entry:
+		return true
+	case <-time.After(50 * time.Millisecond):
+		return false
+	}
+}
+
+func acquireAsync(s *scheduler, job *trackedJob, domain string, priority int) chan *queueEntry {
+	ready := make(chan *queueEntry, 1)
+	go func() {
+		entry, err := s.acquire(context.Background(), job, domain, priority)
+		if err == nil {
+			ready <- entry
+		}
+	}()
+	return ready
+}
+
+func TestSchedulerDomainLimit(t *testing.T) {
+	s := newScheduler(3, DefaultDomainLimits())
+
+	first, err := s.acquire(context.Background(), &trackedJob{}, "video", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second := acquireAsync(s, &trackedJob{}, "video", 1)
+	if dispatched(second) {
+		t.Fatal("second video job started while the video slot was taken")
+	}
+	if _, err := s.acquire(context.Background(), &trackedJob{}, "image", 1); err != nil {
+		t.Fatalf("image job blocked by the video limit: %v", err)
+	}
+
+	s.release(first)
+	if !dispatched(second) {
+		t.Fatal("second video job did not start after the slot was released")
+	}
+}
+
+func TestSchedulerStartsHigherPriorityFirst(t *testing.T) {
+	s := newScheduler(1, nil)
+	running, err := s.acquire(context.Background(), &trackedJob{}, "image", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	low := acquireAsync(s, &trackedJob{}, "image", priorityRank("low"))
+	time.Sleep(10 * time.Millisecond)
+	high := acquireAsync(s, &trackedJob{}, "image", priorityRank("high"))
+	time.Sleep(10 * time.Millisecond)
+
+	s.release(running)
+	if !dispatched(high) {
+		t.Fatal("high priority job did not start first")
+	}
+	if dispatched(low) {
+		t.Fatal("low priority job started alongside the high priority one")
+	}
+}
+
+func TestSchedulerCancelWhileQueued(t *testing.T) {
+	s := newScheduler(1, nil)
+	running, err := s.acquire(context.Background(), &trackedJob{}, "image", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		_, err := s.acquire(ctx, &trackedJob{}, "image", 1)
+		done <- err
+	}()
+	time.Sleep(10 * time.Millisecond)
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Fatalf("acquire error = %v, want context.Canceled", err)
+	}
+
+	s.release(running)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.running != 0 || len(s.queue) != 0 {
+		t.Fatalf("scheduler not empty: running=%d queued=%d", s.running, len(s.queue))
+	}
+}
+
+// Queue positions reach listeners after the scheduler lock is released, and a
+// position published late never overwrites a newer one.
+func TestSchedulerPublishesPositionsOutsideItsLock(t *testing.T) {
+	s := newScheduler(1, nil)
+	running, err := s.acquire(context.Background(), &trackedJob{}, "image", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	positions := make(chan int, 4)
+	job := &trackedJob{result: models.JobResultV1{JobID: "job_q", Status: StatusQueued}}
+	job.onProgressEvent = func(evt models.JobProgressEventV1) {
+		if !s.mu.TryLock() {
+			t.Error("listener ran under the scheduler lock")
+		} else {
+			s.mu.Unlock()
+		}
+		positions <- evt.Progress.QueuePosition
+	}
+	queued := acquireAsync(s, job, "image", 1)
+	if got := <-positions; got != 1 {
+		t.Fatalf("queue position = %d, want 1", got)
+	}
+
+	job.setQueuePosition(job.positionSeq, 3, false)
+	if got := job.snapshot().Progress.QueuePosition; got != 1 {
+		t.Fatalf("stale update moved the job to position %d", got)
+	}
+
+	s.release(running)
+	if !dispatched(queued) {
+		t.Fatal("queued job did not start after the slot was released")
+	}
+}
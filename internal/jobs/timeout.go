@@ -0,0 +1,116 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"fileforge-desktop/internal/models"
+	"fileforge-desktop/internal/tools"
+)
+
+const (
+	jobTimeoutDetailCode  = "JOB_TIMEOUT"
+	itemTimeoutDetailCode = "JOB_ITEM_TIMEOUT"
+)
+
+type jobTimeouts struct {
+	job  time.Duration
+	item time.Duration
+}
+
+// resolveTimeouts takes the job limit from the request and the item limit from
+// the request or, failing that, the tool manifest.
+func resolveTimeouts(tool tools.Tool, req models.JobRequestV1) jobTimeouts {
+	limits := jobTimeouts{job: time.Duration(req.TimeoutMillis) * time.Millisecond}
+
+	itemMillis := req.ItemTimeoutMillis
+	if itemMillis == 0 {
+		itemMillis = tool.Manifest().ItemTimeoutMillis
+	}
+	limits.item = time.Duration(itemMillis) * time.Millisecond
+
+	return limits
+}
+
+func validateTimeouts(req models.JobRequestV1) *models.JobErrorV1 {
+	if req.TimeoutMillis < 0 || req.ItemTimeoutMillis < 0 {
+		return models.NewJobError(models.ErrorCodeValidationInvalidInput, "JOB_TIMEOUT_INVALID", fmt.Sprintf("timeouts must not be negative, got timeoutMillis=%d itemTimeoutMillis=%d", req.TimeoutMillis, req.ItemTimeoutMillis), map[string]any{
+			"timeoutMillis":     req.TimeoutMillis,
+			"itemTimeoutMillis": req.ItemTimeoutMillis,
+		})
+	}
+	return nil
+}
+
+// startDeadline bounds the job context by the job timeout. It is called from
+// the run goroutine once the job holds a scheduler slot, so queue time does not
+// count; cancelling the job still goes through the original cancel func.
+func (j *trackedJob) startDeadline(timeout time.Duration) context.CancelFunc {
+	if timeout <= 0 {
+		return func() {}
+	}
+
+	ctx, cancel := context.WithTimeout(j.ctx, timeout)
+	j.ctx = ctx
+	return cancel
+}
+
+// stoppedOutcome is the outcome of a job whose context ended before the tool
+// finished: timed_out when the job timeout expired, cancelled otherwise.
+func stoppedOutcome(job *trackedJob, items []models.JobResultItemV1, details map[string]any) jobOutcome {
+	if job.ctx.Err() == context.DeadlineExceeded {
+		if details == nil {
+			details = map[string]any{}
+		}
+		if deadline, ok := job.ctx.Deadline(); ok {
+			details["deadline"] = deadline.UnixMilli()
+		}
+		return jobOutcome{status: StatusTimedOut, message: "job timed out", items: items, err: models.NewCanonicalJobError(jobTimeoutDetailCode, "job exceeded its timeout", details)}
+	}
+
+	return jobOutcome{status: StatusCancelled, message: "job cancelled", items: items, err: models.NewCanonicalJobError("JOB_CANCELLED", job.ctx.Err().Error(), details)}
+}
+
+func itemTimeoutError(timeout time.Duration) *models.JobErrorV1 {
+	return models.NewJobError(models.ErrorCodeExecTimeoutTransient, itemTimeoutDetailCode, "item exceeded its timeout", map[string]any{"timeoutMillis": timeout.Milliseconds()})
+}
+
+// withItemTimeout bounds a single-mode execution, which is one item, by the
+// item timeout.
+func withItemTimeout(timeout time.Duration, execute func(ctx context.Context) (models.JobResultItemV1, *models.JobErrorV1)) func(ctx context.Context) (models.JobResultItemV1, *models.JobErrorV1) {
+	if timeout <= 0 {
+		return execute
+	}
+
+	return func(ctx context.Context) (models.JobResultItemV1, *models.JobErrorV1) {
+		itemCtx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+
+		item, jobErr := execute(itemCtx)
+		if jobErr != nil && ctx.Err() == nil && itemCtx.Err() == context.DeadlineExceeded {
+			jobErr = itemTimeoutError(timeout)
+			item.Status = models.JobItemStatusTimedOut
+			item.Error = jobErr
+		}
+		return item, jobErr
+	}
+}
+
+// markTimedOutItems replaces the tool error of batch items whose item context
+// expired, so they surface as timed_out with a transient timeout error.
+func markTimedOutItems(job *trackedJob, timeout time.Duration, items []models.JobResultItemV1) {
+	if timeout <= 0 || job.itemCancels == nil {
+		return
+	}
+
+	for i := range items {
+		if items[i].Success || items[i].Status == models.JobItemStatusCancelled {
+			continue
+		}
+		if job.itemCancels.TimedOut(items[i].InputPath) {
+			items[i].Status = models.JobItemStatusTimedOut
+			items[i].Error = itemTimeoutError(timeout)
+		}
+	}
+}
@@ -2,11 +2,13 @@ package jobs
 
 import (
 	"context"
+	"fmt"
 	"math"
 	"sync"
 	"time"
 
 	"fileforge-desktop/internal/models"
+	"fileforge-desktop/internal/tools"
 )
 
 type trackedJob struct {
@@ -16,6 +18,16 @@ type trackedJob struct {
 	result          models.JobResultV1
 	onProgressEvent func(models.JobProgressEventV1)
 	onStateChanged  func()
+	onCompleted     func(models.JobResultV1)
+	request         *models.JobRequestV1
+	pipeline        *models.PipelineRequestV1
+	checkpoints     []models.JobResultItemV1
+	itemCancels     *tools.ItemCancellation
+	tempOutputs     *tools.TempOutputs
+	fingerprint     string
+	publish         func(models.JobEventV1)
+	emittedItems    map[string]struct{}
+	positionSeq     uint64 // newest scheduler position update applied
 }
 
 func (j *trackedJob) snapshot() models.JobResultV1 {
@@ -24,9 +36,49 @@ func (j *trackedJob) snapshot() models.JobResultV1 {
 	return j.result
 }
 
+// persisted returns the state needed to resume the job after a restart.
+func (j *trackedJob) persisted() persistedJobV1 {
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+	state := persistedJobV1{
+		JobResultV1: j.result,
+		Request:     j.request,
+		Pipeline:    j.pipeline,
+		Checkpoints: append([]models.JobResultItemV1(nil), j.checkpoints...),
+	}
+	if j.request != nil {
+		state.InputSubdirs = j.request.InputSubdirs
+	}
+	return state
+}
+
+// setRequest replaces the stored request once the inputs of a waiting job are
+// expanded.
+func (j *trackedJob) setRequest(req models.JobRequestV1) {
+	j.mu.Lock()
+	j.request = &req
+	j.result.Progress.Total = len(req.InputPaths)
+	j.mu.Unlock()
+}
+
+func (j *trackedJob) recordStep(step models.PipelineStepResultV1) {
+	j.mu.Lock()
+	j.result.Steps = append(j.result.Steps, step)
+	j.mu.Unlock()
+}
+
 func (j *trackedJob) updateProgress(progress models.JobProgressV1) {
 	j.mu.Lock()
 	progress.ETASeconds = estimateETASeconds(j.result.StartedAt, progress.Current, progress.Total)
+	started := progress.Stage == StatusRunning && j.result.Status != StatusRunning
+	if progress.Item != nil {
+		item := identifyItem(*progress.Item)
+		progress.Item = &item
+		if item.Success {
+			j.checkpoints = append(j.checkpoints, item)
+		}
+		j.markItemEmittedLocked(item.ItemID)
+	}
 	j.result.Progress = progress
 	if progress.Stage != "" {
 		j.result.Status = progress.Stage
@@ -44,11 +96,92 @@ func (j *trackedJob) updateProgress(progress models.JobProgressV1) {
 		listener(evt)
 	}
 
+	if started {
+		j.emitEvent(models.JobEventV1{Type: models.JobEventStarted, Progress: &progress})
+	}
+	if progress.Item != nil {
+		j.emitEvent(models.JobEventV1{Type: models.JobEventItemCompleted, Item: progress.Item})
+	}
+
 	if j.onStateChanged != nil {
 		j.onStateChanged()
 	}
 }
 
+// transition moves a job that has not started yet between the waiting and
+// queued states.
+func (j *trackedJob) transition(status, message string) {
+	j.mu.Lock()
+	j.result.Status = status
+	j.result.Message = "job " + status
+	j.result.Progress.Stage = status
+	j.result.Progress.Message = message
+	evt := models.JobProgressEventV1{
+		JobID:    j.result.JobID,
+		ToolID:   j.result.ToolID,
+		Status:   j.result.Status,
+		Progress: j.result.Progress,
+	}
+	listener := j.onProgressEvent
+	j.mu.Unlock()
+
+	if listener != nil {
+		listener(evt)
+	}
+
+	if j.onStateChanged != nil {
+		j.onStateChanged()
+	}
+}
+
+func (j *trackedJob) setQueuePosition(seq uint64, position int, paused bool) {
+	j.mu.Lock()
+	if seq <= j.positionSeq {
+		// A newer position was published first.
+		j.mu.Unlock()
+		return
+	}
+	j.positionSeq = seq
+	if j.result.Status != StatusQueued && j.result.Status != StatusPaused {
+		j.mu.Unlock()
+		return
+	}
+
+	status, message := StatusQueued, fmt.Sprintf("queued (position %d)", position)
+	if paused {
+		status, message = StatusPaused, fmt.Sprintf("paused (position %d)", position)
+	}
+	if j.result.Status == status && j.result.Progress.QueuePosition == position {
+		j.mu.Unlock()
+		return
+	}
+
+	statusChanged := j.result.Status != status
+	j.result.Status = status
+	j.result.Message = "job " + status
+	j.result.Progress.Stage = status
+	j.result.Progress.Message = message
+	j.result.Progress.QueuePosition = position
+	evt := models.JobProgressEventV1{
+		JobID:    j.result.JobID,
+		ToolID:   j.result.ToolID,
+		Status:   j.result.Status,
+		Progress: j.result.Progress,
+	}
+	listener := j.onProgressEvent
+	j.mu.Unlock()
+
+	if listener != nil {
+		listener(evt)
+	}
+
+	// Positions shift on every dispatch and are only informational; persist
+	// pause and resume, not position-only moves.
+	if statusChanged && j.onStateChanged != nil {
+		j.onStateChanged()
+	}
+}
+
 func estimateETASeconds(startedAtMillis int64, current, total int) int {
 	if startedAtMillis <= 0 || total <= 0 || current <= 0 || current >= total {
 		return 0
@@ -70,11 +203,14 @@ func estimateETASeconds(startedAtMillis int64, current, total int) int {
 }
 
 func (j *trackedJob) complete(status string, message string, items []models.JobResultItemV1, jobErr *models.JobErrorV1, endedAt int64) {
+	// Writes still registered here were interrupted; their partial files go.
+	j.tempOutputs.Cleanup()
+
 	j.mu.Lock()
 	j.result.Success = status == models.JobStatusSuccess
 	j.result.Status = status
 	j.result.Message = message
-	j.result.Items = items
+	j.result.Items = identifyItems(items)
 	j.result.Error = jobErr
 	j.result.EndedAt = endedAt
 	if j.result.Progress.Total == 0 {
@@ -97,13 +233,21 @@ func (j *trackedJob) complete(status string, message string, items []models.JobR
 		Progress: j.result.Progress,
 	}
 	listener := j.onProgressEvent
+	final := j.result
 	j.mu.Unlock()
 
 	if listener != nil {
 		listener(evt)
 	}
 
+	j.emitItems(final.Items)
+	j.emitEvent(models.JobEventV1{Type: models.JobEventCompleted, Result: &final})
+
 	if j.onStateChanged != nil {
 		j.onStateChanged()
 	}
+
+	if j.onCompleted != nil {
+		j.onCompleted(final)
+	}
 }
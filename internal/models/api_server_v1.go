@@ -0,0 +1,15 @@
+package models
+
+type APIServerConfigV1 struct {
+	Addr  string `json:"addr,omitempty"`  // loopback host:port, defaults to 127.0.0.1:7345
+	Token string `json:"token,omitempty"` // bearer token, generated when empty
+}
+
+type APIServerStatusResponseV1 struct {
+	Success bool        `json:"success"`
+	Message string      `json:"message"`
+	Running bool        `json:"running"`
+	Addr    string      `json:"addr,omitempty"`
+	Token   string      `json:"token,omitempty"`
+	Error   *JobErrorV1 `json:"error,omitempty"`
+}
@@ -10,6 +10,21 @@ const (
 	JobStatusPartialSuccess = "partial_success"
 	JobStatusCancelled      = "cancelled"
 	JobStatusInterrupted    = "interrupted"
+	JobStatusPaused         = "paused"
+	JobStatusTimedOut       = "timed_out"
+	JobStatusWaiting        = "waiting"
+)
+
+const (
+	JobDependencyAllSucceeded = "all_succeeded"
+	JobDependencyAllFinished  = "all_finished"
+	JobDependencyAnyFinished  = "any_finished"
+)
+
+const (
+	JobPriorityLow    = "low"
+	JobPriorityNormal = "normal"
+	JobPriorityHigh   = "high"
 )
 
 const (
@@ -19,6 +34,7 @@ const (
 	ErrorCodeExecTimeoutTransient   = "EXEC_TIMEOUT_TRANSIENT"
 	ErrorCodeUnsupportedFormat      = "UNSUPPORTED_FORMAT"
 	ErrorCodeCancelledByUser        = "CANCELLED_BY_USER"
+	ErrorCodeDependencyFailed       = "DEPENDENCY_FAILED"
 )
 
 func NewJobError(code, detailCode, message string, details map[string]any) *JobErrorV1 {
@@ -66,6 +82,8 @@ func CanonicalErrorCode(detailCode string) string {
 		return ErrorCodeExecIOTransient
 	case strings.Contains(code, "PDF_PREVIEW"):
 		return ErrorCodeValidationInvalidInput
+	case strings.Contains(code, "JOB_DEPENDENCY_FAILED"):
+		return ErrorCodeDependencyFailed
 	case strings.Contains(code, "RUNTIME"), strings.Contains(code, "DEPENDENCY"), strings.Contains(code, "DEP_MISSING"):
 		return ErrorCodeRuntimeDepMissing
 	case strings.Contains(code, "VALIDATION"), strings.Contains(code, "INVALID"), strings.Contains(code, "NOT_FOUND"), strings.Contains(code, "MISSING"), strings.Contains(code, "PROTECTED"):
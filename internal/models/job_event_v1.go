@@ -0,0 +1,51 @@
+package models
+
+const JobEventVersionV1 = 1
+
+const (
+	JobEventQueued        = "job.queued"
+	JobEventStarted       = "job.started"
+	JobEventItemCompleted = "job.item.completed"
+	JobEventRetrying      = "job.retrying"
+	JobEventCompleted     = "job.completed"
+)
+
+// JobEventV1 is one entry of the job lifecycle stream. Sequence increases by
+// one for every event the orchestrator publishes, across all jobs, so a
+// subscriber that sees a jump knows it missed events and can replay them.
+type JobEventV1 struct {
+	Version   int              `json:"version"`
+	Sequence  uint64           `json:"sequence"`
+	Type      string           `json:"type"`
+	JobID     string           `json:"jobId"`
+	ToolID    string           `json:"toolId"`
+	Status    string           `json:"status"`
+	Timestamp int64            `json:"timestamp"` // unix millis
+	Progress  *JobProgressV1   `json:"progress,omitempty"`
+	Item      *JobResultItemV1 `json:"item,omitempty"`   // job.item.completed
+	Retry     *JobRetryV1      `json:"retry,omitempty"`  // job.retrying
+	Result    *JobResultV1     `json:"result,omitempty"` // job.completed
+}
+
+type JobRetryV1 struct {
+	Attempt     int         `json:"attempt"` // the attempt that just failed
+	MaxAttempts int         `json:"maxAttempts"`
+	DelayMillis int64       `json:"delayMillis"`
+	InputPaths  []string    `json:"inputPaths,omitempty"` // batch inputs being retried
+	Error       *JobErrorV1 `json:"error,omitempty"`
+}
+
+type JobEventsQueryV1 struct {
+	AfterSequence uint64 `json:"afterSequence"`
+	JobID         string `json:"jobId,omitempty"`
+	Limit         int    `json:"limit,omitempty"`
+}
+
+type JobEventsResponseV1 struct {
+	Success        bool         `json:"success"`
+	Message        string       `json:"message"`
+	Events         []JobEventV1 `json:"events"`
+	LatestSequence uint64       `json:"latestSequence"`
+	Complete       bool         `json:"complete"` // false when older events were already dropped from the buffer
+	Error          *JobErrorV1  `json:"error,omitempty"`
+}
@@ -0,0 +1,18 @@
+package models
+
+// JobGraphV1 is the dependency neighbourhood of a job: every job it waits on,
+// directly or transitively, and every job waiting on it. Edges are given by
+// DependsOn on each node.
+type JobGraphV1 struct {
+	JobID string           `json:"jobId"`
+	Nodes []JobGraphNodeV1 `json:"nodes"`
+}
+
+type JobGraphNodeV1 struct {
+	JobID          string   `json:"jobId"`
+	ToolID         string   `json:"toolId"`
+	Status         string   `json:"status"`
+	DependsOn      []string `json:"dependsOn,omitempty"`
+	DependencyRule string   `json:"dependencyRule,omitempty"`
+	ResumedBy      string   `json:"resumedBy,omitempty"`
+}
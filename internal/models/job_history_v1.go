@@ -0,0 +1,51 @@
+package models
+
+type JobHistoryRecordV1 struct {
+	JobID          string             `json:"jobId"`
+	ToolID         string             `json:"toolId"`
+	Status         string             `json:"status"`
+	Request        *JobRequestV1      `json:"request,omitempty"`
+	Pipeline       *PipelineRequestV1 `json:"pipeline,omitempty"`
+	Result         JobResultV1        `json:"result"`
+	StartedAt      int64              `json:"startedAt"`
+	EndedAt        int64              `json:"endedAt"`
+	DurationMillis int64              `json:"durationMillis"`
+	RecordedAt     int64              `json:"recordedAt"`
+	Fingerprint    string             `json:"fingerprint,omitempty"`
+	OutputStamps   []JobOutputStampV1 `json:"outputStamps,omitempty"`
+	InputSubdirs   map[string]string  `json:"inputSubdirs,omitempty"` // Request.InputSubdirs, which the request does not serialize
+}
+
+// JobOutputStampV1 identifies an output file as it was when its job finished,
+// so a later job can tell whether the file is still there and unchanged.
+type JobOutputStampV1 struct {
+	Path    string `json:"path"`
+	Size    int64  `json:"size"`
+	ModTime int64  `json:"modTime"` // unix nanoseconds
+}
+
+type JobHistoryQueryV1 struct {
+	ToolID    string `json:"toolId,omitempty"`
+	Status    string `json:"status,omitempty"`
+	From      int64  `json:"from,omitempty"` // unix millis, inclusive, matched against startedAt
+	To        int64  `json:"to,omitempty"`   // unix millis, inclusive, matched against startedAt
+	InputPath string `json:"inputPath,omitempty"`
+	Limit     int    `json:"limit,omitempty"`
+	Offset    int    `json:"offset,omitempty"`
+}
+
+type ListJobsResponseV1 struct {
+	Success bool                 `json:"success"`
+	Message string               `json:"message"`
+	Total   int                  `json:"total"`
+	Records []JobHistoryRecordV1 `json:"records"`
+	Error   *JobErrorV1          `json:"error,omitempty"`
+}
+
+type JobHistoryResponseV1 struct {
+	Success bool                `json:"success"`
+	Message string              `json:"message"`
+	Found   bool                `json:"found"`
+	Record  *JobHistoryRecordV1 `json:"record,omitempty"`
+	Error   *JobErrorV1         `json:"error,omitempty"`
+}
@@ -0,0 +1,56 @@
+package models
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	JobItemStatusSuccess   = "success"
+	JobItemStatusFailed    = "failed"
+	JobItemStatusCancelled = "cancelled"
+	JobItemStatusTimedOut  = "timed_out"
+	JobItemStatusSkipped   = "skipped"
+)
+
+// ItemIDV1 derives the stable identifier of a batch item from its input path.
+func ItemIDV1(inputPath string) string {
+	trimmed := strings.TrimSpace(inputPath)
+	if trimmed == "" {
+		return ""
+	}
+	sum := sha1.Sum([]byte(filepath.Clean(trimmed)))
+	return "item_" + hex.EncodeToString(sum[:6])
+}
+
+// CancelledItemV1 is the result reported for an item dropped through a
+// per-item cancel request.
+func CancelledItemV1(inputPath, outputPath string) JobResultItemV1 {
+	return JobResultItemV1{
+		ItemID:     ItemIDV1(inputPath),
+		InputPath:  inputPath,
+		OutputPath: outputPath,
+		Status:     JobItemStatusCancelled,
+		Success:    false,
+		Message:    "item cancelled",
+		Error:      NewJobError(ErrorCodeCancelledByUser, "JOB_ITEM_CANCELLED", "item cancelled by user", map[string]any{"inputPath": inputPath}),
+	}
+}
+
+// SkippedItemV1 is the result reported for an item whose output already
+// existed under the skip conflict policy. The existing file counts as the
+// item's output.
+func SkippedItemV1(inputPath, outputPath string) JobResultItemV1 {
+	return JobResultItemV1{
+		ItemID:      ItemIDV1(inputPath),
+		InputPath:   inputPath,
+		OutputPath:  outputPath,
+		Outputs:     []string{outputPath},
+		OutputCount: 1,
+		Status:      JobItemStatusSkipped,
+		Success:     true,
+		Message:     "output already exists, skipped",
+	}
+}
@@ -0,0 +1,42 @@
+package models
+
+const (
+	PlanActionCreate    = "create"
+	PlanActionOverwrite = "overwrite"
+	PlanActionRename    = "rename"
+	PlanActionSkip      = "skip"
+)
+
+// JobPlanV1 is the dry run of a job request: what the tool would write,
+// worked out without touching the output files.
+type JobPlanV1 struct {
+	ToolID         string       `json:"toolId"`
+	Mode           string       `json:"mode"`
+	Items          []PlanItemV1 `json:"items"`
+	OutputCount    int          `json:"outputCount"`              // outputs that would be written; skipped ones excluded
+	EstimatedBytes int64        `json:"estimatedBytes,omitempty"` // rough total size of the written outputs, 0 when unknown
+	ConflictCount  int          `json:"conflictCount"`            // outputs that already exist or collide within the job
+	FailureCount   int          `json:"failureCount"`             // items that would fail before writing anything
+}
+
+type PlanItemV1 struct {
+	ItemID         string         `json:"itemId"`
+	InputPath      string         `json:"inputPath"`
+	Outputs        []PlanOutputV1 `json:"outputs,omitempty"`
+	Warnings       []string       `json:"warnings,omitempty"`
+	EstimatedBytes int64          `json:"estimatedBytes,omitempty"`
+	Error          *JobErrorV1    `json:"error,omitempty"`
+}
+
+type PlanOutputV1 struct {
+	Path          string `json:"path"`
+	Action        string `json:"action"`                  // create, overwrite, rename or skip
+	RequestedPath string `json:"requestedPath,omitempty"` // name the template produced, when it was renamed
+}
+
+type PlanJobResponseV1 struct {
+	Success bool        `json:"success"`
+	Message string      `json:"message"`
+	Plan    *JobPlanV1  `json:"plan,omitempty"`
+	Error   *JobErrorV1 `json:"error,omitempty"`
+}
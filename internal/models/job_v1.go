@@ -8,12 +8,40 @@ type JobErrorV1 struct {
 }
 
 type JobRequestV1 struct {
-	ToolID     string         `json:"toolId"`
-	Mode       string         `json:"mode"` // single | batch
-	InputPaths []string       `json:"inputPaths"`
-	OutputDir  string         `json:"outputDir"`
-	Options    map[string]any `json:"options"`
-	Workers    int            `json:"workers,omitempty"`
+	ToolID            string            `json:"toolId"`
+	Mode              string            `json:"mode"` // single | batch
+	InputPaths        []string          `json:"inputPaths"`
+	OutputDir         string            `json:"outputDir"`
+	Options           map[string]any    `json:"options"`
+	Preset            string            `json:"preset,omitempty"` // saved preset for ToolID; Options override its fields
+	Workers           int               `json:"workers,omitempty"`
+	Priority          string            `json:"priority,omitempty"` // low | normal | high
+	Retry             *RetryPolicyV1    `json:"retry,omitempty"`
+	TimeoutMillis     int64             `json:"timeoutMillis,omitempty"`     // whole run, from leaving the queue
+	ItemTimeoutMillis int64             `json:"itemTimeoutMillis,omitempty"` // per input, overrides the tool default
+	DisableReuse      bool              `json:"disableReuse,omitempty"`      // always run, even if an identical job succeeded before
+	DependsOn         []string          `json:"dependsOn,omitempty"`         // job IDs that must finish first
+	DependencyRule    string            `json:"dependencyRule,omitempty"`    // all_succeeded (default) | all_finished | any_finished
+	Expand            *InputExpansionV1 `json:"expand,omitempty"`
+	InputSubdirs      map[string]string `json:"-"`                        // input -> directory under OutputDir for its outputs in batch mode; set only by expansion
+	ConflictPolicy    string            `json:"conflictPolicy,omitempty"` // error | overwrite | rename (default) | skip, for outputs that already exist
+}
+
+const (
+	ConflictPolicyError     = "error"
+	ConflictPolicyOverwrite = "overwrite"
+	ConflictPolicyRename    = "rename"
+	ConflictPolicySkip      = "skip"
+)
+
+// InputExpansionV1 controls how directories and glob patterns listed in
+// InputPaths are turned into files. Listed files are used as they are.
+type InputExpansionV1 struct {
+	Recursive  bool     `json:"recursive,omitempty"`  // also walk the sub-directories of listed directories
+	Include    []string `json:"include,omitempty"`    // keep files matching any pattern, all when empty
+	Exclude    []string `json:"exclude,omitempty"`    // drop files matching any pattern
+	Extensions []string `json:"extensions,omitempty"` // defaults to the tool's InputExtensions
+	Flatten    bool     `json:"flatten,omitempty"`    // write every output directly into OutputDir
 }
 
 type JobProgressV1 struct {
@@ -22,6 +50,20 @@ type JobProgressV1 struct {
 	Stage      string `json:"stage"`
 	Message    string `json:"message"`
 	ETASeconds int    `json:"etaSeconds,omitempty"`
+	// QueuePosition is the 1-based position while the job waits for a slot.
+	QueuePosition int `json:"queuePosition,omitempty"`
+	// Item is the batch item that just finished, when the executor reports one.
+	Item *JobResultItemV1 `json:"item,omitempty"`
+}
+
+// LastResultItemV1 returns a copy of the most recently appended item, for use
+// as JobProgressV1.Item by batch executors.
+func LastResultItemV1(items []JobResultItemV1) *JobResultItemV1 {
+	if len(items) == 0 {
+		return nil
+	}
+	item := items[len(items)-1]
+	return &item
 }
 
 type JobProgressEventV1 struct {
@@ -32,28 +74,37 @@ type JobProgressEventV1 struct {
 }
 
 type JobResultItemV1 struct {
-	InputPath   string      `json:"inputPath"`
-	OutputPath  string      `json:"outputPath"`
-	Outputs     []string    `json:"outputs,omitempty"`
-	OutputCount int         `json:"outputCount,omitempty"`
-	Attempts    int         `json:"attempts,omitempty"`
-	RetryCount  int         `json:"retryCount,omitempty"`
-	Success     bool        `json:"success"`
-	Message     string      `json:"message"`
-	Error       *JobErrorV1 `json:"error,omitempty"`
+	ItemID        string      `json:"itemId,omitempty"`
+	InputPath     string      `json:"inputPath"`
+	OutputPath    string      `json:"outputPath"`
+	Outputs       []string    `json:"outputs,omitempty"`
+	OutputCount   int         `json:"outputCount,omitempty"`
+	OutputWidth   int         `json:"outputWidth,omitempty"` // pixel size of image outputs, 0 for other tools
+	OutputHeight  int         `json:"outputHeight,omitempty"`
+	OutputBytes   int64       `json:"outputBytes,omitempty"`   // encoded size, reported by tools targeting a file size
+	OutputQuality int         `json:"outputQuality,omitempty"` // encoder quality the output was written with
+	Attempts      int         `json:"attempts,omitempty"`
+	RetryCount    int         `json:"retryCount,omitempty"`
+	Status        string      `json:"status,omitempty"` // success | failed | cancelled | timed_out | skipped
+	Success       bool        `json:"success"`
+	Message       string      `json:"message"`
+	Error         *JobErrorV1 `json:"error,omitempty"`
 }
 
 type JobResultV1 struct {
-	JobID     string            `json:"jobId"`
-	Success   bool              `json:"success"`
-	Message   string            `json:"message"`
-	ToolID    string            `json:"toolId"`
-	Status    string            `json:"status"`
-	Progress  JobProgressV1     `json:"progress"`
-	Items     []JobResultItemV1 `json:"items"`
-	Error     *JobErrorV1       `json:"error,omitempty"`
-	StartedAt int64             `json:"startedAt"`
-	EndedAt   int64             `json:"endedAt,omitempty"`
+	JobID       string                 `json:"jobId"`
+	Success     bool                   `json:"success"`
+	Message     string                 `json:"message"`
+	ToolID      string                 `json:"toolId"`
+	Status      string                 `json:"status"`
+	Progress    JobProgressV1          `json:"progress"`
+	Items       []JobResultItemV1      `json:"items"`
+	Error       *JobErrorV1            `json:"error,omitempty"`
+	Steps       []PipelineStepResultV1 `json:"steps,omitempty"`
+	ResumedFrom string                 `json:"resumedFrom,omitempty"`
+	ReusedFrom  string                 `json:"reusedFrom,omitempty"`
+	StartedAt   int64                  `json:"startedAt"`
+	EndedAt     int64                  `json:"endedAt,omitempty"`
 }
 
 type ValidateJobResponseV1 struct {
@@ -76,6 +127,7 @@ type JobStatusResponseV1 struct {
 	Message string       `json:"message"`
 	Found   bool         `json:"found"`
 	Result  *JobResultV1 `json:"result,omitempty"`
+	Graph   *JobGraphV1  `json:"graph,omitempty"`
 	Error   *JobErrorV1  `json:"error,omitempty"`
 }
 
@@ -86,6 +138,24 @@ type CancelJobResponseV1 struct {
 	Error   *JobErrorV1 `json:"error,omitempty"`
 }
 
+type CancelJobItemResponseV1 struct {
+	Success   bool        `json:"success"`
+	Message   string      `json:"message"`
+	JobID     string      `json:"jobId"`
+	InputPath string      `json:"inputPath"`
+	ItemID    string      `json:"itemId,omitempty"`
+	Error     *JobErrorV1 `json:"error,omitempty"`
+}
+
+type QueueJobResponseV1 struct {
+	Success       bool        `json:"success"`
+	Message       string      `json:"message"`
+	JobID         string      `json:"jobId"`
+	Status        string      `json:"status,omitempty"`
+	QueuePosition int         `json:"queuePosition,omitempty"`
+	Error         *JobErrorV1 `json:"error,omitempty"`
+}
+
 type PDFPreviewSourceResponseV1 struct {
 	Success    bool        `json:"success"`
 	Message    string      `json:"message"`
@@ -101,6 +171,7 @@ type ImagePreviewSourceResponseV1 struct {
 	MimeType   string      `json:"mimeType,omitempty"`
 	Width      int         `json:"width,omitempty"`
 	Height     int         `json:"height,omitempty"`
+	PageCount  int         `json:"pageCount,omitempty"` // pages of a multi-page TIFF; the source shows page 1
 	Error      *JobErrorV1 `json:"error,omitempty"`
 }
 
@@ -112,6 +183,7 @@ type ImageCropPreviewRequestV1 struct {
 	Height      int    `json:"height"`
 	RatioPreset string `json:"ratioPreset,omitempty"`
 	Format      string `json:"format,omitempty"`
+	Page        int    `json:"page,omitempty"` // 1-based page of a multi-page TIFF
 }
 
 type ImageCropPreviewResponseV1 struct {
@@ -124,6 +196,45 @@ type ImageCropPreviewResponseV1 struct {
 	Error      *JobErrorV1 `json:"error,omitempty"`
 }
 
+// ImageMetadataV1 is the metadata report of one image. EXIF holds the named
+// IFD0 and Exif tags; GPS and ICC are decoded separately.
+type ImageMetadataV1 struct {
+	InputPath   string             `json:"inputPath,omitempty"`
+	Format      string             `json:"format"`
+	Width       int                `json:"width"`
+	Height      int                `json:"height"`
+	Orientation int                `json:"orientation,omitempty"` // EXIF orientation, 1 = upright
+	Channels    int                `json:"channels,omitempty"`
+	HasAlpha    bool               `json:"hasAlpha"`
+	HasEXIF     bool               `json:"hasExif"`
+	HasGPS      bool               `json:"hasGps"`
+	HasICC      bool               `json:"hasIcc"`
+	HasXMP      bool               `json:"hasXmp"`
+	EXIF        map[string]any     `json:"exif,omitempty"`
+	GPS         *ImageGPSV1        `json:"gps,omitempty"`
+	ICC         *ImageICCProfileV1 `json:"icc,omitempty"`
+}
+
+type ImageGPSV1 struct {
+	Latitude  float64  `json:"latitude"`           // decimal degrees, negative south
+	Longitude float64  `json:"longitude"`          // decimal degrees, negative west
+	Altitude  *float64 `json:"altitude,omitempty"` // metres, negative below sea level
+	Timestamp string   `json:"timestamp,omitempty"`
+}
+
+type ImageICCProfileV1 struct {
+	Description string `json:"description,omitempty"`
+	ColorSpace  string `json:"colorSpace,omitempty"`
+	Bytes       int    `json:"bytes"`
+}
+
+type ImageMetadataResponseV1 struct {
+	Success  bool             `json:"success"`
+	Message  string           `json:"message"`
+	Metadata *ImageMetadataV1 `json:"metadata,omitempty"`
+	Error    *JobErrorV1      `json:"error,omitempty"`
+}
+
 type ImageAnnotateOperationV1 struct {
 	Type          string  `json:"type"`
 	X             int     `json:"x,omitempty"`
@@ -145,6 +256,7 @@ type ImageAnnotatePreviewRequestV1 struct {
 	Operations  []ImageAnnotateOperationV1 `json:"operations"`
 	Format      string                     `json:"format,omitempty"`
 	OutputColor string                     `json:"outputColor,omitempty"`
+	Page        int                        `json:"page,omitempty"` // 1-based page of a multi-page TIFF
 }
 
 type ImageAnnotatePreviewResponseV1 struct {
@@ -0,0 +1,33 @@
+package models
+
+const (
+	OptionTypeString  = "string"
+	OptionTypeInteger = "integer"
+	OptionTypeNumber  = "number"
+	OptionTypeBoolean = "boolean"
+	OptionTypeObject  = "object"
+	OptionTypeArray   = "array"
+)
+
+// OptionSchemaV1 describes one key of JobRequestV1.Options so that requests can
+// be checked generically and the UI can render a form for the tool.
+type OptionSchemaV1 struct {
+	Key         string           `json:"key"`
+	Type        string           `json:"type"` // string | integer | number | boolean | object | array
+	Label       string           `json:"label,omitempty"`
+	Description string           `json:"description,omitempty"`
+	Required    bool             `json:"required,omitempty"`
+	Default     any              `json:"default,omitempty"`
+	Enum        []string         `json:"enum,omitempty"` // allowed values for strings, matched case-insensitively
+	Min         *float64         `json:"min,omitempty"`
+	Max         *float64         `json:"max,omitempty"`
+	Format      string           `json:"format,omitempty"` // UI hint: path | dir | color | time
+	Modes       []string         `json:"modes,omitempty"`  // modes the option applies to, empty = all
+	Fields      []OptionSchemaV1 `json:"fields,omitempty"` // properties of an object
+	Items       *OptionSchemaV1  `json:"items,omitempty"`  // element schema of an array
+}
+
+// OptionBound returns a pointer for OptionSchemaV1.Min and Max.
+func OptionBound(v float64) *float64 {
+	return &v
+}
@@ -0,0 +1,34 @@
+package models
+
+const PipelineToolIDV1 = "pipeline"
+
+type PipelineStepV1 struct {
+	ToolID  string         `json:"toolId"`
+	Mode    string         `json:"mode,omitempty"` // single | batch; defaults from the tool manifest
+	Options map[string]any `json:"options"`
+}
+
+type PipelineRequestV1 struct {
+	Steps            []PipelineStepV1 `json:"steps"`
+	InputPaths       []string         `json:"inputPaths"`
+	OutputDir        string           `json:"outputDir"`
+	Workers          int              `json:"workers,omitempty"`
+	KeepIntermediate bool             `json:"keepIntermediate,omitempty"`
+	Priority         string           `json:"priority,omitempty"`
+	TimeoutMillis    int64            `json:"timeoutMillis,omitempty"`
+	ConflictPolicy   string           `json:"conflictPolicy,omitempty"` // applies to the outputs of the final step
+}
+
+type PipelineStepResultV1 struct {
+	Index      int               `json:"index"`
+	ToolID     string            `json:"toolId"`
+	Mode       string            `json:"mode"`
+	Status     string            `json:"status"`
+	Message    string            `json:"message"`
+	InputPaths []string          `json:"inputPaths"`
+	OutputDir  string            `json:"outputDir"`
+	Items      []JobResultItemV1 `json:"items"`
+	Error      *JobErrorV1       `json:"error,omitempty"`
+	StartedAt  int64             `json:"startedAt"`
+	EndedAt    int64             `json:"endedAt,omitempty"`
+}
@@ -0,0 +1,44 @@
+package models
+
+const PresetBundleVersionV1 = 1
+
+// PresetV1 is a named set of options for one tool. Presets are identified by
+// tool ID and name; names are matched case-insensitively.
+type PresetV1 struct {
+	ToolID      string         `json:"toolId"`
+	Name        string         `json:"name"`
+	Description string         `json:"description,omitempty"`
+	Options     map[string]any `json:"options"`
+	CreatedAt   int64          `json:"createdAt,omitempty"` // unix millis
+	UpdatedAt   int64          `json:"updatedAt,omitempty"` // unix millis
+}
+
+// PresetBundleV1 is the file format used to share presets.
+type PresetBundleV1 struct {
+	Version    int        `json:"version"`
+	ExportedAt int64      `json:"exportedAt"`
+	Presets    []PresetV1 `json:"presets"`
+}
+
+type ListPresetsResponseV1 struct {
+	Success bool        `json:"success"`
+	Message string      `json:"message"`
+	Presets []PresetV1  `json:"presets"`
+	Error   *JobErrorV1 `json:"error,omitempty"`
+}
+
+type PresetResponseV1 struct {
+	Success bool        `json:"success"`
+	Message string      `json:"message"`
+	Preset  *PresetV1   `json:"preset,omitempty"`
+	Error   *JobErrorV1 `json:"error,omitempty"`
+}
+
+type PresetTransferResponseV1 struct {
+	Success bool        `json:"success"`
+	Message string      `json:"message"`
+	Path    string      `json:"path"`
+	Count   int         `json:"count"`             // presets exported or imported
+	Skipped []string    `json:"skipped,omitempty"` // toolId/name of presets not imported
+	Error   *JobErrorV1 `json:"error,omitempty"`
+}
@@ -0,0 +1,14 @@
+package models
+
+// RetryPolicyV1 describes how failed executions are retried. Tools declare a
+// policy through tools.RetryPolicyProvider; JobRequestV1.Retry overrides the
+// non-zero fields of that policy for one job.
+type RetryPolicyV1 struct {
+	MaxAttempts          int      `json:"maxAttempts,omitempty"`
+	RetryableCodes       []string `json:"retryableCodes,omitempty"` // detail codes, or canonical codes
+	InitialBackoffMillis int      `json:"initialBackoffMillis,omitempty"`
+	MaxBackoffMillis     int      `json:"maxBackoffMillis,omitempty"`
+	BackoffMultiplier    float64  `json:"backoffMultiplier,omitempty"`
+	JitterRatio          float64  `json:"jitterRatio,omitempty"` // 0..1, fraction of the delay randomised
+	AttemptTimeoutMillis int      `json:"attemptTimeoutMillis,omitempty"`
+}
@@ -1,24 +1,33 @@
 package models
 
 type ToolManifestV1 struct {
-	ToolID           string   `json:"toolId"`
-	Name             string   `json:"name"`
-	Description      string   `json:"description"`
-	Domain           string   `json:"domain"`
-	Capability       string   `json:"capability"`
-	Version          string   `json:"version"`
-	SupportsSingle   bool     `json:"supportsSingle"`
-	SupportsBatch    bool     `json:"supportsBatch"`
-	InputExtensions  []string `json:"inputExtensions"`
-	OutputExtensions []string `json:"outputExtensions"`
-	RuntimeDeps      []string `json:"runtimeDependencies"`
-	Tags             []string `json:"tags"`
+	ToolID            string           `json:"toolId"`
+	Name              string           `json:"name"`
+	Description       string           `json:"description"`
+	Domain            string           `json:"domain"`
+	Capability        string           `json:"capability"`
+	Version           string           `json:"version"`
+	SupportsSingle    bool             `json:"supportsSingle"`
+	SupportsBatch     bool             `json:"supportsBatch"`
+	InputExtensions   []string         `json:"inputExtensions"`
+	OutputExtensions  []string         `json:"outputExtensions"`
+	RuntimeDeps       []string         `json:"runtimeDependencies"`
+	Tags              []string         `json:"tags"`
+	ItemTimeoutMillis int64            `json:"itemTimeoutMillis,omitempty"` // default per-input limit, 0 = none
+	Options           []OptionSchemaV1 `json:"options,omitempty"`
 }
 
 type ToolRuntimeStateV1 struct {
-	Status  string `json:"status"` // enabled | disabled | degraded
-	Reason  string `json:"reason,omitempty"`
-	Healthy bool   `json:"healthy"`
+	Status  string            `json:"status"` // enabled | disabled | degraded
+	Reason  string            `json:"reason,omitempty"`
+	Healthy bool              `json:"healthy"`
+	Formats []FormatSupportV1 `json:"formats,omitempty"` // what the linked codecs can read and write, for tools that depend on them
+}
+
+type FormatSupportV1 struct {
+	Format string `json:"format"`
+	Read   bool   `json:"read"`
+	Write  bool   `json:"write"`
 }
 
 type ToolCatalogEntryV1 struct {
@@ -0,0 +1,46 @@
+package models
+
+const (
+	WatchActionLeave  = "leave"
+	WatchActionMove   = "move"
+	WatchActionDelete = "delete"
+)
+
+// WatchFolderV1 submits a job for every file that appears or changes in Dir
+// and matches Pattern, once the file has stopped changing for SettleMillis.
+type WatchFolderV1 struct {
+	ID           string         `json:"id"`
+	Dir          string         `json:"dir"`
+	Pattern      string         `json:"pattern,omitempty"` // glob matched against file names, defaults to *
+	ToolID       string         `json:"toolId"`
+	Mode         string         `json:"mode,omitempty"` // single (default) | batch, one input per job either way
+	OutputDir    string         `json:"outputDir"`
+	Options      map[string]any `json:"options,omitempty"`
+	Preset       string         `json:"preset,omitempty"`       // saved preset for ToolID; Options override its fields
+	SettleMillis int64          `json:"settleMillis,omitempty"` // defaults to 3000
+	OnSuccess    string         `json:"onSuccess,omitempty"`    // leave (default) | move | delete
+	MoveToDir    string         `json:"moveToDir,omitempty"`    // target for move, defaults to Dir/processed
+	Enabled      bool           `json:"enabled"`
+}
+
+type WatchFolderStatusV1 struct {
+	Folder     WatchFolderV1 `json:"folder"`
+	Pending    int           `json:"pending"`   // files submitted whose job has not finished
+	Processed  int           `json:"processed"` // files already handled in their current version
+	LastScanAt int64         `json:"lastScanAt,omitempty"`
+	LastError  string        `json:"lastError,omitempty"`
+}
+
+type ListWatchFoldersResponseV1 struct {
+	Success bool                  `json:"success"`
+	Message string                `json:"message"`
+	Folders []WatchFolderStatusV1 `json:"folders"`
+	Error   *JobErrorV1           `json:"error,omitempty"`
+}
+
+type WatchFolderResponseV1 struct {
+	Success bool           `json:"success"`
+	Message string         `json:"message"`
+	Folder  *WatchFolderV1 `json:"folder,omitempty"`
+	Error   *JobErrorV1    `json:"error,omitempty"`
+}
@@ -11,6 +11,8 @@ import (
 	"github.com/pdfcpu/pdfcpu/pkg/api"
 	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
 	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/types"
+
+	"fileforge-desktop/internal/tools"
 )
 
 const (
@@ -28,6 +30,8 @@ const (
 	ErrorCodeCropPageSelectionBounds  = "PDF_CROP_PAGE_SELECTION_OUT_OF_BOUNDS"
 	ErrorCodeCropOutputExists         = "PDF_CROP_OUTPUT_ALREADY_EXISTS"
 	ErrorCodeCropBatchOutputCollision = "PDF_CROP_BATCH_OUTPUT_COLLISION"
+
+	DefaultCropBatchNameTemplate = "{name}_cropped.{ext}"
 )
 
 type CropMargins struct {
@@ -48,6 +52,8 @@ type CropBatchResult struct {
 	InputPath  string
 	OutputPath string
 	Success    bool
+	Skipped    bool
+	Existing   bool // output already existed and was kept under the skip policy
 	Error      *CropError
 }
 
@@ -68,6 +74,13 @@ func ValidateCropRequest(inputPath, outputPath, pageSelection, preset string, ma
 	return err
 }
 
+// CheckCropRequest runs the checks of ValidateCropRequest without the write
+// test in the output directory, for dry-run plans.
+func CheckCropRequest(inputPath, outputPath, pageSelection, preset string, margins *CropMargins) *CropError {
+	_, _, err := buildCropPlanWithOptions(inputPath, outputPath, pageSelection, preset, margins, true, false)
+	return err
+}
+
 func Crop(ctx context.Context, inputPath, outputPath, pageSelection, preset string, margins *CropMargins) error {
 	if err := ctx.Err(); err != nil {
 		return &CropError{Code: "CANCELED", Message: "crop canceled", Cause: err}
@@ -86,6 +99,16 @@ func Crop(ctx context.Context, inputPath, outputPath, pageSelection, preset stri
 }
 
 func ValidateCropBatchRequest(inputPaths []string, outputDir, pageSelection, preset string, margins *CropMargins) *CropError {
+	return validateCropBatchRequest(inputPaths, outputDir, pageSelection, preset, margins, true)
+}
+
+// CheckCropBatchRequest runs the checks of ValidateCropBatchRequest without the
+// write test in the output directory, for dry-run plans.
+func CheckCropBatchRequest(inputPaths []string, outputDir, pageSelection, preset string, margins *CropMargins) *CropError {
+	return validateCropBatchRequest(inputPaths, outputDir, pageSelection, preset, margins, false)
+}
+
+func validateCropBatchRequest(inputPaths []string, outputDir, pageSelection, preset string, margins *CropMargins, writecheck bool) *CropError {
 	outputDir = strings.TrimSpace(outputDir)
 	if outputDir == "" {
 		return &CropError{Code: ErrorCodeValidation, Message: "outputDir is required"}
@@ -96,7 +119,7 @@ func ValidateCropBatchRequest(inputPaths []string, outputDir, pageSelection, pre
 	}
 
 	outputDirProbe := filepath.Join(outputDir, "_crop_probe_.pdf")
-	if validationErr := validateOutputDir(outputDirProbe); validationErr != nil {
+	if validationErr := validateOutputDir(outputDirProbe, writecheck); validationErr != nil {
 		return &CropError{Code: validationErr.Code, Message: validationErr.Message, Cause: validationErr.Cause}
 	}
 
@@ -121,7 +144,12 @@ func ValidateCropBatchRequest(inputPaths []string, outputDir, pageSelection, pre
 	return nil
 }
 
-func CropBatch(ctx context.Context, inputPaths []string, outputDir, pageSelection, preset string, margins *CropMargins) ([]CropBatchResult, error) {
+// CropBatch crops every input into outputDir, or the sub-directory of it subdirs
+// maps the input to, naming the outputs with nameTemplate or
+// DefaultCropBatchNameTemplate when it is empty and handling existing outputs
+// with conflictPolicy. subdirs may be nil. Inputs for which skip returns true
+// are reported as skipped without being processed; skip may be nil.
+func CropBatch(ctx context.Context, inputPaths []string, outputDir, pageSelection, preset string, margins *CropMargins, nameTemplate, conflictPolicy string, subdirs map[string]string, skip func(inputPath string) bool) ([]CropBatchResult, error) {
 	if err := ctx.Err(); err != nil {
 		return nil, &CropError{Code: "CANCELED", Message: "crop canceled", Cause: err}
 	}
@@ -133,14 +161,43 @@ func CropBatch(ctx context.Context, inputPaths []string, outputDir, pageSelectio
 
 	results := make([]CropBatchResult, 0, len(inputPaths))
 	reservedOutputs := make(map[string]struct{}, len(inputPaths))
-	for _, rawInputPath := range inputPaths {
+	if nameTemplate == "" {
+		nameTemplate = DefaultCropBatchNameTemplate
+	}
+	for index, rawInputPath := range inputPaths {
 		inputPath := strings.TrimSpace(rawInputPath)
-		outputPath := resolveUniqueBatchCropOutputPath(outputDir, inputPath, reservedOutputs)
+		target, conflictErr := tools.ResolveOutputTarget(conflictPolicy, tools.RenderOutputPath(outputDir, nameTemplate, tools.OutputNameVars{
+			InputPath: inputPath,
+			Ext:       "pdf",
+			Tool:      "tool.pdf.crop",
+			Index:     index + 1,
+			Subdir:    subdirs[inputPath],
+		}), reservedOutputs)
+		outputPath := target.Path
 
 		if err := ctx.Err(); err != nil {
 			return results, &CropError{Code: "CANCELED", Message: "crop canceled", Cause: err}
 		}
 
+		if skip != nil && skip(inputPath) {
+			results = append(results, CropBatchResult{InputPath: inputPath, OutputPath: outputPath, Skipped: true})
+			continue
+		}
+
+		if conflictErr != nil {
+			results = append(results, CropBatchResult{
+				InputPath: inputPath,
+				Success:   false,
+				Error:     &CropError{Code: ErrorCodeCropOutputExists, Message: conflictErr.Message, Details: conflictErr.Details},
+			})
+			continue
+		}
+
+		if target.Skip {
+			results = append(results, CropBatchResult{InputPath: inputPath, OutputPath: outputPath, Success: true, Existing: true})
+			continue
+		}
+
 		selectedPages, box, buildErr := buildCropPlanWithoutOutputExistence(inputPath, outputPath, pageSelection, preset, margins)
 		if buildErr != nil {
 			results = append(results, CropBatchResult{
@@ -152,12 +209,15 @@ func CropBatch(ctx context.Context, inputPaths []string, outputDir, pageSelectio
 			continue
 		}
 
-		if err := api.CropFile(inputPath, outputPath, selectedPages, box, nil); err != nil {
+		cropErr := tools.WriteOutput(ctx, target, func(writePath string) error {
+			return api.CropFile(inputPath, writePath, selectedPages, box, nil)
+		})
+		if cropErr != nil {
 			results = append(results, CropBatchResult{
 				InputPath:  inputPath,
 				OutputPath: outputPath,
 				Success:    false,
-				Error:      &CropError{Code: ErrorCodeCropFailed, Message: "failed to crop PDF", Cause: err},
+				Error:      &CropError{Code: ErrorCodeCropFailed, Message: "failed to crop PDF", Cause: cropErr},
 			})
 			continue
 		}
@@ -169,14 +229,14 @@ func CropBatch(ctx context.Context, inputPaths []string, outputDir, pageSelectio
 }
 
 func buildCropPlan(inputPath, outputPath, pageSelection, preset string, margins *CropMargins) ([]string, *model.Box, *CropError) {
-	return buildCropPlanWithOptions(inputPath, outputPath, pageSelection, preset, margins, true)
+	return buildCropPlanWithOptions(inputPath, outputPath, pageSelection, preset, margins, true, true)
 }
 
 func buildCropPlanWithoutOutputExistence(inputPath, outputPath, pageSelection, preset string, margins *CropMargins) ([]string, *model.Box, *CropError) {
-	return buildCropPlanWithOptions(inputPath, outputPath, pageSelection, preset, margins, false)
+	return buildCropPlanWithOptions(inputPath, outputPath, pageSelection, preset, margins, false, true)
 }
 
-func buildCropPlanWithOptions(inputPath, outputPath, pageSelection, preset string, margins *CropMargins, requireOutputNotExists bool) ([]string, *model.Box, *CropError) {
+func buildCropPlanWithOptions(inputPath, outputPath, pageSelection, preset string, margins *CropMargins, requireOutputNotExists, writecheck bool) ([]string, *model.Box, *CropError) {
 	inputPath = strings.TrimSpace(inputPath)
 	if inputPath == "" {
 		return nil, nil, &CropError{Code: ErrorCodeValidation, Message: "inputPath is required"}
@@ -199,7 +259,7 @@ func buildCropPlanWithOptions(inputPath, outputPath, pageSelection, preset strin
 		return nil, nil, &CropError{Code: ErrorCodeOutputCollidesInput, Message: fmt.Sprintf("outputPath collides with input path: %s", inputPath)}
 	}
 
-	if validationErr := validateOutputDir(outputPath); validationErr != nil {
+	if validationErr := validateOutputDir(outputPath, writecheck); validationErr != nil {
 		return nil, nil, &CropError{Code: validationErr.Code, Message: validationErr.Message, Cause: validationErr.Cause}
 	}
 
@@ -237,45 +297,6 @@ func buildCropPlanWithOptions(inputPath, outputPath, pageSelection, preset strin
 
 	return selectedPages, box, nil
 }
-func buildBatchCropOutputName(inputPath string) string {
-	base := strings.TrimSpace(strings.TrimSuffix(filepath.Base(inputPath), filepath.Ext(inputPath)))
-	if base == "" {
-		base = "input"
-	}
-
-	return fmt.Sprintf("%s_cropped.pdf", base)
-}
-
-func resolveUniqueBatchCropOutputPath(outputDir, inputPath string, reserved map[string]struct{}) string {
-	baseOutputName := buildBatchCropOutputName(inputPath)
-	baseWithoutExt := strings.TrimSuffix(baseOutputName, filepath.Ext(baseOutputName))
-	ext := filepath.Ext(baseOutputName)
-	if ext == "" {
-		ext = ".pdf"
-	}
-
-	for candidateIndex := 1; ; candidateIndex++ {
-		candidateName := baseOutputName
-		if candidateIndex > 1 {
-			candidateName = fmt.Sprintf("%s-%d%s", baseWithoutExt, candidateIndex, ext)
-		}
-
-		candidatePath := filepath.Join(outputDir, candidateName)
-		candidateKey := normalizePathKey(candidatePath)
-		if _, exists := reserved[candidateKey]; exists {
-			continue
-		}
-
-		if _, err := os.Stat(candidatePath); err == nil {
-			continue
-		} else if !os.IsNotExist(err) {
-			continue
-		}
-
-		reserved[candidateKey] = struct{}{}
-		return candidatePath
-	}
-}
 
 func parseCropPageSelectionSyntax(pageSelection string) ([]SplitPageRange, *CropError) {
 	trimmed := strings.TrimSpace(pageSelection)
@@ -145,6 +145,16 @@ func codeMessagePrefix(code string) string {
 }
 
 func ValidateMergePaths(inputPaths []string, outputPath string) *MergeError {
+	return validateMergePaths(inputPaths, outputPath, true)
+}
+
+// CheckMergePaths runs the checks of ValidateMergePaths without the write test
+// in the output directory, for dry-run plans.
+func CheckMergePaths(inputPaths []string, outputPath string) *MergeError {
+	return validateMergePaths(inputPaths, outputPath, false)
+}
+
+func validateMergePaths(inputPaths []string, outputPath string, writecheck bool) *MergeError {
 	if len(inputPaths) < 2 {
 		return &MergeError{Code: ErrorCodeValidation, Message: "at least 2 input PDFs are required"}
 	}
@@ -177,7 +187,7 @@ func ValidateMergePaths(inputPaths []string, outputPath string) *MergeError {
 		}
 	}
 
-	if validationErr := validateOutputDir(outputPath); validationErr != nil {
+	if validationErr := validateOutputDir(outputPath, writecheck); validationErr != nil {
 		return validationErr
 	}
 
@@ -197,7 +207,7 @@ func isPDFPath(path string) bool {
 	return strings.EqualFold(filepath.Ext(strings.TrimSpace(path)), ".pdf")
 }
 
-func validateOutputDir(outputPath string) *MergeError {
+func validateOutputDir(outputPath string, writecheck bool) *MergeError {
 	dir := filepath.Dir(outputPath)
 	if strings.TrimSpace(dir) == "" {
 		dir = "."
@@ -214,6 +224,9 @@ func validateOutputDir(outputPath string) *MergeError {
 	if !info.IsDir() {
 		return &MergeError{Code: ErrorCodeOutputDirNotDirectory, Message: fmt.Sprintf("output path parent is not a directory: %s", dir)}
 	}
+	if !writecheck {
+		return nil
+	}
 
 	tmp, err := os.CreateTemp(dir, ".fileforge-writecheck-*.tmp")
 	if err != nil {
@@ -11,6 +11,9 @@ import (
 	"strings"
 
 	"github.com/pdfcpu/pdfcpu/pkg/api"
+
+	"fileforge-desktop/internal/models"
+	"fileforge-desktop/internal/tools"
 )
 
 const (
@@ -25,6 +28,9 @@ const (
 	ErrorCodeSplitOutputExists          = "PDF_SPLIT_OUTPUT_ALREADY_EXISTS"
 	ErrorCodeSplitBatchOutputCollision  = "PDF_SPLIT_BATCH_OUTPUT_COLLISION"
 	ErrorCodeSplitBatchInputDirConflict = "PDF_SPLIT_BATCH_INPUT_DIR_CONFLICT"
+
+	DefaultSplitPageNameTemplate  = "{name}_page_{page:03}.{ext}"
+	DefaultSplitRangeNameTemplate = "{name}_range_{index:03}_p{page}.{ext}"
 )
 
 type SplitPageRange struct {
@@ -40,13 +46,22 @@ type SplitError struct {
 
 type splitPlannedOutput struct {
 	Selection string
-	Output    string
+	Target    tools.OutputTarget
 }
 
 type SplitBatchResult struct {
 	InputPath string
 	OutputDir string
 	Outputs   []string
+	Existing  []string // outputs that already existed and were kept under the skip policy
+	Skipped   bool
+}
+
+// SplitPlan lists the outputs one input would be split into.
+type SplitPlan struct {
+	InputPath string
+	OutputDir string
+	Targets   []tools.OutputTarget
 }
 
 type splitBatchItemPlan struct {
@@ -67,42 +82,85 @@ func (e *SplitError) Unwrap() error {
 	return e.Cause
 }
 
-func Split(ctx context.Context, inputPath, outputDir, strategy, rangesExpr string) ([]string, error) {
+// Split writes one PDF per page or range, named with nameTemplate or the
+// strategy's default template when it is empty, and returns the written
+// outputs and the existing ones kept under the skip conflict policy.
+func Split(ctx context.Context, inputPath, outputDir, strategy, rangesExpr, nameTemplate, conflictPolicy string) ([]string, []string, error) {
 	if err := ctx.Err(); err != nil {
-		return nil, &SplitError{Code: "CANCELED", Message: "split canceled", Cause: err}
+		return nil, nil, &SplitError{Code: "CANCELED", Message: "split canceled", Cause: err}
 	}
 
-	planned, validationErr := buildSplitPlan(inputPath, outputDir, strategy, rangesExpr)
+	planned, validationErr := buildSplitPlan(inputPath, outputDir, strategy, rangesExpr, nameTemplate, conflictPolicy, true)
 	if validationErr != nil {
-		return nil, validationErr
+		return nil, nil, validationErr
 	}
 
 	return executeSplitPlan(ctx, inputPath, planned)
 }
 
-func ValidateSplitRequest(inputPath, outputDir, strategy, rangesExpr string) *SplitError {
-	_, err := buildSplitPlan(inputPath, outputDir, strategy, rangesExpr)
+func ValidateSplitRequest(inputPath, outputDir, strategy, rangesExpr, nameTemplate, conflictPolicy string) *SplitError {
+	_, err := buildSplitPlan(inputPath, outputDir, strategy, rangesExpr, nameTemplate, conflictPolicy, true)
 	return err
 }
 
-func ValidateSplitBatchRequest(inputPaths []string, outputDir, strategy, rangesExpr string, perInputDir bool) *SplitError {
-	_, err := buildSplitBatchPlan(inputPaths, outputDir, strategy, rangesExpr, perInputDir)
+func ValidateSplitBatchRequest(inputPaths []string, outputDir, strategy, rangesExpr, nameTemplate, conflictPolicy string, perInputDir bool, subdirs map[string]string) *SplitError {
+	_, err := buildSplitBatchPlan(inputPaths, outputDir, strategy, rangesExpr, nameTemplate, conflictPolicy, perInputDir, subdirs, true)
 	return err
 }
 
-func SplitBatch(ctx context.Context, inputPaths []string, outputDir, strategy, rangesExpr string, perInputDir bool) ([]SplitBatchResult, error) {
+// PlanSplit resolves the outputs Split would write, without writing them or
+// testing that the output directory is writable.
+func PlanSplit(inputPath, outputDir, strategy, rangesExpr, nameTemplate, conflictPolicy string) (SplitPlan, *SplitError) {
+	planned, err := buildSplitPlan(inputPath, outputDir, strategy, rangesExpr, nameTemplate, conflictPolicy, false)
+	if err != nil {
+		return SplitPlan{}, err
+	}
+	return SplitPlan{InputPath: strings.TrimSpace(inputPath), OutputDir: strings.TrimSpace(outputDir), Targets: splitPlanTargets(planned)}, nil
+}
+
+// PlanSplitBatch resolves the outputs SplitBatch would write, without writing
+// them or testing that the output directories are writable.
+func PlanSplitBatch(inputPaths []string, outputDir, strategy, rangesExpr, nameTemplate, conflictPolicy string, perInputDir bool, subdirs map[string]string) ([]SplitPlan, *SplitError) {
+	plans, err := buildSplitBatchPlan(inputPaths, outputDir, strategy, rangesExpr, nameTemplate, conflictPolicy, perInputDir, subdirs, false)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]SplitPlan, 0, len(plans))
+	for _, plan := range plans {
+		result = append(result, SplitPlan{InputPath: plan.InputPath, OutputDir: plan.OutputDir, Targets: splitPlanTargets(plan.Planned)})
+	}
+	return result, nil
+}
+
+func splitPlanTargets(planned []splitPlannedOutput) []tools.OutputTarget {
+	targets := make([]tools.OutputTarget, 0, len(planned))
+	for _, entry := range planned {
+		targets = append(targets, entry.Target)
+	}
+	return targets
+}
+
+// SplitBatch splits every input. subdirs maps inputs to the sub-directory of
+// outputDir their outputs go to and may be nil. Inputs for which skip returns
+// true are reported as skipped without being processed; skip may be nil.
+func SplitBatch(ctx context.Context, inputPaths []string, outputDir, strategy, rangesExpr, nameTemplate, conflictPolicy string, perInputDir bool, subdirs map[string]string, skip func(inputPath string) bool) ([]SplitBatchResult, error) {
 	if err := ctx.Err(); err != nil {
 		return nil, &SplitError{Code: "CANCELED", Message: "split canceled", Cause: err}
 	}
 
-	plans, validationErr := buildSplitBatchPlan(inputPaths, outputDir, strategy, rangesExpr, perInputDir)
+	plans, validationErr := buildSplitBatchPlan(inputPaths, outputDir, strategy, rangesExpr, nameTemplate, conflictPolicy, perInputDir, subdirs, true)
 	if validationErr != nil {
 		return nil, validationErr
 	}
 
 	results := make([]SplitBatchResult, 0, len(plans))
 	for _, plan := range plans {
-		outputs, err := executeSplitPlan(ctx, plan.InputPath, plan.Planned)
+		if skip != nil && skip(plan.InputPath) {
+			results = append(results, SplitBatchResult{InputPath: plan.InputPath, OutputDir: plan.OutputDir, Skipped: true})
+			continue
+		}
+
+		outputs, existing, err := executeSplitPlan(ctx, plan.InputPath, plan.Planned)
 		if err != nil {
 			return results, err
 		}
@@ -111,17 +169,24 @@ func SplitBatch(ctx context.Context, inputPaths []string, outputDir, strategy, r
 			InputPath: plan.InputPath,
 			OutputDir: plan.OutputDir,
 			Outputs:   append([]string(nil), outputs...),
+			Existing:  existing,
 		})
 	}
 
 	return results, nil
 }
 
-func buildSplitPlan(inputPath, outputDir, strategy, rangesExpr string) ([]splitPlannedOutput, *SplitError) {
-	return buildSplitPlanWithOptions(inputPath, outputDir, strategy, rangesExpr, false)
+// buildSplitPlan and buildSplitBatchPlan test that the output directories are
+// writable with a temporary file only when writecheck is set.
+func buildSplitPlan(inputPath, outputDir, strategy, rangesExpr, nameTemplate, conflictPolicy string, writecheck bool) ([]splitPlannedOutput, *SplitError) {
+	return buildSplitPlanWithOptions(inputPath, outputDir, strategy, rangesExpr, nameTemplate, conflictPolicy, false, writecheck, make(map[string]struct{}))
 }
 
-func buildSplitPlanWithOptions(inputPath, outputDir, strategy, rangesExpr string, allowCreateOutputDir bool) ([]splitPlannedOutput, *SplitError) {
+// buildSplitPlanWithOptions resolves the outputs with tools.ResolveOutputTarget,
+// so outputs that exist on disk are handled by conflictPolicy and outputs
+// already in used get a numeric suffix. Under the error policy colliding and
+// existing outputs fail with the split error codes instead.
+func buildSplitPlanWithOptions(inputPath, outputDir, strategy, rangesExpr, nameTemplate, conflictPolicy string, allowCreateOutputDir, writecheck bool, used map[string]struct{}) ([]splitPlannedOutput, *SplitError) {
 	inputPath = strings.TrimSpace(inputPath)
 	if inputPath == "" {
 		return nil, &SplitError{Code: ErrorCodeValidation, Message: "inputPath is required"}
@@ -140,7 +205,7 @@ func buildSplitPlanWithOptions(inputPath, outputDir, strategy, rangesExpr string
 		return nil, &SplitError{Code: ErrorCodeUnsupportedStrategy, Message: fmt.Sprintf("unsupported split strategy: %s", strategy)}
 	}
 
-	if validationErr := validateOutputDirectory(outputDir, allowCreateOutputDir); validationErr != nil {
+	if validationErr := validateOutputDirectory(outputDir, allowCreateOutputDir, writecheck); validationErr != nil {
 		return nil, validationErr
 	}
 
@@ -153,39 +218,34 @@ func buildSplitPlanWithOptions(inputPath, outputDir, strategy, rangesExpr string
 		return nil, &SplitError{Code: ErrorCodeInvalidInputPDF, Message: fmt.Sprintf("unable to determine page count for: %s", filepath.Base(inputPath)), Cause: pageCountErr}
 	}
 
-	planned := make([]splitPlannedOutput, 0)
 	if strategy == SplitStrategyEveryPage {
-		planned = buildEveryPagePlan(inputPath, outputDir, pageCount)
-	} else {
-		rangesExpr = strings.TrimSpace(rangesExpr)
-		if rangesExpr == "" {
-			return nil, &SplitError{Code: ErrorCodeSplitRangesRequired, Message: "options.ranges is required for strategy=ranges"}
-		}
-
-		ranges, err := ParseSplitRanges(rangesExpr)
-		if err != nil {
-			return nil, err
-		}
-
-		if boundsErr := validateRangesWithinPageCount(ranges, pageCount); boundsErr != nil {
-			return nil, boundsErr
+		if nameTemplate == "" {
+			nameTemplate = DefaultSplitPageNameTemplate
 		}
+		return resolveSplitPlan(buildEveryPagePlan(inputPath, outputDir, nameTemplate, pageCount), conflictPolicy, used)
+	}
 
-		planned = buildRangesPlan(inputPath, outputDir, ranges)
+	rangesExpr = strings.TrimSpace(rangesExpr)
+	if rangesExpr == "" {
+		return nil, &SplitError{Code: ErrorCodeSplitRangesRequired, Message: "options.ranges is required for strategy=ranges"}
 	}
 
-	if err := ensureNoOutputCollisions(planned); err != nil {
+	ranges, err := ParseSplitRanges(rangesExpr)
+	if err != nil {
 		return nil, err
 	}
 
-	if err := ensureOutputsDoNotExist(planned); err != nil {
-		return nil, err
+	if boundsErr := validateRangesWithinPageCount(ranges, pageCount); boundsErr != nil {
+		return nil, boundsErr
 	}
 
-	return planned, nil
+	if nameTemplate == "" {
+		nameTemplate = DefaultSplitRangeNameTemplate
+	}
+	return resolveSplitPlan(buildRangesPlan(inputPath, outputDir, nameTemplate, ranges), conflictPolicy, used)
 }
 
-func buildSplitBatchPlan(inputPaths []string, outputDir, strategy, rangesExpr string, perInputDir bool) ([]splitBatchItemPlan, *SplitError) {
+func buildSplitBatchPlan(inputPaths []string, outputDir, strategy, rangesExpr, nameTemplate, conflictPolicy string, perInputDir bool, subdirs map[string]string, writecheck bool) ([]splitBatchItemPlan, *SplitError) {
 	outputDir = strings.TrimSpace(outputDir)
 	if outputDir == "" {
 		return nil, &SplitError{Code: ErrorCodeValidation, Message: "outputDir is required"}
@@ -195,13 +255,13 @@ func buildSplitBatchPlan(inputPaths []string, outputDir, strategy, rangesExpr st
 		return nil, &SplitError{Code: ErrorCodeValidation, Message: "at least 1 input PDF is required"}
 	}
 
-	if validationErr := validateOutputDirectory(outputDir, false); validationErr != nil {
+	if validationErr := validateOutputDirectory(outputDir, false, writecheck); validationErr != nil {
 		return nil, validationErr
 	}
 
 	plans := make([]splitBatchItemPlan, 0, len(inputPaths))
 	seenInputDirs := make(map[string]string, len(inputPaths))
-	seenOutputs := make(map[string]string)
+	usedOutputs := make(map[string]struct{})
 
 	for _, rawInputPath := range inputPaths {
 		inputPath := strings.TrimSpace(rawInputPath)
@@ -209,10 +269,10 @@ func buildSplitBatchPlan(inputPaths []string, outputDir, strategy, rangesExpr st
 			return nil, &SplitError{Code: ErrorCodeValidation, Message: "inputPath is required"}
 		}
 
-		effectiveOutputDir := outputDir
+		effectiveOutputDir := filepath.Join(outputDir, subdirs[inputPath])
 		allowCreateOutputDir := false
 		if perInputDir {
-			effectiveOutputDir = filepath.Join(outputDir, perInputSplitDirName(inputPath))
+			effectiveOutputDir = filepath.Join(effectiveOutputDir, perInputSplitDirName(inputPath))
 			allowCreateOutputDir = true
 
 			dirKey := normalizePathKey(effectiveOutputDir)
@@ -222,19 +282,11 @@ func buildSplitBatchPlan(inputPaths []string, outputDir, strategy, rangesExpr st
 			seenInputDirs[dirKey] = inputPath
 		}
 
-		planned, err := buildSplitPlanWithOptions(inputPath, effectiveOutputDir, strategy, rangesExpr, allowCreateOutputDir)
+		planned, err := buildSplitPlanWithOptions(inputPath, effectiveOutputDir, strategy, rangesExpr, nameTemplate, conflictPolicy, allowCreateOutputDir, writecheck, usedOutputs)
 		if err != nil {
 			return nil, err
 		}
 
-		for _, entry := range planned {
-			outputKey := normalizePathKey(entry.Output)
-			if previous, exists := seenOutputs[outputKey]; exists {
-				return nil, &SplitError{Code: ErrorCodeSplitBatchOutputCollision, Message: fmt.Sprintf("batch planned outputs collide: %s and %s", previous, entry.Output)}
-			}
-			seenOutputs[outputKey] = entry.Output
-		}
-
 		plans = append(plans, splitBatchItemPlan{
 			InputPath: inputPath,
 			OutputDir: effectiveOutputDir,
@@ -346,42 +398,52 @@ func validateRangesWithinPageCount(ranges []SplitPageRange, pageCount int) *Spli
 	return nil
 }
 
-func executeSplitPlan(ctx context.Context, inputPath string, planned []splitPlannedOutput) ([]string, error) {
+func executeSplitPlan(ctx context.Context, inputPath string, planned []splitPlannedOutput) ([]string, []string, error) {
 	outputs := make([]string, 0, len(planned))
+	var existing []string
 	for _, entry := range planned {
 		if err := ctx.Err(); err != nil {
-			return nil, &SplitError{Code: "CANCELED", Message: "split canceled", Cause: err}
+			return nil, nil, &SplitError{Code: "CANCELED", Message: "split canceled", Cause: err}
+		}
+
+		if entry.Target.Skip {
+			existing = append(existing, entry.Target.Path)
+			continue
 		}
 
-		if err := os.MkdirAll(filepath.Dir(entry.Output), 0o755); err != nil {
-			return nil, &SplitError{Code: ErrorCodeOutputDirNotWritable, Message: fmt.Sprintf("output directory is not writable: %s", filepath.Dir(entry.Output)), Cause: err}
+		if err := os.MkdirAll(filepath.Dir(entry.Target.Path), 0o755); err != nil {
+			return nil, nil, &SplitError{Code: ErrorCodeOutputDirNotWritable, Message: fmt.Sprintf("output directory is not writable: %s", filepath.Dir(entry.Target.Path)), Cause: err}
 		}
 
-		if err := api.TrimFile(inputPath, entry.Output, []string{entry.Selection}, nil); err != nil {
-			return nil, &SplitError{Code: ErrorCodeSplitFailed, Message: fmt.Sprintf("failed to split PDF for selection %s", entry.Selection), Cause: err}
+		err := tools.WriteOutput(ctx, entry.Target, func(writePath string) error {
+			return api.TrimFile(inputPath, writePath, []string{entry.Selection}, nil)
+		})
+		if err != nil {
+			return nil, nil, &SplitError{Code: ErrorCodeSplitFailed, Message: fmt.Sprintf("failed to split PDF for selection %s", entry.Selection), Cause: err}
 		}
 
-		outputs = append(outputs, entry.Output)
+		outputs = append(outputs, entry.Target.Path)
 	}
 
-	return outputs, nil
+	return outputs, existing, nil
 }
 
-func buildEveryPagePlan(inputPath, outputDir string, pageCount int) []splitPlannedOutput {
+// buildEveryPagePlan and buildRangesPlan render the requested output paths;
+// resolveSplitPlan applies the conflict policy to them.
+func buildEveryPagePlan(inputPath, outputDir, nameTemplate string, pageCount int) []splitPlannedOutput {
 	planned := make([]splitPlannedOutput, 0, pageCount)
 	for page := 1; page <= pageCount; page++ {
 		selection := strconv.Itoa(page)
-		outputName := buildEveryPageOutputName(inputPath, page)
 		planned = append(planned, splitPlannedOutput{
 			Selection: selection,
-			Output:    filepath.Join(outputDir, outputName),
+			Target:    tools.OutputTarget{Path: splitOutputPath(inputPath, outputDir, nameTemplate, page, selection)},
 		})
 	}
 
 	return planned
 }
 
-func buildRangesPlan(inputPath, outputDir string, ranges []SplitPageRange) []splitPlannedOutput {
+func buildRangesPlan(inputPath, outputDir, nameTemplate string, ranges []SplitPageRange) []splitPlannedOutput {
 	planned := make([]splitPlannedOutput, 0, len(ranges))
 	for i, r := range ranges {
 		selection := fmt.Sprintf("%d-%d", r.Start, r.End)
@@ -391,25 +453,47 @@ func buildRangesPlan(inputPath, outputDir string, ranges []SplitPageRange) []spl
 
 		planned = append(planned, splitPlannedOutput{
 			Selection: selection,
-			Output:    filepath.Join(outputDir, buildRangeOutputName(inputPath, i+1, r)),
+			Target:    tools.OutputTarget{Path: splitOutputPath(inputPath, outputDir, nameTemplate, i+1, selection)},
 		})
 	}
 
 	return planned
 }
 
-func buildEveryPageOutputName(inputPath string, page int) string {
-	base := strings.TrimSuffix(filepath.Base(inputPath), filepath.Ext(inputPath))
-	return fmt.Sprintf("%s_page_%03d.pdf", base, page)
+func splitOutputPath(inputPath, outputDir, nameTemplate string, index int, pages string) string {
+	return tools.RenderOutputPath(outputDir, nameTemplate, tools.OutputNameVars{
+		InputPath: inputPath,
+		Ext:       "pdf",
+		Tool:      "tool.pdf.split",
+		Index:     index,
+		Page:      pages,
+	})
 }
 
-func buildRangeOutputName(inputPath string, index int, r SplitPageRange) string {
-	base := strings.TrimSuffix(filepath.Base(inputPath), filepath.Ext(inputPath))
-	if r.Start == r.End {
-		return fmt.Sprintf("%s_range_%03d_p%d.pdf", base, index, r.Start)
+func resolveSplitPlan(planned []splitPlannedOutput, conflictPolicy string, used map[string]struct{}) ([]splitPlannedOutput, *SplitError) {
+	if conflictPolicy == models.ConflictPolicyError {
+		if err := ensureNoOutputCollisions(planned); err != nil {
+			return nil, err
+		}
+		if err := ensureOutputsDoNotExist(planned); err != nil {
+			return nil, err
+		}
 	}
 
-	return fmt.Sprintf("%s_range_%03d_p%d-%d.pdf", base, index, r.Start, r.End)
+	for i, entry := range planned {
+		// Collisions within one input were rejected above, so under the error
+		// policy a path already in used belongs to another input of the batch.
+		if _, taken := used[tools.OutputPathKey(entry.Target.Path)]; taken && conflictPolicy == models.ConflictPolicyError {
+			return nil, &SplitError{Code: ErrorCodeSplitBatchOutputCollision, Message: fmt.Sprintf("batch planned outputs collide: %s", entry.Target.Path)}
+		}
+		target, err := tools.ResolveOutputTarget(conflictPolicy, entry.Target.Path, used)
+		if err != nil {
+			return nil, &SplitError{Code: ErrorCodeSplitOutputExists, Message: err.Message}
+		}
+		planned[i].Target = target
+	}
+
+	return planned, nil
 }
 
 func IsSplitErrorCode(err error, code string) bool {
@@ -424,11 +508,11 @@ func IsSplitErrorCode(err error, code string) bool {
 func ensureNoOutputCollisions(planned []splitPlannedOutput) *SplitError {
 	seen := make(map[string]string, len(planned))
 	for _, entry := range planned {
-		key := normalizePathKey(entry.Output)
+		key := normalizePathKey(entry.Target.Path)
 		if previous, exists := seen[key]; exists {
-			return &SplitError{Code: ErrorCodeSplitOutputCollision, Message: fmt.Sprintf("planned outputs collide: %s and %s", previous, entry.Output)}
+			return &SplitError{Code: ErrorCodeSplitOutputCollision, Message: fmt.Sprintf("planned outputs collide: %s and %s", previous, entry.Target.Path)}
 		}
-		seen[key] = entry.Output
+		seen[key] = entry.Target.Path
 	}
 
 	return nil
@@ -436,22 +520,22 @@ func ensureNoOutputCollisions(planned []splitPlannedOutput) *SplitError {
 
 func ensureOutputsDoNotExist(planned []splitPlannedOutput) *SplitError {
 	for _, entry := range planned {
-		_, err := os.Stat(entry.Output)
+		_, err := os.Stat(entry.Target.Path)
 		if err == nil {
-			return &SplitError{Code: ErrorCodeSplitOutputExists, Message: fmt.Sprintf("output already exists: %s", entry.Output)}
+			return &SplitError{Code: ErrorCodeSplitOutputExists, Message: fmt.Sprintf("output already exists: %s", entry.Target.Path)}
 		}
 
 		if errors.Is(err, os.ErrNotExist) {
 			continue
 		}
 
-		return &SplitError{Code: ErrorCodeOutputDirNotWritable, Message: fmt.Sprintf("output directory is not accessible: %s", filepath.Dir(entry.Output)), Cause: err}
+		return &SplitError{Code: ErrorCodeOutputDirNotWritable, Message: fmt.Sprintf("output directory is not accessible: %s", filepath.Dir(entry.Target.Path)), Cause: err}
 	}
 
 	return nil
 }
 
-func validateOutputDirectory(outputDir string, allowCreate bool) *SplitError {
+func validateOutputDirectory(outputDir string, allowCreate, writecheck bool) *SplitError {
 	info, err := os.Stat(outputDir)
 	if err != nil {
 		if errors.Is(err, os.ErrNotExist) {
@@ -471,6 +555,9 @@ func validateOutputDirectory(outputDir string, allowCreate bool) *SplitError {
 				return &SplitError{Code: ErrorCodeOutputDirNotDirectory, Message: fmt.Sprintf("output directory parent is not a directory: %s", parentDir)}
 			}
 
+			if !writecheck {
+				return nil
+			}
 			if writeErr := validateDirectoryWritable(parentDir, ".fileforge-split-parent-writecheck-*.tmp"); writeErr != nil {
 				return writeErr
 			}
@@ -483,6 +570,9 @@ func validateOutputDirectory(outputDir string, allowCreate bool) *SplitError {
 	if !info.IsDir() {
 		return &SplitError{Code: ErrorCodeOutputDirNotDirectory, Message: fmt.Sprintf("output directory is not a directory: %s", outputDir)}
 	}
+	if !writecheck {
+		return nil
+	}
 
 	if writeErr := validateDirectoryWritable(outputDir, ".fileforge-split-writecheck-*.tmp"); writeErr != nil {
 		return writeErr
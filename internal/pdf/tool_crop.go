@@ -9,6 +9,7 @@ import (
 
 	"fileforge-desktop/internal/models"
 	"fileforge-desktop/internal/pdf/engine"
+	"fileforge-desktop/internal/tools"
 )
 
 const ToolIDPDFCropV1 = "tool.pdf.crop"
@@ -41,6 +42,32 @@ func (t *CropTool) Manifest() models.ToolManifestV1 {
 		OutputExtensions: []string{"pdf"},
 		RuntimeDeps:      []string{"pdfcpu"},
 		Tags:             []string{"pdf", "crop", "margins"},
+		Options: []models.OptionSchemaV1{
+			{Key: "cropPreset", Type: models.OptionTypeString, Label: "Crop preset", Required: true, Enum: []string{engine.CropPresetNone, engine.CropPresetSmall, engine.CropPresetMedium, engine.CropPresetLarge, engine.CropPresetCustom}},
+			{Key: "pageSelection", Type: models.OptionTypeString, Label: "Pages", Description: "Page ranges such as 1-3,5; empty crops every page"},
+			{
+				Key:         "margins",
+				Type:        models.OptionTypeObject,
+				Label:       "Margins",
+				Description: "Points removed from each edge, required when cropPreset is custom",
+				Fields: []models.OptionSchemaV1{
+					{Key: "top", Type: models.OptionTypeNumber, Label: "Top", Required: true, Min: models.OptionBound(0)},
+					{Key: "right", Type: models.OptionTypeNumber, Label: "Right", Required: true, Min: models.OptionBound(0)},
+					{Key: "bottom", Type: models.OptionTypeNumber, Label: "Bottom", Required: true, Min: models.OptionBound(0)},
+					{Key: "left", Type: models.OptionTypeNumber, Label: "Left", Required: true, Min: models.OptionBound(0)},
+				},
+			},
+			{Key: "outputPath", Type: models.OptionTypeString, Label: "Output file", Required: true, Format: "path", Modes: []string{"single"}},
+			{Key: "outputDir", Type: models.OptionTypeString, Label: "Output folder", Required: true, Format: "dir", Modes: []string{"batch"}},
+			tools.OutputNameTemplateSchema(engine.DefaultCropBatchNameTemplate),
+		},
+	}
+}
+
+func (t *CropTool) RetryPolicy() models.RetryPolicyV1 {
+	return models.RetryPolicyV1{
+		MaxAttempts:    3,
+		RetryableCodes: []string{"PDF_CROP_EXECUTION", "PDF_CROP_FAILED"},
 	}
 }
 
@@ -62,33 +89,108 @@ func (t *CropTool) Validate(_ context.Context, req models.JobRequestV1) *models.
 		return nil
 	}
 
-	if cropErr := engine.ValidateCropRequest(parsed.InputPath, parsed.OutputPath, parsed.PageSelection, parsed.CropPreset, parsed.Margins); cropErr != nil {
+	target, conflictErr := tools.ResolveOutputTarget(parsed.ConflictPolicy, parsed.OutputPath, nil)
+	if conflictErr != nil {
+		return conflictErr
+	}
+	if target.Skip {
+		return nil
+	}
+
+	if cropErr := engine.ValidateCropRequest(parsed.InputPath, target.WritePath, parsed.PageSelection, parsed.CropPreset, parsed.Margins); cropErr != nil {
 		return mapCropError(cropErr)
 	}
 
 	return nil
 }
 
+func (t *CropTool) Plan(_ context.Context, req models.JobRequestV1) (models.JobPlanV1, *models.JobErrorV1) {
+	parsed, reqErr := cropReqFields(req)
+	if reqErr != nil {
+		return models.JobPlanV1{}, reqErr
+	}
+
+	if parsed.Mode == "single" {
+		item, itemErr := planCropItem(parsed, parsed.InputPath, parsed.OutputPath, nil)
+		if itemErr != nil {
+			return models.JobPlanV1{}, itemErr
+		}
+		return tools.NewJobPlan(ToolIDPDFCropV1, parsed.Mode, []models.PlanItemV1{item}), nil
+	}
+
+	if cropErr := engine.CheckCropBatchRequest(parsed.InputPaths, parsed.OutputDir, parsed.PageSelection, parsed.CropPreset, parsed.Margins); cropErr != nil {
+		return models.JobPlanV1{}, mapCropError(cropErr)
+	}
+
+	nameTemplate := parsed.NameTemplate
+	if nameTemplate == "" {
+		nameTemplate = engine.DefaultCropBatchNameTemplate
+	}
+	reserved := make(map[string]struct{}, len(parsed.InputPaths))
+	items := make([]models.PlanItemV1, 0, len(parsed.InputPaths))
+	for index, inputPath := range parsed.InputPaths {
+		outputPath := tools.RenderOutputPath(parsed.OutputDir, nameTemplate, tools.OutputNameVars{
+			InputPath: inputPath,
+			Ext:       "pdf",
+			Tool:      ToolIDPDFCropV1,
+			Index:     index + 1,
+			Subdir:    parsed.Subdirs[inputPath],
+		})
+		item, itemErr := planCropItem(parsed, inputPath, outputPath, reserved)
+		if itemErr != nil {
+			item = tools.FailedPlanItem(inputPath, itemErr)
+		}
+		items = append(items, item)
+	}
+	return tools.NewJobPlan(ToolIDPDFCropV1, parsed.Mode, items), nil
+}
+
+// planCropItem checks one input the way the crop would, writing nothing. The
+// cropped file keeps every page, so its size is estimated from the input.
+func planCropItem(parsed cropRequestFields, inputPath, outputPath string, reserved map[string]struct{}) (models.PlanItemV1, *models.JobErrorV1) {
+	target, conflictErr := tools.ResolveOutputTarget(parsed.ConflictPolicy, outputPath, reserved)
+	if conflictErr != nil {
+		return models.PlanItemV1{}, conflictErr
+	}
+	if !target.Skip {
+		if cropErr := engine.CheckCropRequest(inputPath, target.WritePath, parsed.PageSelection, parsed.CropPreset, parsed.Margins); cropErr != nil {
+			return models.PlanItemV1{}, mapCropError(cropErr)
+		}
+	}
+	return tools.PlanItem(inputPath, []tools.OutputTarget{target}, tools.FileSize(inputPath)), nil
+}
+
 func (t *CropTool) ExecuteSingle(ctx context.Context, req models.JobRequestV1) (models.JobResultItemV1, *models.JobErrorV1) {
 	parsed, reqErr := cropReqFields(req)
 	if reqErr != nil {
 		return models.JobResultItemV1{InputPath: parsed.InputPath, OutputPath: parsed.OutputPath, Success: false, Message: reqErr.Message, Error: reqErr}, reqErr
 	}
 
-	if cropErr := engine.ValidateCropRequest(parsed.InputPath, parsed.OutputPath, parsed.PageSelection, parsed.CropPreset, parsed.Margins); cropErr != nil {
+	target, conflictErr := tools.ResolveOutputTarget(parsed.ConflictPolicy, parsed.OutputPath, nil)
+	if conflictErr != nil {
+		return models.JobResultItemV1{InputPath: parsed.InputPath, OutputPath: parsed.OutputPath, Success: false, Message: conflictErr.Message, Error: conflictErr}, conflictErr
+	}
+	if target.Skip {
+		return models.SkippedItemV1(parsed.InputPath, target.Path), nil
+	}
+
+	if cropErr := engine.ValidateCropRequest(parsed.InputPath, target.WritePath, parsed.PageSelection, parsed.CropPreset, parsed.Margins); cropErr != nil {
 		jobErr := mapCropError(cropErr)
-		return models.JobResultItemV1{InputPath: parsed.InputPath, OutputPath: parsed.OutputPath, Success: false, Message: jobErr.Message, Error: jobErr}, jobErr
+		return models.JobResultItemV1{InputPath: parsed.InputPath, OutputPath: target.Path, Success: false, Message: jobErr.Message, Error: jobErr}, jobErr
 	}
 
-	if err := engine.Crop(ctx, parsed.InputPath, parsed.OutputPath, parsed.PageSelection, parsed.CropPreset, parsed.Margins); err != nil {
+	err := tools.WriteOutput(ctx, target, func(writePath string) error {
+		return engine.Crop(ctx, parsed.InputPath, writePath, parsed.PageSelection, parsed.CropPreset, parsed.Margins)
+	})
+	if err != nil {
 		jobErr := mapCropError(err)
-		return models.JobResultItemV1{InputPath: parsed.InputPath, OutputPath: parsed.OutputPath, Success: false, Message: jobErr.Message, Error: jobErr}, jobErr
+		return models.JobResultItemV1{InputPath: parsed.InputPath, OutputPath: target.Path, Success: false, Message: jobErr.Message, Error: jobErr}, jobErr
 	}
 
 	return models.JobResultItemV1{
 		InputPath:   parsed.InputPath,
-		OutputPath:  parsed.OutputPath,
-		Outputs:     []string{parsed.OutputPath},
+		OutputPath:  target.Path,
+		Outputs:     []string{target.Path},
 		OutputCount: 1,
 		Success:     true,
 		Message:     "PDF crop successful",
@@ -105,7 +207,9 @@ func (t *CropTool) ExecuteBatch(ctx context.Context, req models.JobRequestV1, on
 		return nil, &models.JobErrorV1{Code: engine.ErrorCodeValidation, Message: "mode must be batch"}
 	}
 
-	results, err := engine.CropBatch(ctx, parsed.InputPaths, parsed.OutputDir, parsed.PageSelection, parsed.CropPreset, parsed.Margins)
+	results, err := engine.CropBatch(ctx, parsed.InputPaths, parsed.OutputDir, parsed.PageSelection, parsed.CropPreset, parsed.Margins, parsed.NameTemplate, parsed.ConflictPolicy, parsed.Subdirs, func(inputPath string) bool {
+		return tools.ItemCancelled(ctx, inputPath)
+	})
 	if err != nil {
 		return nil, mapCropError(err)
 	}
@@ -118,7 +222,11 @@ func (t *CropTool) ExecuteBatch(ctx context.Context, req models.JobRequestV1, on
 			OutputPath: result.OutputPath,
 			Success:    result.Success,
 		}
-		if result.Success {
+		if result.Skipped {
+			item = models.CancelledItemV1(result.InputPath, result.OutputPath)
+		} else if result.Existing {
+			item = models.SkippedItemV1(result.InputPath, result.OutputPath)
+		} else if result.Success {
 			item.Message = "PDF crop successful"
 			item.Outputs = []string{result.OutputPath}
 			item.OutputCount = 1
@@ -141,6 +249,7 @@ func (t *CropTool) ExecuteBatch(ctx context.Context, req models.JobRequestV1, on
 				Total:   len(results),
 				Stage:   "running",
 				Message: fmt.Sprintf("processed %d/%d", i+1, len(results)),
+				Item:    &item,
 			})
 		}
 	}
@@ -155,14 +264,17 @@ func (t *CropTool) ExecuteBatch(ctx context.Context, req models.JobRequestV1, on
 }
 
 type cropRequestFields struct {
-	Mode          string
-	InputPath     string
-	InputPaths    []string
-	OutputPath    string
-	OutputDir     string
-	PageSelection string
-	CropPreset    string
-	Margins       *engine.CropMargins
+	Mode           string
+	InputPath      string
+	InputPaths     []string
+	OutputPath     string
+	OutputDir      string
+	PageSelection  string
+	CropPreset     string
+	Margins        *engine.CropMargins
+	NameTemplate   string
+	ConflictPolicy string
+	Subdirs        map[string]string // output sub-directory of each input, from input expansion
 }
 
 func cropReqFields(req models.JobRequestV1) (cropRequestFields, *models.JobErrorV1) {
@@ -220,15 +332,22 @@ func cropReqFields(req models.JobRequestV1) (cropRequestFields, *models.JobError
 		return cropRequestFields{Mode: mode, InputPath: inputPath, InputPaths: inputPaths, OutputPath: outputPath, OutputDir: outputDir, PageSelection: pageSelection, CropPreset: cropPreset}, &models.JobErrorV1{Code: engine.ErrorCodeCropMarginsRequired, Message: "options.margins is required when cropPreset=custom"}
 	}
 
+	if tmplErr := tools.ValidateOutputNameTemplate(req.Options); tmplErr != nil {
+		return cropRequestFields{Mode: mode, InputPath: inputPath, InputPaths: inputPaths, OutputPath: outputPath, OutputDir: outputDir, PageSelection: pageSelection, CropPreset: cropPreset}, tmplErr
+	}
+
 	return cropRequestFields{
-		Mode:          mode,
-		InputPath:     inputPath,
-		InputPaths:    inputPaths,
-		OutputPath:    outputPath,
-		OutputDir:     outputDir,
-		PageSelection: pageSelection,
-		CropPreset:    cropPreset,
-		Margins:       margins,
+		Mode:           mode,
+		InputPath:      inputPath,
+		InputPaths:     inputPaths,
+		OutputPath:     outputPath,
+		OutputDir:      outputDir,
+		PageSelection:  pageSelection,
+		CropPreset:     cropPreset,
+		Margins:        margins,
+		NameTemplate:   tools.OutputNameTemplate(req.Options, ""),
+		ConflictPolicy: tools.ConflictPolicy(req),
+		Subdirs:        req.InputSubdirs,
 	}, nil
 }
 
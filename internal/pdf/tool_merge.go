@@ -7,6 +7,7 @@ import (
 
 	"fileforge-desktop/internal/models"
 	"fileforge-desktop/internal/pdf/engine"
+	"fileforge-desktop/internal/tools"
 )
 
 const ToolIDPDFMergeV1 = "tool.pdf.merge"
@@ -39,6 +40,16 @@ func (t *MergeTool) Manifest() models.ToolManifestV1 {
 		OutputExtensions: []string{"pdf"},
 		RuntimeDeps:      []string{"pdfcpu"},
 		Tags:             []string{"pdf", "merge", "documents"},
+		Options: []models.OptionSchemaV1{
+			{Key: "outputPath", Type: models.OptionTypeString, Label: "Output file", Description: "Must end in .pdf", Required: true, Format: "path"},
+		},
+	}
+}
+
+func (t *MergeTool) RetryPolicy() models.RetryPolicyV1 {
+	return models.RetryPolicyV1{
+		MaxAttempts:    3,
+		RetryableCodes: []string{"PDF_MERGE_EXECUTION", "PDF_MERGE_FAILED"},
 	}
 }
 
@@ -47,20 +58,57 @@ func (t *MergeTool) RuntimeState(_ context.Context) models.ToolRuntimeStateV1 {
 }
 
 func (t *MergeTool) Validate(_ context.Context, req models.JobRequestV1) *models.JobErrorV1 {
+	_, jobErr := checkMergeRequest(req, engine.ValidateMergePaths)
+	return jobErr
+}
+
+// Plan runs the checks of Validate without its write test in the output
+// directory.
+func (t *MergeTool) Plan(_ context.Context, req models.JobRequestV1) (models.JobPlanV1, *models.JobErrorV1) {
+	target, jobErr := checkMergeRequest(req, engine.CheckMergePaths)
+	if jobErr != nil {
+		return models.JobPlanV1{}, jobErr
+	}
+
+	var estimated int64
+	for _, inputPath := range req.InputPaths {
+		estimated += tools.FileSize(inputPath)
+	}
+	item := tools.PlanItem(strings.Join(req.InputPaths, ","), []tools.OutputTarget{target}, estimated)
+	return tools.NewJobPlan(ToolIDPDFMergeV1, req.Mode, []models.PlanItemV1{item}), nil
+}
+
+func checkMergeRequest(req models.JobRequestV1, checkPaths func(inputPaths []string, outputPath string) *engine.MergeError) (tools.OutputTarget, *models.JobErrorV1) {
 	if req.Mode != "single" {
-		return &models.JobErrorV1{Code: "VALIDATION_ERROR", Message: "mode must be single"}
+		return tools.OutputTarget{}, &models.JobErrorV1{Code: "VALIDATION_ERROR", Message: "mode must be single"}
+	}
+
+	target, conflictErr := tools.ResolveOutputTarget(tools.ConflictPolicy(req), outputPathFromOptions(req.Options), nil)
+	if conflictErr != nil {
+		return tools.OutputTarget{}, conflictErr
 	}
 
-	if mergeErr := engine.ValidateMergePaths(req.InputPaths, outputPathFromOptions(req.Options)); mergeErr != nil {
-		return mapMergeError(mergeErr)
+	if mergeErr := checkPaths(req.InputPaths, target.Path); mergeErr != nil {
+		return tools.OutputTarget{}, mapMergeError(mergeErr)
 	}
 
-	return nil
+	return target, nil
 }
 
 func (t *MergeTool) ExecuteSingle(ctx context.Context, req models.JobRequestV1) (models.JobResultItemV1, *models.JobErrorV1) {
 	outputPath := outputPathFromOptions(req.Options)
-	err := engine.Merge(ctx, req.InputPaths, outputPath)
+	target, conflictErr := tools.ResolveOutputTarget(tools.ConflictPolicy(req), outputPath, nil)
+	if conflictErr != nil {
+		return models.JobResultItemV1{InputPath: strings.Join(req.InputPaths, ","), OutputPath: outputPath, Success: false, Message: conflictErr.Message, Error: conflictErr}, conflictErr
+	}
+	if target.Skip {
+		return models.SkippedItemV1(strings.Join(req.InputPaths, ","), target.Path), nil
+	}
+	outputPath = target.Path
+
+	err := tools.WriteOutput(ctx, target, func(writePath string) error {
+		return engine.Merge(ctx, req.InputPaths, writePath)
+	})
 	if err != nil {
 		jobErr := mapMergeError(err)
 		return models.JobResultItemV1{
@@ -9,6 +9,7 @@ import (
 
 	"fileforge-desktop/internal/models"
 	"fileforge-desktop/internal/pdf/engine"
+	"fileforge-desktop/internal/tools"
 )
 
 const ToolIDPDFSplitV1 = "tool.pdf.split"
@@ -41,6 +42,20 @@ func (t *SplitTool) Manifest() models.ToolManifestV1 {
 		OutputExtensions: []string{"pdf"},
 		RuntimeDeps:      []string{"pdfcpu"},
 		Tags:             []string{"pdf", "split", "pages"},
+		Options: []models.OptionSchemaV1{
+			{Key: "outputDir", Type: models.OptionTypeString, Label: "Output folder", Required: true, Format: "dir"},
+			{Key: "strategy", Type: models.OptionTypeString, Label: "Split by", Default: engine.SplitStrategyEveryPage, Enum: []string{engine.SplitStrategyEveryPage, engine.SplitStrategyRanges}},
+			{Key: "ranges", Type: models.OptionTypeString, Label: "Ranges", Description: "Page ranges such as 1-3,4-6, required when strategy is ranges"},
+			{Key: "perInputDir", Type: models.OptionTypeBoolean, Label: "Folder per input", Description: "Write the pages of each input into its own folder", Default: true, Modes: []string{"batch"}},
+			tools.OutputNameTemplateSchema(engine.DefaultSplitPageNameTemplate),
+		},
+	}
+}
+
+func (t *SplitTool) RetryPolicy() models.RetryPolicyV1 {
+	return models.RetryPolicyV1{
+		MaxAttempts:    3,
+		RetryableCodes: []string{"PDF_SPLIT_EXECUTION", "PDF_SPLIT_FAILED"},
 	}
 }
 
@@ -55,20 +70,53 @@ func (t *SplitTool) Validate(_ context.Context, req models.JobRequestV1) *models
 	}
 
 	if parsed.Mode == "single" {
-		if splitErr := engine.ValidateSplitRequest(parsed.InputPath, parsed.OutputDir, parsed.Strategy, parsed.RangesExpr); splitErr != nil {
+		if splitErr := engine.ValidateSplitRequest(parsed.InputPath, parsed.OutputDir, parsed.Strategy, parsed.RangesExpr, parsed.NameTmpl, parsed.Conflicts); splitErr != nil {
 			return mapSplitError(splitErr)
 		}
 
 		return nil
 	}
 
-	if splitErr := engine.ValidateSplitBatchRequest(parsed.InputPaths, parsed.OutputDir, parsed.Strategy, parsed.RangesExpr, parsed.PerInputDir); splitErr != nil {
+	if splitErr := engine.ValidateSplitBatchRequest(parsed.InputPaths, parsed.OutputDir, parsed.Strategy, parsed.RangesExpr, parsed.NameTmpl, parsed.Conflicts, parsed.PerInputDir, parsed.Subdirs); splitErr != nil {
 		return mapSplitError(splitErr)
 	}
 
 	return nil
 }
 
+func (t *SplitTool) Plan(_ context.Context, req models.JobRequestV1) (models.JobPlanV1, *models.JobErrorV1) {
+	parsed, validationErr := splitReqFields(req)
+	if validationErr != nil {
+		return models.JobPlanV1{}, validationErr
+	}
+
+	var plans []engine.SplitPlan
+	if parsed.Mode == "single" {
+		plan, splitErr := engine.PlanSplit(parsed.InputPath, parsed.OutputDir, parsed.Strategy, parsed.RangesExpr, parsed.NameTmpl, parsed.Conflicts)
+		if splitErr != nil {
+			return models.JobPlanV1{}, mapSplitError(splitErr)
+		}
+		plans = []engine.SplitPlan{plan}
+	} else {
+		batchPlans, splitErr := engine.PlanSplitBatch(parsed.InputPaths, parsed.OutputDir, parsed.Strategy, parsed.RangesExpr, parsed.NameTmpl, parsed.Conflicts, parsed.PerInputDir, parsed.Subdirs)
+		if splitErr != nil {
+			return models.JobPlanV1{}, mapSplitError(splitErr)
+		}
+		plans = batchPlans
+	}
+
+	items := make([]models.PlanItemV1, 0, len(plans))
+	for _, plan := range plans {
+		// The parts share the input's size between them.
+		var bytesPerOutput int64
+		if len(plan.Targets) > 0 {
+			bytesPerOutput = tools.FileSize(plan.InputPath) / int64(len(plan.Targets))
+		}
+		items = append(items, tools.PlanItem(plan.InputPath, plan.Targets, bytesPerOutput))
+	}
+	return tools.NewJobPlan(ToolIDPDFSplitV1, parsed.Mode, items), nil
+}
+
 func (t *SplitTool) ExecuteSingle(ctx context.Context, req models.JobRequestV1) (models.JobResultItemV1, *models.JobErrorV1) {
 	parsed, validationErr := splitReqFields(req)
 	if validationErr != nil {
@@ -80,7 +128,7 @@ func (t *SplitTool) ExecuteSingle(ctx context.Context, req models.JobRequestV1)
 		return models.JobResultItemV1{InputPath: parsed.InputPath, OutputPath: parsed.OutputDir, Success: false, Message: jobErr.Message, Error: jobErr}, jobErr
 	}
 
-	if splitErr := engine.ValidateSplitRequest(parsed.InputPath, parsed.OutputDir, parsed.Strategy, parsed.RangesExpr); splitErr != nil {
+	if splitErr := engine.ValidateSplitRequest(parsed.InputPath, parsed.OutputDir, parsed.Strategy, parsed.RangesExpr, parsed.NameTmpl, parsed.Conflicts); splitErr != nil {
 		jobErr := mapSplitError(splitErr)
 		return models.JobResultItemV1{
 			InputPath:  parsed.InputPath,
@@ -91,7 +139,7 @@ func (t *SplitTool) ExecuteSingle(ctx context.Context, req models.JobRequestV1)
 		}, jobErr
 	}
 
-	outputs, err := engine.Split(ctx, parsed.InputPath, parsed.OutputDir, parsed.Strategy, parsed.RangesExpr)
+	outputs, existing, err := engine.Split(ctx, parsed.InputPath, parsed.OutputDir, parsed.Strategy, parsed.RangesExpr, parsed.NameTmpl, parsed.Conflicts)
 	if err != nil {
 		jobErr := mapSplitError(err)
 		return models.JobResultItemV1{
@@ -103,14 +151,7 @@ func (t *SplitTool) ExecuteSingle(ctx context.Context, req models.JobRequestV1)
 		}, jobErr
 	}
 
-	return models.JobResultItemV1{
-		InputPath:   parsed.InputPath,
-		OutputPath:  parsed.OutputDir,
-		Outputs:     append([]string(nil), outputs...),
-		OutputCount: len(outputs),
-		Success:     true,
-		Message:     fmt.Sprintf("PDF split successful: generated %d files", len(outputs)),
-	}, nil
+	return splitResultItem(parsed.InputPath, parsed.OutputDir, outputs, existing), nil
 }
 
 func (t *SplitTool) ExecuteBatch(ctx context.Context, req models.JobRequestV1, onProgress func(models.JobProgressV1)) ([]models.JobResultItemV1, *models.JobErrorV1) {
@@ -123,25 +164,24 @@ func (t *SplitTool) ExecuteBatch(ctx context.Context, req models.JobRequestV1, o
 		return nil, &models.JobErrorV1{Code: engine.ErrorCodeValidation, Message: "mode must be batch"}
 	}
 
-	if splitErr := engine.ValidateSplitBatchRequest(parsed.InputPaths, parsed.OutputDir, parsed.Strategy, parsed.RangesExpr, parsed.PerInputDir); splitErr != nil {
+	if splitErr := engine.ValidateSplitBatchRequest(parsed.InputPaths, parsed.OutputDir, parsed.Strategy, parsed.RangesExpr, parsed.NameTmpl, parsed.Conflicts, parsed.PerInputDir, parsed.Subdirs); splitErr != nil {
 		return nil, mapSplitError(splitErr)
 	}
 
-	batchResults, err := engine.SplitBatch(ctx, parsed.InputPaths, parsed.OutputDir, parsed.Strategy, parsed.RangesExpr, parsed.PerInputDir)
+	batchResults, err := engine.SplitBatch(ctx, parsed.InputPaths, parsed.OutputDir, parsed.Strategy, parsed.RangesExpr, parsed.NameTmpl, parsed.Conflicts, parsed.PerInputDir, parsed.Subdirs, func(inputPath string) bool {
+		return tools.ItemCancelled(ctx, inputPath)
+	})
 	if err != nil {
 		return nil, mapSplitError(err)
 	}
 
 	items := make([]models.JobResultItemV1, 0, len(batchResults))
 	for i, result := range batchResults {
-		items = append(items, models.JobResultItemV1{
-			InputPath:   result.InputPath,
-			OutputPath:  result.OutputDir,
-			Outputs:     append([]string(nil), result.Outputs...),
-			OutputCount: len(result.Outputs),
-			Success:     true,
-			Message:     fmt.Sprintf("PDF split successful: generated %d files", len(result.Outputs)),
-		})
+		if result.Skipped {
+			items = append(items, models.CancelledItemV1(result.InputPath, result.OutputDir))
+		} else {
+			items = append(items, splitResultItem(result.InputPath, result.OutputDir, result.Outputs, result.Existing))
+		}
 
 		if onProgress != nil {
 			onProgress(models.JobProgressV1{
@@ -149,6 +189,7 @@ func (t *SplitTool) ExecuteBatch(ctx context.Context, req models.JobRequestV1, o
 				Total:   len(batchResults),
 				Stage:   "running",
 				Message: fmt.Sprintf("processed %d/%d", i+1, len(batchResults)),
+				Item:    models.LastResultItemV1(items),
 			})
 		}
 	}
@@ -156,6 +197,31 @@ func (t *SplitTool) ExecuteBatch(ctx context.Context, req models.JobRequestV1, o
 	return items, nil
 }
 
+// splitResultItem reports an input whose outputs all existed and were kept
+// under the skip conflict policy as skipped.
+func splitResultItem(inputPath, outputDir string, outputs, existing []string) models.JobResultItemV1 {
+	if len(outputs) == 0 && len(existing) > 0 {
+		item := models.SkippedItemV1(inputPath, outputDir)
+		item.Outputs = append([]string(nil), existing...)
+		item.OutputCount = len(existing)
+		item.Message = fmt.Sprintf("all %d outputs already exist, skipped", len(existing))
+		return item
+	}
+
+	message := fmt.Sprintf("PDF split successful: generated %d files", len(outputs))
+	if len(existing) > 0 {
+		message += fmt.Sprintf(", kept %d existing", len(existing))
+	}
+	return models.JobResultItemV1{
+		InputPath:   inputPath,
+		OutputPath:  outputDir,
+		Outputs:     append([]string(nil), outputs...),
+		OutputCount: len(outputs),
+		Success:     true,
+		Message:     message,
+	}
+}
+
 type splitRequestFields struct {
 	Mode        string
 	InputPath   string
@@ -164,6 +230,9 @@ type splitRequestFields struct {
 	Strategy    string
 	RangesExpr  string
 	PerInputDir bool
+	NameTmpl    string
+	Conflicts   string
+	Subdirs     map[string]string // output sub-directory of each input, from input expansion
 }
 
 func splitReqFields(req models.JobRequestV1) (splitRequestFields, *models.JobErrorV1) {
@@ -204,6 +273,10 @@ func splitReqFields(req models.JobRequestV1) (splitRequestFields, *models.JobErr
 		return splitRequestFields{Mode: mode, InputPath: firstInputPath(inputPaths), InputPaths: inputPaths, OutputDir: filepath.Clean(outputDir), Strategy: strategy}, &models.JobErrorV1{Code: engine.ErrorCodeSplitRangesRequired, Message: "options.ranges is required for strategy=ranges"}
 	}
 
+	if tmplErr := tools.ValidateOutputNameTemplate(req.Options); tmplErr != nil {
+		return splitRequestFields{Mode: mode, InputPath: firstInputPath(inputPaths), InputPaths: inputPaths, OutputDir: filepath.Clean(outputDir), Strategy: strategy}, tmplErr
+	}
+
 	return splitRequestFields{
 		Mode:        mode,
 		InputPath:   firstInputPath(inputPaths),
@@ -212,6 +285,9 @@ func splitReqFields(req models.JobRequestV1) (splitRequestFields, *models.JobErr
 		Strategy:    strategy,
 		RangesExpr:  rangesExpr,
 		PerInputDir: optionBool(req.Options, "perInputDir", mode == "batch"),
+		NameTmpl:    tools.OutputNameTemplate(req.Options, ""),
+		Conflicts:   tools.ConflictPolicy(req),
+		Subdirs:     req.InputSubdirs,
 	}, nil
 }
 
@@ -0,0 +1,190 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"fileforge-desktop/internal/models"
+	"fileforge-desktop/internal/tools"
+)
+
+// ValidateRequest checks the conflict policy and req.Options against the
+// option schema of the tool manifest and then runs the tool's own validation.
+// Keys the schema does not declare are left to the tool.
+func ValidateRequest(ctx context.Context, tool tools.Tool, req models.JobRequestV1) *models.JobErrorV1 {
+	if requestErr := ValidateRequestOptions(tool, req); requestErr != nil {
+		return requestErr
+	}
+	return tool.Validate(ctx, req)
+}
+
+// ValidateRequestOptions runs the checks of ValidateRequest that do not call
+// into the tool.
+func ValidateRequestOptions(tool tools.Tool, req models.JobRequestV1) *models.JobErrorV1 {
+	if policyErr := tools.ValidateConflictPolicy(req); policyErr != nil {
+		policyErr.Details = withToolID(policyErr.Details, tool.ID())
+		return policyErr
+	}
+	if schemaErr := ValidateOptions(tool.Manifest().Options, req.Mode, req.Options); schemaErr != nil {
+		schemaErr.Details = withToolID(schemaErr.Details, tool.ID())
+		return schemaErr
+	}
+	return nil
+}
+
+// ValidateOptions checks options against schema for the given mode. Options
+// whose Modes do not include mode are not checked.
+func ValidateOptions(schema []models.OptionSchemaV1, mode string, options map[string]any) *models.JobErrorV1 {
+	for _, option := range schema {
+		if !optionAppliesTo(option, mode) {
+			continue
+		}
+		if optionErr := validateOption(option, "options."+option.Key, options[option.Key]); optionErr != nil {
+			return optionErr
+		}
+	}
+	return nil
+}
+
+func optionAppliesTo(option models.OptionSchemaV1, mode string) bool {
+	if len(option.Modes) == 0 {
+		return true
+	}
+	for _, m := range option.Modes {
+		if strings.EqualFold(m, strings.TrimSpace(mode)) {
+			return true
+		}
+	}
+	return false
+}
+
+func validateOption(option models.OptionSchemaV1, path string, value any) *models.JobErrorV1 {
+	if isEmptyOption(value) {
+		if option.Required {
+			return optionError("OPTION_MISSING", fmt.Sprintf("%s is required", path), path, nil)
+		}
+		return nil
+	}
+
+	switch option.Type {
+	case models.OptionTypeString:
+		s, ok := value.(string)
+		if !ok {
+			return optionError("OPTION_INVALID", fmt.Sprintf("%s must be a string", path), path, value)
+		}
+		if len(option.Enum) > 0 && !enumContains(option.Enum, s) {
+			return optionError("OPTION_INVALID", fmt.Sprintf("%s must be one of %s, got '%s'", path, strings.Join(option.Enum, ", "), s), path, value)
+		}
+	case models.OptionTypeInteger, models.OptionTypeNumber:
+		n, ok := optionNumber(value)
+		if !ok {
+			return optionError("OPTION_INVALID", fmt.Sprintf("%s must be a number", path), path, value)
+		}
+		if option.Type == models.OptionTypeInteger && n != math.Trunc(n) {
+			return optionError("OPTION_INVALID", fmt.Sprintf("%s must be an integer", path), path, value)
+		}
+		if option.Min != nil && n < *option.Min {
+			return optionError("OPTION_INVALID", fmt.Sprintf("%s must be at least %g", path, *option.Min), path, value)
+		}
+		if option.Max != nil && n > *option.Max {
+			return optionError("OPTION_INVALID", fmt.Sprintf("%s must be at most %g", path, *option.Max), path, value)
+		}
+	case models.OptionTypeBoolean:
+		if _, ok := value.(bool); !ok {
+			return optionError("OPTION_INVALID", fmt.Sprintf("%s must be a boolean", path), path, value)
+		}
+	case models.OptionTypeObject:
+		fields, ok := value.(map[string]any)
+		if !ok {
+			return optionError("OPTION_INVALID", fmt.Sprintf("%s must be an object", path), path, nil)
+		}
+		for _, field := range option.Fields {
+			if fieldErr := validateOption(field, path+"."+field.Key, fields[field.Key]); fieldErr != nil {
+				return fieldErr
+			}
+		}
+	case models.OptionTypeArray:
+		// Go callers may pass typed slices; only decoded JSON arrays are checked
+		// item by item.
+		if reflect.ValueOf(value).Kind() != reflect.Slice {
+			return optionError("OPTION_INVALID", fmt.Sprintf("%s must be an array", path), path, nil)
+		}
+		if option.Required && reflect.ValueOf(value).Len() == 0 {
+			return optionError("OPTION_MISSING", fmt.Sprintf("%s must not be empty", path), path, nil)
+		}
+		items, ok := value.([]any)
+		if !ok || option.Items == nil {
+			return nil
+		}
+		for i, item := range items {
+			if itemErr := validateOption(*option.Items, fmt.Sprintf("%s[%d]", path, i), item); itemErr != nil {
+				return itemErr
+			}
+		}
+	}
+	return nil
+}
+
+// isEmptyOption treats a missing value and a blank string alike, as the tools
+// do when they read their options.
+func isEmptyOption(value any) bool {
+	if value == nil {
+		return true
+	}
+	s, ok := value.(string)
+	return ok && strings.TrimSpace(s) == ""
+}
+
+// optionNumber accepts numeric strings too, as the tools' own option readers
+// do.
+func optionNumber(value any) (float64, bool) {
+	switch casted := value.(type) {
+	case string:
+		parsed, err := strconv.ParseFloat(strings.TrimSpace(casted), 64)
+		if err != nil || math.IsNaN(parsed) || math.IsInf(parsed, 0) {
+			return 0, false
+		}
+		return parsed, true
+	case float64:
+		return casted, true
+	case float32:
+		return float64(casted), true
+	case int:
+		return float64(casted), true
+	case int32:
+		return float64(casted), true
+	case int64:
+		return float64(casted), true
+	}
+	return 0, false
+}
+
+func enumContains(enum []string, value string) bool {
+	value = strings.TrimSpace(value)
+	for _, allowed := range enum {
+		if strings.EqualFold(allowed, value) {
+			return true
+		}
+	}
+	return false
+}
+
+func optionError(detailCode, message, path string, value any) *models.JobErrorV1 {
+	details := map[string]any{"option": path}
+	if value != nil {
+		details["value"] = value
+	}
+	return models.NewCanonicalJobError(detailCode, message, details)
+}
+
+func withToolID(details map[string]any, toolID string) map[string]any {
+	if details == nil {
+		details = make(map[string]any, 1)
+	}
+	details["toolId"] = toolID
+	return details
+}
@@ -0,0 +1,67 @@
+package registry
+
+import (
+	"testing"
+
+	"fileforge-desktop/internal/models"
+)
+
+func testOptionSchema() []models.OptionSchemaV1 {
+	return []models.OptionSchemaV1{
+		{Key: "format", Type: models.OptionTypeString, Required: true, Enum: []string{"png", "jpeg"}},
+		{Key: "quality", Type: models.OptionTypeInteger, Min: models.OptionBound(1), Max: models.OptionBound(100)},
+		{Key: "scale", Type: models.OptionTypeNumber, Min: models.OptionBound(0.1)},
+		{Key: "strip", Type: models.OptionTypeBoolean},
+		{Key: "outputPath", Type: models.OptionTypeString, Required: true, Modes: []string{"single"}},
+		{Key: "crop", Type: models.OptionTypeObject, Fields: []models.OptionSchemaV1{
+			{Key: "width", Type: models.OptionTypeInteger, Required: true, Min: models.OptionBound(1)},
+		}},
+		{Key: "pages", Type: models.OptionTypeArray, Items: &models.OptionSchemaV1{Type: models.OptionTypeInteger, Min: models.OptionBound(1)}},
+	}
+}
+
+func TestValidateOptions(t *testing.T) {
+	tests := []struct {
+		name       string
+		mode       string
+		options    map[string]any
+		wantCode   string
+		wantOption string
+	}{
+		{name: "valid batch", mode: "batch", options: map[string]any{"format": "PNG", "quality": float64(80), "strip": true}},
+		{name: "numeric string", mode: "batch", options: map[string]any{"format": "png", "quality": " 80 ", "scale": "0.5"}},
+		{name: "typed slice", mode: "batch", options: map[string]any{"format": "png", "pages": []int{1, 2}}},
+		{name: "missing required", mode: "batch", options: map[string]any{"format": "  "}, wantCode: "OPTION_MISSING", wantOption: "options.format"},
+		{name: "enum", mode: "batch", options: map[string]any{"format": "gif"}, wantCode: "OPTION_INVALID", wantOption: "options.format"},
+		{name: "string type", mode: "batch", options: map[string]any{"format": float64(2024)}, wantCode: "OPTION_INVALID", wantOption: "options.format"},
+		{name: "integer fraction", mode: "batch", options: map[string]any{"format": "png", "quality": 80.5}, wantCode: "OPTION_INVALID", wantOption: "options.quality"},
+		{name: "above max", mode: "batch", options: map[string]any{"format": "png", "quality": float64(101)}, wantCode: "OPTION_INVALID", wantOption: "options.quality"},
+		{name: "below min", mode: "batch", options: map[string]any{"format": "png", "scale": 0.01}, wantCode: "OPTION_INVALID", wantOption: "options.scale"},
+		{name: "not a number", mode: "batch", options: map[string]any{"format": "png", "quality": "high"}, wantCode: "OPTION_INVALID", wantOption: "options.quality"},
+		{name: "infinite number", mode: "batch", options: map[string]any{"format": "png", "scale": "Inf"}, wantCode: "OPTION_INVALID", wantOption: "options.scale"},
+		{name: "boolean", mode: "batch", options: map[string]any{"format": "png", "strip": "true"}, wantCode: "OPTION_INVALID", wantOption: "options.strip"},
+		{name: "mode specific", mode: "single", options: map[string]any{"format": "png"}, wantCode: "OPTION_MISSING", wantOption: "options.outputPath"},
+		{name: "object field", mode: "batch", options: map[string]any{"format": "png", "crop": map[string]any{}}, wantCode: "OPTION_MISSING", wantOption: "options.crop.width"},
+		{name: "object type", mode: "batch", options: map[string]any{"format": "png", "crop": "10x10"}, wantCode: "OPTION_INVALID", wantOption: "options.crop"},
+		{name: "array item", mode: "batch", options: map[string]any{"format": "png", "pages": []any{float64(1), float64(0)}}, wantCode: "OPTION_INVALID", wantOption: "options.pages[1]"},
+		{name: "array type", mode: "batch", options: map[string]any{"format": "png", "pages": "1,2"}, wantCode: "OPTION_INVALID", wantOption: "options.pages"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateOptions(testOptionSchema(), tt.mode, tt.options)
+			if tt.wantCode == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %+v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("want %s for %s, got nil", tt.wantCode, tt.wantOption)
+			}
+			if err.DetailCode != tt.wantCode || err.Details["option"] != tt.wantOption {
+				t.Fatalf("got %s for %v, want %s for %s", err.DetailCode, err.Details["option"], tt.wantCode, tt.wantOption)
+			}
+		})
+	}
+}
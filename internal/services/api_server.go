@@ -0,0 +1,356 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"fileforge-desktop/internal/models"
+)
+
+const (
+	DefaultAPIServerAddr = "127.0.0.1:7345"
+
+	apiMaxRequestBytes   = 1 << 20
+	apiKeepAliveInterval = 15 * time.Second
+	apiShutdownTimeout   = 5 * time.Second
+)
+
+// apiServer serves the V1 job contracts over loopback HTTP for local tools
+// that cannot use the Wails bindings. Every request goes through the same
+// ToolingService methods the bindings call.
+type apiServer struct {
+	service  *ToolingService
+	token    string
+	listener net.Listener
+	http     *http.Server
+	cancel   context.CancelFunc // ends open event streams on shutdown
+}
+
+func (s *ToolingService) StartAPIServerV1(cfg models.APIServerConfigV1) models.APIServerStatusResponseV1 {
+	s.apiMu.Lock()
+	defer s.apiMu.Unlock()
+
+	if s.apiServer != nil {
+		return apiServerFailure("api server already running", models.NewCanonicalJobError("API_SERVER_RUNNING", fmt.Sprintf("api server is already listening on %s", s.apiServer.listener.Addr()), nil))
+	}
+
+	addr := strings.TrimSpace(cfg.Addr)
+	if addr == "" {
+		addr = DefaultAPIServerAddr
+	}
+	if err := requireLoopback(addr); err != nil {
+		log.Printf("tooling.api.start_rejected addr=%s err=%v", addr, err)
+		return apiServerFailure("api server not started", models.NewCanonicalJobError("API_ADDR_INVALID", err.Error(), map[string]any{"addr": addr}))
+	}
+
+	token := strings.TrimSpace(cfg.Token)
+	if token == "" {
+		generated, err := newAPIToken()
+		if err != nil {
+			return apiServerFailure("api server not started", models.NewCanonicalJobError("API_TOKEN_ERROR", err.Error(), nil))
+		}
+		token = generated
+	}
+
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		log.Printf("tooling.api.listen_failed addr=%s err=%v", addr, err)
+		return apiServerFailure("api server not started", models.NewCanonicalJobError("API_LISTEN_ERROR", err.Error(), map[string]any{"addr": addr}))
+	}
+
+	baseCtx, cancel := context.WithCancel(context.Background())
+	server := &apiServer{service: s, token: token, listener: listener, cancel: cancel}
+	server.http = &http.Server{
+		Handler:           server.routes(),
+		ReadHeaderTimeout: 10 * time.Second,
+		BaseContext:       func(net.Listener) context.Context { return baseCtx },
+	}
+	go func() {
+		if err := server.http.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Printf("tooling.api.serve_failed addr=%s err=%v", listener.Addr(), err)
+		}
+	}()
+	s.apiServer = server
+
+	log.Printf("tooling.api.started addr=%s", listener.Addr())
+	return models.APIServerStatusResponseV1{
+		Success: true,
+		Message: "api server started",
+		Running: true,
+		Addr:    listener.Addr().String(),
+		Token:   token,
+	}
+}
+
+func (s *ToolingService) StopAPIServerV1() models.APIServerStatusResponseV1 {
+	ctx, cancel := context.WithTimeout(context.Background(), apiShutdownTimeout)
+	defer cancel()
+
+	if !s.stopAPIServer(ctx) {
+		return models.APIServerStatusResponseV1{Success: true, Message: "api server not running"}
+	}
+	return models.APIServerStatusResponseV1{Success: true, Message: "api server stopped"}
+}
+
+func (s *ToolingService) GetAPIServerStatusV1() models.APIServerStatusResponseV1 {
+	s.apiMu.Lock()
+	defer s.apiMu.Unlock()
+
+	if s.apiServer == nil {
+		return models.APIServerStatusResponseV1{Success: true, Message: "api server not running"}
+	}
+	return models.APIServerStatusResponseV1{
+		Success: true,
+		Message: "api server running",
+		Running: true,
+		Addr:    s.apiServer.listener.Addr().String(),
+		Token:   s.apiServer.token,
+	}
+}
+
+// stopAPIServer shuts the server down and reports whether one was running.
+func (s *ToolingService) stopAPIServer(ctx context.Context) bool {
+	s.apiMu.Lock()
+	server := s.apiServer
+	s.apiServer = nil
+	s.apiMu.Unlock()
+	if server == nil {
+		return false
+	}
+
+	server.cancel()
+	if err := server.http.Shutdown(ctx); err != nil {
+		server.http.Close()
+	}
+	log.Printf("tooling.api.stopped addr=%s", server.listener.Addr())
+	return true
+}
+
+func apiServerFailure(message string, jobErr *models.JobErrorV1) models.APIServerStatusResponseV1 {
+	return models.APIServerStatusResponseV1{Success: false, Message: message, Error: jobErr}
+}
+
+func requireLoopback(addr string) error {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return fmt.Errorf("invalid listen address %q: %w", addr, err)
+	}
+	if !isLoopbackHost(host) {
+		return fmt.Errorf("listen address %q is not a loopback address", addr)
+	}
+	return nil
+}
+
+func isLoopbackHost(host string) bool {
+	if strings.EqualFold(host, "localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+func newAPIToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("generate api token: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+func (a *apiServer) routes() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v1/tools", a.handleListTools)
+	mux.HandleFunc("POST /v1/jobs/validate", a.handleValidateJob)
+	mux.HandleFunc("POST /v1/jobs/plan", a.handlePlanJob)
+	mux.HandleFunc("POST /v1/jobs", a.handleRunJob)
+	mux.HandleFunc("GET /v1/jobs/{jobId}", a.handleJobStatus)
+	mux.HandleFunc("POST /v1/jobs/{jobId}/cancel", a.handleCancelJob)
+	mux.HandleFunc("GET /v1/events", a.handleEvents)
+	return a.authorize(mux)
+}
+
+// authorize rejects requests without the bearer token. The Host check keeps
+// web pages from reaching the server through DNS rebinding. EventSource cannot
+// set headers, so the event stream also accepts the token as a query parameter.
+func (a *apiServer) authorize(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host, _, err := net.SplitHostPort(r.Host)
+		if err != nil {
+			host = r.Host
+		}
+		if !isLoopbackHost(strings.Trim(host, "[]")) {
+			writeAPIError(w, http.StatusForbidden, "API_HOST_FORBIDDEN", fmt.Sprintf("host '%s' is not allowed", r.Host))
+			return
+		}
+
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok {
+			// CutPrefix leaves the whole header in place; a token without the
+			// Bearer scheme does not count.
+			token = ""
+			if r.URL.Path == "/v1/events" {
+				token = r.URL.Query().Get("token")
+			}
+		}
+		if subtle.ConstantTimeCompare([]byte(strings.TrimSpace(token)), []byte(a.token)) != 1 {
+			log.Printf("tooling.api.unauthorized method=%s path=%s", r.Method, r.URL.Path)
+			writeAPIError(w, http.StatusUnauthorized, "API_UNAUTHORIZED", "missing or invalid bearer token")
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (a *apiServer) handleListTools(w http.ResponseWriter, _ *http.Request) {
+	writeAPIResponse(w, http.StatusOK, a.service.ListToolsV1())
+}
+
+func (a *apiServer) handleValidateJob(w http.ResponseWriter, r *http.Request) {
+	var req models.JobRequestV1
+	if !decodeAPIRequest(w, r, &req) {
+		return
+	}
+	res := a.service.ValidateJobV1(req)
+	writeAPIResponse(w, apiStatus(res.Success, http.StatusOK, res.Error), res)
+}
+
+func (a *apiServer) handlePlanJob(w http.ResponseWriter, r *http.Request) {
+	var req models.JobRequestV1
+	if !decodeAPIRequest(w, r, &req) {
+		return
+	}
+	res := a.service.PlanJobV1(req)
+	writeAPIResponse(w, apiStatus(res.Success, http.StatusOK, res.Error), res)
+}
+
+func (a *apiServer) handleRunJob(w http.ResponseWriter, r *http.Request) {
+	var req models.JobRequestV1
+	if !decodeAPIRequest(w, r, &req) {
+		return
+	}
+	res := a.service.RunJobV1(req)
+	writeAPIResponse(w, apiStatus(res.Success, http.StatusAccepted, res.Error), res)
+}
+
+func (a *apiServer) handleJobStatus(w http.ResponseWriter, r *http.Request) {
+	res := a.service.GetJobStatusV1(r.PathValue("jobId"))
+	writeAPIResponse(w, apiStatus(res.Success, http.StatusOK, res.Error), res)
+}
+
+func (a *apiServer) handleCancelJob(w http.ResponseWriter, r *http.Request) {
+	res := a.service.CancelJobV1(r.PathValue("jobId"))
+	writeAPIResponse(w, apiStatus(res.Success, http.StatusAccepted, res.Error), res)
+}
+
+// handleEvents streams the JobProgressEventV1 values the Wails event bus
+// receives as Server-Sent Events, optionally for a single job.
+func (a *apiServer) handleEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeAPIError(w, http.StatusInternalServerError, "API_STREAM_UNSUPPORTED", "streaming is not supported")
+		return
+	}
+
+	jobID := r.URL.Query().Get("jobId")
+	events, unsubscribe := a.service.progress.subscribe()
+	defer unsubscribe()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(apiKeepAliveInterval)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		case evt, open := <-events:
+			if !open {
+				return
+			}
+			if jobID != "" && evt.JobID != jobID {
+				continue
+			}
+			payload, err := json.Marshal(evt)
+			if err != nil {
+				log.Printf("tooling.api.event_encode_failed jobId=%s err=%v", evt.JobID, err)
+				continue
+			}
+			if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", JobProgressEventNameV1, payload); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+func decodeAPIRequest(w http.ResponseWriter, r *http.Request, dst any) bool {
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, apiMaxRequestBytes))
+	if err := decoder.Decode(dst); err != nil {
+		writeAPIError(w, http.StatusBadRequest, "API_REQUEST_INVALID", fmt.Sprintf("invalid request body: %v", err))
+		return false
+	}
+	return true
+}
+
+// apiStatus maps a contract response onto an HTTP status. The response body is
+// always the contract itself, so clients can rely on its error codes.
+func apiStatus(success bool, okStatus int, jobErr *models.JobErrorV1) int {
+	if success {
+		return okStatus
+	}
+	if jobErr == nil {
+		return http.StatusInternalServerError
+	}
+
+	switch {
+	case strings.Contains(jobErr.DetailCode, "NOT_FOUND"):
+		return http.StatusNotFound
+	case jobErr.Code == models.ErrorCodeValidationInvalidInput, jobErr.Code == models.ErrorCodeUnsupportedFormat:
+		return http.StatusUnprocessableEntity
+	case jobErr.Code == models.ErrorCodeRuntimeDepMissing:
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func writeAPIError(w http.ResponseWriter, status int, detailCode, message string) {
+	writeAPIResponse(w, status, struct {
+		Success bool               `json:"success"`
+		Message string             `json:"message"`
+		Error   *models.JobErrorV1 `json:"error"`
+	}{
+		Success: false,
+		Message: message,
+		Error:   models.NewCanonicalJobError(detailCode, message, nil),
+	})
+}
+
+func writeAPIResponse(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		log.Printf("tooling.api.write_failed status=%d err=%v", status, err)
+	}
+}
@@ -0,0 +1,68 @@
+package services
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"fileforge-desktop/internal/models"
+)
+
+func TestAPIServerAuthorize(t *testing.T) {
+	const token = "secret-token"
+	a := &apiServer{token: token}
+	handler := a.authorize(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	tests := []struct {
+		name       string
+		target     string
+		host       string
+		auth       string
+		wantStatus int
+		wantCode   string
+	}{
+		{name: "bearer token", target: "/v1/tools", host: "127.0.0.1:7345", auth: "Bearer " + token, wantStatus: http.StatusNoContent},
+		{name: "localhost", target: "/v1/tools", host: "localhost:7345", auth: "Bearer " + token, wantStatus: http.StatusNoContent},
+		{name: "ipv6 loopback", target: "/v1/tools", host: "[::1]:7345", auth: "Bearer " + token, wantStatus: http.StatusNoContent},
+		{name: "missing token", target: "/v1/tools", host: "127.0.0.1:7345", wantStatus: http.StatusUnauthorized, wantCode: "API_UNAUTHORIZED"},
+		{name: "wrong token", target: "/v1/tools", host: "127.0.0.1:7345", auth: "Bearer wrong", wantStatus: http.StatusUnauthorized, wantCode: "API_UNAUTHORIZED"},
+		{name: "not a bearer token", target: "/v1/tools", host: "127.0.0.1:7345", auth: token, wantStatus: http.StatusUnauthorized, wantCode: "API_UNAUTHORIZED"},
+		{name: "foreign host", target: "/v1/tools", host: "evil.example:7345", auth: "Bearer " + token, wantStatus: http.StatusForbidden, wantCode: "API_HOST_FORBIDDEN"},
+		{name: "foreign host without port", target: "/v1/tools", host: "evil.example", auth: "Bearer " + token, wantStatus: http.StatusForbidden, wantCode: "API_HOST_FORBIDDEN"},
+		{name: "query token on events", target: "/v1/events?token=" + token, host: "127.0.0.1:7345", wantStatus: http.StatusNoContent},
+		{name: "wrong query token on events", target: "/v1/events?token=wrong", host: "127.0.0.1:7345", wantStatus: http.StatusUnauthorized, wantCode: "API_UNAUTHORIZED"},
+		{name: "query token elsewhere", target: "/v1/tools?token=" + token, host: "127.0.0.1:7345", wantStatus: http.StatusUnauthorized, wantCode: "API_UNAUTHORIZED"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.target, nil)
+			req.Host = tt.host
+			if tt.auth != "" {
+				req.Header.Set("Authorization", tt.auth)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if tt.wantCode == "" {
+				return
+			}
+			var body struct {
+				Success bool               `json:"success"`
+				Error   *models.JobErrorV1 `json:"error"`
+			}
+			if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+				t.Fatal(err)
+			}
+			if body.Success || body.Error == nil || body.Error.DetailCode != tt.wantCode {
+				t.Fatalf("body = %+v, want %s", body, tt.wantCode)
+			}
+		})
+	}
+}
@@ -0,0 +1,193 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"maps"
+	"strings"
+
+	"fileforge-desktop/internal/jobs"
+	"fileforge-desktop/internal/models"
+	"fileforge-desktop/internal/registry"
+	"fileforge-desktop/internal/tools"
+)
+
+func (s *ToolingService) ListPresetsV1(toolID string) models.ListPresetsResponseV1 {
+	if s.presets == nil {
+		return models.ListPresetsResponseV1{Success: false, Message: "presets unavailable", Presets: []models.PresetV1{}, Error: presetsUnavailableError()}
+	}
+
+	return models.ListPresetsResponseV1{
+		Success: true,
+		Message: "presets listed",
+		Presets: s.presets.List(strings.TrimSpace(toolID)),
+	}
+}
+
+// SavePresetV1 creates or replaces a preset. The preset's options are checked
+// against the tool's option schema; when sample has input paths they are also
+// checked with the tool's Validate against that job, the way the form the
+// preset was saved from would run it. Presets are validated again by every job
+// that uses them.
+func (s *ToolingService) SavePresetV1(preset models.PresetV1, sample models.JobRequestV1) models.PresetResponseV1 {
+	if s.presets == nil {
+		return models.PresetResponseV1{Success: false, Message: "presets unavailable", Error: presetsUnavailableError()}
+	}
+
+	preset.ToolID = strings.TrimSpace(preset.ToolID)
+	preset.Name = strings.TrimSpace(preset.Name)
+	if preset.Name == "" {
+		return presetFailure("preset not saved", models.NewCanonicalJobError("PRESET_INVALID", "preset name is required", nil))
+	}
+	tool, err := s.registry.GetToolV2(preset.ToolID)
+	if err != nil {
+		return presetFailure("preset not saved", models.NewCanonicalJobError("TOOL_NOT_FOUND", err.Error(), map[string]any{"toolId": preset.ToolID}))
+	}
+	if schemaErr := validatePresetOptions(tool, preset.Options); schemaErr != nil {
+		log.Printf("tooling.presets.invalid toolId=%s name=%s errorCode=%s", preset.ToolID, preset.Name, schemaErr.Code)
+		return presetFailure("preset options are invalid", schemaErr)
+	}
+
+	if len(sample.InputPaths) > 0 {
+		options := make(map[string]any, len(sample.Options)+len(preset.Options))
+		maps.Copy(options, sample.Options)
+		maps.Copy(options, preset.Options)
+		sample.ToolID, sample.Preset, sample.Options = preset.ToolID, "", options
+
+		if res := s.orchestrator.Validate(s.contextOrBackground(), sample); !res.Valid {
+			code := ""
+			if res.Error != nil {
+				code = res.Error.Code
+			}
+			log.Printf("tooling.presets.invalid toolId=%s name=%s errorCode=%s", preset.ToolID, preset.Name, code)
+			return presetFailure("preset options are invalid", res.Error)
+		}
+	}
+
+	saved, err := s.presets.Save(preset)
+	if err != nil {
+		log.Printf("tooling.presets.save_failed toolId=%s name=%s err=%v", preset.ToolID, preset.Name, err)
+		return presetFailure("preset not saved", models.NewCanonicalJobError("PRESET_SAVE_ERROR", err.Error(), nil))
+	}
+
+	log.Printf("tooling.presets.saved toolId=%s name=%s", saved.ToolID, saved.Name)
+	return models.PresetResponseV1{Success: true, Message: "preset saved", Preset: &saved}
+}
+
+func (s *ToolingService) DeletePresetV1(toolID, name string) models.PresetResponseV1 {
+	if s.presets == nil {
+		return models.PresetResponseV1{Success: false, Message: "presets unavailable", Error: presetsUnavailableError()}
+	}
+
+	deleted, err := s.presets.Delete(toolID, name)
+	if !deleted {
+		return presetFailure("preset not found", models.NewCanonicalJobError("NOT_FOUND", fmt.Sprintf("preset '%s' not found for tool '%s'", name, toolID), nil))
+	}
+	if err != nil {
+		log.Printf("tooling.presets.save_failed toolId=%s name=%s err=%v", toolID, name, err)
+		return presetFailure("preset not deleted", models.NewCanonicalJobError("PRESET_SAVE_ERROR", err.Error(), nil))
+	}
+
+	log.Printf("tooling.presets.deleted toolId=%s name=%s", toolID, name)
+	return models.PresetResponseV1{Success: true, Message: "preset deleted"}
+}
+
+// ExportPresetsV1 writes the presets for toolID, or all presets when toolID is
+// empty, to a bundle file at path.
+func (s *ToolingService) ExportPresetsV1(path, toolID string) models.PresetTransferResponseV1 {
+	if s.presets == nil {
+		return models.PresetTransferResponseV1{Success: false, Message: "presets unavailable", Path: path, Error: presetsUnavailableError()}
+	}
+
+	presets := s.presets.List(strings.TrimSpace(toolID))
+	if err := jobs.WritePresetBundle(path, presets); err != nil {
+		log.Printf("tooling.presets.export_failed path=%s err=%v", path, err)
+		return models.PresetTransferResponseV1{
+			Success: false,
+			Message: "presets not exported",
+			Path:    path,
+			Error:   models.NewCanonicalJobError("PRESET_EXPORT_ERROR", err.Error(), nil),
+		}
+	}
+
+	log.Printf("tooling.presets.exported path=%s count=%d", path, len(presets))
+	return models.PresetTransferResponseV1{Success: true, Message: "presets exported", Path: path, Count: len(presets)}
+}
+
+// ImportPresetsV1 adds the presets from a bundle file. Presets for tools that
+// are not installed, whose options do not match the tool's option schema, or
+// that would replace an existing preset without overwrite, are skipped.
+func (s *ToolingService) ImportPresetsV1(path string, overwrite bool) models.PresetTransferResponseV1 {
+	if s.presets == nil {
+		return models.PresetTransferResponseV1{Success: false, Message: "presets unavailable", Path: path, Error: presetsUnavailableError()}
+	}
+
+	bundle, err := jobs.ReadPresetBundle(path)
+	if err != nil {
+		log.Printf("tooling.presets.import_failed path=%s err=%v", path, err)
+		return models.PresetTransferResponseV1{
+			Success: false,
+			Message: "presets not imported",
+			Path:    path,
+			Error:   models.NewCanonicalJobError("PRESET_IMPORT_INVALID", err.Error(), nil),
+		}
+	}
+
+	accepted := make([]models.PresetV1, 0, len(bundle.Presets))
+	skipped := make([]string, 0)
+	for _, preset := range bundle.Presets {
+		tool, err := s.registry.GetToolV2(strings.TrimSpace(preset.ToolID))
+		if err != nil || strings.TrimSpace(preset.Name) == "" {
+			skipped = append(skipped, preset.ToolID+"/"+preset.Name)
+			continue
+		}
+		if schemaErr := validatePresetOptions(tool, preset.Options); schemaErr != nil {
+			log.Printf("tooling.presets.import_invalid toolId=%s name=%s errorCode=%s", preset.ToolID, preset.Name, schemaErr.Code)
+			skipped = append(skipped, preset.ToolID+"/"+preset.Name)
+			continue
+		}
+		accepted = append(accepted, preset)
+	}
+
+	imported, existing, err := s.presets.Import(accepted, overwrite)
+	skipped = append(skipped, existing...)
+	if err != nil {
+		log.Printf("tooling.presets.import_failed path=%s err=%v", path, err)
+		return models.PresetTransferResponseV1{
+			Success: false,
+			Message: "presets not imported",
+			Path:    path,
+			Skipped: skipped,
+			Error:   models.NewCanonicalJobError("PRESET_SAVE_ERROR", err.Error(), nil),
+		}
+	}
+
+	log.Printf("tooling.presets.imported path=%s count=%d skipped=%d", path, imported, len(skipped))
+	return models.PresetTransferResponseV1{Success: true, Message: "presets imported", Path: path, Count: imported, Skipped: skipped}
+}
+
+// validatePresetOptions checks the options a preset sets against the tool's
+// option schema. A preset holds only some options, so options it leaves out
+// are not required, and mode-specific options are checked whatever the mode.
+func validatePresetOptions(tool tools.Tool, options map[string]any) *models.JobErrorV1 {
+	schema := make([]models.OptionSchemaV1, 0, len(options))
+	for _, option := range tool.Manifest().Options {
+		if _, ok := options[option.Key]; ok {
+			option.Modes = nil
+			schema = append(schema, option)
+		}
+	}
+	if schemaErr := registry.ValidateOptions(schema, "", options); schemaErr != nil {
+		schemaErr.Details["toolId"] = tool.ID()
+		return schemaErr
+	}
+	return nil
+}
+
+func presetFailure(message string, jobErr *models.JobErrorV1) models.PresetResponseV1 {
+	return models.PresetResponseV1{Success: false, Message: message, Error: jobErr}
+}
+
+func presetsUnavailableError() *models.JobErrorV1 {
+	return models.NewCanonicalJobError("PRESETS_UNAVAILABLE", "presets are not loaded", nil)
+}
@@ -0,0 +1,56 @@
+package services
+
+import (
+	"log"
+	"sync"
+
+	"fileforge-desktop/internal/models"
+)
+
+const progressSubscriberBuffer = 64
+
+// progressHub fans job progress events out to subscribers other than the Wails
+// event bus, such as API server event streams. A subscriber that falls behind
+// loses events rather than stalling the jobs that publish them.
+type progressHub struct {
+	mu          sync.Mutex
+	nextID      int
+	subscribers map[int]chan models.JobProgressEventV1
+}
+
+func newProgressHub() *progressHub {
+	return &progressHub{subscribers: make(map[int]chan models.JobProgressEventV1)}
+}
+
+func (h *progressHub) subscribe() (<-chan models.JobProgressEventV1, func()) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.nextID++
+	id := h.nextID
+	ch := make(chan models.JobProgressEventV1, progressSubscriberBuffer)
+	h.subscribers[id] = ch
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			h.mu.Lock()
+			delete(h.subscribers, id)
+			h.mu.Unlock()
+			close(ch)
+		})
+	}
+}
+
+func (h *progressHub) publish(evt models.JobProgressEventV1) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for id, ch := range h.subscribers {
+		select {
+		case ch <- evt:
+		default:
+			log.Printf("tooling.progress.dropped subscriber=%d jobId=%s", id, evt.JobID)
+		}
+	}
+}
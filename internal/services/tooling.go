@@ -6,20 +6,32 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"sync"
 
 	"fileforge-desktop/internal/jobs"
 	"fileforge-desktop/internal/models"
 	"fileforge-desktop/internal/registry"
+	"fileforge-desktop/internal/utils/procwatch"
+	"fileforge-desktop/internal/watch"
 
 	"github.com/wailsapp/wails/v3/pkg/application"
 )
 
-const JobProgressEventNameV1 = "jobs/progress/v1"
+const (
+	JobProgressEventNameV1 = "jobs/progress/v1"
+	JobEventNameV1         = "jobs/events/v1"
+)
 
 type ToolingService struct {
 	ctx          context.Context
 	registry     *registry.Registry
 	orchestrator *jobs.Orchestrator
+	progress     *progressHub
+	presets      *jobs.PresetStore
+	watch        *watch.Manager
+
+	apiMu     sync.Mutex
+	apiServer *apiServer
 }
 
 func NewToolingService(reg *registry.Registry) *ToolingService {
@@ -27,21 +39,48 @@ func NewToolingService(reg *registry.Registry) *ToolingService {
 		reg = registry.GetGlobalRegistry()
 	}
 
+	progress := newProgressHub()
+	orchestrator := jobs.NewOrchestratorWithProgressEmitter(reg, 2, func(evt models.JobProgressEventV1) {
+		progress.publish(evt)
+		app := application.Get()
+		if app == nil {
+			return
+		}
+		app.Event.Emit(JobProgressEventNameV1, evt)
+	})
+	orchestrator.SetEventEmitter(func(evt models.JobEventV1) {
+		app := application.Get()
+		if app == nil {
+			return
+		}
+		app.Event.Emit(JobEventNameV1, evt)
+	})
+
 	return &ToolingService{
-		registry: reg,
-		orchestrator: jobs.NewOrchestratorWithProgressEmitter(reg, 2, func(evt models.JobProgressEventV1) {
-			app := application.Get()
-			if app == nil {
-				return
-			}
-			app.Event.Emit(JobProgressEventNameV1, evt)
-		}),
+		registry:     reg,
+		orchestrator: orchestrator,
+		progress:     progress,
 	}
 }
 
 func (s *ToolingService) SetContext(ctx context.Context) {
 	s.ctx = ctx
 
+	historyPath := defaultJobsHistoryPath()
+	if history, err := jobs.OpenHistoryStore(historyPath, jobs.DefaultHistoryRetention()); err != nil {
+		log.Printf("tooling.history.open_failed path=%s err=%v", historyPath, err)
+	} else {
+		s.orchestrator.SetHistoryStore(history)
+	}
+
+	presetsPath := defaultPresetsPath()
+	if presets, err := jobs.OpenPresetStore(presetsPath); err != nil {
+		log.Printf("tooling.presets.open_failed path=%s err=%v", presetsPath, err)
+	} else {
+		s.presets = presets
+		s.orchestrator.SetPresetStore(presets)
+	}
+
 	storePath := defaultJobsPersistencePath()
 	s.orchestrator.SetPersistencePath(storePath)
 	if err := s.orchestrator.RecoverInterruptedJobs(); err != nil {
@@ -49,15 +88,53 @@ func (s *ToolingService) SetContext(ctx context.Context) {
 	} else {
 		log.Printf("tooling.recovery.ok path=%s", storePath)
 	}
+
+	watchPath := defaultWatchFoldersPath()
+	if manager, err := watch.OpenManager(s.orchestrator, watchPath); err != nil {
+		log.Printf("tooling.watch.open_failed path=%s err=%v", watchPath, err)
+	} else {
+		s.watch = manager
+		s.watch.Start(watch.DefaultPollInterval)
+	}
+}
+
+// Shutdown stops the API server and watch folders and kills child processes still running on
+// behalf of jobs so that no ffmpeg or LibreOffice process outlives the
+// application.
+func (s *ToolingService) Shutdown(ctx context.Context) error {
+	s.stopAPIServer(ctx)
+	if s.watch != nil {
+		s.watch.Stop()
+	}
+	if killed := procwatch.Default().KillAll(); killed > 0 {
+		log.Printf("tooling.shutdown.killed_processes count=%d", killed)
+	}
+	return nil
 }
 
 func defaultJobsPersistencePath() string {
+	return defaultConfigFilePath("jobs_state_v1.json")
+}
+
+func defaultJobsHistoryPath() string {
+	return defaultConfigFilePath("jobs_history_v1.jsonl")
+}
+
+func defaultPresetsPath() string {
+	return defaultConfigFilePath("presets_v1.json")
+}
+
+func defaultWatchFoldersPath() string {
+	return defaultConfigFilePath("watch_folders_v1.json")
+}
+
+func defaultConfigFilePath(name string) string {
 	configDir, err := os.UserConfigDir()
 	if err != nil || configDir == "" {
-		return filepath.Join(".", ".fileforge", "jobs_state_v1.json")
+		return filepath.Join(".", ".fileforge", name)
 	}
 
-	return filepath.Join(configDir, "fileforge", "jobs_state_v1.json")
+	return filepath.Join(configDir, "fileforge", name)
 }
 
 func (s *ToolingService) contextOrBackground() context.Context {
@@ -90,6 +167,18 @@ func (s *ToolingService) ValidateJobV1(req models.JobRequestV1) models.ValidateJ
 	return res
 }
 
+func (s *ToolingService) PlanJobV1(req models.JobRequestV1) models.PlanJobResponseV1 {
+	res := s.orchestrator.Plan(s.contextOrBackground(), req)
+	if !res.Success {
+		code := ""
+		if res.Error != nil {
+			code = res.Error.Code
+		}
+		log.Printf("tooling.plan.failed toolId=%s mode=%s errorCode=%s", req.ToolID, req.Mode, code)
+	}
+	return res
+}
+
 func (s *ToolingService) RunJobV1(req models.JobRequestV1) models.RunJobResponseV1 {
 	res, err := s.orchestrator.Submit(s.contextOrBackground(), req)
 	if err != nil {
@@ -115,6 +204,56 @@ func (s *ToolingService) RunJobV1(req models.JobRequestV1) models.RunJobResponse
 	return res
 }
 
+func (s *ToolingService) ResumeJobV1(jobID string) models.RunJobResponseV1 {
+	res, err := s.orchestrator.Resume(s.contextOrBackground(), jobID)
+	if err != nil {
+		log.Printf("tooling.resume.submit_failed jobId=%s err=%v", jobID, err)
+		return models.RunJobResponseV1{
+			Success: false,
+			Message: "job resume failed",
+			Status:  jobs.StatusFailed,
+			Error:   models.NewCanonicalJobError("SUBMIT_ERROR", err.Error(), nil),
+		}
+	}
+
+	if !res.Success {
+		code := ""
+		if res.Error != nil {
+			code = res.Error.Code
+		}
+		log.Printf("tooling.resume.rejected jobId=%s errorCode=%s", jobID, code)
+	} else {
+		log.Printf("tooling.resume.submitted jobId=%s resumedJobId=%s", jobID, res.JobID)
+	}
+
+	return res
+}
+
+func (s *ToolingService) RunPipelineV1(req models.PipelineRequestV1) models.RunJobResponseV1 {
+	res, err := s.orchestrator.SubmitPipeline(s.contextOrBackground(), req)
+	if err != nil {
+		log.Printf("tooling.pipeline.submit_failed steps=%d err=%v", len(req.Steps), err)
+		return models.RunJobResponseV1{
+			Success: false,
+			Message: "pipeline submission failed",
+			Status:  jobs.StatusFailed,
+			Error:   models.NewCanonicalJobError("SUBMIT_ERROR", err.Error(), nil),
+		}
+	}
+
+	if !res.Success {
+		code := ""
+		if res.Error != nil {
+			code = res.Error.Code
+		}
+		log.Printf("tooling.pipeline.rejected steps=%d errorCode=%s", len(req.Steps), code)
+	} else {
+		log.Printf("tooling.pipeline.submitted steps=%d jobId=%s", len(req.Steps), res.JobID)
+	}
+
+	return res
+}
+
 func (s *ToolingService) CancelJobV1(jobID string) models.CancelJobResponseV1 {
 	if err := s.orchestrator.Cancel(jobID); err != nil {
 		log.Printf("tooling.cancel.failed jobId=%s err=%v", jobID, err)
@@ -134,6 +273,97 @@ func (s *ToolingService) CancelJobV1(jobID string) models.CancelJobResponseV1 {
 	}
 }
 
+func (s *ToolingService) CancelJobItemV1(jobID, inputPath string) models.CancelJobItemResponseV1 {
+	itemID, err := s.orchestrator.CancelItem(jobID, inputPath)
+	if err != nil {
+		log.Printf("tooling.cancel_item.failed jobId=%s inputPath=%s err=%v", jobID, inputPath, err)
+		return models.CancelJobItemResponseV1{
+			Success:   false,
+			Message:   "item cancel failed",
+			JobID:     jobID,
+			InputPath: inputPath,
+			Error:     models.NewCanonicalJobError("JOB_ITEM_NOT_FOUND", err.Error(), map[string]any{"jobId": jobID, "inputPath": inputPath}),
+		}
+	}
+
+	log.Printf("tooling.cancel_item.requested jobId=%s itemId=%s", jobID, itemID)
+	return models.CancelJobItemResponseV1{
+		Success:   true,
+		Message:   "item cancel requested",
+		JobID:     jobID,
+		InputPath: inputPath,
+		ItemID:    itemID,
+	}
+}
+
+func (s *ToolingService) ReorderQueuedJobV1(jobID string, position int) models.QueueJobResponseV1 {
+	final, err := s.orchestrator.MoveQueued(jobID, position)
+	if err != nil {
+		log.Printf("tooling.queue.reorder_failed jobId=%s position=%d err=%v", jobID, position, err)
+		return queueJobFailure(jobID, "reorder failed", err)
+	}
+
+	log.Printf("tooling.queue.reordered jobId=%s position=%d", jobID, final)
+	status := jobs.StatusQueued
+	if final == 0 {
+		status = jobs.StatusRunning
+	}
+	return models.QueueJobResponseV1{
+		Success:       true,
+		Message:       "job reordered",
+		JobID:         jobID,
+		Status:        status,
+		QueuePosition: final,
+	}
+}
+
+func (s *ToolingService) PauseQueuedJobV1(jobID string) models.QueueJobResponseV1 {
+	position, err := s.orchestrator.PauseQueued(jobID)
+	if err != nil {
+		log.Printf("tooling.queue.pause_failed jobId=%s err=%v", jobID, err)
+		return queueJobFailure(jobID, "pause failed", err)
+	}
+
+	log.Printf("tooling.queue.paused jobId=%s position=%d", jobID, position)
+	return models.QueueJobResponseV1{
+		Success:       true,
+		Message:       "job paused",
+		JobID:         jobID,
+		Status:        jobs.StatusPaused,
+		QueuePosition: position,
+	}
+}
+
+func (s *ToolingService) ResumeQueuedJobV1(jobID string) models.QueueJobResponseV1 {
+	position, err := s.orchestrator.ResumeQueued(jobID)
+	if err != nil {
+		log.Printf("tooling.queue.resume_failed jobId=%s err=%v", jobID, err)
+		return queueJobFailure(jobID, "resume failed", err)
+	}
+
+	log.Printf("tooling.queue.resumed jobId=%s position=%d", jobID, position)
+	status := jobs.StatusQueued
+	if position == 0 {
+		status = jobs.StatusRunning
+	}
+	return models.QueueJobResponseV1{
+		Success:       true,
+		Message:       "job resumed",
+		JobID:         jobID,
+		Status:        status,
+		QueuePosition: position,
+	}
+}
+
+func queueJobFailure(jobID, message string, err error) models.QueueJobResponseV1 {
+	return models.QueueJobResponseV1{
+		Success: false,
+		Message: message,
+		JobID:   jobID,
+		Error:   models.NewCanonicalJobError("JOB_NOT_QUEUED", err.Error(), map[string]any{"jobId": jobID}),
+	}
+}
+
 func (s *ToolingService) GetJobStatusV1(jobID string) models.JobStatusResponseV1 {
 	job, found := s.orchestrator.GetJob(jobID)
 	if !found {
@@ -146,10 +376,135 @@ func (s *ToolingService) GetJobStatusV1(jobID string) models.JobStatusResponseV1
 		}
 	}
 
-	return models.JobStatusResponseV1{
+	res := models.JobStatusResponseV1{
 		Success: true,
 		Message: "job status retrieved",
 		Found:   true,
 		Result:  &job,
 	}
+	if graph, ok := s.orchestrator.DependencyGraph(jobID); ok {
+		res.Graph = &graph
+	}
+	return res
+}
+
+func (s *ToolingService) ListJobsV1(query models.JobHistoryQueryV1) models.ListJobsResponseV1 {
+	if query.From > 0 && query.To > 0 && query.From > query.To {
+		return models.ListJobsResponseV1{
+			Success: false,
+			Message: "invalid job history query",
+			Records: []models.JobHistoryRecordV1{},
+			Error:   models.NewCanonicalJobError("JOB_HISTORY_INVALID_RANGE", "from must not be after to", map[string]any{"from": query.From, "to": query.To}),
+		}
+	}
+
+	records, total := s.orchestrator.ListJobHistory(query)
+	return models.ListJobsResponseV1{
+		Success: true,
+		Message: "job history retrieved",
+		Total:   total,
+		Records: records,
+	}
+}
+
+func (s *ToolingService) GetJobHistoryV1(jobID string) models.JobHistoryResponseV1 {
+	record, found := s.orchestrator.GetJobHistory(jobID)
+	if !found {
+		log.Printf("tooling.history.not_found jobId=%s", jobID)
+		return models.JobHistoryResponseV1{
+			Success: false,
+			Message: "job not found in history",
+			Found:   false,
+			Error:   models.NewCanonicalJobError("NOT_FOUND", fmt.Sprintf("job '%s' not found in history", jobID), nil),
+		}
+	}
+
+	return models.JobHistoryResponseV1{
+		Success: true,
+		Message: "job history retrieved",
+		Found:   true,
+		Record:  &record,
+	}
+}
+
+// GetJobEventsV1 replays lifecycle events published after query.AfterSequence
+// so a subscriber can fill gaps in the jobs/events/v1 stream.
+func (s *ToolingService) GetJobEventsV1(query models.JobEventsQueryV1) models.JobEventsResponseV1 {
+	events, latest, complete := s.orchestrator.JobEvents(query)
+	if !complete {
+		log.Printf("tooling.events.gap afterSequence=%d latestSequence=%d", query.AfterSequence, latest)
+	}
+
+	return models.JobEventsResponseV1{
+		Success:        true,
+		Message:        "job events retrieved",
+		Events:         events,
+		LatestSequence: latest,
+		Complete:       complete,
+	}
+}
+
+func (s *ToolingService) ListWatchFoldersV1() models.ListWatchFoldersResponseV1 {
+	if s.watch == nil {
+		return models.ListWatchFoldersResponseV1{
+			Success: false,
+			Message: "watch folders unavailable",
+			Folders: []models.WatchFolderStatusV1{},
+			Error:   watchUnavailableError(),
+		}
+	}
+
+	return models.ListWatchFoldersResponseV1{
+		Success: true,
+		Message: "watch folders listed",
+		Folders: s.watch.List(),
+	}
+}
+
+func (s *ToolingService) SaveWatchFolderV1(folder models.WatchFolderV1) models.WatchFolderResponseV1 {
+	if s.watch == nil {
+		return models.WatchFolderResponseV1{Success: false, Message: "watch folders unavailable", Error: watchUnavailableError()}
+	}
+
+	if _, err := s.registry.GetToolV2(folder.ToolID); err != nil {
+		return models.WatchFolderResponseV1{
+			Success: false,
+			Message: "watch folder not saved",
+			Error:   models.NewCanonicalJobError("TOOL_NOT_FOUND", err.Error(), map[string]any{"toolId": folder.ToolID}),
+		}
+	}
+
+	saved, jobErr := s.watch.Save(folder)
+	if jobErr != nil {
+		log.Printf("tooling.watch.save_rejected id=%s dir=%s errorCode=%s", folder.ID, folder.Dir, jobErr.Code)
+		return models.WatchFolderResponseV1{Success: false, Message: "watch folder not saved", Error: jobErr}
+	}
+
+	log.Printf("tooling.watch.saved id=%s dir=%s toolId=%s enabled=%t", saved.ID, saved.Dir, saved.ToolID, saved.Enabled)
+	return models.WatchFolderResponseV1{Success: true, Message: "watch folder saved", Folder: &saved}
+}
+
+func (s *ToolingService) RemoveWatchFolderV1(id string) models.WatchFolderResponseV1 {
+	if s.watch == nil {
+		return models.WatchFolderResponseV1{Success: false, Message: "watch folders unavailable", Error: watchUnavailableError()}
+	}
+
+	removed, err := s.watch.Remove(id)
+	if !removed {
+		return models.WatchFolderResponseV1{
+			Success: false,
+			Message: "watch folder not found",
+			Error:   models.NewCanonicalJobError("NOT_FOUND", fmt.Sprintf("watch folder '%s' not found", id), nil),
+		}
+	}
+	if err != nil {
+		log.Printf("tooling.watch.save_failed id=%s err=%v", id, err)
+	}
+
+	log.Printf("tooling.watch.removed id=%s", id)
+	return models.WatchFolderResponseV1{Success: true, Message: "watch folder removed"}
+}
+
+func watchUnavailableError() *models.JobErrorV1 {
+	return models.NewCanonicalJobError("WATCH_UNAVAILABLE", "watch folders are not loaded", nil)
 }
@@ -0,0 +1,72 @@
+package tools
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"fileforge-desktop/internal/models"
+)
+
+// OutputTarget is where a tool writes one output under the request's conflict
+// policy.
+type OutputTarget struct {
+	Path          string // final output path
+	WritePath     string // temporary file next to Path that the tool writes to
+	Skip          bool   // Path already exists and the policy is skip
+	Action        string // models.PlanAction*, reported by dry runs
+	RequestedPath string // path before a rename
+}
+
+// ConflictPolicy returns the normalised conflict policy of req, rename when
+// unset.
+func ConflictPolicy(req models.JobRequestV1) string {
+	policy := strings.ToLower(strings.TrimSpace(req.ConflictPolicy))
+	if policy == "" {
+		return models.ConflictPolicyRename
+	}
+	return policy
+}
+
+// ValidateConflictPolicy rejects unknown conflict policies.
+func ValidateConflictPolicy(req models.JobRequestV1) *models.JobErrorV1 {
+	switch ConflictPolicy(req) {
+	case models.ConflictPolicyError, models.ConflictPolicyOverwrite, models.ConflictPolicyRename, models.ConflictPolicySkip:
+		return nil
+	}
+	return models.NewCanonicalJobError("JOB_CONFLICT_POLICY_INVALID", fmt.Sprintf("conflictPolicy must be one of error, overwrite, rename, skip, got '%s'", req.ConflictPolicy), map[string]any{"conflictPolicy": req.ConflictPolicy})
+}
+
+// ResolveOutputTarget applies policy to outputPath. Outputs that collide with
+// an earlier output of the same job, recorded in used, are renamed under every
+// policy except error, so one job never overwrites its own results. The chosen
+// path is added to used; used may be nil.
+func ResolveOutputTarget(policy, outputPath string, used map[string]struct{}) (OutputTarget, *models.JobErrorV1) {
+	_, planned := used[OutputPathKey(outputPath)]
+	_, statErr := os.Stat(outputPath)
+	exists := statErr == nil
+
+	switch {
+	case policy == models.ConflictPolicyError && (planned || exists):
+		return OutputTarget{}, models.NewJobError(models.ErrorCodeValidationInvalidInput, "OUTPUT_EXISTS", fmt.Sprintf("output already exists: %s", outputPath), map[string]any{"outputPath": outputPath})
+	case planned || policy == models.ConflictPolicyRename || policy == "":
+		path := NextAvailableOutputPath(outputPath, used)
+		target := OutputTarget{Path: path, WritePath: tempOutputPath(path), Action: models.PlanActionCreate}
+		if path != outputPath {
+			target.Action = models.PlanActionRename
+			target.RequestedPath = outputPath
+		}
+		return target, nil
+	}
+
+	if used != nil {
+		used[OutputPathKey(outputPath)] = struct{}{}
+	}
+	switch {
+	case exists && policy == models.ConflictPolicySkip:
+		return OutputTarget{Path: outputPath, WritePath: outputPath, Skip: true, Action: models.PlanActionSkip}, nil
+	case exists:
+		return OutputTarget{Path: outputPath, WritePath: tempOutputPath(outputPath), Action: models.PlanActionOverwrite}, nil
+	}
+	return OutputTarget{Path: outputPath, WritePath: tempOutputPath(outputPath), Action: models.PlanActionCreate}, nil
+}
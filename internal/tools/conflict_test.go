@@ -0,0 +1,107 @@
+package tools
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"fileforge-desktop/internal/models"
+)
+
+func TestResolveOutputTargetExistingOutput(t *testing.T) {
+	dir := t.TempDir()
+	existing := filepath.Join(dir, "out.png")
+	if err := os.WriteFile(existing, []byte("x"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		policy     string
+		wantPath   string
+		wantAction string
+		wantSkip   bool
+		wantErr    string
+	}{
+		{policy: models.ConflictPolicyError, wantErr: "OUTPUT_EXISTS"},
+		{policy: models.ConflictPolicyOverwrite, wantPath: existing, wantAction: models.PlanActionOverwrite},
+		{policy: models.ConflictPolicyRename, wantPath: filepath.Join(dir, "out-2.png"), wantAction: models.PlanActionRename},
+		{policy: "", wantPath: filepath.Join(dir, "out-2.png"), wantAction: models.PlanActionRename},
+		{policy: models.ConflictPolicySkip, wantPath: existing, wantAction: models.PlanActionSkip, wantSkip: true},
+	}
+
+	for _, tt := range tests {
+		t.Run("policy="+tt.policy, func(t *testing.T) {
+			target, err := ResolveOutputTarget(tt.policy, existing, map[string]struct{}{})
+			if tt.wantErr != "" {
+				if err == nil || err.DetailCode != tt.wantErr {
+					t.Fatalf("error = %v, want %s", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if target.Path != tt.wantPath || target.Action != tt.wantAction || target.Skip != tt.wantSkip {
+				t.Fatalf("target = %+v, want path %s action %s skip %v", target, tt.wantPath, tt.wantAction, tt.wantSkip)
+			}
+			if tt.wantAction == models.PlanActionRename && target.RequestedPath != existing {
+				t.Fatalf("requested path = %s, want %s", target.RequestedPath, existing)
+			}
+		})
+	}
+}
+
+func TestResolveOutputTargetWritesThroughTempFile(t *testing.T) {
+	outputPath := filepath.Join(t.TempDir(), "new.png")
+
+	target, err := ResolveOutputTarget(models.ConflictPolicyOverwrite, outputPath, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if target.Path != outputPath || target.Action != models.PlanActionCreate {
+		t.Fatalf("target = %+v, want create of %s", target, outputPath)
+	}
+	if filepath.Dir(target.WritePath) != filepath.Dir(outputPath) || !strings.HasPrefix(filepath.Base(target.WritePath), TempOutputPrefix) {
+		t.Fatalf("write path %s is not a temp file next to %s", target.WritePath, outputPath)
+	}
+}
+
+// Outputs of the same job are never overwritten, whatever the policy.
+func TestResolveOutputTargetRenamesOutputsOfTheSameJob(t *testing.T) {
+	outputPath := filepath.Join(t.TempDir(), "page.png")
+
+	for _, policy := range []string{models.ConflictPolicyOverwrite, models.ConflictPolicySkip, models.ConflictPolicyRename} {
+		used := map[string]struct{}{}
+		first, err := ResolveOutputTarget(policy, outputPath, used)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", policy, err)
+		}
+		second, err := ResolveOutputTarget(policy, outputPath, used)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", policy, err)
+		}
+		if first.Path != outputPath || second.Path == first.Path || second.Action != models.PlanActionRename {
+			t.Fatalf("%s: targets %+v and %+v, want the second renamed", policy, first, second)
+		}
+	}
+
+	used := map[string]struct{}{}
+	if _, err := ResolveOutputTarget(models.ConflictPolicyError, outputPath, used); err != nil {
+		t.Fatalf("error policy: unexpected error on first output: %v", err)
+	}
+	if _, err := ResolveOutputTarget(models.ConflictPolicyError, outputPath, used); err == nil {
+		t.Fatal("error policy: want OUTPUT_EXISTS for the second output")
+	}
+}
+
+func TestValidateConflictPolicy(t *testing.T) {
+	for _, policy := range []string{"", "error", "Overwrite", " rename ", "skip"} {
+		if err := ValidateConflictPolicy(models.JobRequestV1{ConflictPolicy: policy}); err != nil {
+			t.Errorf("ValidateConflictPolicy(%q) = %v, want nil", policy, err)
+		}
+	}
+	if err := ValidateConflictPolicy(models.JobRequestV1{ConflictPolicy: "replace"}); err == nil || err.DetailCode != "JOB_CONFLICT_POLICY_INVALID" {
+		t.Errorf("ValidateConflictPolicy(replace) = %v, want JOB_CONFLICT_POLICY_INVALID", err)
+	}
+}
@@ -25,3 +25,15 @@ type SingleExecutorWithProgress interface {
 type BatchExecutor interface {
 	ExecuteBatch(ctx context.Context, req models.JobRequestV1, onProgress func(models.JobProgressV1)) ([]models.JobResultItemV1, *models.JobErrorV1)
 }
+
+// Planner is implemented by tools that can describe the outputs of a request
+// without writing anything; see NewJobPlan.
+type Planner interface {
+	Plan(ctx context.Context, req models.JobRequestV1) (models.JobPlanV1, *models.JobErrorV1)
+}
+
+// RetryPolicyProvider is implemented by tools whose failures can be transient.
+// Tools without it are executed once.
+type RetryPolicyProvider interface {
+	RetryPolicy() models.RetryPolicyV1
+}
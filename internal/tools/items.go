@@ -0,0 +1,129 @@
+package tools
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"fileforge-desktop/internal/models"
+)
+
+type itemCancellationKey struct{}
+
+type itemTimeoutKey struct{}
+
+// ItemCancellation records per-item cancel requests for one batch job. Batch
+// executors consult it through ItemCancelled and ItemContext so a single input
+// can be dropped while the rest of the batch keeps running.
+type ItemCancellation struct {
+	mu        sync.Mutex
+	cancelled map[string]struct{}
+	timedOut  map[string]struct{}
+	running   map[string]context.CancelFunc
+}
+
+func NewItemCancellation() *ItemCancellation {
+	return &ItemCancellation{
+		cancelled: make(map[string]struct{}),
+		timedOut:  make(map[string]struct{}),
+		running:   make(map[string]context.CancelFunc),
+	}
+}
+
+// Cancel marks the item as cancelled and interrupts it if it is running.
+func (c *ItemCancellation) Cancel(inputPath string) string {
+	itemID := models.ItemIDV1(inputPath)
+
+	c.mu.Lock()
+	c.cancelled[itemID] = struct{}{}
+	cancel := c.running[itemID]
+	c.mu.Unlock()
+
+	if cancel != nil {
+		cancel()
+	}
+	return itemID
+}
+
+func (c *ItemCancellation) IsCancelled(inputPath string) bool {
+	if c == nil {
+		return false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, ok := c.cancelled[models.ItemIDV1(inputPath)]
+	return ok
+}
+
+// TimedOut reports whether the last run of the item exceeded its item timeout.
+func (c *ItemCancellation) TimedOut(inputPath string) bool {
+	if c == nil {
+		return false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, ok := c.timedOut[models.ItemIDV1(inputPath)]
+	return ok
+}
+
+func WithItemCancellation(ctx context.Context, c *ItemCancellation) context.Context {
+	return context.WithValue(ctx, itemCancellationKey{}, c)
+}
+
+func itemCancellationFrom(ctx context.Context) *ItemCancellation {
+	c, _ := ctx.Value(itemCancellationKey{}).(*ItemCancellation)
+	return c
+}
+
+// WithItemTimeout sets the limit ItemContext applies to each batch item.
+func WithItemTimeout(ctx context.Context, timeout time.Duration) context.Context {
+	if timeout <= 0 {
+		return ctx
+	}
+	return context.WithValue(ctx, itemTimeoutKey{}, timeout)
+}
+
+// ItemCancelled reports whether a per-item cancel was requested for inputPath.
+func ItemCancelled(ctx context.Context, inputPath string) bool {
+	return itemCancellationFrom(ctx).IsCancelled(inputPath)
+}
+
+// ItemContext derives the context used while processing one batch item. It is
+// cancelled with the job, by a per-item cancel or when the item timeout
+// expires; stop must be called once the item is finished.
+func ItemContext(ctx context.Context, inputPath string) (context.Context, context.CancelFunc) {
+	var itemCtx context.Context
+	var cancel context.CancelFunc
+	if timeout, ok := ctx.Value(itemTimeoutKey{}).(time.Duration); ok {
+		itemCtx, cancel = context.WithTimeout(ctx, timeout)
+	} else {
+		itemCtx, cancel = context.WithCancel(ctx)
+	}
+
+	c := itemCancellationFrom(ctx)
+	if c == nil {
+		return itemCtx, cancel
+	}
+
+	itemID := models.ItemIDV1(inputPath)
+	c.mu.Lock()
+	delete(c.timedOut, itemID)
+	if _, cancelled := c.cancelled[itemID]; cancelled {
+		cancel()
+	} else {
+		c.running[itemID] = cancel
+	}
+	c.mu.Unlock()
+
+	return itemCtx, func() {
+		c.mu.Lock()
+		delete(c.running, itemID)
+		if ctx.Err() == nil && itemCtx.Err() == context.DeadlineExceeded {
+			c.timedOut[itemID] = struct{}{}
+		}
+		c.mu.Unlock()
+		cancel()
+	}
+}
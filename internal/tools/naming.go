@@ -0,0 +1,222 @@
+package tools
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"fileforge-desktop/internal/models"
+)
+
+// OutputNameTemplateOption is the request option that replaces a tool's
+// default output file name, e.g. "{name}_{width}x{height}.{ext}".
+const OutputNameTemplateOption = "outputNameTemplate"
+
+// OutputNameTemplateSchema declares the outputNameTemplate option for a tool
+// manifest; defaultTemplate is the name the tool uses without it.
+func OutputNameTemplateSchema(defaultTemplate string) models.OptionSchemaV1 {
+	return models.OptionSchemaV1{
+		Key:         OutputNameTemplateOption,
+		Type:        models.OptionTypeString,
+		Label:       "Output name",
+		Description: "Placeholders: {name} {ext} {index} {date} {time} {tool} {page} {width} {height}",
+		Default:     defaultTemplate,
+	}
+}
+
+// OutputNameVars are the values substituted into an output naming template.
+type OutputNameVars struct {
+	InputPath string
+	Ext       string // output extension without the dot
+	Tool      string // tool ID; {tool} renders its last segment
+	Index     int    // 1-based position of the input in the batch
+	Page      string // page number or range for tools writing one file per page
+	Width     int
+	Height    int
+	Now       time.Time
+	Subdir    string // directory under outputDir mirroring where input expansion found the input
+}
+
+var outputNamePlaceholderPattern = regexp.MustCompile(`\{([^{}]*)\}`)
+
+var outputNamePlaceholders = map[string]struct{}{
+	"name":   {},
+	"ext":    {},
+	"index":  {},
+	"date":   {},
+	"time":   {},
+	"tool":   {},
+	"page":   {},
+	"width":  {},
+	"height": {},
+}
+
+// OutputNameTemplate returns the outputNameTemplate option, or fallback when
+// the request does not set one.
+func OutputNameTemplate(options map[string]any, fallback string) string {
+	if options != nil {
+		if template, ok := options[OutputNameTemplateOption].(string); ok && strings.TrimSpace(template) != "" {
+			return strings.TrimSpace(template)
+		}
+	}
+	return fallback
+}
+
+// ValidateOutputNameTemplate checks the outputNameTemplate option of a request.
+// Placeholders are {name}, {ext}, {index}, {date}, {time}, {tool}, {page},
+// {width} and {height}; numeric ones accept a zero-padding width such as
+// {index:03}.
+func ValidateOutputNameTemplate(options map[string]any) *models.JobErrorV1 {
+	template := OutputNameTemplate(options, "")
+	if template == "" {
+		return nil
+	}
+
+	if strings.ContainsAny(template, `/\`) || template == "." || template == ".." {
+		return outputNameTemplateError("outputNameTemplate must be a file name, not a path", template)
+	}
+	for _, match := range outputNamePlaceholderPattern.FindAllStringSubmatch(template, -1) {
+		key, pad, _ := strings.Cut(match[1], ":")
+		if _, ok := outputNamePlaceholders[key]; !ok {
+			return outputNameTemplateError(fmt.Sprintf("outputNameTemplate contains unsupported placeholder {%s}", match[1]), template)
+		}
+		if pad != "" {
+			if width, err := strconv.Atoi(pad); err != nil || width < 1 || width > 10 {
+				return outputNameTemplateError(fmt.Sprintf("outputNameTemplate has an invalid padding in {%s}", match[1]), template)
+			}
+		}
+	}
+	return nil
+}
+
+func outputNameTemplateError(message, template string) *models.JobErrorV1 {
+	return models.NewCanonicalJobError("OUTPUT_NAME_TEMPLATE_INVALID", message, map[string]any{"option": "options." + OutputNameTemplateOption, "value": template})
+}
+
+// OutputNameUses reports whether template references the placeholder key, so
+// tools can skip work such as reading image dimensions when it is not needed.
+func OutputNameUses(template, key string) bool {
+	for _, match := range outputNamePlaceholderPattern.FindAllStringSubmatch(template, -1) {
+		if name, _, _ := strings.Cut(match[1], ":"); name == key {
+			return true
+		}
+	}
+	return false
+}
+
+// RenderOutputName expands template into a file name. The extension is
+// appended when the template does not place {ext} itself, and characters that
+// are not allowed in file names are replaced.
+func RenderOutputName(template string, vars OutputNameVars) string {
+	now := vars.Now
+	if now.IsZero() {
+		now = time.Now()
+	}
+	index := vars.Index
+	if index < 1 {
+		index = 1
+	}
+
+	name := outputNamePlaceholderPattern.ReplaceAllStringFunc(template, func(placeholder string) string {
+		key, pad, _ := strings.Cut(placeholder[1:len(placeholder)-1], ":")
+		switch key {
+		case "name":
+			return outputBaseName(vars.InputPath)
+		case "ext":
+			return vars.Ext
+		case "index":
+			return padOutputNumber(strconv.Itoa(index), pad)
+		case "date":
+			return now.Format("2006-01-02")
+		case "time":
+			return now.Format("150405")
+		case "tool":
+			return vars.Tool[strings.LastIndex(vars.Tool, ".")+1:]
+		case "page":
+			return padOutputNumber(vars.Page, pad)
+		case "width":
+			return padOutputNumber(strconv.Itoa(vars.Width), pad)
+		case "height":
+			return padOutputNumber(strconv.Itoa(vars.Height), pad)
+		}
+		return placeholder
+	})
+
+	if vars.Ext != "" && !OutputNameUses(template, "ext") {
+		name += "." + vars.Ext
+	}
+	name = strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`/\:*?"<>|`, r) || r < 0x20 {
+			return '_'
+		}
+		return r
+	}, strings.TrimSpace(name))
+	// A name that is only an extension, such as an empty {page}, would be a
+	// hidden file.
+	if strings.Trim(strings.TrimSuffix(name, filepath.Ext(name)), ".") == "" {
+		name = "output" + name
+	}
+	return name
+}
+
+func outputBaseName(inputPath string) string {
+	base := strings.TrimSpace(strings.TrimSuffix(filepath.Base(inputPath), filepath.Ext(inputPath)))
+	if base == "" || base == "." {
+		return "output"
+	}
+	return base
+}
+
+// padOutputNumber zero-pads value to the width given in the placeholder, for
+// plain numbers only; ranges such as "3-5" are left as they are.
+func padOutputNumber(value, pad string) string {
+	width, err := strconv.Atoi(pad)
+	if err != nil || value == "" || strings.Trim(value, "0123456789") != "" {
+		return value
+	}
+	if len(value) >= width {
+		return value
+	}
+	return strings.Repeat("0", width-len(value)) + value
+}
+
+// RenderOutputPath renders template for vars into outputDir, or its
+// vars.Subdir sub-directory. Collisions with existing files are left to
+// ResolveOutputTarget.
+func RenderOutputPath(outputDir, template string, vars OutputNameVars) string {
+	return filepath.Join(outputDir, vars.Subdir, RenderOutputName(template, vars))
+}
+
+// NextAvailableOutputPath returns outputPath, or the first of "<base>-2.ext",
+// "<base>-3.ext", ... that neither exists on disk nor is already in used. The
+// returned path is added to used; used may be nil.
+func NextAvailableOutputPath(outputPath string, used map[string]struct{}) string {
+	ext := filepath.Ext(outputPath)
+	base := strings.TrimSuffix(outputPath, ext)
+	candidate := outputPath
+	for suffix := 2; !outputPathAvailable(candidate, used); suffix++ {
+		candidate = fmt.Sprintf("%s-%d%s", base, suffix, ext)
+	}
+	if used != nil {
+		used[OutputPathKey(candidate)] = struct{}{}
+	}
+	return candidate
+}
+
+// OutputPathKey normalises a path for collision checks; file systems on the
+// desktop platforms are usually case-insensitive.
+func OutputPathKey(outputPath string) string {
+	return strings.ToLower(filepath.Clean(outputPath))
+}
+
+func outputPathAvailable(outputPath string, used map[string]struct{}) bool {
+	if _, exists := used[OutputPathKey(outputPath)]; exists {
+		return false
+	}
+	_, err := os.Stat(outputPath)
+	return err != nil
+}
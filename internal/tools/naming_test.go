@@ -0,0 +1,91 @@
+package tools
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRenderOutputName(t *testing.T) {
+	now := time.Date(2026, 3, 4, 5, 6, 7, 0, time.UTC)
+	tests := []struct {
+		name     string
+		template string
+		vars     OutputNameVars
+		want     string
+	}{
+		{"appends ext", "{name}_small", OutputNameVars{InputPath: "/in/photo.jpg", Ext: "png"}, "photo_small.png"},
+		{"places ext", "{name}.{ext}", OutputNameVars{InputPath: "/in/photo.jpg", Ext: "png"}, "photo.png"},
+		{"pads index", "{name}_{index:03}", OutputNameVars{InputPath: "a.jpg", Ext: "jpg", Index: 7}, "a_007.jpg"},
+		{"index defaults to 1", "{index}", OutputNameVars{Ext: "jpg"}, "1.jpg"},
+		{"date and time", "{date}_{time}", OutputNameVars{Ext: "pdf", Now: now}, "2026-03-04_050607.pdf"},
+		{"tool short name", "{name}_{tool}", OutputNameVars{InputPath: "a.pdf", Ext: "pdf", Tool: "tool.pdf.merge"}, "a_merge.pdf"},
+		{"dimensions", "{width}x{height}", OutputNameVars{Ext: "png", Width: 640, Height: 480}, "640x480.png"},
+		{"page range is not padded", "{name}_p{page:03}", OutputNameVars{InputPath: "doc.pdf", Ext: "pdf", Page: "3-5"}, "doc_p3-5.pdf"},
+		{"page number is padded", "{name}_p{page:03}", OutputNameVars{InputPath: "doc.pdf", Ext: "pdf", Page: "4"}, "doc_p004.pdf"},
+		{"replaces invalid characters", "{name}:{ext}?", OutputNameVars{InputPath: "a.jpg", Ext: "jpg"}, "a_jpg_"},
+		{"empty name", "{page}", OutputNameVars{Ext: "png"}, "output.png"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := RenderOutputName(tt.template, tt.vars); got != tt.want {
+				t.Fatalf("RenderOutputName(%q) = %q, want %q", tt.template, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateOutputNameTemplate(t *testing.T) {
+	tests := []struct {
+		template string
+		valid    bool
+	}{
+		{"", true},
+		{"{name}_{index:04}.{ext}", true},
+		{"{name}_{width}x{height}", true},
+		{"{unknown}", false},
+		{"{index:0}", false},
+		{"{index:x}", false},
+		{"sub/{name}", false},
+		{`sub\{name}`, false},
+		{"..", false},
+	}
+
+	for _, tt := range tests {
+		err := ValidateOutputNameTemplate(map[string]any{OutputNameTemplateOption: tt.template})
+		if (err == nil) != tt.valid {
+			t.Errorf("ValidateOutputNameTemplate(%q) = %v, want valid=%v", tt.template, err, tt.valid)
+		}
+		if err != nil && err.DetailCode != "OUTPUT_NAME_TEMPLATE_INVALID" {
+			t.Errorf("ValidateOutputNameTemplate(%q) detail code = %s", tt.template, err.DetailCode)
+		}
+	}
+}
+
+func TestNextAvailableOutputPath(t *testing.T) {
+	dir := t.TempDir()
+	existing := filepath.Join(dir, "out.png")
+	if err := os.WriteFile(existing, []byte("x"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	used := map[string]struct{}{}
+	if got, want := NextAvailableOutputPath(existing, used), filepath.Join(dir, "out-2.png"); got != want {
+		t.Fatalf("first collision = %s, want %s", got, want)
+	}
+	if got, want := NextAvailableOutputPath(existing, used), filepath.Join(dir, "out-3.png"); got != want {
+		t.Fatalf("second collision = %s, want %s", got, want)
+	}
+
+	// Collisions are case-insensitive, as on the desktop file systems.
+	if got, want := NextAvailableOutputPath(filepath.Join(dir, "OUT-2.png"), used), filepath.Join(dir, "OUT-2-2.png"); got != want {
+		t.Fatalf("case-insensitive collision = %s, want %s", got, want)
+	}
+
+	fresh := filepath.Join(dir, "fresh.png")
+	if got := NextAvailableOutputPath(fresh, nil); got != fresh {
+		t.Fatalf("free path = %s, want %s", got, fresh)
+	}
+}
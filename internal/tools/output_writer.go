@@ -0,0 +1,215 @@
+package tools
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TempOutputPrefix starts the name of the temporary file an output is written
+// to before it is renamed into place.
+const TempOutputPrefix = ".fileforge-tmp-"
+
+// tempOutputSweepAge keeps the startup sweep away from temporary files that
+// another running instance may still be writing.
+const tempOutputSweepAge = time.Minute
+
+type tempOutputsKey struct{}
+
+// TempOutputs records the temporary files of the writes in progress for one
+// job, so the orchestrator can remove those a failed or cancelled item left
+// behind.
+type TempOutputs struct {
+	mu    sync.Mutex
+	paths map[string]struct{}
+}
+
+func NewTempOutputs() *TempOutputs {
+	return &TempOutputs{paths: make(map[string]struct{})}
+}
+
+func WithTempOutputs(ctx context.Context, t *TempOutputs) context.Context {
+	return context.WithValue(ctx, tempOutputsKey{}, t)
+}
+
+func tempOutputsFrom(ctx context.Context) *TempOutputs {
+	t, _ := ctx.Value(tempOutputsKey{}).(*TempOutputs)
+	return t
+}
+
+func (t *TempOutputs) add(path string) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	t.paths[path] = struct{}{}
+	t.mu.Unlock()
+}
+
+func (t *TempOutputs) remove(path string) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	delete(t.paths, path)
+	t.mu.Unlock()
+}
+
+// Cleanup removes the temporary files of writes that never finished and
+// returns how many were removed.
+func (t *TempOutputs) Cleanup() int {
+	if t == nil {
+		return 0
+	}
+	t.mu.Lock()
+	paths := t.paths
+	t.paths = make(map[string]struct{})
+	t.mu.Unlock()
+
+	removed := 0
+	for path := range paths {
+		if err := os.Remove(path); err == nil {
+			removed++
+		}
+	}
+	return removed
+}
+
+// WriteOutput runs write against target.WritePath and renames the result into
+// place. The temporary file is removed when write fails or ctx is cancelled
+// before the rename, so an interrupted write never leaves a partial output.
+func WriteOutput(ctx context.Context, target OutputTarget, write func(writePath string) error) error {
+	temps := tempOutputsFrom(ctx)
+	temps.add(target.WritePath)
+
+	err := write(target.WritePath)
+	if err == nil {
+		err = ctx.Err()
+	}
+	if err != nil {
+		target.Discard()
+	} else {
+		err = target.Commit()
+	}
+	temps.remove(target.WritePath)
+	return err
+}
+
+// WriteOutputFile writes data for target through its temporary file.
+func WriteOutputFile(ctx context.Context, target OutputTarget, data []byte, perm os.FileMode) error {
+	return WriteOutput(ctx, target, func(writePath string) error {
+		f, err := os.OpenFile(writePath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+		if err != nil {
+			return err
+		}
+		if _, err := f.Write(data); err != nil {
+			f.Close()
+			return err
+		}
+		if err := f.Sync(); err != nil {
+			f.Close()
+			return err
+		}
+		return f.Close()
+	})
+}
+
+// Commit flushes the temporary file to disk and renames it over Path. Renaming
+// within one directory replaces the old output in a single step, so readers
+// never see a partial file.
+func (t OutputTarget) Commit() error {
+	if t.WritePath == t.Path {
+		return nil
+	}
+	if err := syncFile(t.WritePath); err != nil {
+		t.Discard()
+		return fmt.Errorf("sync %s: %w", t.WritePath, err)
+	}
+	if err := os.Rename(t.WritePath, t.Path); err != nil {
+		t.Discard()
+		return fmt.Errorf("replace %s: %w", t.Path, err)
+	}
+	syncDir(filepath.Dir(t.Path))
+	return nil
+}
+
+// Discard removes the temporary file of a failed write.
+func (t OutputTarget) Discard() {
+	if t.WritePath != t.Path {
+		_ = os.Remove(t.WritePath)
+	}
+}
+
+// SweepTempOutputs removes the temporary files that writes interrupted by a
+// crash left directly in dirs and returns their paths. Files modified within
+// the last minute are kept, as another instance may still be writing them.
+func SweepTempOutputs(dirs []string) []string {
+	seen := make(map[string]struct{}, len(dirs))
+	cutoff := time.Now().Add(-tempOutputSweepAge)
+	var removed []string
+	for _, dir := range dirs {
+		dir = strings.TrimSpace(dir)
+		if dir == "" {
+			continue
+		}
+		if _, ok := seen[OutputPathKey(dir)]; ok {
+			continue
+		}
+		seen[OutputPathKey(dir)] = struct{}{}
+
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			if entry.IsDir() || !strings.HasPrefix(entry.Name(), TempOutputPrefix) {
+				continue
+			}
+			info, err := entry.Info()
+			if err != nil || info.ModTime().After(cutoff) {
+				continue
+			}
+			path := filepath.Join(dir, entry.Name())
+			if os.Remove(path) == nil {
+				removed = append(removed, path)
+			}
+		}
+	}
+	return removed
+}
+
+// tempOutputPath keeps the extension of outputPath, as encoders pick the
+// format from it.
+func tempOutputPath(outputPath string) string {
+	suffix := make([]byte, 6)
+	_, _ = rand.Read(suffix)
+	name := TempOutputPrefix + hex.EncodeToString(suffix) + "-" + filepath.Base(outputPath)
+	return filepath.Join(filepath.Dir(outputPath), name)
+}
+
+func syncFile(path string) error {
+	f, err := os.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// syncDir persists the rename on file systems that need it; opening a
+// directory is not supported everywhere, so failures are ignored.
+func syncDir(dir string) {
+	if f, err := os.Open(dir); err == nil {
+		_ = f.Sync()
+		_ = f.Close()
+	}
+}
@@ -0,0 +1,144 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"fileforge-desktop/internal/models"
+)
+
+func dirEntries(t *testing.T, dir string) []string {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	return names
+}
+
+func TestWriteOutputFileReplacesExistingOutput(t *testing.T) {
+	dir := t.TempDir()
+	outputPath := filepath.Join(dir, "out.txt")
+	if err := os.WriteFile(outputPath, []byte("old"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	target, jobErr := ResolveOutputTarget(models.ConflictPolicyOverwrite, outputPath, nil)
+	if jobErr != nil {
+		t.Fatal(jobErr)
+	}
+	if err := WriteOutputFile(context.Background(), target, []byte("new"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	content, err := os.ReadFile(outputPath)
+	if err != nil || string(content) != "new" {
+		t.Fatalf("output = %q, %v, want new", content, err)
+	}
+	if names := dirEntries(t, dir); len(names) != 1 {
+		t.Fatalf("directory holds %v, want only out.txt", names)
+	}
+}
+
+func TestWriteOutputDiscardsFailedWrites(t *testing.T) {
+	dir := t.TempDir()
+	outputPath := filepath.Join(dir, "out.txt")
+	if err := os.WriteFile(outputPath, []byte("old"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	target, jobErr := ResolveOutputTarget(models.ConflictPolicyOverwrite, outputPath, nil)
+	if jobErr != nil {
+		t.Fatal(jobErr)
+	}
+
+	writeErr := errors.New("encoder failed")
+	err := WriteOutput(context.Background(), target, func(writePath string) error {
+		if err := os.WriteFile(writePath, []byte("partial"), 0o644); err != nil {
+			return err
+		}
+		return writeErr
+	})
+	if !errors.Is(err, writeErr) {
+		t.Fatalf("error = %v, want %v", err, writeErr)
+	}
+
+	// A write that finishes after the job was cancelled is not committed either.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err = WriteOutput(ctx, target, func(writePath string) error {
+		return os.WriteFile(writePath, []byte("late"), 0o644)
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("error = %v, want context.Canceled", err)
+	}
+
+	content, _ := os.ReadFile(outputPath)
+	if string(content) != "old" {
+		t.Fatalf("output = %q, want the old content kept", content)
+	}
+	if names := dirEntries(t, dir); len(names) != 1 {
+		t.Fatalf("directory holds %v, want only out.txt", names)
+	}
+}
+
+func TestTempOutputsCleanupRemovesUnfinishedWrites(t *testing.T) {
+	dir := t.TempDir()
+	temps := NewTempOutputs()
+	ctx := WithTempOutputs(context.Background(), temps)
+	target, jobErr := ResolveOutputTarget(models.ConflictPolicyRename, filepath.Join(dir, "out.txt"), nil)
+	if jobErr != nil {
+		t.Fatal(jobErr)
+	}
+
+	// The job is stopped while the write is still in progress.
+	_ = WriteOutput(ctx, target, func(writePath string) error {
+		if err := os.WriteFile(writePath, []byte("partial"), 0o644); err != nil {
+			return err
+		}
+		if removed := temps.Cleanup(); removed != 1 {
+			t.Errorf("Cleanup removed %d files, want 1", removed)
+		}
+		return errors.New("interrupted")
+	})
+
+	if names := dirEntries(t, dir); len(names) != 0 {
+		t.Fatalf("directory holds %v, want nothing", names)
+	}
+}
+
+func TestSweepTempOutputs(t *testing.T) {
+	dir := t.TempDir()
+	stale := filepath.Join(dir, TempOutputPrefix+"aaaa-out.png")
+	fresh := filepath.Join(dir, TempOutputPrefix+"bbbb-out.png")
+	output := filepath.Join(dir, "out.png")
+	for _, path := range []string{stale, fresh, output} {
+		if err := os.WriteFile(path, []byte("x"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	old := time.Now().Add(-2 * tempOutputSweepAge)
+	if err := os.Chtimes(stale, old, old); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(output, old, old); err != nil {
+		t.Fatal(err)
+	}
+
+	removed := SweepTempOutputs([]string{dir, dir, ""})
+	if len(removed) != 1 || removed[0] != stale {
+		t.Fatalf("removed %v, want only %s", removed, stale)
+	}
+	for _, path := range []string{fresh, output} {
+		if _, err := os.Stat(path); err != nil {
+			t.Fatalf("%s was removed: %v", path, err)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package tools
+
+import (
+	"fmt"
+	"os"
+
+	"fileforge-desktop/internal/models"
+)
+
+// PlanItem describes the outputs planned for one input; bytesPerOutput is the
+// estimated size of each output that would be written, 0 when unknown. How
+// the conflict policy resolved existing outputs is reported as warnings.
+func PlanItem(inputPath string, targets []OutputTarget, bytesPerOutput int64) models.PlanItemV1 {
+	item := models.PlanItemV1{
+		ItemID:    models.ItemIDV1(inputPath),
+		InputPath: inputPath,
+		Outputs:   make([]models.PlanOutputV1, 0, len(targets)),
+	}
+	for _, target := range targets {
+		item.Outputs = append(item.Outputs, models.PlanOutputV1{Path: target.Path, Action: target.Action, RequestedPath: target.RequestedPath})
+		switch target.Action {
+		case models.PlanActionOverwrite:
+			item.Warnings = append(item.Warnings, fmt.Sprintf("output exists and will be overwritten: %s", target.Path))
+		case models.PlanActionRename:
+			item.Warnings = append(item.Warnings, fmt.Sprintf("%s is taken, writing %s instead", target.RequestedPath, target.Path))
+		case models.PlanActionSkip:
+			item.Warnings = append(item.Warnings, fmt.Sprintf("output exists and will be skipped: %s", target.Path))
+			continue
+		}
+		item.EstimatedBytes += bytesPerOutput
+	}
+	return item
+}
+
+// FailedPlanItem is the plan of an input that would fail before writing.
+func FailedPlanItem(inputPath string, err *models.JobErrorV1) models.PlanItemV1 {
+	return models.PlanItemV1{
+		ItemID:    models.ItemIDV1(inputPath),
+		InputPath: inputPath,
+		Warnings:  []string{err.Message},
+		Error:     err,
+	}
+}
+
+// NewJobPlan totals the items of a plan.
+func NewJobPlan(toolID, mode string, items []models.PlanItemV1) models.JobPlanV1 {
+	plan := models.JobPlanV1{ToolID: toolID, Mode: mode, Items: items}
+	if plan.Items == nil {
+		plan.Items = []models.PlanItemV1{}
+	}
+	for _, item := range plan.Items {
+		if item.Error != nil {
+			plan.FailureCount++
+		}
+		plan.EstimatedBytes += item.EstimatedBytes
+		for _, output := range item.Outputs {
+			if output.Action != models.PlanActionSkip {
+				plan.OutputCount++
+			}
+			if output.Action != models.PlanActionCreate {
+				plan.ConflictCount++
+			}
+		}
+	}
+	return plan
+}
+
+// FileSize is the size of path, or 0 when it cannot be read; plans use it for
+// rough output size estimates.
+func FileSize(path string) int64 {
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0
+	}
+	return info.Size()
+}
@@ -0,0 +1,95 @@
+package procwatch
+
+import (
+	"context"
+	"os/exec"
+	"sync"
+	"time"
+)
+
+// killGracePeriod bounds how long Wait keeps waiting for output pipes after the
+// process group has been killed.
+const killGracePeriod = 5 * time.Second
+
+// Watchdog tracks the process groups of running child processes. A tool binary
+// such as LibreOffice can fork helpers that outlive it when it is killed or
+// exits early; killing the whole group reaps them as well.
+type Watchdog struct {
+	mu     sync.Mutex
+	groups map[int]struct{}
+}
+
+func NewWatchdog() *Watchdog {
+	return &Watchdog{groups: make(map[int]struct{})}
+}
+
+var defaultWatchdog = NewWatchdog()
+
+// Default returns the process-wide watchdog used by Command and Run.
+func Default() *Watchdog {
+	return defaultWatchdog
+}
+
+// Command builds a command that runs in its own process group. Cancelling ctx
+// kills the whole group rather than only the direct child.
+func Command(ctx context.Context, name string, args ...string) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, name, args...)
+	configureGroup(cmd)
+	cmd.Cancel = func() error {
+		return killGroup(cmd.Process.Pid)
+	}
+	cmd.WaitDelay = killGracePeriod
+	return cmd
+}
+
+// Run starts cmd, waits for it and then kills anything left in its process
+// group. The command should come from Command.
+func Run(cmd *exec.Cmd) error {
+	return defaultWatchdog.Run(cmd)
+}
+
+func (w *Watchdog) Run(cmd *exec.Cmd) error {
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	pid := cmd.Process.Pid
+	w.mu.Lock()
+	w.groups[pid] = struct{}{}
+	w.mu.Unlock()
+
+	err := cmd.Wait()
+
+	w.mu.Lock()
+	delete(w.groups, pid)
+	w.mu.Unlock()
+	reapGroup(pid)
+
+	return err
+}
+
+// KillAll kills every tracked process group and returns how many were
+// signalled. It is meant for application shutdown.
+func (w *Watchdog) KillAll() int {
+	w.mu.Lock()
+	pids := make([]int, 0, len(w.groups))
+	for pid := range w.groups {
+		pids = append(pids, pid)
+	}
+	w.mu.Unlock()
+
+	killed := 0
+	for _, pid := range pids {
+		if killGroup(pid) == nil {
+			killed++
+		}
+	}
+	return killed
+}
+
+// Running reports how many tracked process groups are still alive.
+func (w *Watchdog) Running() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return len(w.groups)
+}
@@ -0,0 +1,27 @@
+//go:build !windows
+
+package procwatch
+
+import (
+	"errors"
+	"os/exec"
+	"syscall"
+)
+
+func configureGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+}
+
+// killGroup sends SIGKILL to the process group led by pid. A group that has
+// already exited is not an error.
+func killGroup(pid int) error {
+	if err := syscall.Kill(-pid, syscall.SIGKILL); err != nil && !errors.Is(err, syscall.ESRCH) {
+		return err
+	}
+	return nil
+}
+
+// reapGroup kills helpers the finished leader left behind in its group.
+func reapGroup(pid int) {
+	_ = killGroup(pid)
+}
@@ -0,0 +1,29 @@
+//go:build windows
+
+package procwatch
+
+import (
+	"os/exec"
+	"strconv"
+	"syscall"
+)
+
+const createNewProcessGroup = 0x00000200
+
+func configureGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{CreationFlags: createNewProcessGroup}
+}
+
+// killGroup terminates the process tree rooted at pid. Windows has no process
+// groups to signal, so taskkill walks the tree instead; once the root has
+// exited its former children can no longer be found this way.
+func killGroup(pid int) error {
+	kill := exec.Command("taskkill", "/T", "/F", "/PID", strconv.Itoa(pid))
+	kill.SysProcAttr = &syscall.SysProcAttr{HideWindow: true}
+	_ = kill.Run()
+	return nil
+}
+
+// reapGroup is a no-op: after the root exits its pid may already belong to an
+// unrelated process, so there is nothing safe left to kill.
+func reapGroup(int) {}
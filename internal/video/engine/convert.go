@@ -1,14 +1,16 @@
 package engine
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
 	"os"
-	"os/exec"
 	pathpkg "path"
 	"path/filepath"
 	"strings"
+
+	"fileforge-desktop/internal/utils/procwatch"
 )
 
 const (
@@ -64,9 +66,12 @@ type CommandRunner interface {
 type ExecCommandRunner struct{}
 
 func (r *ExecCommandRunner) Run(ctx context.Context, name string, args []string) error {
-	cmd := exec.CommandContext(ctx, name, args...)
-	if output, err := cmd.CombinedOutput(); err != nil {
-		return fmt.Errorf("command failed: %w (%s)", err, strings.TrimSpace(string(output)))
+	cmd := procwatch.Command(ctx, name, args...)
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+	if err := procwatch.Run(cmd); err != nil {
+		return fmt.Errorf("command failed: %w (%s)", err, strings.TrimSpace(output.String()))
 	}
 
 	return nil
@@ -80,6 +85,16 @@ type ConvertRequest struct {
 }
 
 func ValidateConvertRequest(req ConvertRequest) *VideoError {
+	return validateConvertRequest(req, true)
+}
+
+// CheckConvertRequest runs the checks of ValidateConvertRequest without
+// the write test in the output directory, for dry-run plans.
+func CheckConvertRequest(req ConvertRequest) *VideoError {
+	return validateConvertRequest(req, false)
+}
+
+func validateConvertRequest(req ConvertRequest, writecheck bool) *VideoError {
 	inputPath := strings.TrimSpace(req.InputPath)
 	if inputPath == "" {
 		return &VideoError{Code: ErrorCodeVideoValidation, Message: "inputPath is required"}
@@ -108,7 +123,7 @@ func ValidateConvertRequest(req ConvertRequest) *VideoError {
 		return &VideoError{Code: ErrorCodeVideoOutputCollides, Message: "outputPath collides with inputPath"}
 	}
 
-	if dirErr := validateOutputDir(outputPath); dirErr != nil {
+	if dirErr := validateOutputDir(outputPath, writecheck); dirErr != nil {
 		return dirErr
 	}
 
@@ -215,7 +230,7 @@ func Convert(ctx context.Context, probe RuntimeProbe, runner CommandRunner, req
 	return nil
 }
 
-func validateOutputDir(outputPath string) *VideoError {
+func validateOutputDir(outputPath string, writecheck bool) *VideoError {
 	dir := filepath.Dir(strings.TrimSpace(outputPath))
 	if strings.TrimSpace(dir) == "" {
 		dir = "."
@@ -233,6 +248,10 @@ func validateOutputDir(outputPath string) *VideoError {
 		return &VideoError{Code: ErrorCodeVideoOutputDirNotDirectory, Message: fmt.Sprintf("output path parent is not a directory: %s", dir)}
 	}
 
+	if !writecheck {
+		return nil
+	}
+
 	tmp, err := os.CreateTemp(dir, ".fileforge-video-writecheck-*.tmp")
 	if err != nil {
 		return &VideoError{Code: ErrorCodeVideoOutputDirNotWritable, Message: fmt.Sprintf("output directory is not writable: %s", dir), Cause: err}
@@ -42,6 +42,16 @@ type MergeRequest struct {
 }
 
 func ValidateMergeRequest(req MergeRequest) *VideoError {
+	return validateMergeRequest(req, true)
+}
+
+// CheckMergeRequest runs the checks of ValidateMergeRequest without
+// the write test in the output directory, for dry-run plans.
+func CheckMergeRequest(req MergeRequest) *VideoError {
+	return validateMergeRequest(req, false)
+}
+
+func validateMergeRequest(req MergeRequest, writecheck bool) *VideoError {
 	if len(req.InputPaths) < 2 {
 		return &VideoError{Code: ErrorCodeVideoMergeInsufficientInputs, Message: "at least 2 inputPaths are required for merge"}
 	}
@@ -94,7 +104,7 @@ func ValidateMergeRequest(req MergeRequest) *VideoError {
 		}
 	}
 
-	if dirErr := validateMergeOutputDir(outputPath); dirErr != nil {
+	if dirErr := validateMergeOutputDir(outputPath, writecheck); dirErr != nil {
 		return dirErr
 	}
 
@@ -291,7 +301,7 @@ func escapeConcatFilePath(path string) string {
 	return strings.ReplaceAll(path, "'", "'\\''")
 }
 
-func validateMergeOutputDir(outputPath string) *VideoError {
+func validateMergeOutputDir(outputPath string, writecheck bool) *VideoError {
 	dir := filepath.Dir(strings.TrimSpace(outputPath))
 	if strings.TrimSpace(dir) == "" {
 		dir = "."
@@ -309,6 +319,10 @@ func validateMergeOutputDir(outputPath string) *VideoError {
 		return &VideoError{Code: ErrorCodeVideoMergeOutputDirNotDirectory, Message: fmt.Sprintf("output path parent is not a directory: %s", dir)}
 	}
 
+	if !writecheck {
+		return nil
+	}
+
 	tmp, err := os.CreateTemp(dir, ".fileforge-video-merge-writecheck-*.tmp")
 	if err != nil {
 		return &VideoError{Code: ErrorCodeVideoMergeOutputDirNotWritable, Message: fmt.Sprintf("output directory is not writable: %s", dir), Cause: err}
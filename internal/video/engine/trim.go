@@ -53,6 +53,16 @@ type TrimProgressRunner interface {
 }
 
 func ValidateTrimRequest(req TrimRequest) *VideoError {
+	return validateTrimRequest(req, true)
+}
+
+// CheckTrimRequest runs the checks of ValidateTrimRequest without
+// the write test in the output directory, for dry-run plans.
+func CheckTrimRequest(req TrimRequest) *VideoError {
+	return validateTrimRequest(req, false)
+}
+
+func validateTrimRequest(req TrimRequest, writecheck bool) *VideoError {
 	inputPath := strings.TrimSpace(req.InputPath)
 	if inputPath == "" {
 		return &VideoError{Code: ErrorCodeVideoTrimValidation, Message: "inputPath is required"}
@@ -97,7 +107,7 @@ func ValidateTrimRequest(req TrimRequest) *VideoError {
 		return &VideoError{Code: ErrorCodeVideoTrimOutputCollides, Message: "outputPath collides with inputPath"}
 	}
 
-	if dirErr := validateTrimOutputDir(outputPath); dirErr != nil {
+	if dirErr := validateTrimOutputDir(outputPath, writecheck); dirErr != nil {
 		return dirErr
 	}
 
@@ -351,7 +361,7 @@ func IsTrimFallbackEligible(err *VideoError) bool {
 	return isTrimFallbackEligible(err)
 }
 
-func validateTrimOutputDir(outputPath string) *VideoError {
+func validateTrimOutputDir(outputPath string, writecheck bool) *VideoError {
 	dir := filepath.Dir(strings.TrimSpace(outputPath))
 	if strings.TrimSpace(dir) == "" {
 		dir = "."
@@ -369,6 +379,10 @@ func validateTrimOutputDir(outputPath string) *VideoError {
 		return &VideoError{Code: ErrorCodeVideoTrimOutputDirNotDirectory, Message: fmt.Sprintf("output path parent is not a directory: %s", dir)}
 	}
 
+	if !writecheck {
+		return nil
+	}
+
 	tmp, err := os.CreateTemp(dir, ".fileforge-video-trim-writecheck-*.tmp")
 	if err != nil {
 		return &VideoError{Code: ErrorCodeVideoTrimOutputDirNotWritable, Message: fmt.Sprintf("output directory is not writable: %s", dir), Cause: err}
@@ -4,10 +4,11 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"path/filepath"
 	"strings"
+	"time"
 
 	"fileforge-desktop/internal/models"
+	"fileforge-desktop/internal/tools"
 	"fileforge-desktop/internal/video/engine"
 )
 
@@ -36,18 +37,32 @@ func (t *ConvertTool) Capability() string {
 
 func (t *ConvertTool) Manifest() models.ToolManifestV1 {
 	return models.ToolManifestV1{
-		ToolID:           t.ID(),
-		Name:             "Video Convert",
-		Description:      "Convert videos to mp4 or webm with quality presets",
-		Domain:           "video",
-		Capability:       t.Capability(),
-		Version:          "v1",
-		SupportsSingle:   true,
-		SupportsBatch:    true,
-		InputExtensions:  []string{"mp4", "mov", "mkv"},
-		OutputExtensions: []string{"mp4", "webm"},
-		RuntimeDeps:      []string{"ffmpeg", "ffprobe"},
-		Tags:             []string{"video", "convert", "ffmpeg"},
+		ToolID:            t.ID(),
+		Name:              "Video Convert",
+		Description:       "Convert videos to mp4 or webm with quality presets",
+		Domain:            "video",
+		Capability:        t.Capability(),
+		Version:           "v1",
+		SupportsSingle:    true,
+		SupportsBatch:     true,
+		InputExtensions:   []string{"mp4", "mov", "mkv"},
+		OutputExtensions:  []string{"mp4", "webm"},
+		RuntimeDeps:       []string{"ffmpeg", "ffprobe"},
+		Tags:              []string{"video", "convert", "ffmpeg"},
+		ItemTimeoutMillis: (4 * time.Hour).Milliseconds(),
+		Options: []models.OptionSchemaV1{
+			{Key: "targetFormat", Type: models.OptionTypeString, Label: "Output format", Required: true, Enum: []string{engine.TargetFormatMP4, engine.TargetFormatWebM}},
+			{Key: "qualityPreset", Type: models.OptionTypeString, Label: "Quality", Required: true, Enum: []string{engine.QualityPresetHigh, engine.QualityPresetMedium, engine.QualityPresetLow}},
+			{Key: "outputPath", Type: models.OptionTypeString, Label: "Output file", Required: true, Format: "path", Modes: []string{"single"}},
+			tools.OutputNameTemplateSchema("{name}_converted.{ext}"),
+		},
+	}
+}
+
+func (t *ConvertTool) RetryPolicy() models.RetryPolicyV1 {
+	return models.RetryPolicyV1{
+		MaxAttempts:    3,
+		RetryableCodes: []string{"VIDEO_CONVERT_EXECUTION", "VIDEO_CONVERT_FAILED"},
 	}
 }
 
@@ -80,6 +95,15 @@ func (t *ConvertTool) Validate(ctx context.Context, req models.JobRequestV1) *mo
 			return jobErr
 		}
 
+		target, conflictErr := tools.ResolveOutputTarget(tools.ConflictPolicy(req), convertReq.OutputPath, nil)
+		if conflictErr != nil {
+			return conflictErr
+		}
+		if target.Skip {
+			return nil
+		}
+		convertReq.OutputPath = target.WritePath
+
 		if validationErr := engine.ValidateConvertRequest(convertReq); validationErr != nil {
 			return mapVideoError(validationErr)
 		}
@@ -89,7 +113,15 @@ func (t *ConvertTool) Validate(ctx context.Context, req models.JobRequestV1) *mo
 			return jobErr
 		}
 
-		for _, convertReq := range convertReqs {
+		targets, conflicts := resolveVideoTargets(req, convertOutputPaths(convertReqs))
+		for i, convertReq := range convertReqs {
+			if conflicts[i] != nil {
+				return conflicts[i]
+			}
+			if targets[i].Skip {
+				continue
+			}
+			convertReq.OutputPath = targets[i].WritePath
 			if validationErr := engine.ValidateConvertRequest(convertReq); validationErr != nil {
 				return mapVideoError(validationErr)
 			}
@@ -101,18 +133,69 @@ func (t *ConvertTool) Validate(ctx context.Context, req models.JobRequestV1) *mo
 	return nil
 }
 
+func (t *ConvertTool) Plan(ctx context.Context, req models.JobRequestV1) (models.JobPlanV1, *models.JobErrorV1) {
+	probe := t.probe
+	if probe == nil {
+		probe = engine.NewFFmpegRuntimeProbe()
+	}
+	if runtimeErr := probe.Check(ctx); runtimeErr != nil {
+		return models.JobPlanV1{}, mapVideoError(runtimeErr)
+	}
+
+	var convertReqs []engine.ConvertRequest
+	switch strings.TrimSpace(req.Mode) {
+	case "single":
+		convertReq, jobErr := parseConvertRequest(req)
+		if jobErr != nil {
+			return models.JobPlanV1{}, jobErr
+		}
+		convertReqs = []engine.ConvertRequest{convertReq}
+	case "batch":
+		batchReqs, jobErr := parseConvertBatchRequests(req)
+		if jobErr != nil {
+			return models.JobPlanV1{}, jobErr
+		}
+		convertReqs = batchReqs
+	default:
+		return models.JobPlanV1{}, &models.JobErrorV1{Code: engine.ErrorCodeVideoValidation, Message: "mode must be single or batch"}
+	}
+
+	targets, conflicts := resolveVideoTargets(req, convertOutputPaths(convertReqs))
+	items := make([]models.PlanItemV1, 0, len(convertReqs))
+	for i, convertReq := range convertReqs {
+		items = append(items, planVideoItem(convertReq.InputPath, targets[i], conflicts[i], func(writePath string) *engine.VideoError {
+			convertReq.OutputPath = writePath
+			return engine.CheckConvertRequest(convertReq)
+		}))
+	}
+	if req.Mode == "single" && items[0].Error != nil {
+		return models.JobPlanV1{}, items[0].Error
+	}
+	return tools.NewJobPlan(ToolIDVideoConvertV1, req.Mode, items), nil
+}
+
 func (t *ConvertTool) ExecuteSingle(ctx context.Context, req models.JobRequestV1) (models.JobResultItemV1, *models.JobErrorV1) {
 	convertReq, parseErr := parseConvertRequest(req)
 	if parseErr != nil {
 		return models.JobResultItemV1{InputPath: firstInputPath(req.InputPaths), OutputPath: optionString(req.Options, "outputPath"), Success: false, Message: parseErr.Message, Error: parseErr}, parseErr
 	}
 
-	if err := engine.Convert(ctx, t.probe, t.runner, convertReq); err != nil {
-		jobErr := mapVideoError(err)
-		return models.JobResultItemV1{InputPath: convertReq.InputPath, OutputPath: convertReq.OutputPath, Success: false, Message: jobErr.Message, Error: jobErr}, jobErr
+	target, conflictErr := tools.ResolveOutputTarget(tools.ConflictPolicy(req), convertReq.OutputPath, nil)
+	if conflictErr != nil {
+		return models.JobResultItemV1{InputPath: convertReq.InputPath, OutputPath: convertReq.OutputPath, Success: false, Message: conflictErr.Message, Error: conflictErr}, conflictErr
+	}
+	if target.Skip {
+		return models.SkippedItemV1(convertReq.InputPath, target.Path), nil
+	}
+	convertReq.OutputPath = target.WritePath
+
+	if jobErr := writeVideoOutput(ctx, target, func() *engine.VideoError {
+		return engine.Convert(ctx, t.probe, t.runner, convertReq)
+	}); jobErr != nil {
+		return models.JobResultItemV1{InputPath: convertReq.InputPath, OutputPath: target.Path, Success: false, Message: jobErr.Message, Error: jobErr}, jobErr
 	}
 
-	return models.JobResultItemV1{InputPath: convertReq.InputPath, OutputPath: convertReq.OutputPath, Outputs: []string{convertReq.OutputPath}, OutputCount: 1, Success: true, Message: "Video conversion successful"}, nil
+	return models.JobResultItemV1{InputPath: convertReq.InputPath, OutputPath: target.Path, Outputs: []string{target.Path}, OutputCount: 1, Success: true, Message: "Video conversion successful"}, nil
 }
 
 func (t *ConvertTool) ExecuteBatch(ctx context.Context, req models.JobRequestV1, onProgress func(models.JobProgressV1)) ([]models.JobResultItemV1, *models.JobErrorV1) {
@@ -123,6 +206,7 @@ func (t *ConvertTool) ExecuteBatch(ctx context.Context, req models.JobRequestV1,
 
 	items := make([]models.JobResultItemV1, 0, len(convertReqs))
 	var firstErr *models.JobErrorV1
+	targets, conflicts := resolveVideoTargets(req, convertOutputPaths(convertReqs))
 
 	for index, convertReq := range convertReqs {
 		select {
@@ -132,18 +216,43 @@ func (t *ConvertTool) ExecuteBatch(ctx context.Context, req models.JobRequestV1,
 		default:
 		}
 
-		if err := engine.Convert(ctx, t.probe, t.runner, convertReq); err != nil {
-			jobErr := mapVideoError(err)
+		if tools.ItemCancelled(ctx, convertReq.InputPath) {
+			items = append(items, models.CancelledItemV1(convertReq.InputPath, convertReq.OutputPath))
+			if onProgress != nil {
+				onProgress(models.JobProgressV1{Current: index + 1, Total: len(convertReqs), Stage: "running", Message: fmt.Sprintf("processed %d/%d", index+1, len(convertReqs)), Item: models.LastResultItemV1(items)})
+			}
+			continue
+		}
+
+		target := targets[index]
+		if conflicts[index] != nil {
 			if firstErr == nil {
-				firstErr = jobErr
+				firstErr = conflicts[index]
 			}
-			items = append(items, models.JobResultItemV1{InputPath: convertReq.InputPath, OutputPath: convertReq.OutputPath, Success: false, Message: jobErr.Message, Error: jobErr})
+			items = append(items, models.JobResultItemV1{InputPath: convertReq.InputPath, OutputPath: convertReq.OutputPath, Success: false, Message: conflicts[index].Message, Error: conflicts[index]})
+		} else if target.Skip {
+			items = append(items, models.SkippedItemV1(convertReq.InputPath, target.Path))
 		} else {
-			items = append(items, models.JobResultItemV1{InputPath: convertReq.InputPath, OutputPath: convertReq.OutputPath, Outputs: []string{convertReq.OutputPath}, OutputCount: 1, Success: true, Message: "Video conversion successful"})
+			convertReq.OutputPath = target.WritePath
+			itemCtx, stopItem := tools.ItemContext(ctx, convertReq.InputPath)
+			jobErr := writeVideoOutput(itemCtx, target, func() *engine.VideoError {
+				return engine.Convert(itemCtx, t.probe, t.runner, convertReq)
+			})
+			stopItem()
+			if jobErr != nil && ctx.Err() == nil && tools.ItemCancelled(ctx, convertReq.InputPath) {
+				items = append(items, models.CancelledItemV1(convertReq.InputPath, target.Path))
+			} else if jobErr != nil {
+				if firstErr == nil {
+					firstErr = jobErr
+				}
+				items = append(items, models.JobResultItemV1{InputPath: convertReq.InputPath, OutputPath: target.Path, Success: false, Message: jobErr.Message, Error: jobErr})
+			} else {
+				items = append(items, models.JobResultItemV1{InputPath: convertReq.InputPath, OutputPath: target.Path, Outputs: []string{target.Path}, OutputCount: 1, Success: true, Message: "Video conversion successful"})
+			}
 		}
 
 		if onProgress != nil {
-			onProgress(models.JobProgressV1{Current: index + 1, Total: len(convertReqs), Stage: "running", Message: fmt.Sprintf("processed %d/%d", index+1, len(convertReqs))})
+			onProgress(models.JobProgressV1{Current: index + 1, Total: len(convertReqs), Stage: "running", Message: fmt.Sprintf("processed %d/%d", index+1, len(convertReqs)), Item: models.LastResultItemV1(items)})
 		}
 	}
 
@@ -211,7 +320,10 @@ func parseConvertBatchRequests(req models.JobRequestV1) ([]engine.ConvertRequest
 	}
 
 	reqs := make([]engine.ConvertRequest, 0, len(req.InputPaths))
-	plannedOutputs := make(map[string]struct{}, len(req.InputPaths))
+	if tmplErr := tools.ValidateOutputNameTemplate(req.Options); tmplErr != nil {
+		return nil, tmplErr
+	}
+	nameTemplate := tools.OutputNameTemplate(req.Options, "{name}_converted.{ext}")
 
 	for index, rawInputPath := range req.InputPaths {
 		inputPath := strings.TrimSpace(rawInputPath)
@@ -219,18 +331,13 @@ func parseConvertBatchRequests(req models.JobRequestV1) ([]engine.ConvertRequest
 			return nil, &models.JobErrorV1{Code: engine.ErrorCodeVideoValidation, Message: "inputPaths must not contain empty values"}
 		}
 
-		baseName := strings.TrimSuffix(filepath.Base(inputPath), filepath.Ext(inputPath))
-		if baseName == "" {
-			baseName = fmt.Sprintf("video_%d", index+1)
-		}
-
-		outputFileName := fmt.Sprintf("%s_converted.%s", baseName, targetFormat)
-		outputPath := filepath.Join(outputDir, outputFileName)
-		normalizedOutputKey := strings.ToLower(filepath.Clean(outputPath))
-		if _, exists := plannedOutputs[normalizedOutputKey]; exists {
-			return nil, &models.JobErrorV1{Code: engine.ErrorCodeVideoValidation, Message: "batch output collision detected; input filenames must be unique"}
-		}
-		plannedOutputs[normalizedOutputKey] = struct{}{}
+		outputPath := tools.RenderOutputPath(outputDir, nameTemplate, tools.OutputNameVars{
+			InputPath: inputPath,
+			Ext:       targetFormat,
+			Tool:      ToolIDVideoConvertV1,
+			Index:     index + 1,
+			Subdir:    req.InputSubdirs[rawInputPath],
+		})
 
 		reqs = append(reqs, engine.ConvertRequest{
 			InputPath:     inputPath,
@@ -243,6 +350,57 @@ func parseConvertBatchRequests(req models.JobRequestV1) ([]engine.ConvertRequest
 	return reqs, nil
 }
 
+func convertOutputPaths(reqs []engine.ConvertRequest) []string {
+	paths := make([]string, 0, len(reqs))
+	for _, req := range reqs {
+		paths = append(paths, req.OutputPath)
+	}
+	return paths
+}
+
+// resolveVideoTargets applies the request's conflict policy to the planned
+// outputs of a batch, in order, so outputs within the batch never collide.
+func resolveVideoTargets(req models.JobRequestV1, outputPaths []string) ([]tools.OutputTarget, []*models.JobErrorV1) {
+	policy := tools.ConflictPolicy(req)
+	used := make(map[string]struct{}, len(outputPaths))
+	targets := make([]tools.OutputTarget, len(outputPaths))
+	conflicts := make([]*models.JobErrorV1, len(outputPaths))
+	for i, outputPath := range outputPaths {
+		targets[i], conflicts[i] = tools.ResolveOutputTarget(policy, outputPath, used)
+	}
+	return targets, conflicts
+}
+
+// planVideoItem plans one output, checking the engine request against the
+// temporary path the write would use. Re-encoding gives no reliable size, so
+// the input size stands in as the estimate.
+func planVideoItem(inputPath string, target tools.OutputTarget, conflictErr *models.JobErrorV1, validate func(writePath string) *engine.VideoError) models.PlanItemV1 {
+	if conflictErr != nil {
+		return tools.FailedPlanItem(inputPath, conflictErr)
+	}
+	if !target.Skip {
+		if validationErr := validate(target.WritePath); validationErr != nil {
+			return tools.FailedPlanItem(inputPath, mapVideoError(validationErr))
+		}
+	}
+	return tools.PlanItem(inputPath, []tools.OutputTarget{target}, tools.FileSize(inputPath))
+}
+
+// writeVideoOutput runs an engine call that writes to target.WritePath through
+// the shared output writer, so ffmpeg never writes the final path directly.
+func writeVideoOutput(ctx context.Context, target tools.OutputTarget, run func() *engine.VideoError) *models.JobErrorV1 {
+	err := tools.WriteOutput(ctx, target, func(string) error {
+		if videoErr := run(); videoErr != nil {
+			return videoErr
+		}
+		return nil
+	})
+	if err != nil {
+		return mapVideoError(err)
+	}
+	return nil
+}
+
 func mapVideoError(err error) *models.JobErrorV1 {
 	var videoErr *engine.VideoError
 	if !errors.As(err, &videoErr) {
@@ -3,8 +3,10 @@ package video
 import (
 	"context"
 	"strings"
+	"time"
 
 	"fileforge-desktop/internal/models"
+	"fileforge-desktop/internal/tools"
 	"fileforge-desktop/internal/video/engine"
 )
 
@@ -33,18 +35,32 @@ func (t *MergeTool) Capability() string {
 
 func (t *MergeTool) Manifest() models.ToolManifestV1 {
 	return models.ToolManifestV1{
-		ToolID:           t.ID(),
-		Name:             "Video Merge",
-		Description:      "Merge multiple videos in input order into one mp4 or webm output",
-		Domain:           "video",
-		Capability:       t.Capability(),
-		Version:          "v1",
-		SupportsSingle:   true,
-		SupportsBatch:    false,
-		InputExtensions:  []string{"mp4", "mov", "mkv", "webm"},
-		OutputExtensions: []string{"mp4", "webm"},
-		RuntimeDeps:      []string{"ffmpeg", "ffprobe"},
-		Tags:             []string{"video", "merge", "concat", "ffmpeg"},
+		ToolID:            t.ID(),
+		Name:              "Video Merge",
+		Description:       "Merge multiple videos in input order into one mp4 or webm output",
+		Domain:            "video",
+		Capability:        t.Capability(),
+		Version:           "v1",
+		SupportsSingle:    true,
+		SupportsBatch:     false,
+		InputExtensions:   []string{"mp4", "mov", "mkv", "webm"},
+		OutputExtensions:  []string{"mp4", "webm"},
+		RuntimeDeps:       []string{"ffmpeg", "ffprobe"},
+		Tags:              []string{"video", "merge", "concat", "ffmpeg"},
+		ItemTimeoutMillis: (4 * time.Hour).Milliseconds(),
+		Options: []models.OptionSchemaV1{
+			{Key: "mergeMode", Type: models.OptionTypeString, Label: "Merge mode", Description: "copy joins streams without re-encoding when the inputs match", Default: engine.MergeModeAuto, Enum: []string{engine.MergeModeAuto, engine.MergeModeCopy, engine.MergeModeReencode}},
+			{Key: "targetFormat", Type: models.OptionTypeString, Label: "Output format", Required: true, Enum: []string{engine.TargetFormatMP4, engine.TargetFormatWebM}},
+			{Key: "qualityPreset", Type: models.OptionTypeString, Label: "Quality", Required: true, Enum: []string{engine.QualityPresetHigh, engine.QualityPresetMedium, engine.QualityPresetLow}},
+			{Key: "outputPath", Type: models.OptionTypeString, Label: "Output file", Required: true, Format: "path"},
+		},
+	}
+}
+
+func (t *MergeTool) RetryPolicy() models.RetryPolicyV1 {
+	return models.RetryPolicyV1{
+		MaxAttempts:    3,
+		RetryableCodes: []string{"VIDEO_MERGE_EXECUTION", "VIDEO_MERGE_FAILED"},
 	}
 }
 
@@ -62,9 +78,30 @@ func (t *MergeTool) RuntimeState(ctx context.Context) models.ToolRuntimeStateV1
 }
 
 func (t *MergeTool) Validate(ctx context.Context, req models.JobRequestV1) *models.JobErrorV1 {
+	_, _, jobErr := t.checkRequest(ctx, req, engine.ValidateMergeRequest)
+	return jobErr
+}
+
+// Plan runs the checks of Validate without its write test in the output
+// directory.
+func (t *MergeTool) Plan(ctx context.Context, req models.JobRequestV1) (models.JobPlanV1, *models.JobErrorV1) {
+	mergeReq, target, jobErr := t.checkRequest(ctx, req, engine.CheckMergeRequest)
+	if jobErr != nil {
+		return models.JobPlanV1{}, jobErr
+	}
+
+	var estimated int64
+	for _, inputPath := range mergeReq.InputPaths {
+		estimated += tools.FileSize(inputPath)
+	}
+	item := tools.PlanItem(firstInputPath(mergeReq.InputPaths), []tools.OutputTarget{target}, estimated)
+	return tools.NewJobPlan(ToolIDVideoMergeV1, req.Mode, []models.PlanItemV1{item}), nil
+}
+
+func (t *MergeTool) checkRequest(ctx context.Context, req models.JobRequestV1, checkMerge func(engine.MergeRequest) *engine.VideoError) (engine.MergeRequest, tools.OutputTarget, *models.JobErrorV1) {
 	mergeReq, jobErr := parseMergeRequest(req)
 	if jobErr != nil {
-		return jobErr
+		return engine.MergeRequest{}, tools.OutputTarget{}, jobErr
 	}
 
 	probe := t.probe
@@ -72,14 +109,24 @@ func (t *MergeTool) Validate(ctx context.Context, req models.JobRequestV1) *mode
 		probe = engine.NewFFmpegRuntimeProbe()
 	}
 	if runtimeErr := probe.Check(ctx); runtimeErr != nil {
-		return mapVideoError(runtimeErr)
+		return engine.MergeRequest{}, tools.OutputTarget{}, mapVideoError(runtimeErr)
+	}
+
+	target, conflictErr := tools.ResolveOutputTarget(tools.ConflictPolicy(req), mergeReq.OutputPath, nil)
+	if conflictErr != nil {
+		return engine.MergeRequest{}, tools.OutputTarget{}, conflictErr
+	}
+	if target.Skip {
+		return mergeReq, target, nil
 	}
 
-	if validationErr := engine.ValidateMergeRequest(mergeReq); validationErr != nil {
-		return mapVideoError(validationErr)
+	checked := mergeReq
+	checked.OutputPath = target.WritePath
+	if validationErr := checkMerge(checked); validationErr != nil {
+		return engine.MergeRequest{}, tools.OutputTarget{}, mapVideoError(validationErr)
 	}
 
-	return nil
+	return mergeReq, target, nil
 }
 
 func (t *MergeTool) ExecuteSingle(ctx context.Context, req models.JobRequestV1) (models.JobResultItemV1, *models.JobErrorV1) {
@@ -88,12 +135,22 @@ func (t *MergeTool) ExecuteSingle(ctx context.Context, req models.JobRequestV1)
 		return models.JobResultItemV1{InputPath: firstInputPath(req.InputPaths), OutputPath: optionString(req.Options, "outputPath"), Success: false, Message: parseErr.Message, Error: parseErr}, parseErr
 	}
 
-	if err := engine.Merge(ctx, t.probe, t.runner, mergeReq); err != nil {
-		jobErr := mapVideoError(err)
-		return models.JobResultItemV1{InputPath: firstInputPath(mergeReq.InputPaths), OutputPath: mergeReq.OutputPath, Success: false, Message: jobErr.Message, Error: jobErr}, jobErr
+	target, conflictErr := tools.ResolveOutputTarget(tools.ConflictPolicy(req), mergeReq.OutputPath, nil)
+	if conflictErr != nil {
+		return models.JobResultItemV1{InputPath: firstInputPath(mergeReq.InputPaths), OutputPath: mergeReq.OutputPath, Success: false, Message: conflictErr.Message, Error: conflictErr}, conflictErr
+	}
+	if target.Skip {
+		return models.SkippedItemV1(firstInputPath(mergeReq.InputPaths), target.Path), nil
+	}
+	mergeReq.OutputPath = target.WritePath
+
+	if jobErr := writeVideoOutput(ctx, target, func() *engine.VideoError {
+		return engine.Merge(ctx, t.probe, t.runner, mergeReq)
+	}); jobErr != nil {
+		return models.JobResultItemV1{InputPath: firstInputPath(mergeReq.InputPaths), OutputPath: target.Path, Success: false, Message: jobErr.Message, Error: jobErr}, jobErr
 	}
 
-	return models.JobResultItemV1{InputPath: firstInputPath(mergeReq.InputPaths), OutputPath: mergeReq.OutputPath, Outputs: []string{mergeReq.OutputPath}, OutputCount: 1, Success: true, Message: "Video merge successful"}, nil
+	return models.JobResultItemV1{InputPath: firstInputPath(mergeReq.InputPaths), OutputPath: target.Path, Outputs: []string{target.Path}, OutputCount: 1, Success: true, Message: "Video merge successful"}, nil
 }
 
 func parseMergeRequest(req models.JobRequestV1) (engine.MergeRequest, *models.JobErrorV1) {
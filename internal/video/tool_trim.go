@@ -3,11 +3,12 @@ package video
 import (
 	"context"
 	"fmt"
-	"path/filepath"
 	"strings"
+	"time"
 
 	"fileforge-desktop/internal/jobs"
 	"fileforge-desktop/internal/models"
+	"fileforge-desktop/internal/tools"
 	"fileforge-desktop/internal/video/engine"
 )
 
@@ -36,18 +37,35 @@ func (t *TrimTool) Capability() string {
 
 func (t *TrimTool) Manifest() models.ToolManifestV1 {
 	return models.ToolManifestV1{
-		ToolID:           t.ID(),
-		Name:             "Video Trim",
-		Description:      "Trim videos by start/end time to mp4 or webm",
-		Domain:           "video",
-		Capability:       t.Capability(),
-		Version:          "v1",
-		SupportsSingle:   true,
-		SupportsBatch:    true,
-		InputExtensions:  []string{"mp4", "mov", "mkv"},
-		OutputExtensions: []string{"mp4", "webm"},
-		RuntimeDeps:      []string{"ffmpeg", "ffprobe"},
-		Tags:             []string{"video", "trim", "ffmpeg"},
+		ToolID:            t.ID(),
+		Name:              "Video Trim",
+		Description:       "Trim videos by start/end time to mp4 or webm",
+		Domain:            "video",
+		Capability:        t.Capability(),
+		Version:           "v1",
+		SupportsSingle:    true,
+		SupportsBatch:     true,
+		InputExtensions:   []string{"mp4", "mov", "mkv"},
+		OutputExtensions:  []string{"mp4", "webm"},
+		RuntimeDeps:       []string{"ffmpeg", "ffprobe"},
+		Tags:              []string{"video", "trim", "ffmpeg"},
+		ItemTimeoutMillis: (2 * time.Hour).Milliseconds(),
+		Options: []models.OptionSchemaV1{
+			{Key: "startTime", Type: models.OptionTypeNumber, Label: "Start", Description: "Seconds from the start of the video", Required: true, Min: models.OptionBound(0), Format: "time"},
+			{Key: "endTime", Type: models.OptionTypeNumber, Label: "End", Description: "Seconds from the start of the video, after startTime", Required: true, Min: models.OptionBound(0), Format: "time"},
+			{Key: "trimMode", Type: models.OptionTypeString, Label: "Trim mode", Description: "copy cuts on keyframes without re-encoding", Default: engine.TrimModeAuto, Enum: []string{engine.TrimModeAuto, engine.TrimModeCopy, engine.TrimModeReencode}},
+			{Key: "targetFormat", Type: models.OptionTypeString, Label: "Output format", Required: true, Enum: []string{engine.TargetFormatMP4, engine.TargetFormatWebM}},
+			{Key: "qualityPreset", Type: models.OptionTypeString, Label: "Quality", Required: true, Enum: []string{engine.QualityPresetHigh, engine.QualityPresetMedium, engine.QualityPresetLow}},
+			{Key: "outputPath", Type: models.OptionTypeString, Label: "Output file", Required: true, Format: "path", Modes: []string{"single"}},
+			tools.OutputNameTemplateSchema("{name}_trimmed.{ext}"),
+		},
+	}
+}
+
+func (t *TrimTool) RetryPolicy() models.RetryPolicyV1 {
+	return models.RetryPolicyV1{
+		MaxAttempts:    3,
+		RetryableCodes: []string{"VIDEO_TRIM_EXECUTION", "VIDEO_TRIM_FAILED"},
 	}
 }
 
@@ -80,6 +98,15 @@ func (t *TrimTool) Validate(ctx context.Context, req models.JobRequestV1) *model
 			return jobErr
 		}
 
+		target, conflictErr := tools.ResolveOutputTarget(tools.ConflictPolicy(req), trimReq.OutputPath, nil)
+		if conflictErr != nil {
+			return conflictErr
+		}
+		if target.Skip {
+			return nil
+		}
+		trimReq.OutputPath = target.WritePath
+
 		if validationErr := engine.ValidateTrimRequest(trimReq); validationErr != nil {
 			return mapVideoError(validationErr)
 		}
@@ -89,7 +116,15 @@ func (t *TrimTool) Validate(ctx context.Context, req models.JobRequestV1) *model
 			return jobErr
 		}
 
-		for _, trimReq := range trimReqs {
+		targets, conflicts := resolveVideoTargets(req, trimOutputPaths(trimReqs))
+		for i, trimReq := range trimReqs {
+			if conflicts[i] != nil {
+				return conflicts[i]
+			}
+			if targets[i].Skip {
+				continue
+			}
+			trimReq.OutputPath = targets[i].WritePath
 			if validationErr := engine.ValidateTrimRequest(trimReq); validationErr != nil {
 				return mapVideoError(validationErr)
 			}
@@ -101,6 +136,47 @@ func (t *TrimTool) Validate(ctx context.Context, req models.JobRequestV1) *model
 	return nil
 }
 
+func (t *TrimTool) Plan(ctx context.Context, req models.JobRequestV1) (models.JobPlanV1, *models.JobErrorV1) {
+	probe := t.probe
+	if probe == nil {
+		probe = engine.NewFFmpegRuntimeProbe()
+	}
+	if runtimeErr := probe.Check(ctx); runtimeErr != nil {
+		return models.JobPlanV1{}, mapVideoError(runtimeErr)
+	}
+
+	var trimReqs []engine.TrimRequest
+	switch strings.TrimSpace(req.Mode) {
+	case "single":
+		trimReq, jobErr := parseTrimRequest(req)
+		if jobErr != nil {
+			return models.JobPlanV1{}, jobErr
+		}
+		trimReqs = []engine.TrimRequest{trimReq}
+	case "batch":
+		batchReqs, jobErr := parseTrimBatchRequests(req)
+		if jobErr != nil {
+			return models.JobPlanV1{}, jobErr
+		}
+		trimReqs = batchReqs
+	default:
+		return models.JobPlanV1{}, &models.JobErrorV1{Code: engine.ErrorCodeVideoTrimValidation, Message: "mode must be single or batch"}
+	}
+
+	targets, conflicts := resolveVideoTargets(req, trimOutputPaths(trimReqs))
+	items := make([]models.PlanItemV1, 0, len(trimReqs))
+	for i, trimReq := range trimReqs {
+		items = append(items, planVideoItem(trimReq.InputPath, targets[i], conflicts[i], func(writePath string) *engine.VideoError {
+			trimReq.OutputPath = writePath
+			return engine.CheckTrimRequest(trimReq)
+		}))
+	}
+	if req.Mode == "single" && items[0].Error != nil {
+		return models.JobPlanV1{}, items[0].Error
+	}
+	return tools.NewJobPlan(ToolIDVideoTrimV1, req.Mode, items), nil
+}
+
 func (t *TrimTool) ExecuteSingle(ctx context.Context, req models.JobRequestV1) (models.JobResultItemV1, *models.JobErrorV1) {
 	return t.executeSingle(ctx, req, nil)
 }
@@ -115,30 +191,43 @@ func (t *TrimTool) executeSingle(ctx context.Context, req models.JobRequestV1, o
 		return models.JobResultItemV1{InputPath: firstInputPath(req.InputPaths), OutputPath: optionString(req.Options, "outputPath"), Success: false, Message: parseErr.Message, Error: parseErr}, parseErr
 	}
 
+	target, conflictErr := tools.ResolveOutputTarget(tools.ConflictPolicy(req), trimReq.OutputPath, nil)
+	if conflictErr != nil {
+		return models.JobResultItemV1{InputPath: trimReq.InputPath, OutputPath: trimReq.OutputPath, Success: false, Message: conflictErr.Message, Error: conflictErr}, conflictErr
+	}
+	if target.Skip {
+		return models.SkippedItemV1(trimReq.InputPath, target.Path), nil
+	}
+	trimReq.OutputPath = target.WritePath
+
 	var fallbackInfo string
-	trimErr := engine.TrimWithProgress(ctx, t.probe, t.runner, trimReq, func(evt engine.TrimProgressEvent) {
-		if onProgress != nil {
-			total := 100
-			current := int(evt.Percent)
-			if current < 0 {
-				current = 0
-			}
-			if current > total {
-				current = total
-			}
-			message := strings.TrimSpace(evt.Message)
-			if message == "" {
-				message = evt.Stage
+	var trimErr *engine.VideoError
+	writeErr := writeVideoOutput(ctx, target, func() *engine.VideoError {
+		trimErr = engine.TrimWithProgress(ctx, t.probe, t.runner, trimReq, func(evt engine.TrimProgressEvent) {
+			if onProgress != nil {
+				total := 100
+				current := int(evt.Percent)
+				if current < 0 {
+					current = 0
+				}
+				if current > total {
+					current = total
+				}
+				message := strings.TrimSpace(evt.Message)
+				if message == "" {
+					message = evt.Stage
+				}
+				stage := strings.TrimSpace(evt.Stage)
+				if stage == "" {
+					stage = jobs.StatusRunning
+				}
+				onProgress(models.JobProgressV1{Current: current, Total: total, Stage: stage, Message: message})
 			}
-			stage := strings.TrimSpace(evt.Stage)
-			if stage == "" {
-				stage = jobs.StatusRunning
+			if strings.Contains(evt.Stage, "fallback") {
+				fallbackInfo = evt.Message
 			}
-			onProgress(models.JobProgressV1{Current: current, Total: total, Stage: stage, Message: message})
-		}
-		if strings.Contains(evt.Stage, "fallback") {
-			fallbackInfo = evt.Message
-		}
+		})
+		return trimErr
 	})
 
 	if trimErr != nil {
@@ -149,11 +238,14 @@ func (t *TrimTool) executeSingle(ctx context.Context, req models.JobRequestV1, o
 		if trimErr.Code == engine.ErrorCodeVideoTrimCopyFailed && trimReq.TrimMode == engine.TrimModeAuto {
 			jobErr.Details = map[string]any{"fallbackUsed": false}
 		}
-		return models.JobResultItemV1{InputPath: trimReq.InputPath, OutputPath: trimReq.OutputPath, Success: false, Message: jobErr.Message, Error: jobErr}, jobErr
+		return models.JobResultItemV1{InputPath: trimReq.InputPath, OutputPath: target.Path, Success: false, Message: jobErr.Message, Error: jobErr}, jobErr
+	}
+	if writeErr != nil {
+		return models.JobResultItemV1{InputPath: trimReq.InputPath, OutputPath: target.Path, Success: false, Message: writeErr.Message, Error: writeErr}, writeErr
 	}
 
 	message := "Video trim successful"
-	item := models.JobResultItemV1{InputPath: trimReq.InputPath, OutputPath: trimReq.OutputPath, Outputs: []string{trimReq.OutputPath}, OutputCount: 1, Success: true, Message: message}
+	item := models.JobResultItemV1{InputPath: trimReq.InputPath, OutputPath: target.Path, Outputs: []string{target.Path}, OutputCount: 1, Success: true, Message: message}
 
 	if trimReq.TrimMode == engine.TrimModeAuto && fallbackInfo != "" {
 		item.Message = message + " (fallback re-encode used)"
@@ -174,6 +266,7 @@ func (t *TrimTool) ExecuteBatch(ctx context.Context, req models.JobRequestV1, on
 
 	items := make([]models.JobResultItemV1, 0, len(trimReqs))
 	var firstErr *models.JobErrorV1
+	targets, conflicts := resolveVideoTargets(req, trimOutputPaths(trimReqs))
 
 	for index, trimReq := range trimReqs {
 		select {
@@ -183,18 +276,43 @@ func (t *TrimTool) ExecuteBatch(ctx context.Context, req models.JobRequestV1, on
 		default:
 		}
 
-		if err := engine.Trim(ctx, t.probe, t.runner, trimReq); err != nil {
-			jobErr := mapVideoError(err)
+		if tools.ItemCancelled(ctx, trimReq.InputPath) {
+			items = append(items, models.CancelledItemV1(trimReq.InputPath, trimReq.OutputPath))
+			if onProgress != nil {
+				onProgress(models.JobProgressV1{Current: index + 1, Total: len(trimReqs), Stage: jobs.StatusRunning, Message: fmt.Sprintf("processed %d/%d", index+1, len(trimReqs)), Item: models.LastResultItemV1(items)})
+			}
+			continue
+		}
+
+		target := targets[index]
+		if conflicts[index] != nil {
 			if firstErr == nil {
-				firstErr = jobErr
+				firstErr = conflicts[index]
 			}
-			items = append(items, models.JobResultItemV1{InputPath: trimReq.InputPath, OutputPath: trimReq.OutputPath, Success: false, Message: jobErr.Message, Error: jobErr})
+			items = append(items, models.JobResultItemV1{InputPath: trimReq.InputPath, OutputPath: trimReq.OutputPath, Success: false, Message: conflicts[index].Message, Error: conflicts[index]})
+		} else if target.Skip {
+			items = append(items, models.SkippedItemV1(trimReq.InputPath, target.Path))
 		} else {
-			items = append(items, models.JobResultItemV1{InputPath: trimReq.InputPath, OutputPath: trimReq.OutputPath, Outputs: []string{trimReq.OutputPath}, OutputCount: 1, Success: true, Message: "Video trim successful"})
+			trimReq.OutputPath = target.WritePath
+			itemCtx, stopItem := tools.ItemContext(ctx, trimReq.InputPath)
+			jobErr := writeVideoOutput(itemCtx, target, func() *engine.VideoError {
+				return engine.Trim(itemCtx, t.probe, t.runner, trimReq)
+			})
+			stopItem()
+			if jobErr != nil && ctx.Err() == nil && tools.ItemCancelled(ctx, trimReq.InputPath) {
+				items = append(items, models.CancelledItemV1(trimReq.InputPath, target.Path))
+			} else if jobErr != nil {
+				if firstErr == nil {
+					firstErr = jobErr
+				}
+				items = append(items, models.JobResultItemV1{InputPath: trimReq.InputPath, OutputPath: target.Path, Success: false, Message: jobErr.Message, Error: jobErr})
+			} else {
+				items = append(items, models.JobResultItemV1{InputPath: trimReq.InputPath, OutputPath: target.Path, Outputs: []string{target.Path}, OutputCount: 1, Success: true, Message: "Video trim successful"})
+			}
 		}
 
 		if onProgress != nil {
-			onProgress(models.JobProgressV1{Current: index + 1, Total: len(trimReqs), Stage: jobs.StatusRunning, Message: fmt.Sprintf("processed %d/%d", index+1, len(trimReqs))})
+			onProgress(models.JobProgressV1{Current: index + 1, Total: len(trimReqs), Stage: jobs.StatusRunning, Message: fmt.Sprintf("processed %d/%d", index+1, len(trimReqs)), Item: models.LastResultItemV1(items)})
 		}
 	}
 
@@ -295,7 +413,10 @@ func parseTrimBatchRequests(req models.JobRequestV1) ([]engine.TrimRequest, *mod
 	}
 
 	reqs := make([]engine.TrimRequest, 0, len(req.InputPaths))
-	plannedOutputs := make(map[string]struct{}, len(req.InputPaths))
+	if tmplErr := tools.ValidateOutputNameTemplate(req.Options); tmplErr != nil {
+		return nil, tmplErr
+	}
+	nameTemplate := tools.OutputNameTemplate(req.Options, "{name}_trimmed.{ext}")
 
 	for index, rawInputPath := range req.InputPaths {
 		inputPath := strings.TrimSpace(rawInputPath)
@@ -303,18 +424,13 @@ func parseTrimBatchRequests(req models.JobRequestV1) ([]engine.TrimRequest, *mod
 			return nil, &models.JobErrorV1{Code: engine.ErrorCodeVideoTrimValidation, Message: "inputPaths must not contain empty values"}
 		}
 
-		baseName := strings.TrimSuffix(filepath.Base(inputPath), filepath.Ext(inputPath))
-		if baseName == "" {
-			baseName = fmt.Sprintf("video_%d", index+1)
-		}
-
-		outputFileName := fmt.Sprintf("%s_trimmed.%s", baseName, targetFormat)
-		outputPath := filepath.Join(outputDir, outputFileName)
-		normalizedOutputKey := strings.ToLower(filepath.Clean(outputPath))
-		if _, exists := plannedOutputs[normalizedOutputKey]; exists {
-			return nil, &models.JobErrorV1{Code: engine.ErrorCodeVideoTrimValidation, Message: "batch output collision detected; input filenames must be unique"}
-		}
-		plannedOutputs[normalizedOutputKey] = struct{}{}
+		outputPath := tools.RenderOutputPath(outputDir, nameTemplate, tools.OutputNameVars{
+			InputPath: inputPath,
+			Ext:       targetFormat,
+			Tool:      ToolIDVideoTrimV1,
+			Index:     index + 1,
+			Subdir:    req.InputSubdirs[rawInputPath],
+		})
 
 		reqs = append(reqs, engine.TrimRequest{
 			InputPath:     inputPath,
@@ -347,3 +463,11 @@ func optionNumber(options map[string]any, key string) (float64, bool) {
 
 	return n, true
 }
+
+func trimOutputPaths(reqs []engine.TrimRequest) []string {
+	paths := make([]string, 0, len(reqs))
+	for _, req := range reqs {
+		paths = append(paths, req.OutputPath)
+	}
+	return paths
+}
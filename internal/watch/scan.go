@@ -0,0 +1,334 @@
+package watch
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"maps"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"fileforge-desktop/internal/models"
+)
+
+const (
+	stampSubmitFailed = "submit_failed"
+	stampOutput       = "output"
+)
+
+// Start polls the watch folders every interval until Stop is called.
+func (m *Manager) Start(interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultPollInterval
+	}
+
+	m.mu.Lock()
+	if m.stop != nil {
+		m.mu.Unlock()
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	m.stop, m.done = cancel, done
+	m.mu.Unlock()
+
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			m.Poll(time.Now())
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+func (m *Manager) Stop() {
+	m.mu.Lock()
+	stop, done := m.stop, m.done
+	m.stop, m.done = nil, nil
+	m.mu.Unlock()
+
+	if stop != nil {
+		stop()
+		<-done
+	}
+}
+
+type submission struct {
+	folderID string
+	path     string
+	stamp    fileStamp
+	req      models.JobRequestV1
+}
+
+// Poll settles finished jobs, then scans every enabled folder and submits the
+// files that have stopped changing. Jobs are submitted without holding the
+// manager lock because hashing large inputs for reuse can take a while.
+func (m *Manager) Poll(now time.Time) {
+	m.mu.Lock()
+	changed := false
+	submissions := make([]submission, 0)
+	for _, state := range m.sortedLocked() {
+		if m.settleJobsLocked(state) {
+			changed = true
+		}
+		if !state.config.Enabled {
+			continue
+		}
+		ready, pruned := scanFolderLocked(state, now)
+		changed = changed || pruned
+		submissions = append(submissions, ready...)
+	}
+	m.mu.Unlock()
+
+	results := make([]models.RunJobResponseV1, len(submissions))
+	errs := make([]error, len(submissions))
+	for i, sub := range submissions {
+		results[i], errs[i] = m.runner.Submit(context.Background(), sub.req)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i, sub := range submissions {
+		state, ok := m.folders[sub.folderID]
+		if !ok {
+			continue
+		}
+		if m.recordSubmissionLocked(state, sub, results[i], errs[i]) {
+			changed = true
+		}
+	}
+	if changed {
+		if err := m.saveLocked(); err != nil {
+			log.Printf("tooling.watch.save_failed path=%s err=%v", m.path, err)
+		}
+	}
+}
+
+// scanFolderLocked lists the folder and returns the files that are ready to
+// submit. It also reports whether processed entries for deleted files were
+// dropped.
+func scanFolderLocked(state *folderState, now time.Time) ([]submission, bool) {
+	state.lastScanAt = now.UnixMilli()
+
+	entries, err := os.ReadDir(state.config.Dir)
+	if err != nil {
+		state.lastError = fmt.Sprintf("scan failed: %v", err)
+		log.Printf("tooling.watch.scan_failed id=%s dir=%s err=%v", state.config.ID, state.config.Dir, err)
+		return nil, false
+	}
+
+	settle := time.Duration(state.config.SettleMillis) * time.Millisecond
+	present := make(map[string]struct{}, len(entries))
+	ready := make([]submission, 0)
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || strings.HasPrefix(name, ".") || !matchesPattern(state.config.Pattern, name) {
+			continue
+		}
+		path := filepath.Join(state.config.Dir, name)
+		present[path] = struct{}{}
+
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		size, modTime := info.Size(), info.ModTime().UnixNano()
+
+		if stamp, ok := state.processed[path]; ok && stamp.Size == size && stamp.ModTime == modTime {
+			delete(state.candidates, path)
+			continue
+		}
+		if _, ok := state.pending[path]; ok {
+			continue
+		}
+
+		// A file is ready once its size and modification time have not changed
+		// for the settle delay, so copies still in progress are not picked up.
+		seen, ok := state.candidates[path]
+		if !ok || seen.size != size || seen.modTime != modTime {
+			state.candidates[path] = candidate{size: size, modTime: modTime, stableSince: now}
+			continue
+		}
+		if now.Sub(seen.stableSince) < settle {
+			continue
+		}
+
+		delete(state.candidates, path)
+		ready = append(ready, submission{
+			folderID: state.config.ID,
+			path:     path,
+			stamp:    fileStamp{Size: size, ModTime: modTime},
+			req:      folderRequest(state.config, path),
+		})
+	}
+
+	for path := range state.candidates {
+		if _, ok := present[path]; !ok {
+			delete(state.candidates, path)
+		}
+	}
+	pruned := false
+	for path := range state.processed {
+		if _, ok := present[path]; !ok {
+			delete(state.processed, path)
+			pruned = true
+		}
+	}
+	return ready, pruned
+}
+
+func matchesPattern(pattern, name string) bool {
+	matched, err := filepath.Match(strings.ToLower(pattern), strings.ToLower(name))
+	return err == nil && matched
+}
+
+func folderRequest(folder models.WatchFolderV1, path string) models.JobRequestV1 {
+	options := make(map[string]any, len(folder.Options)+1)
+	maps.Copy(options, folder.Options)
+	// Some tools read the output directory from their options, as pipeline
+	// steps do.
+	if _, ok := options["outputDir"]; !ok && folder.OutputDir != "" {
+		options["outputDir"] = folder.OutputDir
+	}
+
+	return models.JobRequestV1{
+		ToolID:     folder.ToolID,
+		Mode:       folder.Mode,
+		InputPaths: []string{path},
+		OutputDir:  folder.OutputDir,
+		Options:    options,
+		Preset:     folder.Preset,
+	}
+}
+
+// recordSubmissionLocked tracks a submitted job, or marks the file as handled
+// when the submission was rejected so it is not retried until it changes.
+// Either way the folder has changed and must be saved.
+func (m *Manager) recordSubmissionLocked(state *folderState, sub submission, res models.RunJobResponseV1, err error) bool {
+	if err == nil && res.Success {
+		state.pending[sub.path] = pendingJob{jobID: res.JobID, stamp: sub.stamp}
+		log.Printf("tooling.watch.submitted id=%s path=%s jobId=%s", state.config.ID, sub.path, res.JobID)
+		return true
+	}
+
+	message := ""
+	switch {
+	case err != nil:
+		message = err.Error()
+	case res.Error != nil:
+		message = res.Error.Message
+	default:
+		message = res.Message
+	}
+	state.lastError = fmt.Sprintf("%s: %s", filepath.Base(sub.path), message)
+	log.Printf("tooling.watch.submit_failed id=%s path=%s err=%s", state.config.ID, sub.path, message)
+
+	stamp := sub.stamp
+	stamp.Status = stampSubmitFailed
+	state.processed[sub.path] = stamp
+	return true
+}
+
+// settleJobsLocked handles pending jobs that have finished. An interrupted job
+// is forgotten so the file is submitted again; any other outcome marks the
+// file as handled, and a success also applies the folder's onSuccess action.
+func (m *Manager) settleJobsLocked(state *folderState) bool {
+	changed := false
+	for path, pending := range state.pending {
+		result, found := m.runner.GetJob(pending.jobID)
+		if found && !isFinalStatus(result.Status) {
+			continue
+		}
+		delete(state.pending, path)
+		changed = true
+		if !found || result.Status == models.JobStatusInterrupted {
+			continue
+		}
+
+		stamp := pending.stamp
+		stamp.JobID, stamp.Status = pending.jobID, result.Status
+		state.processed[path] = stamp
+		markOutputsLocked(state, result.Items)
+
+		if result.Status != models.JobStatusSuccess {
+			state.lastError = fmt.Sprintf("%s: job %s", filepath.Base(path), result.Status)
+			log.Printf("tooling.watch.job_failed id=%s path=%s jobId=%s status=%s", state.config.ID, path, pending.jobID, result.Status)
+			continue
+		}
+		log.Printf("tooling.watch.job_succeeded id=%s path=%s jobId=%s", state.config.ID, path, pending.jobID)
+
+		if err := applyOnSuccess(state.config, path); err != nil {
+			state.lastError = fmt.Sprintf("%s: %v", filepath.Base(path), err)
+			log.Printf("tooling.watch.action_failed id=%s path=%s action=%s err=%v", state.config.ID, path, state.config.OnSuccess, err)
+		}
+	}
+	return changed
+}
+
+// markOutputsLocked records outputs written into the watched folder itself so
+// they are not picked up as new inputs.
+func markOutputsLocked(state *folderState, items []models.JobResultItemV1) {
+	for _, item := range items {
+		outputs := item.Outputs
+		if len(outputs) == 0 && item.OutputPath != "" {
+			outputs = []string{item.OutputPath}
+		}
+		for _, output := range outputs {
+			if filepath.Dir(output) != state.config.Dir {
+				continue
+			}
+			info, err := os.Stat(output)
+			if err != nil {
+				continue
+			}
+			state.processed[output] = fileStamp{Size: info.Size(), ModTime: info.ModTime().UnixNano(), Status: stampOutput}
+		}
+	}
+}
+
+func applyOnSuccess(folder models.WatchFolderV1, path string) error {
+	switch folder.OnSuccess {
+	case models.WatchActionDelete:
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("delete original: %w", err)
+		}
+	case models.WatchActionMove:
+		if err := os.MkdirAll(folder.MoveToDir, 0o755); err != nil {
+			return fmt.Errorf("create move directory: %w", err)
+		}
+		if err := os.Rename(path, availablePath(folder.MoveToDir, filepath.Base(path))); err != nil {
+			return fmt.Errorf("move original: %w", err)
+		}
+	}
+	return nil
+}
+
+// availablePath returns dir/name, or dir/name_N with the first free N when a
+// file of that name was moved there before.
+func availablePath(dir, name string) string {
+	target := filepath.Join(dir, name)
+	ext := filepath.Ext(name)
+	base := strings.TrimSuffix(name, ext)
+	for i := 1; ; i++ {
+		if _, err := os.Stat(target); os.IsNotExist(err) {
+			return target
+		}
+		target = filepath.Join(dir, fmt.Sprintf("%s_%d%s", base, i, ext))
+	}
+}
+
+func isFinalStatus(status string) bool {
+	switch status {
+	case models.JobStatusQueued, models.JobStatusRunning, models.JobStatusPaused, models.JobStatusWaiting:
+		return false
+	}
+	return true
+}
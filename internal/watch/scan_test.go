@@ -0,0 +1,238 @@
+package watch
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"fileforge-desktop/internal/models"
+)
+
+// fakeRunner accepts every submission and reports the job statuses set by the
+// test. Submitted jobs stay queued until then.
+type fakeRunner struct {
+	mu        sync.Mutex
+	submitted []models.JobRequestV1
+	jobs      map[string]models.JobResultV1
+}
+
+func newFakeRunner() *fakeRunner {
+	return &fakeRunner{jobs: make(map[string]models.JobResultV1)}
+}
+
+func (r *fakeRunner) Submit(ctx context.Context, req models.JobRequestV1) (models.RunJobResponseV1, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.submitted = append(r.submitted, req)
+	jobID := fmt.Sprintf("job_%d", len(r.submitted))
+	r.jobs[jobID] = models.JobResultV1{JobID: jobID, Status: models.JobStatusQueued}
+	return models.RunJobResponseV1{Success: true, JobID: jobID}, nil
+}
+
+func (r *fakeRunner) GetJob(jobID string) (models.JobResultV1, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	result, ok := r.jobs[jobID]
+	return result, ok
+}
+
+func (r *fakeRunner) finish(jobID, status string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.jobs[jobID] = models.JobResultV1{JobID: jobID, Status: status}
+}
+
+func (r *fakeRunner) submissions() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.submitted)
+}
+
+// newTestManager watches a fresh directory with a one second settle delay and
+// keeps its state next to it.
+func newTestManager(t *testing.T, runner Runner, folder models.WatchFolderV1) (*Manager, string) {
+	t.Helper()
+	root := t.TempDir()
+	folder.Dir = filepath.Join(root, "in")
+	if err := os.Mkdir(folder.Dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	folder.ID, folder.ToolID, folder.Enabled, folder.SettleMillis = "watch_test", "tool.image.test", true, 1000
+
+	m, err := OpenManager(runner, filepath.Join(root, "watch_folders_v1.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, jobErr := m.Save(folder); jobErr != nil {
+		t.Fatal(jobErr)
+	}
+	return m, folder.Dir
+}
+
+func writeInput(t *testing.T, path, content string, modTime time.Time) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPollWaitsForFilesToSettle(t *testing.T) {
+	runner := newFakeRunner()
+	m, dir := newTestManager(t, runner, models.WatchFolderV1{})
+	input := filepath.Join(dir, "a.jpg")
+	start := time.Now()
+	writeInput(t, input, "a", start.Add(-time.Hour))
+
+	m.Poll(start)
+	m.Poll(start.Add(900 * time.Millisecond))
+	if got := runner.submissions(); got != 0 {
+		t.Fatalf("%d jobs submitted before the settle delay", got)
+	}
+
+	// A write during the settle delay starts it again.
+	writeInput(t, input, "a, still copying", start.Add(-time.Minute))
+	m.Poll(start.Add(1100 * time.Millisecond))
+	m.Poll(start.Add(2000 * time.Millisecond))
+	if got := runner.submissions(); got != 0 {
+		t.Fatalf("%d jobs submitted for a file that just changed", got)
+	}
+
+	m.Poll(start.Add(2200 * time.Millisecond))
+	m.Poll(start.Add(5000 * time.Millisecond))
+	if got := runner.submissions(); got != 1 {
+		t.Fatalf("%d jobs submitted, want 1 once the file settled", got)
+	}
+	if req := runner.submitted[0]; len(req.InputPaths) != 1 || req.InputPaths[0] != input {
+		t.Fatalf("submitted inputs = %v, want %s", req.InputPaths, input)
+	}
+}
+
+func TestPollResubmitsChangedFiles(t *testing.T) {
+	runner := newFakeRunner()
+	m, dir := newTestManager(t, runner, models.WatchFolderV1{})
+	input := filepath.Join(dir, "a.jpg")
+	start := time.Now()
+	writeInput(t, input, "a", start.Add(-time.Hour))
+
+	m.Poll(start)
+	m.Poll(start.Add(2 * time.Second))
+	runner.finish("job_1", models.JobStatusSuccess)
+	m.Poll(start.Add(4 * time.Second))
+	m.Poll(start.Add(6 * time.Second))
+	if got := runner.submissions(); got != 1 {
+		t.Fatalf("%d jobs submitted for an unchanged file, want 1", got)
+	}
+
+	writeInput(t, input, "a, edited", start.Add(-time.Minute))
+	m.Poll(start.Add(8 * time.Second))
+	m.Poll(start.Add(10 * time.Second))
+	if got := runner.submissions(); got != 2 {
+		t.Fatalf("%d jobs submitted after the file changed, want 2", got)
+	}
+}
+
+// An interrupted job is forgotten and its file submitted again.
+func TestPollResubmitsInterruptedJobs(t *testing.T) {
+	runner := newFakeRunner()
+	m, dir := newTestManager(t, runner, models.WatchFolderV1{})
+	start := time.Now()
+	writeInput(t, filepath.Join(dir, "a.jpg"), "a", start.Add(-time.Hour))
+
+	m.Poll(start)
+	m.Poll(start.Add(2 * time.Second))
+	runner.finish("job_1", models.JobStatusInterrupted)
+	m.Poll(start.Add(4 * time.Second))
+	m.Poll(start.Add(6 * time.Second))
+	if got := runner.submissions(); got != 2 {
+		t.Fatalf("%d jobs submitted, want the interrupted file submitted again", got)
+	}
+}
+
+func TestPollAppliesOnSuccess(t *testing.T) {
+	start := time.Now()
+	for _, action := range []string{models.WatchActionMove, models.WatchActionDelete} {
+		t.Run(action, func(t *testing.T) {
+			runner := newFakeRunner()
+			m, dir := newTestManager(t, runner, models.WatchFolderV1{OnSuccess: action})
+			writeInput(t, filepath.Join(dir, "a.jpg"), "a", start.Add(-time.Hour))
+			writeInput(t, filepath.Join(dir, "b.jpg"), "b", start.Add(-time.Hour))
+
+			m.Poll(start)
+			m.Poll(start.Add(2 * time.Second))
+			runner.finish("job_1", models.JobStatusSuccess)
+			runner.finish("job_2", models.JobStatusFailed)
+			m.Poll(start.Add(4 * time.Second))
+
+			if _, err := os.Stat(filepath.Join(dir, "a.jpg")); !os.IsNotExist(err) {
+				t.Fatalf("succeeded input still in the watched folder: %v", err)
+			}
+			if _, err := os.Stat(filepath.Join(dir, "b.jpg")); err != nil {
+				t.Fatalf("failed input was touched: %v", err)
+			}
+			_, err := os.Stat(filepath.Join(dir, defaultMoveSubdir, "a.jpg"))
+			if moved := err == nil; moved != (action == models.WatchActionMove) {
+				t.Fatalf("input moved = %v for onSuccess %s", moved, action)
+			}
+		})
+	}
+}
+
+// Jobs in flight when the app stops are settled after the restart instead of
+// being submitted a second time.
+func TestPendingJobsSurviveRestart(t *testing.T) {
+	runner := newFakeRunner()
+	m, dir := newTestManager(t, runner, models.WatchFolderV1{})
+	start := time.Now()
+	writeInput(t, filepath.Join(dir, "a.jpg"), "a", start.Add(-time.Hour))
+	writeInput(t, filepath.Join(dir, "b.jpg"), "b", start.Add(-time.Hour))
+	m.Poll(start)
+	m.Poll(start.Add(2 * time.Second))
+	if got := runner.submissions(); got != 2 {
+		t.Fatalf("%d jobs submitted, want 2", got)
+	}
+
+	// One job finished while the app was down, the other is still queued.
+	runner.finish("job_1", models.JobStatusSuccess)
+	restarted, err := OpenManager(runner, m.path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status := restarted.List()[0]; status.Pending != 2 {
+		t.Fatalf("restored %d pending jobs, want 2", status.Pending)
+	}
+
+	restarted.Poll(start.Add(4 * time.Second))
+	restarted.Poll(start.Add(6 * time.Second))
+	if got := runner.submissions(); got != 2 {
+		t.Fatalf("%d jobs submitted after the restart, want still 2", got)
+	}
+	status := restarted.List()[0]
+	if status.Pending != 1 || status.Processed != 1 {
+		t.Fatalf("status = %+v, want 1 pending and 1 processed", status)
+	}
+}
+
+// Saving goes through a temp file that is renamed into place, not left behind.
+func TestSaveLeavesNoTempFiles(t *testing.T) {
+	m, _ := newTestManager(t, newFakeRunner(), models.WatchFolderV1{})
+
+	entries, err := os.ReadDir(filepath.Dir(m.path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range entries {
+		if entry.Name() != "in" && entry.Name() != filepath.Base(m.path) {
+			t.Fatalf("unexpected file %s next to the watch folders file", entry.Name())
+		}
+	}
+	if _, err := OpenManager(newFakeRunner(), m.path); err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+}
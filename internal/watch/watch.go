@@ -0,0 +1,316 @@
+package watch
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"fileforge-desktop/internal/models"
+)
+
+const (
+	DefaultPollInterval = 2 * time.Second
+	DefaultSettleDelay  = 3 * time.Second
+	defaultMoveSubdir   = "processed"
+)
+
+// Runner is the part of the job orchestrator that watch folders use.
+type Runner interface {
+	Submit(ctx context.Context, req models.JobRequestV1) (models.RunJobResponseV1, error)
+	GetJob(jobID string) (models.JobResultV1, bool)
+}
+
+// Manager polls the configured watch folders and submits a job for each new or
+// modified file. Handled files are remembered by size and modification time,
+// and files with a job in flight by that job's ID, so that a restart does not
+// process them again.
+type Manager struct {
+	runner Runner
+	path   string
+
+	mu      sync.Mutex
+	folders map[string]*folderState
+	stop    context.CancelFunc
+	done    chan struct{}
+}
+
+type folderState struct {
+	config     models.WatchFolderV1
+	processed  map[string]fileStamp
+	candidates map[string]candidate
+	pending    map[string]pendingJob
+	lastScanAt int64
+	lastError  string
+}
+
+// fileStamp records the version of a file that was handled and how.
+type fileStamp struct {
+	Size    int64  `json:"size"`
+	ModTime int64  `json:"modTime"` // unix nanoseconds
+	JobID   string `json:"jobId,omitempty"`
+	Status  string `json:"status"`
+}
+
+type candidate struct {
+	size        int64
+	modTime     int64
+	stableSince time.Time
+}
+
+type pendingJob struct {
+	jobID string
+	stamp fileStamp
+}
+
+type persistedFolderV1 struct {
+	models.WatchFolderV1
+	Processed map[string]fileStamp `json:"processed,omitempty"`
+	Pending   map[string]fileStamp `json:"pending,omitempty"` // stamps carry the submitted job ID
+}
+
+func OpenManager(runner Runner, path string) (*Manager, error) {
+	m := &Manager{
+		runner:  runner,
+		path:    path,
+		folders: make(map[string]*folderState),
+	}
+
+	if err := m.load(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (m *Manager) load() error {
+	content, err := os.ReadFile(m.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("read watch folders: %w", err)
+	}
+	if len(content) == 0 {
+		return nil
+	}
+
+	persisted := make([]persistedFolderV1, 0)
+	if err := json.Unmarshal(content, &persisted); err != nil {
+		return fmt.Errorf("decode watch folders: %w", err)
+	}
+
+	for _, folder := range persisted {
+		state := newFolderState(folder.WatchFolderV1)
+		for path, stamp := range folder.Processed {
+			state.processed[path] = stamp
+		}
+		// Jobs submitted before the restart are settled against the
+		// orchestrator on the next poll instead of being submitted again.
+		for path, stamp := range folder.Pending {
+			if stamp.JobID != "" {
+				state.pending[path] = pendingJob{jobID: stamp.JobID, stamp: stamp}
+			}
+		}
+		m.folders[folder.ID] = state
+	}
+	return nil
+}
+
+// saveLocked writes the folders with their processed files and pending jobs.
+// The file is replaced through a rename so a crash mid-write keeps the
+// previous version. The caller holds m.mu.
+func (m *Manager) saveLocked() error {
+	persisted := make([]persistedFolderV1, 0, len(m.folders))
+	for _, state := range m.sortedLocked() {
+		folder := persistedFolderV1{WatchFolderV1: state.config, Processed: state.processed}
+		if len(state.pending) > 0 {
+			folder.Pending = make(map[string]fileStamp, len(state.pending))
+			for path, pending := range state.pending {
+				stamp := pending.stamp
+				stamp.JobID = pending.jobID
+				folder.Pending[path] = stamp
+			}
+		}
+		persisted = append(persisted, folder)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(m.path), 0o755); err != nil {
+		return fmt.Errorf("create watch folder directory: %w", err)
+	}
+
+	payload, err := json.MarshalIndent(persisted, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode watch folders: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(m.path), ".watch-folders-*.tmp")
+	if err != nil {
+		return fmt.Errorf("create watch folders temp file: %w", err)
+	}
+	if _, err := tmp.Write(payload); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return fmt.Errorf("write watch folders: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("close watch folders temp file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), m.path); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("replace watch folders: %w", err)
+	}
+	return nil
+}
+
+func (m *Manager) sortedLocked() []*folderState {
+	states := make([]*folderState, 0, len(m.folders))
+	for _, state := range m.folders {
+		states = append(states, state)
+	}
+	sort.Slice(states, func(i, j int) bool { return states[i].config.ID < states[j].config.ID })
+	return states
+}
+
+func newFolderState(config models.WatchFolderV1) *folderState {
+	return &folderState{
+		config:     config,
+		processed:  make(map[string]fileStamp),
+		candidates: make(map[string]candidate),
+		pending:    make(map[string]pendingJob),
+	}
+}
+
+// Save creates a watch folder, or replaces the one with the same ID. Files
+// already handled stay handled unless the folder now watches another directory
+// or runs another tool.
+func (m *Manager) Save(folder models.WatchFolderV1) (models.WatchFolderV1, *models.JobErrorV1) {
+	if jobErr := normalizeFolder(&folder); jobErr != nil {
+		return models.WatchFolderV1{}, jobErr
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if folder.ID == "" {
+		folder.ID = fmt.Sprintf("watch_%d", time.Now().UnixNano())
+	}
+
+	state, ok := m.folders[folder.ID]
+	if !ok || state.config.Dir != folder.Dir || state.config.ToolID != folder.ToolID {
+		state = newFolderState(folder)
+		m.folders[folder.ID] = state
+	}
+	state.config = folder
+	state.lastError = ""
+
+	if err := m.saveLocked(); err != nil {
+		return models.WatchFolderV1{}, models.NewCanonicalJobError("WATCH_SAVE_ERROR", err.Error(), map[string]any{"id": folder.ID})
+	}
+	return folder, nil
+}
+
+func (m *Manager) Remove(id string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.folders[id]; !ok {
+		return false, nil
+	}
+	delete(m.folders, id)
+	return true, m.saveLocked()
+}
+
+func (m *Manager) List() []models.WatchFolderStatusV1 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	statuses := make([]models.WatchFolderStatusV1, 0, len(m.folders))
+	for _, state := range m.sortedLocked() {
+		statuses = append(statuses, models.WatchFolderStatusV1{
+			Folder:     state.config,
+			Pending:    len(state.pending),
+			Processed:  len(state.processed),
+			LastScanAt: state.lastScanAt,
+			LastError:  state.lastError,
+		})
+	}
+	return statuses
+}
+
+func normalizeFolder(folder *models.WatchFolderV1) *models.JobErrorV1 {
+	folder.ID = strings.TrimSpace(folder.ID)
+	folder.ToolID = strings.TrimSpace(folder.ToolID)
+	folder.Preset = strings.TrimSpace(folder.Preset)
+	if folder.ToolID == "" {
+		return watchValidationError("toolId is required", nil)
+	}
+
+	dir := strings.TrimSpace(folder.Dir)
+	if dir == "" {
+		return watchValidationError("dir is required", nil)
+	}
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return watchValidationError(fmt.Sprintf("invalid dir: %v", err), map[string]any{"dir": dir})
+	}
+	info, err := os.Stat(absDir)
+	if err != nil || !info.IsDir() {
+		return watchValidationError(fmt.Sprintf("dir '%s' is not an existing directory", absDir), map[string]any{"dir": absDir})
+	}
+	folder.Dir = absDir
+
+	folder.Pattern = strings.TrimSpace(folder.Pattern)
+	if folder.Pattern == "" {
+		folder.Pattern = "*"
+	}
+	if _, err := filepath.Match(folder.Pattern, ""); err != nil {
+		return watchValidationError(fmt.Sprintf("invalid pattern '%s'", folder.Pattern), map[string]any{"pattern": folder.Pattern})
+	}
+
+	folder.Mode = strings.ToLower(strings.TrimSpace(folder.Mode))
+	switch folder.Mode {
+	case "":
+		folder.Mode = "single"
+	case "single", "batch":
+	default:
+		return watchValidationError(fmt.Sprintf("mode must be single or batch, got '%s'", folder.Mode), map[string]any{"mode": folder.Mode})
+	}
+
+	if folder.SettleMillis < 0 {
+		return watchValidationError("settleMillis must not be negative", map[string]any{"settleMillis": folder.SettleMillis})
+	}
+	if folder.SettleMillis == 0 {
+		folder.SettleMillis = DefaultSettleDelay.Milliseconds()
+	}
+
+	folder.OnSuccess = strings.ToLower(strings.TrimSpace(folder.OnSuccess))
+	switch folder.OnSuccess {
+	case "":
+		folder.OnSuccess = models.WatchActionLeave
+	case models.WatchActionLeave, models.WatchActionDelete:
+	case models.WatchActionMove:
+		moveTo := strings.TrimSpace(folder.MoveToDir)
+		if moveTo == "" {
+			moveTo = filepath.Join(folder.Dir, defaultMoveSubdir)
+		}
+		absMoveTo, err := filepath.Abs(moveTo)
+		if err != nil || absMoveTo == folder.Dir {
+			return watchValidationError("moveToDir must be a directory other than dir", map[string]any{"moveToDir": folder.MoveToDir})
+		}
+		folder.MoveToDir = absMoveTo
+	default:
+		return watchValidationError(fmt.Sprintf("onSuccess must be leave, move or delete, got '%s'", folder.OnSuccess), map[string]any{"onSuccess": folder.OnSuccess})
+	}
+
+	return nil
+}
+
+func watchValidationError(message string, details map[string]any) *models.JobErrorV1 {
+	return models.NewCanonicalJobError("WATCH_FOLDER_INVALID", message, details)
+}